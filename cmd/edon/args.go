@@ -0,0 +1,27 @@
+package main
+
+import "flag"
+
+// parseInterspersed parses args with fs, allowing flags after positional
+// arguments as in `edon bundle main.js -o out.js`. Everything after -- is
+// positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) error {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			break
+		}
+		// flag stops at the first positional argument, or just after --
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+	return fs.Parse(append([]string{"--"}, positional...))
+}
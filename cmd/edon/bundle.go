@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/katungi/edon/internal/bundle"
+	"github.com/katungi/edon/internal/errors"
+)
+
+var (
+	BundleCmd        = flag.NewFlagSet("bundle", flag.ExitOnError)
+	bundleOutput     = BundleCmd.String("o", "", "File to write the bundle to, stdout by default")
+	bundleFormat     = BundleCmd.String("format", "esm", "Bundle format: esm or iife")
+	bundleMinify     = BundleCmd.Bool("minify", false, "Minify the bundle")
+	bundleSourceMap  = BundleCmd.Bool("sourcemap", false, "Write an external source map next to the bundle")
+	bundleGlobalName = BundleCmd.String("global-name", "", "Global to assign the exports of an iife bundle to")
+	bundleFlags      = addLoaderFlags(BundleCmd)
+)
+
+// HandleBundle bundles an entry point and everything it imports into a
+// single file
+func HandleBundle() error {
+	if BundleCmd.NArg() != 1 {
+		return errors.ErrEntryRequired
+	}
+	if *bundleSourceMap && *bundleOutput == "" {
+		return fmt.Errorf("-sourcemap needs an output file, set with -o")
+	}
+
+	moduleLoader, cfg, err := bundleFlags.newModuleLoader()
+	if err != nil {
+		return err
+	}
+
+	entry := BundleCmd.Arg(0)
+	if _, err := os.Stat(entry); err == nil {
+		if entry, err = filepath.Abs(entry); err != nil {
+			return err
+		}
+	}
+	graph, err := moduleLoader.LoadGraph(context.Background(), entry)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", entry, err)
+	}
+
+	outfile := *bundleOutput
+	if outfile == "" {
+		outfile = "bundle.js"
+	}
+	files, err := bundle.Bundle(graph, outfile,
+		bundle.WithFormat(bundle.Format(*bundleFormat)),
+		bundle.WithMinify(*bundleMinify),
+		bundle.WithSourceMap(*bundleSourceMap),
+		bundle.WithGlobalName(*bundleGlobalName),
+		bundle.WithCompilerOptions(cfg.CompilerOptions),
+	)
+	if err != nil {
+		return err
+	}
+
+	if *bundleOutput == "" {
+		_, err := os.Stdout.Write(files[0].Contents)
+		return err
+	}
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+			return errors.WrapWith(errors.ErrFileWrite, err, f.Path)
+		}
+		if err := os.WriteFile(f.Path, f.Contents, 0644); err != nil {
+			return errors.WrapWith(errors.ErrFileWrite, err, f.Path)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Bundled %d modules into %s (%d bytes)\n", len(graph.Modules), *bundleOutput, len(files[0].Contents))
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+var (
+	CacheCmd   = flag.NewFlagSet("cache", flag.ExitOnError)
+	cacheClean = CacheCmd.Bool("clean", false, "Remove all cached bytecode from ~/.edon/gen first")
+	cacheFlags = addLoaderFlags(CacheCmd)
+)
+
+// HandleCache loads every module the entry points import so later runs
+// don't touch the network. With -clean it first empties the bytecode
+// cache, which otherwise keeps the bytecode of every version of every
+// module ever run.
+func HandleCache() error {
+	if *cacheClean {
+		genDir, err := loader.DefaultGenDir()
+		if err != nil {
+			return err
+		}
+		removed, err := loader.NewCodeCache(genDir).Clean()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d cached bytecode files from %s\n", removed, genDir)
+		if CacheCmd.NArg() == 0 {
+			return nil
+		}
+	}
+	if CacheCmd.NArg() < 1 {
+		return fmt.Errorf("entry module is required")
+	}
+
+	moduleLoader, _, err := cacheFlags.newModuleLoader()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range CacheCmd.Args() {
+		// Entries may be files or remote URLs
+		if _, err := os.Stat(entry); err == nil {
+			if entry, err = filepath.Abs(entry); err != nil {
+				return err
+			}
+		}
+
+		graph, err := moduleLoader.LoadGraph(context.Background(), entry)
+		if err != nil {
+			return fmt.Errorf("failed to cache %s: %w", entry, err)
+		}
+
+		ids := make([]string, 0, len(graph.Modules))
+		for id, module := range graph.Modules {
+			if module.Type != loader.TypeLocal && module.Type != loader.TypeNode && module.Type != loader.TypeEdon {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			fmt.Printf("Cached %s\n", id)
+		}
+		fmt.Printf("%s: %d modules, %d remote\n", entry, len(graph.Modules), len(ids))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/runtime"
+)
+
+var (
+	CheckCmd   = flag.NewFlagSet("check", flag.ExitOnError)
+	checkQuiet = CheckCmd.Bool("quiet", false, "Print only the problems found")
+	checkFlags = addLoaderFlags(CheckCmd)
+)
+
+// HandleCheck resolves the module graphs of the given entry points and
+// compiles every module in them without running anything, printing each
+// problem found as file:line:col: message
+func HandleCheck() error {
+	if CheckCmd.NArg() < 1 {
+		return errors.ErrEntryRequired
+	}
+
+	moduleLoader, _, err := checkFlags.newModuleLoader()
+	if err != nil {
+		return err
+	}
+
+	// Entries share modules; each is checked and reported once
+	checked := make(map[string]bool)
+	reported := make(map[string]bool)
+	problems := 0
+	report := func(err error) {
+		msg := relativeLocation(err)
+		if !reported[msg] {
+			reported[msg] = true
+			problems++
+			fmt.Fprintln(os.Stderr, msg)
+		}
+	}
+
+	for _, entry := range CheckCmd.Args() {
+		if _, err := os.Stat(entry); err == nil {
+			if entry, err = filepath.Abs(entry); err != nil {
+				return err
+			}
+		} else if !strings.Contains(entry, ":") {
+			return errors.Wrap(errors.ErrFileNotFound, entry)
+		}
+		graph, errs := moduleLoader.ResolveGraph(context.Background(), entry)
+		for _, err := range errs {
+			report(err)
+		}
+		if graph == nil {
+			continue
+		}
+
+		// A module is compiled as the root only when it is an entry
+		partial := &loader.ModuleGraph{Root: graph.Root, Modules: make(map[string]*loader.GraphModule)}
+		for id, module := range graph.Modules {
+			if !checked[id] || id == graph.Root {
+				partial.Modules[id] = module
+				checked[id] = true
+			}
+		}
+		for _, err := range runtime.CheckGraph(partial) {
+			report(err)
+		}
+	}
+
+	if problems > 0 {
+		color.New(color.FgRed).Fprintf(os.Stderr, "Found %d %s in %d %s\n", problems, plural(problems, "problem", "problems"), len(checked), plural(len(checked), "module", "modules"))
+		return errors.ErrCheckFailed
+	}
+	if !*checkQuiet {
+		fmt.Printf("Checked %d %s\n", len(checked), plural(len(checked), "module", "modules"))
+	}
+	return nil
+}
+
+// relativeLocation is the message of a check error, with its file relative
+// to the working directory when it is under it
+func relativeLocation(err error) string {
+	msg := err.Error()
+	wd, wdErr := os.Getwd()
+	if wdErr != nil {
+		return msg
+	}
+	var file string
+	var srcErr *loader.SourceError
+	var impErr *loader.ImportError
+	switch {
+	case errors.As(err, &srcErr):
+		file = srcErr.File
+	case errors.As(err, &impErr):
+		file = impErr.File
+	default:
+		return msg
+	}
+	rel, relErr := filepath.Rel(wd, file)
+	if relErr != nil || strings.HasPrefix(rel, "..") || !strings.HasPrefix(msg, file) {
+		return msg
+	}
+	return rel + msg[len(file):]
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// checkExitCode is the status edon check exits with: 1 when it found
+// problems and 2 when it could not run, as linters do
+func checkExitCode(err error) int {
+	if errors.Is(err, errors.ErrCheckFailed) {
+		return 1
+	}
+	return 2
+}
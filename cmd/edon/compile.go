@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/node"
+	"github.com/katungi/edon/internal/runtime"
+	"github.com/katungi/edon/internal/standalone"
+)
+
+var (
+	CompileCmd    = flag.NewFlagSet("compile", flag.ExitOnError)
+	compileOutput = CompileCmd.String("o", "", "Executable to write, named after the entry by default")
+	compileRead   = CompileCmd.Bool("allow-read", false, "Let the program read files")
+	compileWrite  = CompileCmd.Bool("allow-write", false, "Let the program write files")
+	compileEnv    = CompileCmd.Bool("allow-env", false, "Let the program read and set environment variables")
+	compileAll    = CompileCmd.Bool("allow-all", false, "Grant the program every permission")
+	compileFlags  = addLoaderFlags(CompileCmd)
+)
+
+// HandleCompile compiles an entry point and everything it imports into a
+// standalone executable
+func HandleCompile() error {
+	if CompileCmd.NArg() != 1 {
+		return errors.ErrEntryRequired
+	}
+
+	moduleLoader, cfg, err := compileFlags.newModuleLoader()
+	if err != nil {
+		return err
+	}
+
+	entry := CompileCmd.Arg(0)
+	if _, err := os.Stat(entry); err == nil {
+		if entry, err = filepath.Abs(entry); err != nil {
+			return err
+		}
+	}
+	graph, err := moduleLoader.LoadGraph(context.Background(), entry)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", entry, err)
+	}
+	program, err := runtime.Compile(graph, moduleLoader.CodeCache())
+	if err != nil {
+		return err
+	}
+	program.Permissions = node.Permissions{Read: *compileRead, Write: *compileWrite, Env: *compileEnv}
+	switch {
+	case *compileAll:
+		program.Permissions = node.AllowAll
+	case program.Permissions == node.Permissions{}:
+		// Without flags the program gets what edon.json grants scripts
+		if cfg.Permissions != nil {
+			program.Permissions = *cfg.Permissions
+		}
+	}
+
+	out := *compileOutput
+	if out == "" {
+		out = strings.TrimSuffix(filepath.Base(entry), filepath.Ext(entry))
+	}
+	if out == "" {
+		return errors.ErrOutputRequired
+	}
+	if goruntime.GOOS == "windows" && filepath.Ext(out) == "" {
+		out += ".exe"
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return errors.WrapWith(errors.ErrFileRead, err, "locate the edon executable")
+	}
+	if err := standalone.Build(exe, out, program); err != nil {
+		return err
+	}
+
+	granted := "no permissions"
+	if flags := program.Permissions.Flags(); len(flags) > 0 {
+		granted = strings.Join(flags, " ")
+	}
+	fmt.Fprintf(os.Stderr, "Compiled %d modules into %s (%s)\n", len(graph.Modules), out, granted)
+	return nil
+}
+
+// runEmbedded runs the program a compiled executable carries, passing it
+// every command-line argument
+func runEmbedded(program *runtime.Program) error {
+	rt, err := runtime.New(runtime.WithArgs(os.Args[1:]...), runtime.WithPermissions(program.Permissions))
+	if err != nil {
+		return fmt.Errorf("failed to initialize runtime: %w", err)
+	}
+	defer rt.Close()
+
+	main, err := os.Executable()
+	if err != nil {
+		main = os.Args[0]
+	}
+	return rt.ExecuteProgram(program, main)
+}
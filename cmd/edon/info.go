@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"runtime/debug"
+
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+var (
+	InfoCmd   = flag.NewFlagSet("info", flag.ExitOnError)
+	infoJSON  = InfoCmd.Bool("json", false, "Print the module graph as JSON")
+	infoDot   = InfoCmd.Bool("dot", false, "Print the module graph in Graphviz dot format")
+	infoFlags = addLoaderFlags(InfoCmd)
+)
+
+// HandleInfo prints the module graph of an entry point, or where edon keeps
+// its caches when no entry point is given
+func HandleInfo() error {
+	if InfoCmd.NArg() < 1 {
+		return printCacheInfo()
+	}
+
+	moduleLoader, _, err := infoFlags.newModuleLoader()
+	if err != nil {
+		return err
+	}
+
+	entry := InfoCmd.Arg(0)
+	if _, err := os.Stat(entry); err == nil {
+		if entry, err = filepath.Abs(entry); err != nil {
+			return err
+		}
+	}
+	graph, err := moduleLoader.LoadGraph(context.Background(), entry)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", entry, err)
+	}
+	info, err := moduleLoader.Info(graph)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *infoJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	case *infoDot:
+		return info.WriteDot(os.Stdout)
+	}
+	return info.WriteTree(os.Stdout)
+}
+
+func printCacheInfo() error {
+	depsDir, err := loader.DefaultDepsDir()
+	if err != nil {
+		return err
+	}
+	npmDir, err := loader.NPMCacheDir()
+	if err != nil {
+		return err
+	}
+	binDir, err := loader.BinDir()
+	if err != nil {
+		return err
+	}
+	genDir, err := loader.DefaultGenDir()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("edon version: %s (%s)\n", version, commit)
+	fmt.Printf("Go version: %s\n", goruntime.Version())
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			switch dep.Path {
+			case "github.com/buke/quickjs-go":
+				fmt.Printf("QuickJS bindings: %s\n", dep.Version)
+			case "github.com/evanw/esbuild":
+				fmt.Printf("esbuild: %s\n", dep.Version)
+			}
+		}
+	}
+	fmt.Printf("Remote modules cache: %s\n", depsDir)
+	fmt.Printf("npm cache: %s\n", npmDir)
+	fmt.Printf("Bytecode cache: %s\n", genDir)
+	fmt.Printf("Package binaries: %s\n", binDir)
+	return nil
+}
@@ -1,19 +1,30 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"github.com/fatih/color"
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/templates"
 )
 
-var InitCmd = flag.NewFlagSet("init", flag.ExitOnError)
+var (
+	InitCmd      = flag.NewFlagSet("init", flag.ExitOnError)
+	initTemplate = InitCmd.String("template", templates.Default, "Template to start from; -list shows them")
+	initForce    = InitCmd.Bool("force", false, "Overwrite files that already exist")
+	initList     = InitCmd.Bool("list", false, "List the templates")
+)
 
+// HandleInit writes a new project from a template into the given directory,
+// or the working directory
 func HandleInit() error {
-	// Get current directory or use the provided path
+	if *initList {
+		printTemplates()
+		return nil
+	}
+
 	dir := InitCmd.Arg(0)
 	if dir == "" {
 		var err error
@@ -23,40 +34,31 @@ func HandleInit() error {
 		}
 	}
 
-	// Create project directory if it doesn't exist
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create project directory: %w", err)
+	files, err := templates.Render(*initTemplate, templates.Data{Name: templates.PackageName(dir)})
+	if errors.Is(err, errors.ErrUnknownTemplate) {
+		printTemplates()
 	}
-
-	// Create package.json
-	packageJSON := map[string]interface{}{
-		"name":        filepath.Base(dir),
-		"version":     "1.0.0",
-		"description": "A new Edon project",
-		"main":        "index.js",
-		"scripts": map[string]string{
-			"start": "edon index.js",
-		},
-	}
-
-	packageJSONBytes, err := json.MarshalIndent(packageJSON, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to create package.json: %w", err)
+		return err
 	}
 
-	if err := os.WriteFile(filepath.Join(dir, "package.json"), packageJSONBytes, 0644); err != nil {
-		return fmt.Errorf("failed to write package.json: %w", err)
+	if err := templates.Write(dir, files, *initForce); err != nil {
+		if errors.Is(err, errors.ErrFileExists) {
+			return fmt.Errorf("%w (pass -force to overwrite)", err)
+		}
+		return err
 	}
 
-	// Create index.js
-	indexJSContent := "console.log('Hello from Edon!');"
-	if err := os.WriteFile(filepath.Join(dir, "index.js"), []byte(indexJSContent), 0644); err != nil {
-		return fmt.Errorf("failed to write index.js: %w", err)
+	color.Green("✓ Initialized a new %s project in %s", *initTemplate, dir)
+	for _, f := range files {
+		color.Green("✓ Created %s", f.Path)
 	}
-
-	color.Green("✓ Successfully initialized new Edon project in %s", dir)
-	color.Green("✓ Created package.json")
-	color.Green("✓ Created index.js")
-
 	return nil
-}
\ No newline at end of file
+}
+
+func printTemplates() {
+	fmt.Println("Templates:")
+	for _, t := range templates.List() {
+		fmt.Printf("  %-12s %s\n", t.Name, t.Description)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+	"strings"
+
+	"github.com/katungi/edon/internal/config"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/packages"
+)
+
+// loaderFlags are the module loading flags shared by running a file and the
+// subcommands that load module graphs
+type loaderFlags struct {
+	allowImport *string
+	config      *string
+	importMap   *string
+	lockWrite   *bool
+	cachedOnly  *bool
+	strict      *bool
+	reload      reloadFlag
+}
+
+func addLoaderFlags(fs *flag.FlagSet) *loaderFlags {
+	f := &loaderFlags{
+		allowImport: fs.String("allow-import", "", "Comma-separated hosts remote modules may be imported from"),
+		config:      fs.String("config", "", "Path to the edon.json configuration file"),
+		importMap:   fs.String("import-map", "", "Path to an import map, overriding the one in edon.json"),
+		cachedOnly:  fs.Bool("cached-only", false, "Load modules from the caches only and never touch the network"),
+		lockWrite:   fs.Bool("lock-write", false, "Update the hashes in edon.lock instead of rejecting changed remote modules"),
+		strict:      fs.Bool("strict-cycles", false, "Treat circular imports as errors"),
+	}
+	fs.Var(&f.reload, "reload", "Refetch cached remote modules, or only those under the given comma-separated URL prefixes")
+	return f
+}
+
+// loadConfig reads the file given by -config, or the nearest edon.json
+func (f *loaderFlags) loadConfig() (*config.Config, error) {
+	if *f.config != "" {
+		return config.Load(*f.config)
+	}
+	return config.Find(".")
+}
+
+// newModuleLoader creates a loader configured by the flags and edon.json,
+// returning the configuration too so edon.json is only read once
+func (f *loaderFlags) newModuleLoader() (*loader.ModuleLoader, *config.Config, error) {
+	cfg, err := f.loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var importMap *loader.ImportMap
+	if *f.importMap != "" {
+		importMap, err = loader.LoadImportMap(*f.importMap)
+	} else {
+		importMap, err = cfg.LoadImportMap()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lock, err := loader.LoadLockfile(lockfilePath(cfg), *f.lockWrite)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return loader.NewModuleLoader(
+		loader.WithImportPolicy(cfg.ImportPolicy(loader.ParseAllowImport(*f.allowImport)...)),
+		loader.WithReload(f.reload...),
+		loader.WithImportMap(importMap),
+		loader.WithLockfile(lock),
+		loader.WithCachedOnly(*f.cachedOnly),
+		loader.WithStrictCycles(*f.strict),
+		loader.WithCompilerOptions(cfg.CompilerOptions),
+	), cfg, nil
+}
+
+// lockfilePath returns where edon.lock lives: next to edon.json, or else
+// next to the nearest package.json, or else in the working directory. Runs
+// and package commands share it, so both see the same lockfile.
+func lockfilePath(cfg *config.Config) string {
+	if cfg.Path != "" {
+		return filepath.Join(filepath.Dir(cfg.Path), loader.LockfileName)
+	}
+	if dir, err := packages.FindDir("."); err == nil {
+		return filepath.Join(dir, loader.LockfileName)
+	}
+	return loader.LockfileName
+}
+
+// reloadFlag is -reload, which reloads everything, or -reload=prefix,...
+type reloadFlag []string
+
+func (r *reloadFlag) String() string {
+	return strings.Join(*r, ",")
+}
+
+func (r *reloadFlag) Set(value string) error {
+	switch value {
+	case "true":
+		*r = []string{""}
+	case "false":
+		*r = nil
+	default:
+		*r = nil
+		for _, prefix := range strings.Split(value, ",") {
+			if prefix = strings.TrimSpace(prefix); prefix != "" {
+				*r = append(*r, prefix)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *reloadFlag) IsBoolFlag() bool {
+	return true
+}
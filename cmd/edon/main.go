@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -9,6 +10,8 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/katungi/edon/internal/runtime"
+	"github.com/katungi/edon/internal/standalone"
+	"github.com/katungi/edon/internal/testrunner"
 )
 
 var (
@@ -16,9 +19,21 @@ var (
 	evalScript  = flag.String("eval", "", "Evaluate a JavaScript expression")
 	showVersion = flag.Bool("version", false, "Show version information")
 	showHelp    = flag.Bool("help", false, "Show help information")
+	runFlags    = addLoaderFlags(flag.CommandLine)
 )
 
 func main() {
+	// A compiled executable runs the program it carries instead of the CLI
+	program, err := standalone.Detect()
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(1)
+	}
+	if program != nil {
+		exitWith(runEmbedded(program))
+		return
+	}
+
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
 		case "install":
@@ -28,8 +43,113 @@ func main() {
 				os.Exit(1)
 			}
 			return
+		case "add":
+			parseInterspersed(AddCmd, os.Args[2:])
+			if err := HandleAdd(); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "remove":
+			RemoveCmd.Parse(os.Args[2:])
+			if err := HandleRemove(); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "list":
+			ListCmd.Parse(os.Args[2:])
+			if err := HandleList(); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "outdated":
+			OutdatedCmd.Parse(os.Args[2:])
+			if err := HandleOutdated(); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "update":
+			parseInterspersed(UpdateCmd, os.Args[2:])
+			if err := HandleUpdate(); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "why":
+			WhyCmd.Parse(os.Args[2:])
+			if err := HandleWhy(); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "cache":
+			parseInterspersed(CacheCmd, os.Args[2:])
+			if err := HandleCache(); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "vendor":
+			parseInterspersed(VendorCmd, os.Args[2:])
+			if err := HandleVendor(); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "info":
+			parseInterspersed(InfoCmd, os.Args[2:])
+			if err := HandleInfo(); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "run":
+			RunCmd.Parse(os.Args[2:])
+			if err := HandleRun(); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(scriptExitCode(err))
+			}
+			return
+		case "test":
+			TestCmd.Parse(os.Args[2:])
+			if err := HandleTest(); err != nil {
+				// Failing tests have been reported already
+				if !errors.Is(err, testrunner.ErrTestsFailed) {
+					color.Red("Error: %v", err)
+				}
+				os.Exit(1)
+			}
+			return
+		case "check":
+			parseInterspersed(CheckCmd, os.Args[2:])
+			if err := HandleCheck(); err != nil {
+				// Problems have been printed already
+				code := checkExitCode(err)
+				if code != 1 {
+					color.Red("Error: %v", err)
+				}
+				os.Exit(code)
+			}
+			return
+		case "bundle":
+			parseInterspersed(BundleCmd, os.Args[2:])
+			if err := HandleBundle(); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "compile":
+			parseInterspersed(CompileCmd, os.Args[2:])
+			if err := HandleCompile(); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			return
 		case "init":
-			InitCmd.Parse(os.Args[2:])
+			parseInterspersed(InitCmd, os.Args[2:])
 			if err := HandleInit(); err != nil {
 				color.Red("Error: %v", err)
 				os.Exit(1)
@@ -41,13 +161,24 @@ func main() {
 	flag.Usage = printHelp
 	flag.Parse()
 
-	if err := run(); err != nil {
-		if err != runtime.ErrExit && err != runtime.ErrInterrupt {
-			color.Red("Error: %v", err)
-		}
+	exitWith(run())
+}
 
-		os.Exit(1)
+// exitWith exits with the status a script ended with, printing err unless
+// the script exited on purpose
+func exitWith(err error) {
+	if err == nil {
+		return
+	}
+	var exitErr *runtime.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Code)
 	}
+	if !errors.Is(err, runtime.ErrExit) && !errors.Is(err, runtime.ErrInterrupt) {
+		color.Red("Error: %v", err)
+	}
+
+	os.Exit(1)
 }
 
 // Version information
@@ -70,8 +201,28 @@ func run() error {
 		return nil
 	}
 
+	if watchPaths.enabled {
+		if flag.NArg() == 0 {
+			return fmt.Errorf("-watch needs a file to run")
+		}
+		return runWatch(flag.Arg(0))
+	}
+
+	moduleLoader, cfg, err := runFlags.newModuleLoader()
+	if err != nil {
+		return err
+	}
+
 	// Create new runtime instance
-	rt, err := runtime.New()
+	var args []string
+	if flag.NArg() > 1 {
+		args = flag.Args()[1:]
+	}
+	rt, err := runtime.New(
+		runtime.WithModuleLoader(moduleLoader),
+		runtime.WithArgs(args...),
+		runtime.WithPermissions(cfg.RunPermissions()),
+	)
 	if err != nil {
 		return fmt.Errorf("failed to initialize runtime: %w", err)
 	}
@@ -117,11 +268,38 @@ Halo JavaScript Runtime
 
 Usage:
   %s [options] [file]
+  %s install [packages]         Install package.json dependencies, or packages
+  %s add <pkg>[@range] [-dev]   Add a dependency to package.json and install it
+  %s remove <pkg>...            Remove a dependency from package.json
+  %s list [-depth n]            Show the installed dependency tree
+  %s outdated                   Show dependencies with newer versions
+  %s update [pkg...] [-latest]  Update dependencies within their ranges, or to latest
+  %s why <pkg>                  Show the dependency paths that lead to a package
+  %s cache [options] [entry]    Fetch and cache a module graph, or -clean the bytecode cache
+  %s vendor [options] <entry>   Copy remote and npm modules into ./vendor
+  %s info [options] [entry]     Show the module graph, or the cache locations
+  %s init [options] [dir]       Create a project from a template (-list shows them)
+  %s run [script] [-- args]     Run an edon.json task or package.json script
+  %s test [options] [paths]     Run the *_test.js and *.test.ts files under paths
+  %s check [options] <entry>... Report syntax and import errors without running
+  %s bundle <entry> -o out.js   Bundle a module graph into one self-contained file
+  %s compile <entry> -o tool    Compile a module graph into a standalone executable
 
 Options:
-  -eval string    Execute a JavaScript expression
-  -version        Show version information
-  -help           Show this help message
+  -eval string          Execute a JavaScript expression
+  -allow-import hosts   Also allow remote imports from these hosts
+                        (e.g. esm.sh,*.example.com,localhost:8000)
+  -config path          Use this edon.json instead of the nearest one
+  -import-map path      Remap import specifiers with this import map
+  -reload[=prefixes]    Refetch cached remote modules, all or by URL prefix
+  -cached-only          Never touch the network, fail on modules not in the cache
+  -lock-write           Record new hashes for changed remote modules in edon.lock
+  -strict-cycles        Fail on circular imports instead of allowing them
+  -watch[=paths]        Restart the script when its files, or these paths, change
+  -watch-exclude globs  Ignore changes to files matching these patterns
+  -clear-screen         Clear the terminal before each restart
+  -version              Show version information
+  -help                 Show this help message
 
 Examples:
   # Start REPL
@@ -132,6 +310,18 @@ Examples:
 
   # Evaluate expression
   %s -eval "console.log('Hello, World!')"
+
+  # Import from a private mirror
+  %s -allow-import=mirror.example.com script.js
+
+  # Refresh cached modules from esm.sh
+  %s -reload=https://esm.sh/ script.js
+
+  # Start a command-line tool in ./greeter
+  %s init -template cli greeter
+
+  # Restart on changes to the script's modules or anything under ./templates
+  %s -watch=templates -watch-exclude='*.tmp' script.js
 `
-	fmt.Printf(help, exe, exe, exe, exe)
+	fmt.Printf(help, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe)
 }
@@ -5,19 +5,33 @@ import (
 	"flag"
 	"fmt"
 
+	"github.com/fatih/color"
+	"github.com/katungi/edon/internal/config"
 	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/packages"
 )
 
 var (
-	InstallCmd = flag.NewFlagSet("install", flag.ExitOnError)
+	InstallCmd        = flag.NewFlagSet("install", flag.ExitOnError)
+	installCachedOnly = InstallCmd.Bool("cached-only", false, "Install from the package cache only and never touch the network")
+
+	AddCmd        = flag.NewFlagSet("add", flag.ExitOnError)
+	addDev        = AddCmd.Bool("dev", false, "Add the packages to devDependencies")
+	addCachedOnly = AddCmd.Bool("cached-only", false, "Install from the package cache only and never touch the network")
+
+	RemoveCmd = flag.NewFlagSet("remove", flag.ExitOnError)
 )
 
+// HandleInstall installs the given packages into the package cache, or
+// without arguments the dependencies of the nearest package.json
 func HandleInstall() error {
-	if InstallCmd.NArg() < 1 {
-		return fmt.Errorf("package name is required")
+	if InstallCmd.NArg() == 0 {
+		return installProject(*installCachedOnly, func(p *packages.Project) (*packages.Result, error) {
+			return p.Install(context.Background())
+		})
 	}
 
-	pm, err := loader.NewNPMPackageManager()
+	pm, err := loader.NewNPMPackageManager(loader.NPMCachedOnly(*installCachedOnly))
 	if err != nil {
 		return fmt.Errorf("failed to initialize NPM package manager: %v", err)
 	}
@@ -33,3 +47,68 @@ func HandleInstall() error {
 
 	return nil
 }
+
+// HandleAdd adds packages to package.json and installs them
+func HandleAdd() error {
+	return installProject(*addCachedOnly, func(p *packages.Project) (*packages.Result, error) {
+		return p.Add(context.Background(), AddCmd.Args(), *addDev)
+	})
+}
+
+// HandleRemove removes packages from package.json and the lockfile
+func HandleRemove() error {
+	return installProject(false, func(p *packages.Project) (*packages.Result, error) {
+		return p.Remove(context.Background(), RemoveCmd.Args())
+	})
+}
+
+// installProject runs install on the nearest package.json, then saves the
+// lockfile and reports what is installed
+func installProject(cachedOnly bool, install func(*packages.Project) (*packages.Result, error)) error {
+	project, lock, err := openProject(cachedOnly)
+	if err != nil {
+		return err
+	}
+	result, err := install(project)
+	if err != nil {
+		return err
+	}
+	if err := lock.Save(); err != nil {
+		return err
+	}
+
+	for _, dep := range result.Direct {
+		kind := ""
+		if dep.Dev {
+			kind = " (dev)"
+		}
+		color.Green("+ %s@%s%s", dep.Name, dep.Version, kind)
+	}
+	fmt.Printf("Installed %d packages, %d downloaded\n", result.Packages, result.Downloaded)
+	return nil
+}
+
+// openProject opens the nearest package.json with the lockfile runs use
+func openProject(cachedOnly bool) (*packages.Project, *loader.Lockfile, error) {
+	pm, err := loader.NewNPMPackageManager(loader.NPMCachedOnly(cachedOnly))
+	if err != nil {
+		return nil, nil, err
+	}
+	dir, err := packages.FindDir(".")
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, err := config.Find(".")
+	if err != nil {
+		return nil, nil, err
+	}
+	lock, err := loader.LoadLockfile(lockfilePath(cfg), false)
+	if err != nil {
+		return nil, nil, err
+	}
+	project, err := packages.Open(dir, pm, lock)
+	if err != nil {
+		return nil, nil, err
+	}
+	return project, lock, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/katungi/edon/internal/errors"
+)
+
+var (
+	ListCmd   = flag.NewFlagSet("list", flag.ExitOnError)
+	listDepth = ListCmd.Int("depth", 0, "Levels of dependencies to show below the direct ones, -1 for all")
+
+	OutdatedCmd = flag.NewFlagSet("outdated", flag.ExitOnError)
+
+	UpdateCmd    = flag.NewFlagSet("update", flag.ExitOnError)
+	updateLatest = UpdateCmd.Bool("latest", false, "Move to the latest versions, updating the ranges in package.json")
+
+	WhyCmd = flag.NewFlagSet("why", flag.ExitOnError)
+)
+
+// HandleList prints the installed dependency tree
+func HandleList() error {
+	project, _, err := openProject(false)
+	if err != nil {
+		return err
+	}
+	tree, err := project.Tree()
+	if err != nil {
+		return err
+	}
+	return tree.Write(os.Stdout, *listDepth)
+}
+
+// HandleOutdated prints the dependencies that have newer versions
+func HandleOutdated() error {
+	project, _, err := openProject(false)
+	if err != nil {
+		return err
+	}
+	outdated, err := project.Outdated(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(outdated) == 0 {
+		fmt.Println("All dependencies are up to date")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Package\tCurrent\tWanted\tLatest")
+	for _, o := range outdated {
+		name, current := o.Name, o.Current
+		if o.Dev {
+			name += " (dev)"
+		}
+		if current == "" {
+			current = "missing"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, current, o.Wanted, o.Latest)
+	}
+	return w.Flush()
+}
+
+// HandleUpdate updates the given packages, or all of them
+func HandleUpdate() error {
+	project, lock, err := openProject(false)
+	if err != nil {
+		return err
+	}
+	changes, result, err := project.Update(context.Background(), UpdateCmd.Args(), *updateLatest)
+	if err != nil {
+		return err
+	}
+	if err := lock.Save(); err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		if c.From == "" {
+			color.Green("+ %s@%s", c.Name, c.To)
+			continue
+		}
+		color.Green("↑ %s %s -> %s", c.Name, c.From, c.To)
+	}
+	if len(changes) == 0 {
+		fmt.Println("Direct dependencies are up to date")
+	}
+	fmt.Printf("Installed %d packages, %d downloaded\n", result.Packages, result.Downloaded)
+	return nil
+}
+
+// HandleWhy prints every dependency path that leads to a package
+func HandleWhy() error {
+	if WhyCmd.NArg() != 1 {
+		return errors.ErrPackageRequired
+	}
+	project, _, err := openProject(false)
+	if err != nil {
+		return err
+	}
+	tree, err := project.Tree()
+	if err != nil {
+		return err
+	}
+	paths, err := tree.Why(WhyCmd.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		fmt.Println(strings.Join(path, " > "))
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/katungi/edon/internal/config"
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/scripts"
+	"github.com/katungi/edon/internal/shell"
+)
+
+var RunCmd = flag.NewFlagSet("run", flag.ExitOnError)
+
+// HandleRun runs a package.json script, or lists the scripts when no name
+// is given. Arguments after the name, or after --, are passed to the script.
+func HandleRun() error {
+	pkg, err := findTasks()
+	if err != nil {
+		return err
+	}
+
+	if RunCmd.NArg() < 1 {
+		printScripts(pkg)
+		return nil
+	}
+
+	name, args := RunCmd.Arg(0), RunCmd.Args()[1:]
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+
+	// Scripts find installed package binaries and edon itself on PATH
+	var binDirs []string
+	if dir, err := loader.BinDir(); err == nil {
+		binDirs = append(binDirs, dir)
+	}
+	if exe, err := os.Executable(); err == nil {
+		binDirs = append(binDirs, filepath.Dir(exe))
+	}
+
+	// Interrupts reach the script's commands directly; edon waits for them
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return pkg.Run(ctx, name, args, scripts.WithBinDirs(binDirs...))
+}
+
+// findTasks reads the scripts of the nearest package.json and the tasks of
+// the nearest edon.json, which take precedence. Without a package.json the
+// tasks run in the directory of edon.json.
+func findTasks() (*scripts.Package, error) {
+	cfg, err := config.Find(".")
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := scripts.Find(".")
+	if err != nil && (cfg.Tasks == nil || !errors.Is(err, errors.ErrNoPackageJSON)) {
+		return nil, err
+	}
+	if cfg.Tasks == nil {
+		return pkg, nil
+	}
+
+	tasks, err := scripts.ParseScripts(cfg.Tasks)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidConfig, fmt.Sprintf("%s: tasks: %v", cfg.Path, err))
+	}
+	if pkg == nil {
+		pkg = &scripts.Package{Dir: filepath.Dir(cfg.Path)}
+	}
+	pkg.AddTasks(tasks)
+	return pkg, nil
+}
+
+func printScripts(pkg *scripts.Package) {
+	if len(pkg.Scripts) == 0 {
+		fmt.Printf("No scripts in %s\n", filepath.Join(pkg.Dir, "package.json"))
+		return
+	}
+	name := pkg.Name
+	if name == "" {
+		name = filepath.Base(pkg.Dir)
+	}
+	fmt.Printf("Scripts available in %s via `edon run`:\n", name)
+	for _, s := range pkg.Scripts {
+		fmt.Printf("  %s\n    %s\n", s.Name, s.Command)
+	}
+}
+
+// scriptExitCode is the status edon run exits with: the failing command's,
+// or 1 for any other error
+func scriptExitCode(err error) int {
+	var exitErr *shell.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
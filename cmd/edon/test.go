@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/testrunner"
+)
+
+var (
+	TestCmd      = flag.NewFlagSet("test", flag.ExitOnError)
+	testFilter   = TestCmd.String("filter", "", "Run only tests whose names contain this text, or match it when written /like this/")
+	testReporter = TestCmd.String("reporter", "pretty", "Report results as pretty, tap or junit")
+	testOutput   = TestCmd.String("output", "", "Write the report to this file instead of stdout")
+	testFlags    = addLoaderFlags(TestCmd)
+)
+
+// HandleTest runs the test files under the given paths, or under the
+// working directory
+func HandleTest() error {
+	files, err := testrunner.Discover(TestCmd.Args())
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.ErrNoTestFiles
+	}
+
+	moduleLoader, _, err := testFlags.newModuleLoader()
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *testOutput != "" {
+		f, err := os.Create(*testOutput)
+		if err != nil {
+			return errors.WrapWith(errors.ErrFileWrite, err, *testOutput)
+		}
+		defer f.Close()
+		out = f
+	}
+	reporter, err := testrunner.NewReporter(*testReporter, out)
+	if err != nil {
+		return err
+	}
+
+	_, err = testrunner.Run(files, reporter,
+		testrunner.WithFilter(*testFilter),
+		testrunner.WithModuleLoader(moduleLoader),
+	)
+	return err
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+var (
+	VendorCmd    = flag.NewFlagSet("vendor", flag.ExitOnError)
+	vendorOutput = VendorCmd.String("output", "vendor", "Directory to vendor modules into")
+	vendorFlags  = addLoaderFlags(VendorCmd)
+)
+
+// HandleVendor copies the remote and npm modules the entry points import
+// into the vendor directory, next to an import map that points at them
+func HandleVendor() error {
+	if VendorCmd.NArg() < 1 {
+		return fmt.Errorf("entry module is required")
+	}
+
+	moduleLoader, _, err := vendorFlags.newModuleLoader()
+	if err != nil {
+		return err
+	}
+
+	graphs := make([]*loader.ModuleGraph, 0, VendorCmd.NArg())
+	for _, entry := range VendorCmd.Args() {
+		if _, err := os.Stat(entry); err == nil {
+			if entry, err = filepath.Abs(entry); err != nil {
+				return err
+			}
+		}
+		graph, err := moduleLoader.LoadGraph(context.Background(), entry)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", entry, err)
+		}
+		graphs = append(graphs, graph)
+	}
+
+	mapPath, err := loader.Vendor(*vendorOutput, graphs...)
+	if err != nil {
+		return fmt.Errorf("failed to vendor modules: %w", err)
+	}
+
+	fmt.Printf("Vendored modules into %s\n", *vendorOutput)
+	fmt.Printf("Run with -import-map=%s, or set \"importMap\" in edon.json\n", mapPath)
+	return nil
+}
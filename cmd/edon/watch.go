@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/exec"
+	"os/signal"
+	goruntime "runtime"
+	"strings"
+	"time"
+
+	"github.com/katungi/edon/internal/watch"
+)
+
+var (
+	watchPaths   watchFlag
+	watchExclude listFlag
+	clearScreen  = flag.Bool("clear-screen", false, "Clear the terminal before each restart in watch mode")
+)
+
+func init() {
+	flag.Var(&watchPaths, "watch", "Restart the script when its files, or the given comma-separated paths, change")
+	flag.Var(&watchExclude, "watch-exclude", "Comma-separated glob patterns of files watch mode ignores")
+}
+
+// runWatch runs the script in a child process, and again whenever one of
+// its files changes. Each run gets a fresh runtime, and a process can be
+// stopped whatever its event loop is waiting on.
+func runWatch(script string) error {
+	moduleLoader, _, err := runFlags.newModuleLoader()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	args := childArgs(os.Args[1:], flag.NArg())
+	return watch.Run(ctx, moduleLoader, script, func(ctx context.Context) error {
+		exe, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		cmd := exec.CommandContext(ctx, exe, args...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if goruntime.GOOS != "windows" {
+			cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+		}
+		cmd.WaitDelay = 2 * time.Second
+
+		err = cmd.Run()
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	},
+		watch.WithPaths(watchPaths.paths...),
+		watch.WithExclude(watchExclude...),
+		watch.WithClearScreen(*clearScreen),
+	)
+}
+
+// childArgs returns args without the watch mode flags. positional counts
+// the arguments after the flags, which are left alone.
+func childArgs(args []string, positional int) []string {
+	flags, rest := args[:len(args)-positional], args[len(args)-positional:]
+	var out []string
+	for i := 0; i < len(flags); i++ {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(flags[i], "-"), "=")
+		switch name {
+		case "watch", "clear-screen":
+			continue
+		case "watch-exclude":
+			if !hasValue {
+				i++
+			}
+			continue
+		}
+		out = append(out, flags[i])
+	}
+	return append(out, rest...)
+}
+
+// watchFlag is -watch, or -watch=path,... to watch more paths as well
+type watchFlag struct {
+	enabled bool
+	paths   []string
+}
+
+func (w *watchFlag) String() string {
+	return strings.Join(w.paths, ",")
+}
+
+func (w *watchFlag) Set(value string) error {
+	switch value {
+	case "true":
+		w.enabled, w.paths = true, nil
+	case "false":
+		w.enabled, w.paths = false, nil
+	default:
+		w.enabled, w.paths = true, splitList(value)
+	}
+	return nil
+}
+
+func (w *watchFlag) IsBoolFlag() bool {
+	return true
+}
+
+// listFlag collects comma-separated values, and may be repeated
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, splitList(value)...)
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
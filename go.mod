@@ -5,11 +5,14 @@ go 1.25.3
 require (
 	github.com/buke/quickjs-go v0.6.8
 	github.com/chzyer/readline v1.5.1
+	github.com/evanw/esbuild v0.28.2
 	github.com/fatih/color v1.18.0
+	github.com/fsnotify/fsnotify v1.10.1
+	github.com/tetratelabs/wazero v1.12.0
 )
 
 require (
 	github.com/mattn/go-colorable v0.1.14 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
-	golang.org/x/sys v0.39.0 // indirect
+	golang.org/x/sys v0.44.0 // indirect
 )
@@ -0,0 +1,232 @@
+// Package bundle turns a module graph into a single JavaScript file that
+// runs without fetching anything. Modules are handed to esbuild from the
+// graph, so they are resolved and loaded exactly as edon runs them, and
+// esbuild hoists them into one scope.
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+// Format is the module format of a bundle
+type Format string
+
+const (
+	// ESM is an ES module, which may use top-level await and keeps imports
+	// of built-in modules
+	ESM Format = "esm"
+	// IIFE is a classic script wrapping the bundle in a function
+	IIFE Format = "iife"
+)
+
+// Option configures a bundle
+type Option func(*bundler)
+
+type bundler struct {
+	format     Format
+	minify     bool
+	sourceMap  bool
+	globalName string
+	tsconfig   string
+}
+
+// WithFormat sets the format of the bundle, ESM by default
+func WithFormat(format Format) Option {
+	return func(b *bundler) {
+		b.format = format
+	}
+}
+
+// WithMinify minifies whitespace, identifiers and syntax
+func WithMinify(minify bool) Option {
+	return func(b *bundler) {
+		b.minify = minify
+	}
+}
+
+// WithSourceMap also produces an external source map, linked from the end
+// of the bundle
+func WithSourceMap(sourceMap bool) Option {
+	return func(b *bundler) {
+		b.sourceMap = sourceMap
+	}
+}
+
+// WithGlobalName assigns the exports of an IIFE bundle to this global
+func WithGlobalName(name string) Option {
+	return func(b *bundler) {
+		b.globalName = name
+	}
+}
+
+// WithCompilerOptions sets the tsconfig compilerOptions TypeScript and JSX
+// modules are compiled with, which should be those the graph was loaded with
+func WithCompilerOptions(options json.RawMessage) Option {
+	return func(b *bundler) {
+		b.tsconfig = ""
+		if len(options) > 0 {
+			b.tsconfig = `{"compilerOptions":` + string(options) + `}`
+		}
+	}
+}
+
+// File is a file of a bundle
+type File struct {
+	Path     string
+	Contents []byte
+}
+
+// namespace holds the modules that do not live on disk
+const namespace = "edon"
+
+// Bundle bundles graph into outfile, returning the bundle followed, when a
+// source map was asked for, by the map next to it. Nothing is written.
+func Bundle(graph *loader.ModuleGraph, outfile string, opts ...Option) ([]File, error) {
+	b := &bundler{format: ESM}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	var format api.Format
+	switch b.format {
+	case ESM:
+		format = api.FormatESModule
+	case IIFE:
+		format = api.FormatIIFE
+	default:
+		return nil, errors.Wrap(errors.ErrBundle, fmt.Sprintf("unknown format %q, want esm or iife", b.format))
+	}
+
+	outfile, err := filepath.Abs(outfile)
+	if err != nil {
+		return nil, errors.WrapWith(errors.ErrBundle, err, "")
+	}
+
+	sourceMap := api.SourceMapNone
+	if b.sourceMap {
+		sourceMap = api.SourceMapLinked
+	}
+
+	result := api.Build(api.BuildOptions{
+		EntryPoints:       []string{graph.Root},
+		Bundle:            true,
+		Write:             false,
+		Outfile:           outfile,
+		AbsWorkingDir:     filepath.Dir(outfile),
+		Format:            format,
+		GlobalName:        b.globalName,
+		Platform:          api.PlatformNeutral,
+		Target:            api.ESNext,
+		MinifyWhitespace:  b.minify,
+		MinifyIdentifiers: b.minify,
+		MinifySyntax:      b.minify,
+		Sourcemap:         sourceMap,
+		TsconfigRaw:       b.tsconfig,
+		Charset:           api.CharsetUTF8,
+		LogLevel:          api.LogLevelSilent,
+		Plugins:           []api.Plugin{graphPlugin(graph, b.format)},
+	})
+	if len(result.Errors) > 0 {
+		return nil, buildError(result.Errors)
+	}
+
+	// The bundle comes first, then its source map
+	files := make([]File, 0, len(result.OutputFiles))
+	for _, out := range result.OutputFiles {
+		f := File{Path: out.Path, Contents: out.Contents}
+		if out.Path == outfile {
+			files = append([]File{f}, files...)
+		} else {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// graphPlugin resolves and loads every module from graph. Each module is
+// loaded with the ID of its graph module as plugin data, which its imports
+// are resolved against. TypeScript and JSX are loaded as written, so that
+// source maps point into them. Built-in modules stay imports, which only an
+// ES module can have.
+func graphPlugin(graph *loader.ModuleGraph, format Format) api.Plugin {
+	return api.Plugin{
+		Name: "edon-graph",
+		Setup: func(build api.PluginBuild) {
+			build.OnResolve(api.OnResolveOptions{Filter: ".*"}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+				if args.Kind == api.ResolveEntryPoint {
+					return resolved(graph, args.Path), nil
+				}
+				importer, _ := args.PluginData.(string)
+				id, ok := graph.Modules[importer].Specifiers[args.Path]
+				if !ok {
+					// Dynamic imports that failed to load fail when they run
+					if args.Kind == api.ResolveJSDynamicImport {
+						return api.OnResolveResult{Path: args.Path, External: true}, nil
+					}
+					return api.OnResolveResult{}, fmt.Errorf("%q is not in the module graph", args.Path)
+				}
+				if module := graph.Modules[id]; module.Type == loader.TypeNode || module.Type == loader.TypeEdon {
+					if format != ESM {
+						return api.OnResolveResult{}, fmt.Errorf("built-in module %q can only be imported by an esm bundle", id)
+					}
+					return api.OnResolveResult{Path: id, External: true}, nil
+				}
+				return resolved(graph, id), nil
+			})
+
+			load := func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+				id, _ := args.PluginData.(string)
+				module, ok := graph.Modules[id]
+				if !ok {
+					return api.OnLoadResult{}, fmt.Errorf("%q is not in the module graph", args.Path)
+				}
+				source, loader := module.Original()
+				source = stripAttributes(source)
+				return api.OnLoadResult{Contents: &source, Loader: loader, PluginData: id}, nil
+			}
+			build.OnLoad(api.OnLoadOptions{Filter: ".*", Namespace: "file"}, load)
+			build.OnLoad(api.OnLoadOptions{Filter: ".*", Namespace: namespace}, load)
+		},
+	}
+}
+
+// resolved locates the module id: by file path for modules from disk, so
+// source maps name them by relative path, and by URL for the rest. The
+// type of a JSON, text or bytes import becomes the path suffix.
+func resolved(graph *loader.ModuleGraph, id string) api.OnResolveResult {
+	module := graph.Modules[id]
+	result := api.OnResolveResult{Path: module.Path, Namespace: "file", PluginData: id}
+	if module.Path == "" {
+		result.Path, result.Namespace = module.URL, namespace
+	}
+	result.Suffix = strings.TrimPrefix(id, result.Path)
+	return result
+}
+
+// stripAttributes removes import attributes, whose types the graph has
+// already turned into JavaScript modules. Their specifiers become the
+// import keys, which the resolver looks the modules up by.
+func stripAttributes(source string) string {
+	return loader.RewriteImports(source, loader.ScanImports(source), func(imp loader.Import) (string, bool) {
+		return imp.Key(), imp.AttrEnd > 0
+	})
+}
+
+func buildError(messages []api.Message) error {
+	lines := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		line := msg.Text
+		if msg.Location != nil {
+			line = fmt.Sprintf("%s:%d:%d: %s", msg.Location.File, msg.Location.Line, msg.Location.Column+1, msg.Text)
+		}
+		lines = append(lines, line)
+	}
+	return errors.Wrap(errors.ErrBundle, strings.Join(lines, "\n"))
+}
@@ -0,0 +1,137 @@
+// Package config reads edon.json, the per-project configuration file.
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/modules/node"
+)
+
+// FileName is the name of the project configuration file
+const FileName = "edon.json"
+
+// Config is the contents of an edon.json file
+type Config struct {
+	RemoteImports *RemoteImports `json:"remoteImports,omitempty"`
+
+	// ImportMap is the path of an import map file, relative to edon.json
+	ImportMap string `json:"importMap,omitempty"`
+	// Imports and Scopes embed an import map in edon.json itself
+	Imports json.RawMessage `json:"imports,omitempty"`
+	Scopes  json.RawMessage `json:"scopes,omitempty"`
+
+	// Permissions limit what scripts run by edon may reach, and are what
+	// edon compile grants when given no -allow flags. Without them scripts
+	// may reach everything.
+	Permissions *node.Permissions `json:"permissions,omitempty"`
+	// Tasks are commands for edon run, by name, next to package.json scripts
+	Tasks json.RawMessage `json:"tasks,omitempty"`
+	// CompilerOptions are tsconfig compilerOptions for TypeScript and JSX
+	CompilerOptions json.RawMessage `json:"compilerOptions,omitempty"`
+
+	// Path is the file the configuration was read from, empty when no file
+	// was found
+	Path string `json:"-"`
+}
+
+// RemoteImports controls which hosts remote modules may be imported from
+type RemoteImports struct {
+	// Allow replaces the default list of trusted hosts when set
+	Allow []string `json:"allow,omitempty"`
+	// Headers are sent to matching hosts. Values may reference environment
+	// variables, e.g. "Bearer ${MIRROR_TOKEN}".
+	Headers map[string]map[string]string `json:"headers,omitempty"`
+}
+
+// Load reads the configuration file at path
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrConfigRead, err.Error())
+	}
+
+	cfg := &Config{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidConfig, path+": "+err.Error())
+	}
+	cfg.Path = path
+	return cfg, nil
+}
+
+// Find loads the nearest edon.json in dir or one of its parents. It returns
+// an empty configuration when there is none.
+func Find(dir string) (*Config, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrConfigRead, err.Error())
+	}
+
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return &Config{}, nil
+		}
+		dir = parent
+	}
+}
+
+// RunPermissions returns the permissions scripts run with
+func (c *Config) RunPermissions() node.Permissions {
+	if c.Permissions == nil {
+		return node.AllowAll
+	}
+	return *c.Permissions
+}
+
+// ImportPolicy builds the remote import policy from the configuration, with
+// extra host patterns (from --allow-import) added on top
+func (c *Config) ImportPolicy(extra ...string) *loader.ImportPolicy {
+	policy := loader.DefaultImportPolicy()
+	if c.RemoteImports != nil {
+		if c.RemoteImports.Allow != nil {
+			policy = loader.NewImportPolicy(c.RemoteImports.Allow...)
+		}
+		for pattern, headers := range c.RemoteImports.Headers {
+			expanded := make(map[string]string, len(headers))
+			for name, value := range headers {
+				expanded[name] = os.ExpandEnv(value)
+			}
+			policy.SetHeaders(pattern, expanded)
+		}
+	}
+	policy.Allow(extra...)
+	return policy
+}
+
+// LoadImportMap returns the import map the configuration names or embeds, or
+// nil if it has none
+func (c *Config) LoadImportMap() (*loader.ImportMap, error) {
+	if c.ImportMap != "" {
+		path := c.ImportMap
+		if !filepath.IsAbs(path) && c.Path != "" {
+			path = filepath.Join(filepath.Dir(c.Path), path)
+		}
+		return loader.LoadImportMap(path)
+	}
+	if c.Imports == nil && c.Scopes == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(map[string]json.RawMessage{"imports": c.Imports, "scopes": c.Scopes})
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidConfig, err.Error())
+	}
+	base, err := filepath.Abs(c.Path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrConfigRead, err.Error())
+	}
+	return loader.ParseImportMap(data, loader.FileURL(base), c.Path)
+}
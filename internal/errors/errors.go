@@ -16,9 +16,13 @@ var (
 	ErrRuntimeInit   = errors.New("failed to initialize runtime")
 	ErrBuiltinInit   = errors.New("failed to initialize builtins")
 	ErrConsoleInit   = errors.New("failed to initialize console")
+	ErrWasmInit      = errors.New("failed to initialize WebAssembly")
+	ErrNodeInit      = errors.New("failed to initialize node built-ins")
+	ErrEdonInit      = errors.New("failed to initialize the Edon global")
 	ErrEvalFailed    = errors.New("evaluation failed")
 	ErrFileNotFound  = errors.New("file not found")
 	ErrFileRead      = errors.New("failed to read file")
+	ErrFileWrite     = errors.New("failed to write file")
 	ErrInvalidScript = errors.New("invalid script")
 )
 
@@ -29,16 +33,82 @@ var (
 	ErrUnsupportedModule  = errors.New("unsupported module type")
 	ErrModuleNotFound     = errors.New("module not found")
 	ErrCircularDependency = errors.New("circular dependency detected")
-	ErrJSRNotImplemented  = errors.New("JSR module loading not implemented yet")
+	ErrInvalidSpecifier   = errors.New("invalid module specifier")
+	ErrUnresolvedImport   = errors.New("unable to resolve import")
+	ErrImportNotAllowed   = errors.New("remote import not allowed")
+	ErrInvalidImportMap   = errors.New("invalid import map")
+	ErrModuleIntegrity    = errors.New("module integrity check failed")
+	ErrInvalidLockfile    = errors.New("invalid lockfile")
+	ErrNotCached          = errors.New("not in cache")
+	ErrInvalidImportType  = errors.New("invalid import type")
+	ErrInvalidJSON        = errors.New("invalid JSON module")
+	ErrInvalidWasm        = errors.New("invalid WebAssembly module")
 )
 
 // NPM errors
 var (
-	ErrPackageRequired = errors.New("package name is required")
-	ErrPackageNotFound = errors.New("package not found")
-	ErrPackageInstall  = errors.New("failed to install package")
-	ErrPackageFetch    = errors.New("failed to fetch package metadata")
-	ErrCacheDir        = errors.New("failed to create cache directory")
+	ErrPackageRequired         = errors.New("package name is required")
+	ErrPackageNotFound         = errors.New("package not found")
+	ErrPackageInstall          = errors.New("failed to install package")
+	ErrPackageFetch            = errors.New("failed to fetch package metadata")
+	ErrCacheDir                = errors.New("failed to create cache directory")
+	ErrPackageIntegrity        = errors.New("package integrity check failed")
+	ErrInvalidPackageConfig    = errors.New("invalid package configuration")
+	ErrInvalidPackageTarget    = errors.New("invalid package target")
+	ErrPackagePathNotExported  = errors.New("package subpath is not exported")
+	ErrPackageImportNotDefined = errors.New("package import is not defined")
+	ErrNotDependency           = errors.New("not a dependency of the project")
+	ErrNotInstalled            = errors.New("package is not installed")
+)
+
+// Config errors
+var (
+	ErrConfigRead    = errors.New("failed to read config file")
+	ErrInvalidConfig = errors.New("invalid config file")
+)
+
+// Script errors
+var (
+	ErrNoPackageJSON   = errors.New("no package.json found")
+	ErrScriptNotFound  = errors.New("missing script")
+	ErrShellSyntax     = errors.New("invalid shell syntax")
+	ErrCommandNotFound = errors.New("command not found")
+)
+
+// Test errors
+var (
+	ErrNoTestFiles     = errors.New("no test files found")
+	ErrTestsFailed     = errors.New("tests failed")
+	ErrUnknownReporter = errors.New("unknown test reporter")
+)
+
+// Check errors
+var (
+	ErrEntryRequired = errors.New("entry module is required")
+	ErrCheckFailed   = errors.New("check found problems")
+)
+
+// Bundle errors
+var (
+	ErrBundle = errors.New("failed to bundle")
+)
+
+// Compile errors
+var (
+	ErrInvalidProgram = errors.New("invalid compiled program")
+	ErrOutputRequired = errors.New("output file is required")
+)
+
+// Watch errors
+var (
+	ErrWatch               = errors.New("failed to watch files")
+	ErrInvalidWatchPattern = errors.New("invalid watch exclude pattern")
+)
+
+// Init errors
+var (
+	ErrUnknownTemplate = errors.New("unknown template")
+	ErrFileExists      = errors.New("file already exists")
 )
 
 // Server errors
@@ -77,3 +147,8 @@ func Is(err, target error) bool {
 func As(err error, target any) bool {
 	return errors.As(err, target)
 }
+
+// Join returns an error that wraps each of errs
+func Join(errs ...error) error {
+	return errors.Join(errs...)
+}
@@ -0,0 +1,138 @@
+// Package edon provides the Edon global and the edon: built-in modules:
+// edon:test, for describe/it style tests next to Edon.test, and edon:assert.
+//
+// Tests register with a registry in the global's prelude while their file
+// is evaluated; RunTests then runs them and collects the results.
+package edon
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/buke/quickjs-go"
+)
+
+//go:embed js
+var sources embed.FS
+
+// opsName is the global the registry is reachable under
+const opsName = "__edon"
+
+// builtins maps each built-in module name to its source file
+var builtins = map[string]string{
+	"assert": "js/assert.js",
+	"test":   "js/test.js",
+}
+
+// Builtins returns the names of the built-in modules
+func Builtins() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsBuiltin reports whether name, with or without the edon: prefix, is a
+// built-in module
+func IsBuiltin(name string) bool {
+	_, ok := builtins[strings.TrimPrefix(name, "edon:")]
+	return ok
+}
+
+// Source returns the JavaScript source of a built-in module
+func Source(name string) (string, bool) {
+	file, ok := builtins[strings.TrimPrefix(name, "edon:")]
+	if !ok {
+		return "", false
+	}
+	data, err := sources.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Init installs the Edon global in ctx
+func Init(ctx *quickjs.Context) error {
+	ctx.Globals().Set(opsName, ctx.Object())
+
+	prelude, err := sources.ReadFile("js/prelude.js")
+	if err != nil {
+		return err
+	}
+	result := ctx.Eval(string(prelude), quickjs.EvalFileName("edon:prelude"))
+	defer result.Free()
+	if result.IsException() {
+		return ctx.Exception()
+	}
+	return nil
+}
+
+// TestStatus is the outcome of a test or step
+type TestStatus string
+
+const (
+	TestPassed  TestStatus = "passed"
+	TestFailed  TestStatus = "failed"
+	TestIgnored TestStatus = "ignored"
+)
+
+// TestResult is the outcome of a test, or of a step within one
+type TestResult struct {
+	// Name is the test name, prefixed with the names of the describe
+	// blocks around it, separated by " > "
+	Name   string     `json:"name"`
+	Status TestStatus `json:"status"`
+	// Error is the message and stack of what failed the test
+	Error  string       `json:"error,omitempty"`
+	Millis float64      `json:"ms"`
+	Steps  []TestResult `json:"steps,omitempty"`
+}
+
+// Duration returns how long the test took
+func (r TestResult) Duration() time.Duration {
+	return time.Duration(r.Millis * float64(time.Millisecond))
+}
+
+// TestReport holds the results of the tests registered in a context
+type TestReport struct {
+	Tests []TestResult `json:"tests"`
+	// Filtered counts the tests skipped for not matching the filter or,
+	// when some tests are marked only, for not being marked
+	Filtered int `json:"filtered"`
+	// Only is set when some tests were marked only
+	Only bool `json:"only"`
+}
+
+// RunTests runs the tests registered in ctx whose names match filter: a
+// substring, or a regular expression between slashes. An empty filter
+// matches every test.
+func RunTests(ctx *quickjs.Context, filter string) (*TestReport, error) {
+	ops := ctx.Globals().Get(opsName)
+	defer ops.Free()
+	run := ops.Get("runTests")
+	defer run.Free()
+	if !run.IsFunction() {
+		return nil, fmt.Errorf("the test registry is not installed")
+	}
+
+	arg := ctx.String(filter)
+	defer arg.Free()
+	result := ctx.Await(run.Execute(ops, arg))
+	defer result.Free()
+	if result.IsException() {
+		return nil, ctx.Exception()
+	}
+
+	var report TestReport
+	if err := json.Unmarshal([]byte(result.String()), &report); err != nil {
+		return nil, fmt.Errorf("decode test results: %w", err)
+	}
+	return &report, nil
+}
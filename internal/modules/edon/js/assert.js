@@ -0,0 +1,214 @@
+// edon:assert: assertions for tests. Each throws an AssertionError when
+// its check fails.
+import { inspect, isDeepStrictEqual } from "node:util";
+
+export class AssertionError extends Error {
+  constructor(message, options = {}) {
+    super(message, options.cause === undefined ? undefined : { cause: options.cause });
+    this.name = "AssertionError";
+    if ("actual" in options) this.actual = options.actual;
+    if ("expected" in options) this.expected = options.expected;
+  }
+}
+
+const show = (value) => inspect(value, { depth: 8 });
+
+function explain(message, fallback) {
+  return message === undefined ? fallback : `${fallback}: ${message}`;
+}
+
+function compared(actual, expected) {
+  return `\n\n    actual: ${show(actual)}\n  expected: ${show(expected)}\n`;
+}
+
+export function assert(condition, message) {
+  if (!condition) {
+    throw new AssertionError(message ?? "Expected a truthy value, got " + show(condition));
+  }
+}
+
+export function assertFalse(condition, message) {
+  if (condition) {
+    throw new AssertionError(message ?? "Expected a falsy value, got " + show(condition));
+  }
+}
+
+export function assertEquals(actual, expected, message) {
+  if (!isDeepStrictEqual(actual, expected)) {
+    throw new AssertionError(explain(message, "Values are not equal") + compared(actual, expected), { actual, expected });
+  }
+}
+
+export function assertNotEquals(actual, expected, message) {
+  if (isDeepStrictEqual(actual, expected)) {
+    throw new AssertionError(explain(message, `Expected actual to not equal ${show(expected)}`), { actual, expected });
+  }
+}
+
+export function assertStrictEquals(actual, expected, message) {
+  if (!Object.is(actual, expected)) {
+    const fallback = isDeepStrictEqual(actual, expected)
+      ? "Values have the same structure but are not the same reference"
+      : "Values are not strictly equal";
+    throw new AssertionError(explain(message, fallback) + compared(actual, expected), { actual, expected });
+  }
+}
+
+export function assertNotStrictEquals(actual, expected, message) {
+  if (Object.is(actual, expected)) {
+    throw new AssertionError(explain(message, `Expected actual to not be ${show(expected)}`), { actual, expected });
+  }
+}
+
+export function assertExists(actual, message) {
+  if (actual === null || actual === undefined) {
+    throw new AssertionError(message ?? `Expected actual to not be null or undefined, got ${actual}`, { actual });
+  }
+}
+
+export function assertAlmostEquals(actual, expected, tolerance = 1e-7, message) {
+  if (Object.is(actual, expected)) {
+    return;
+  }
+  const delta = Math.abs(expected - actual);
+  if (!(delta <= tolerance)) {
+    throw new AssertionError(
+      explain(message, `Expected actual ${show(actual)} to be close to ${show(expected)}: delta ${delta} is above ${tolerance}`),
+      { actual, expected },
+    );
+  }
+}
+
+export function assertInstanceOf(actual, type, message) {
+  if (!(actual instanceof type)) {
+    const got = actual?.constructor?.name ?? show(actual);
+    throw new AssertionError(explain(message, `Expected an instance of ${type.name}, got ${got}`), { actual });
+  }
+}
+
+export function assertMatch(actual, pattern, message) {
+  if (!pattern.test(actual)) {
+    throw new AssertionError(explain(message, `Expected ${show(actual)} to match ${pattern}`), { actual });
+  }
+}
+
+export function assertNotMatch(actual, pattern, message) {
+  if (pattern.test(actual)) {
+    throw new AssertionError(explain(message, `Expected ${show(actual)} to not match ${pattern}`), { actual });
+  }
+}
+
+export function assertStringIncludes(actual, expected, message) {
+  if (!String(actual).includes(expected)) {
+    throw new AssertionError(explain(message, `Expected ${show(actual)} to include ${show(expected)}`), { actual, expected });
+  }
+}
+
+export function assertArrayIncludes(actual, expected, message) {
+  const missing = [...expected].filter((item) => ![...actual].some((value) => isDeepStrictEqual(value, item)));
+  if (missing.length > 0) {
+    throw new AssertionError(explain(message, `Expected ${show(actual)} to include ${show(missing)}`), { actual, expected });
+  }
+}
+
+// matches reports whether actual has every property of expected, comparing
+// plain objects recursively and everything else deeply
+function matches(actual, expected) {
+  if (expected === null || typeof expected !== "object" || Array.isArray(expected) || Object.getPrototypeOf(expected) !== Object.prototype) {
+    if (Array.isArray(expected) && Array.isArray(actual)) {
+      return actual.length === expected.length && expected.every((item, i) => matches(actual[i], item));
+    }
+    return isDeepStrictEqual(actual, expected);
+  }
+  if (actual === null || typeof actual !== "object") {
+    return false;
+  }
+  return Reflect.ownKeys(expected).every((key) => key in actual && matches(actual[key], expected[key]));
+}
+
+export function assertObjectMatch(actual, expected, message) {
+  if (!matches(actual, expected)) {
+    throw new AssertionError(explain(message, "Object does not match") + compared(actual, expected), { actual, expected });
+  }
+}
+
+// checkError verifies a thrown value against the optional class and
+// message substring assertThrows and assertRejects take
+function checkError(error, type, includes, message) {
+  if (type === undefined) {
+    return error;
+  }
+  if (!(error instanceof type)) {
+    const got = error?.constructor?.name ?? show(error);
+    throw new AssertionError(explain(message, `Expected error to be an instance of ${type.name}, got ${got}`), { cause: error });
+  }
+  if (includes !== undefined && !String(error.message).includes(includes)) {
+    throw new AssertionError(
+      explain(message, `Expected error message to include ${show(includes)}, got ${show(error.message)}`),
+      { cause: error },
+    );
+  }
+  return error;
+}
+
+// errorArgs sorts out the optional (type, includes, message) arguments
+function errorArgs(args) {
+  if (typeof args[0] === "function") {
+    return args;
+  }
+  return [undefined, undefined, args[0]];
+}
+
+export function assertThrows(fn, ...args) {
+  const [type, includes, message] = errorArgs(args);
+  try {
+    fn();
+  } catch (error) {
+    return checkError(error, type, includes, message);
+  }
+  throw new AssertionError(explain(message, "Expected function to throw"));
+}
+
+export async function assertRejects(fn, ...args) {
+  const [type, includes, message] = errorArgs(args);
+  const promise = typeof fn === "function" ? fn() : fn;
+  if (promise === null || typeof promise?.then !== "function") {
+    throw new AssertionError(explain(message, "Expected function to return a promise"));
+  }
+  try {
+    await promise;
+  } catch (error) {
+    return checkError(error, type, includes, message);
+  }
+  throw new AssertionError(explain(message, "Expected promise to reject"));
+}
+
+export function fail(message) {
+  throw new AssertionError(message === undefined ? "Failed assertion" : `Failed assertion: ${message}`);
+}
+
+export function unreachable(message) {
+  throw new AssertionError(message ?? "Unreachable code was reached");
+}
+
+export default {
+  AssertionError,
+  assert,
+  assertFalse,
+  assertEquals,
+  assertNotEquals,
+  assertStrictEquals,
+  assertNotStrictEquals,
+  assertExists,
+  assertAlmostEquals,
+  assertInstanceOf,
+  assertMatch,
+  assertNotMatch,
+  assertStringIncludes,
+  assertArrayIncludes,
+  assertObjectMatch,
+  assertThrows,
+  assertRejects,
+  fail,
+  unreachable,
+};
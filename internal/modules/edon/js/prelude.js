@@ -0,0 +1,315 @@
+// Sets up the Edon global and the test registry Edon.test and the edon:test
+// module share. Tests register while their file is evaluated; the runtime
+// then calls runTests on the hidden ops object.
+((ops) => {
+  "use strict";
+
+  delete globalThis.__edon;
+  Object.defineProperty(globalThis, "__edon", { value: ops });
+
+  const now = () => globalThis.performance?.now?.() ?? Date.now();
+
+  function createSuite(name, options = {}) {
+    return {
+      kind: "suite",
+      name,
+      only: Boolean(options.only),
+      ignore: Boolean(options.ignore),
+      children: [],
+      hooks: { beforeAll: [], afterAll: [], beforeEach: [], afterEach: [] },
+      runnable: 0,
+    };
+  }
+
+  const root = createSuite("");
+  let current = root;
+  let running = false;
+
+  // definition accepts the forms Edon.test and t.step take: (fn),
+  // (name, fn), (name, options, fn), (options, fn) and ({ name, fn })
+  function definition(args, what) {
+    let [first, second, third] = args;
+    let def;
+    if (typeof first === "function") {
+      def = { name: first.name, fn: first };
+    } else if (typeof first === "string") {
+      def = typeof second === "function" ? { name: first, fn: second } : { ...second, name: first, fn: third };
+    } else if (first !== null && typeof first === "object") {
+      def = typeof second === "function" ? { ...first, fn: second } : { ...first };
+    } else {
+      throw new TypeError(`${what} expects a name and a function`);
+    }
+    if (typeof def.fn !== "function") {
+      throw new TypeError(`${what} "${def.name ?? ""}" is missing its function`);
+    }
+    if (!def.name) {
+      throw new TypeError(`${what} needs a name, or a named function`);
+    }
+    return def;
+  }
+
+  function register(args, extra) {
+    if (running) {
+      throw new Error("Tests cannot be registered while tests are running; use t.step() for nested tests");
+    }
+    const def = { ...definition(args, "test"), ...extra };
+    current.children.push({ kind: "test", name: def.name, fn: def.fn, only: Boolean(def.only), ignore: Boolean(def.ignore) });
+  }
+
+  function describe(...args) {
+    const def = definition(args, "describe");
+    const suite = createSuite(def.name, def);
+    current.children.push(suite);
+    const parent = current;
+    current = suite;
+    try {
+      const result = def.fn();
+      if (result !== null && typeof result?.then === "function") {
+        throw new TypeError(`describe "${def.name}" must not be async; register tests synchronously and await inside them`);
+      }
+    } finally {
+      current = parent;
+    }
+  }
+
+  function hook(kind) {
+    return (fn) => {
+      if (typeof fn !== "function") {
+        throw new TypeError(`${kind} expects a function`);
+      }
+      current.hooks[kind].push(fn);
+    };
+  }
+
+  function test(...args) {
+    register(args);
+  }
+  test.only = (...args) => register(args, { only: true });
+  test.ignore = (...args) => register(args, { ignore: true });
+  test.skip = test.ignore;
+
+  describe.only = (...args) => describe(...withOptions(args, { only: true }));
+  describe.ignore = (...args) => describe(...withOptions(args, { ignore: true }));
+  describe.skip = describe.ignore;
+
+  function withOptions(args, options) {
+    const [first, second, third] = args;
+    if (typeof first === "string") {
+      return typeof second === "function" ? [first, options, second] : [first, { ...second, ...options }, third];
+    }
+    return [{ ...first, ...options }, second];
+  }
+
+  const hooks = {
+    beforeAll: hook("beforeAll"),
+    afterAll: hook("afterAll"),
+    beforeEach: hook("beforeEach"),
+    afterEach: hook("afterEach"),
+  };
+  Object.assign(test, hooks);
+
+  // internalFrame matches stack frames in the runner and the edon: modules
+  const internalFrame = /^\s*at .*\(edon:[^)]*\)$/;
+
+  function formatError(error) {
+    if (error instanceof Error) {
+      const stack = typeof error.stack === "string"
+        ? error.stack.trimEnd().split("\n").filter((line) => !internalFrame.test(line)).join("\n")
+        : "";
+      const head = `${error.name}: ${error.message}`;
+      return stack.startsWith(head) ? stack : stack ? `${head}\n${stack}` : head;
+    }
+    try {
+      return `Uncaught ${typeof error === "string" ? JSON.stringify(error) : String(error)}`;
+    } catch {
+      return "Uncaught exception";
+    }
+  }
+
+  // stepLists holds the step results of each test context
+  const stepLists = new WeakMap();
+
+  // TestContext is the t passed to tests and steps
+  class TestContext {
+    #pending = 0;
+
+    constructor(name, steps, parent) {
+      this.name = name;
+      this.parent = parent;
+      stepLists.set(this, steps);
+    }
+
+    get pending() {
+      return this.#pending;
+    }
+
+    async step(...args) {
+      const def = definition(args, "t.step");
+      const result = { name: def.name, status: def.ignore ? "ignored" : "running", ms: 0, steps: [] };
+      stepLists.get(this).push(result);
+      if (def.ignore) {
+        return false;
+      }
+
+      this.#pending++;
+      try {
+        const t = new TestContext(def.name, result.steps, this);
+        const outcome = await execute(t, () => def.fn(t));
+        // A step its parent did not wait for has been failed already
+        if (result.status === "running") {
+          Object.assign(result, outcome);
+        }
+      } finally {
+        this.#pending--;
+      }
+      return result.status === "passed";
+    }
+  }
+
+  // execute runs a test or step body, failing it if it throws, leaves steps
+  // running or has failing steps
+  async function execute(t, body) {
+    const start = now();
+    let error;
+    try {
+      await body();
+    } catch (e) {
+      error = e;
+    }
+
+    let message = error === undefined ? undefined : formatError(error);
+    if (message === undefined && t.pending > 0) {
+      message = `Error: "${t.name}" finished before its steps did; await t.step()`;
+    }
+    for (const step of stepLists.get(t)) {
+      if (step.status === "running") {
+        Object.assign(step, { status: "failed", error: "Error: step did not finish before its parent" });
+      }
+    }
+    if (message === undefined) {
+      const failed = stepLists.get(t).filter((s) => s.status === "failed").length;
+      if (failed > 0) {
+        message = `Error: ${failed} step${failed > 1 ? "s" : ""} failed`;
+      }
+    }
+    return { status: message === undefined ? "passed" : "failed", error: message, ms: now() - start };
+  }
+
+  function matcher(filter) {
+    if (!filter) {
+      return () => true;
+    }
+    const regexp = /^\/(.*)\/([a-z]*)$/s.exec(filter);
+    if (regexp !== null) {
+      const pattern = new RegExp(regexp[1], regexp[2]);
+      return (name) => pattern.test(name);
+    }
+    return (name) => name.includes(filter);
+  }
+
+  function hasOnly(suite) {
+    return suite.children.some((child) => child.only || (child.kind === "suite" && hasOnly(child)));
+  }
+
+  // plan decides which tests run, are ignored or are filtered out, and
+  // counts the runnable tests under each suite
+  function plan(suite, path, matches, anyOnly, only, ignore) {
+    suite.runnable = 0;
+    for (const child of suite.children) {
+      const childPath = [...path, child.name];
+      if (child.kind === "suite") {
+        suite.runnable += plan(child, childPath, matches, anyOnly, only || child.only, ignore || child.ignore);
+        continue;
+      }
+      if (!matches(childPath.join(" > ")) || (anyOnly && !only && !child.only)) {
+        child.state = "filtered";
+      } else if (ignore || child.ignore) {
+        child.state = "ignored";
+      } else {
+        child.state = "run";
+        suite.runnable++;
+      }
+    }
+    return suite.runnable;
+  }
+
+  async function runHooks(list, t) {
+    for (const fn of list) {
+      await fn(t);
+    }
+  }
+
+  ops.runTests = async (filter) => {
+    running = true;
+    const anyOnly = hasOnly(root);
+    plan(root, [], matcher(filter), anyOnly, false, false);
+
+    const report = { tests: [], filtered: 0, only: anyOnly };
+
+    const runTest = async (test, name, before, after) => {
+      const steps = [];
+      const t = new TestContext(name, steps);
+      const result = await execute(t, async () => {
+        try {
+          await runHooks(before, t);
+          await test.fn(t);
+        } finally {
+          await runHooks(after, t);
+        }
+      });
+      return { name, ...result, steps };
+    };
+
+    const runSuite = async (suite, path, before, after, setupError) => {
+      before = [...before, ...suite.hooks.beforeEach];
+      after = [...suite.hooks.afterEach, ...after];
+      const active = suite.runnable > 0 && setupError === undefined;
+      if (active) {
+        try {
+          await runHooks(suite.hooks.beforeAll);
+        } catch (e) {
+          setupError = formatError(e);
+        }
+      }
+
+      for (const child of suite.children) {
+        const childPath = [...path, child.name];
+        if (child.kind === "suite") {
+          await runSuite(child, childPath, before, after, setupError);
+          continue;
+        }
+        const name = childPath.join(" > ");
+        switch (child.state) {
+          case "filtered":
+            report.filtered++;
+            break;
+          case "ignored":
+            report.tests.push({ name, status: "ignored", ms: 0 });
+            break;
+          default:
+            report.tests.push(setupError === undefined ? await runTest(child, name, before, after) : { name, status: "failed", error: setupError, ms: 0 });
+        }
+      }
+
+      if (active) {
+        try {
+          await runHooks(suite.hooks.afterAll);
+        } catch (e) {
+          report.tests.push({ name: [...path, "afterAll"].join(" > "), status: "failed", error: formatError(e), ms: 0 });
+        }
+      }
+    };
+
+    try {
+      await runSuite(root, [], [], [], undefined);
+    } finally {
+      running = false;
+    }
+    return JSON.stringify(report);
+  };
+
+  ops.testing = { test, describe, it: test, ...hooks };
+
+  const Edon = { test };
+  Object.defineProperty(globalThis, "Edon", { value: Edon, writable: true, configurable: true });
+})(globalThis.__edon);
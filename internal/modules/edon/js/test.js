@@ -0,0 +1,7 @@
+// edon:test: describe/it style tests, run by edon test alongside the tests
+// registered with Edon.test
+const { test, describe, it, beforeAll, afterAll, beforeEach, afterEach } = globalThis.__edon.testing;
+
+export { test, describe, it, beforeAll, afterAll, beforeEach, afterEach };
+
+export default { test, describe, it, beforeAll, afterAll, beforeEach, afterEach };
@@ -0,0 +1,194 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// ImportType is the type import attribute of an import, which says how the
+// imported file is turned into a module
+type ImportType string
+
+const (
+	// ImportJavaScript is a plain import without a type attribute
+	ImportJavaScript ImportType = ""
+	// ImportJSON makes the parsed JSON the default export
+	ImportJSON ImportType = "json"
+	// ImportText makes the file's text the default export
+	ImportText ImportType = "text"
+	// ImportBytes makes the file's bytes, as a Uint8Array, the default export
+	ImportBytes ImportType = "bytes"
+)
+
+// typedID is the module ID of a file imported with a type attribute, kept
+// apart from the same file imported as JavaScript
+func typedID(id string, as ImportType) string {
+	if as == ImportJavaScript {
+		return id
+	}
+	return id + "#type=" + string(as)
+}
+
+// checkImportType makes sure module may be imported as the given type
+func checkImportType(module *Module, as ImportType) error {
+	switch as {
+	case ImportJavaScript:
+		if module.isJSON() {
+			return errors.Wrap(errors.ErrInvalidImportType, fmt.Sprintf(`%s is JSON and must be imported with { type: "json" }`, module.URL))
+		}
+	case ImportJSON:
+		if !module.isJSON() {
+			return errors.Wrap(errors.ErrInvalidImportType, fmt.Sprintf(`%s is imported with { type: "json" } but is %s`, module.URL, module.describeContent()))
+		}
+	case ImportText, ImportBytes:
+	default:
+		return errors.Wrap(errors.ErrInvalidImportType, fmt.Sprintf("%s: unsupported import type %q", module.URL, string(as)))
+	}
+	return nil
+}
+
+// isJSON reports whether module holds JSON, going by the content type it
+// was served with or else by its file extension
+func (m *Module) isJSON() bool {
+	if m.contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(m.contentType)
+		return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+	}
+	return m.extension() == ".json"
+}
+
+// describeContent names what module holds, for errors
+func (m *Module) describeContent() string {
+	if m.contentType != "" {
+		return "served as " + m.contentType
+	}
+	if ext := m.extension(); ext != "" {
+		return "a " + ext + " file"
+	}
+	return "not JSON"
+}
+
+func (m *Module) extension() string {
+	if m.Path != "" {
+		return strings.ToLower(path.Ext(m.Path))
+	}
+	if parsed, err := url.Parse(m.URL); err == nil {
+		return strings.ToLower(path.Ext(parsed.Path))
+	}
+	return ""
+}
+
+// typedSource builds the JavaScript module a typed import evaluates to
+func typedSource(module *Module, as ImportType) (string, error) {
+	switch as {
+	case ImportJSON:
+		value, err := jsonToJS(module.Content)
+		if err != nil {
+			file := module.Path
+			if file == "" {
+				file = module.URL
+			}
+			return "", errors.Wrap(errors.ErrInvalidJSON, fmt.Sprintf("%s:%v", file, err))
+		}
+		return "export default " + value + ";\n", nil
+	case ImportText:
+		text, _ := json.Marshal(module.Content)
+		return "export default " + string(text) + ";\n", nil
+	case ImportBytes:
+		return "export default Uint8Array.from(" + latin1Literal(module.Content) + ", (c) => c.charCodeAt(0));\n", nil
+	}
+	return module.Content, nil
+}
+
+// jsonToJS parses JSON and writes it back as a JavaScript literal. Object
+// keys named __proto__ become computed keys so they stay plain properties,
+// as they are with JSON.parse.
+func jsonToJS(source string) (string, error) {
+	data := bytes.TrimPrefix([]byte(source), []byte("\xef\xbb\xbf"))
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var b strings.Builder
+	if err := writeJSONValue(dec, &b); err != nil {
+		return "", jsonError(data, dec, err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return "", jsonError(data, dec, fmt.Errorf("unexpected data after the top-level value"))
+	}
+	return b.String(), nil
+}
+
+func writeJSONValue(dec *json.Decoder, b *strings.Builder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		closing := json.Delim('}')
+		if v == '[' {
+			closing = ']'
+		}
+		b.WriteRune(rune(v))
+		for first := true; dec.More(); first = false {
+			if !first {
+				b.WriteByte(',')
+			}
+			if v == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				quoted, _ := json.Marshal(key)
+				if key == "__proto__" {
+					b.WriteString("[" + string(quoted) + "]:")
+				} else {
+					b.WriteString(string(quoted) + ":")
+				}
+			}
+			if err := writeJSONValue(dec, b); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		b.WriteRune(rune(closing))
+	case string:
+		quoted, _ := json.Marshal(v)
+		b.Write(quoted)
+	case json.Number:
+		b.WriteString(v.String())
+	case bool:
+		fmt.Fprint(b, v)
+	case nil:
+		b.WriteString("null")
+	}
+	return nil
+}
+
+// jsonError adds the line and column of the character the decoder stopped
+// at to err
+func jsonError(data []byte, dec *json.Decoder, err error) error {
+	offset := dec.InputOffset()
+	if syntaxErr, ok := err.(*json.SyntaxError); ok {
+		offset = syntaxErr.Offset
+	}
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		err = fmt.Errorf("unexpected end of JSON input")
+	}
+	// Both offsets point just past the character
+	offset = max(min(offset, int64(len(data)))-1, 0)
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(data[:offset], '\n')
+	return fmt.Errorf("%d:%d: %v", line, column, err)
+}
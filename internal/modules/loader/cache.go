@@ -0,0 +1,215 @@
+package loader
+
+import (
+	"container/list"
+	"context"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// ModuleCache represents a thread-safe cache for loaded modules. Concurrent
+// loads of the same URL share a single fetch. Local modules are dropped
+// once their file changes, and a cache with a limit evicts the least
+// recently used modules.
+type ModuleCache struct {
+	mu       sync.Mutex
+	modules  map[string]*list.Element
+	lru      *list.List // front is the most recently used
+	limit    int
+	inflight map[string]*inflightLoad
+}
+
+// cachedModule is a module in the LRU list
+type cachedModule struct {
+	url    string
+	module *Module
+}
+
+// inflightLoad is a load other callers can wait on
+type inflightLoad struct {
+	done   chan struct{}
+	module *Module
+	err    error
+}
+
+// NewModuleCache creates a cache holding at most limit modules, or any
+// number of them if limit is 0
+func NewModuleCache(limit int) *ModuleCache {
+	return &ModuleCache{
+		modules:  make(map[string]*list.Element),
+		lru:      list.New(),
+		limit:    limit,
+		inflight: make(map[string]*inflightLoad),
+	}
+}
+
+// Get returns the cached module for url, if any. A local module whose file
+// changed since it was read is removed instead.
+func (c *ModuleCache) Get(url string) *Module {
+	c.mu.Lock()
+	elem, ok := c.modules[url]
+	if !ok {
+		c.mu.Unlock()
+		return nil
+	}
+	c.lru.MoveToFront(elem)
+	module := elem.Value.(*cachedModule).module
+	c.mu.Unlock()
+
+	if module.changedOnDisk() {
+		c.mu.Lock()
+		if c.modules[url] == elem {
+			c.remove(elem)
+		}
+		c.mu.Unlock()
+		return nil
+	}
+	return module
+}
+
+// Len returns the number of cached modules
+func (c *ModuleCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Invalidate removes the module loaded from url, reporting whether it was
+// cached
+func (c *ModuleCache) Invalidate(url string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.modules[url]
+	if ok {
+		c.remove(elem)
+	}
+	return ok
+}
+
+// InvalidatePrefix removes every module whose URL starts with prefix and
+// returns how many were removed
+func (c *ModuleCache) InvalidatePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for url, elem := range c.modules {
+		if strings.HasPrefix(url, prefix) {
+			c.remove(elem)
+			removed++
+		}
+	}
+	return removed
+}
+
+// InvalidateAll empties the cache
+func (c *ModuleCache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.modules = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// invalidateIDs removes the modules registered in graphs under ids, whatever
+// URL they were loaded from, and returns how many were removed
+func (c *ModuleCache) invalidateIDs(ids ...string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stale := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		stale[id] = true
+	}
+	removed := 0
+	for _, elem := range c.modules {
+		if stale[elem.Value.(*cachedModule).module.ID()] {
+			c.remove(elem)
+			removed++
+		}
+	}
+	return removed
+}
+
+// all returns every cached module
+func (c *ModuleCache) all() []*Module {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	modules := make([]*Module, 0, c.lru.Len())
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		modules = append(modules, elem.Value.(*cachedModule).module)
+	}
+	return modules
+}
+
+// add caches module under url, evicting the least recently used modules
+// over the limit. Callers hold c.mu.
+func (c *ModuleCache) add(url string, module *Module) {
+	if elem, ok := c.modules[url]; ok {
+		c.remove(elem)
+	}
+	c.modules[url] = c.lru.PushFront(&cachedModule{url: url, module: module})
+	for c.limit > 0 && c.lru.Len() > c.limit {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove drops elem from the cache. Callers hold c.mu.
+func (c *ModuleCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.modules, elem.Value.(*cachedModule).url)
+}
+
+// load returns the cached module for url, calling load to fill the cache if
+// it is missing. Callers asking for a url that is already being loaded wait
+// for that load instead of starting their own. Failed loads are not cached.
+func (c *ModuleCache) load(ctx context.Context, url string, load func() (*Module, error)) (*Module, error) {
+	if module := c.Get(url); module != nil {
+		return module, nil
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.modules[url]; ok {
+		c.mu.Unlock()
+		return elem.Value.(*cachedModule).module, nil
+	}
+	if call, ok := c.inflight[url]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.module, call.err
+		case <-ctx.Done():
+			return nil, errors.Wrap(errors.ErrModuleNotFound, ctx.Err().Error())
+		}
+	}
+	call := &inflightLoad{done: make(chan struct{})}
+	c.inflight[url] = call
+	c.mu.Unlock()
+
+	call.module, call.err = load()
+
+	c.mu.Lock()
+	if call.err == nil {
+		c.add(url, call.module)
+	}
+	delete(c.inflight, url)
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.module, call.err
+}
+
+// changedOnDisk reports whether the file of a local module was modified
+// since the module was read
+func (m *Module) changedOnDisk() bool {
+	if m.Type != TypeLocal || m.modTime.IsZero() {
+		return false
+	}
+	stat, err := os.Stat(m.Path)
+	return err != nil || !stat.ModTime().Equal(m.modTime) || stat.Size() != m.size
+}
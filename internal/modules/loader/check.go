@@ -0,0 +1,142 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SourceError is an error at a position in a module's source, such as a
+// syntax error
+type SourceError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+	// Err is the category of the error, ErrInvalidScript for syntax errors
+	Err error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// SourceErrors returns the source errors in err's tree, in order
+func SourceErrors(err error) []*SourceError {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *SourceError:
+		return []*SourceError{e}
+	case interface{ Unwrap() []error }:
+		var found []*SourceError
+		for _, inner := range e.Unwrap() {
+			found = append(found, SourceErrors(inner)...)
+		}
+		return found
+	case interface{ Unwrap() error }:
+		return SourceErrors(e.Unwrap())
+	}
+	return nil
+}
+
+// ImportError is an import that could not be resolved or loaded, at the
+// position of its specifier in the importing module
+type ImportError struct {
+	File      string
+	Line      int
+	Column    int
+	Specifier string
+	Err       error
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %v", e.File, e.Line, e.Column, e.Err)
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// Position returns the 1-based line and column of a byte offset in source,
+// counting columns in characters
+func Position(source string, offset int) (line, column int) {
+	offset = min(max(offset, 0), len(source))
+	before := source[:offset]
+	line = strings.Count(before, "\n") + 1
+	if i := strings.LastIndexByte(before, '\n'); i >= 0 {
+		before = before[i+1:]
+	}
+	return line, utf8.RuneCountInString(before) + 1
+}
+
+// ResolveGraph loads entry and everything it imports like LoadGraph, but
+// carries on past imports that fail, so that it can report all of them. It
+// returns the modules it could load along with an error for each failure:
+// an *ImportError, or the *SourceError of a module that does not parse.
+// Failing dynamic imports are reported for local modules only, as other
+// modules may guard them.
+func (l *ModuleLoader) ResolveGraph(ctx context.Context, entry string) (*ModuleGraph, []error) {
+	root, err := l.LoadModule(ctx, entry)
+	if err != nil {
+		if found := SourceErrors(err); len(found) > 0 {
+			return nil, sourceErrorList(found)
+		}
+		return nil, []error{err}
+	}
+
+	graph := &ModuleGraph{
+		Root:    root.ID(),
+		Modules: make(map[string]*GraphModule),
+		Deps:    NewDependencyGraph(),
+		collect: true,
+	}
+	graph.Deps.SetStrict(l.strictCycles)
+
+	if l.concurrency > 1 {
+		l.prefetch(ctx, root)
+	}
+	if err := l.addToGraph(ctx, graph, root); err != nil {
+		graph.failed = append(graph.failed, err)
+	}
+	graph.failed = append(graph.failed, graph.missing...)
+	return graph, graph.failed
+}
+
+// importFailed records a failed import while resolving a graph, reporting
+// whether the walk should carry on
+func (graph *ModuleGraph) importFailed(module *Module, imp Import, err error) bool {
+	if !graph.collect {
+		return false
+	}
+	if imp.Dynamic && module.Type != TypeLocal {
+		return true
+	}
+	// A module that does not parse is reported where the problem is
+	if found := SourceErrors(err); len(found) > 0 {
+		graph.failed = append(graph.failed, sourceErrorList(found)...)
+		return true
+	}
+	line, column := module.OriginalPosition(Position(module.JS(), imp.Start))
+	graph.failed = append(graph.failed, &ImportError{
+		File:      module.ID(),
+		Line:      line,
+		Column:    column,
+		Specifier: imp.Specifier,
+		Err:       err,
+	})
+	return true
+}
+
+func sourceErrorList(found []*SourceError) []error {
+	errs := make([]error, len(found))
+	for i, e := range found {
+		errs[i] = e
+	}
+	return errs
+}
@@ -0,0 +1,97 @@
+package loader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// CodeCache stores the bytecode modules compile to, so unchanged modules
+// are not parsed again on the next run. Entries are keyed by everything the
+// bytecode depends on, and are never invalidated: a changed module simply
+// gets a new key, and the old entry stays until Clean removes everything.
+type CodeCache struct {
+	dir string
+}
+
+// NewCodeCache creates a bytecode cache rooted at dir
+func NewCodeCache(dir string) *CodeCache {
+	return &CodeCache{dir: dir}
+}
+
+// DefaultGenDir returns ~/.edon/gen
+func DefaultGenDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(errors.ErrCacheDir, err.Error())
+	}
+	return filepath.Join(homeDir, ".edon", "gen"), nil
+}
+
+// Dir returns the directory the cache lives in
+func (c *CodeCache) Dir() string {
+	return c.dir
+}
+
+// CodeKey identifies the bytecode engine compiles source to, as the module
+// id or, when module is false, as a script
+func CodeKey(engine, id, source string, module bool) string {
+	kind := "script"
+	if module {
+		kind = "module"
+	}
+	h := sha256.New()
+	for _, part := range []string{engine, kind, id, source} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Path returns the file the bytecode under key is cached in
+func (c *CodeCache) Path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".qjsc")
+}
+
+// Get returns the bytecode cached under key
+func (c *CodeCache) Get(key string) ([]byte, bool) {
+	code, err := os.ReadFile(c.Path(key))
+	if err != nil || len(code) == 0 {
+		return nil, false
+	}
+	return code, true
+}
+
+// Put caches code under key
+func (c *CodeCache) Put(key string, code []byte) error {
+	path := c.Path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(errors.ErrCacheDir, err.Error())
+	}
+	return writeFileAtomic(path, code)
+}
+
+// Clean removes every cached entry and returns how many there were
+func (c *CodeCache) Clean() (int, error) {
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*", "*.qjsc"))
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrCacheDir, err.Error())
+	}
+	for i, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return i, errors.Wrap(errors.ErrCacheDir, err.Error())
+		}
+	}
+
+	// Shard directories left empty go too
+	shards, _ := os.ReadDir(c.dir)
+	for _, shard := range shards {
+		if shard.IsDir() {
+			_ = os.Remove(filepath.Join(c.dir, shard.Name()))
+		}
+	}
+	return len(paths), nil
+}
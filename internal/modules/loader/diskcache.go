@@ -0,0 +1,121 @@
+package loader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// CacheEntry is the metadata stored next to a cached remote module
+type CacheEntry struct {
+	URL string `json:"url"`
+	// FinalURL is where URL ended up after following redirects
+	FinalURL    string    `json:"finalUrl"`
+	ContentType string    `json:"contentType,omitempty"`
+	ETag        string    `json:"etag,omitempty"`
+	FetchedAt   time.Time `json:"fetchedAt"`
+}
+
+// DiskCache stores remote modules on disk, keyed by URL, so they are only
+// fetched once across runs
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates a cache rooted at dir
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{dir: dir}
+}
+
+// DefaultDepsDir returns ~/.edon/deps
+func DefaultDepsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(errors.ErrCacheDir, err.Error())
+	}
+	return filepath.Join(homeDir, ".edon", "deps"), nil
+}
+
+// Dir returns the directory the cache lives in
+func (c *DiskCache) Dir() string {
+	return c.dir
+}
+
+// Path returns the file the content of rawURL is cached in. Entries are
+// grouped by scheme and host and named after a hash of the full URL.
+func (c *DiskCache) Path(rawURL string) string {
+	scheme, host := "other", "unknown"
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Host != "" {
+		scheme, host = parsed.Scheme, strings.ReplaceAll(parsed.Host, ":", "_")
+	}
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(c.dir, scheme, host, hex.EncodeToString(sum[:]))
+}
+
+// Get returns the cached entry and content for rawURL
+func (c *DiskCache) Get(rawURL string) (*CacheEntry, []byte, bool) {
+	path := c.Path(rawURL)
+
+	meta, err := os.ReadFile(path + ".metadata.json")
+	if err != nil {
+		return nil, nil, false
+	}
+	entry := &CacheEntry{}
+	if err := json.Unmarshal(meta, entry); err != nil || entry.URL != rawURL {
+		return nil, nil, false
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, false
+	}
+	return entry, content, true
+}
+
+// Put stores content and its metadata under entry.URL
+func (c *DiskCache) Put(entry *CacheEntry, content []byte) error {
+	path := c.Path(entry.URL)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(errors.ErrCacheDir, err.Error())
+	}
+
+	meta, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return errors.Wrap(errors.ErrCacheDir, err.Error())
+	}
+
+	// The content goes first so a metadata file always has content behind it
+	if err := writeFileAtomic(path, content); err != nil {
+		return err
+	}
+	return writeFileAtomic(path+".metadata.json", meta)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place
+// so concurrent readers never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return errors.Wrap(errors.ErrCacheDir, err.Error())
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(errors.ErrCacheDir, err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(errors.ErrCacheDir, err.Error())
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrap(errors.ErrCacheDir, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/node"
+)
+
+// ModuleGraph is a module and everything it imports, loaded and ready to
+// hand to the JavaScript engine
+type ModuleGraph struct {
+	// Root is the ID of the entry module
+	Root    string
+	Modules map[string]*GraphModule
+	Deps    *DependencyGraph
+
+	// missing collects the imports a cached-only load found no copy of
+	missing []error
+	// collect is set by ResolveGraph, which collects failed imports in
+	// failed instead of stopping at the first
+	collect bool
+	failed  []error
+}
+
+// CacheMissError lists every import of a module graph that is not in the
+// cache, so an offline run reports them all before anything executes
+type CacheMissError struct {
+	Missing []error
+}
+
+func (e *CacheMissError) Error() string {
+	lines := make([]string, 0, len(e.Missing)+1)
+	lines = append(lines, fmt.Sprintf("%d imports are not in the cache:", len(e.Missing)))
+	for _, err := range e.Missing {
+		lines = append(lines, "  "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *CacheMissError) Unwrap() error {
+	return errors.ErrNotCached
+}
+
+// GraphModule is a module in a ModuleGraph
+type GraphModule struct {
+	*Module
+	// Source is the module content with every resolved import specifier
+	// rewritten to the ID of the module it refers to
+	Source string
+	// Imports lists the IDs of the modules this one statically imports
+	Imports []string
+	// Specifiers maps the Key of each import to the ID of the module it
+	// loaded
+	Specifiers map[string]string
+}
+
+// LoadGraph loads entry and, transitively, every module it imports
+func (l *ModuleLoader) LoadGraph(ctx context.Context, entry string) (*ModuleGraph, error) {
+	root, err := l.LoadModule(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &ModuleGraph{
+		Root:    root.ID(),
+		Modules: make(map[string]*GraphModule),
+		Deps:    NewDependencyGraph(),
+	}
+	graph.Deps.SetStrict(l.strictCycles)
+
+	// Fetch the whole graph in parallel first so the walk below, which has
+	// to go in import order, only reads from the cache
+	if l.concurrency > 1 {
+		l.prefetch(ctx, root)
+	}
+	if err := l.addToGraph(ctx, graph, root); err != nil {
+		return nil, err
+	}
+	if len(graph.missing) > 0 {
+		return nil, &CacheMissError{Missing: graph.missing}
+	}
+
+	// Hashes of newly fetched modules are kept once the graph is complete
+	if l.lock != nil {
+		if err := l.lock.Save(); err != nil {
+			return nil, err
+		}
+	}
+	return graph, nil
+}
+
+// Order returns the module IDs with every module after its dependencies.
+// Modules in a cycle come after the first module of the cycle to be reached,
+// which is the order ES modules evaluate them in.
+func (g *ModuleGraph) Order() ([]string, error) {
+	return g.Deps.ResolveDependencies(g.Root)
+}
+
+// LoadOrder returns every module ID of the graph, each after its static
+// dependencies: Order, followed by the modules only dynamic imports reach.
+// The engine needs them all loaded before the root runs, since it cannot
+// fetch a dynamic import itself.
+func (g *ModuleGraph) LoadOrder() ([]string, error) {
+	order, err := g.Order()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(g.Modules))
+	for _, id := range order {
+		seen[id] = true
+	}
+
+	dynamicOnly := make([]string, 0)
+	for id := range g.Modules {
+		if !seen[id] {
+			dynamicOnly = append(dynamicOnly, id)
+		}
+	}
+	sort.Strings(dynamicOnly)
+	for _, id := range dynamicOnly {
+		deps, err := g.Deps.ResolveDependencies(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range deps {
+			if !seen[dep] {
+				seen[dep] = true
+				order = append(order, dep)
+			}
+		}
+	}
+	return order, nil
+}
+
+// Cycles returns the circular imports of the graph as paths that start and
+// end with the same module
+func (g *ModuleGraph) Cycles() [][]string {
+	return g.Deps.Cycles()
+}
+
+func (l *ModuleLoader) addToGraph(ctx context.Context, graph *ModuleGraph, module *Module) error {
+	id := module.ID()
+	if _, ok := graph.Modules[id]; ok {
+		return nil
+	}
+
+	node := &GraphModule{Module: module, Imports: make([]string, 0), Specifiers: make(map[string]string)}
+	graph.Modules[id] = node
+
+	imports := ScanImports(module.JS())
+	resolved := make(map[int]string, len(imports))
+	children := make([]*Module, 0, len(imports))
+
+	for i, imp := range imports {
+		child, err := l.loadImport(ctx, imp.Specifier, id, imp.Type)
+		if err != nil && graph.importFailed(module, imp, err) {
+			continue
+		}
+		if err != nil && l.cachedOnly && errors.Is(err, errors.ErrNotCached) {
+			// Keep walking to find everything else that is missing
+			graph.missing = append(graph.missing, err)
+			continue
+		}
+		if err != nil {
+			// A dynamic import that cannot be loaded is left as written and
+			// only fails if it runs
+			if imp.Dynamic {
+				continue
+			}
+			return err
+		}
+		resolved[i] = child.ID()
+		node.Specifiers[imp.Key()] = child.ID()
+
+		if !imp.Dynamic {
+			// The cycle path already names both modules
+			if err := graph.Deps.AddDependency(id, child.ID()); err != nil {
+				if !graph.collect {
+					return err
+				}
+				graph.failed = append(graph.failed, err)
+				continue
+			}
+			_ = l.imports.AddDependency(id, child.ID())
+			node.Imports = append(node.Imports, child.ID())
+		}
+		children = append(children, child)
+	}
+
+	index := 0
+	node.Source = RewriteImports(module.JS(), imports, func(Import) (string, bool) {
+		target, ok := resolved[index]
+		index++
+		return target, ok
+	})
+
+	for _, child := range children {
+		if err := l.addToGraph(ctx, graph, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadImport resolves specifier against the module that imports it and loads
+// the result as the given type
+func (l *ModuleLoader) loadImport(ctx context.Context, specifier, referrer string, as ImportType) (*Module, error) {
+	target, mapped, err := l.resolve(specifier, referrer)
+	if err != nil {
+		return nil, err
+	}
+
+	module, err := l.LoadModuleAs(ctx, target, as)
+	if err != nil {
+		if mapped {
+			return nil, errors.Wrap(err, fmt.Sprintf("import %q (mapped to %q by import map %s) from %s", specifier, target, l.importMap.Source, referrer))
+		}
+		return nil, errors.Wrap(err, fmt.Sprintf("import %q from %s", specifier, referrer))
+	}
+	return module, nil
+}
+
+// Resolve turns specifier, as written in the module identified by referrer,
+// into something LoadModule accepts
+func (l *ModuleLoader) Resolve(specifier, referrer string) (string, error) {
+	target, _, err := l.resolve(specifier, referrer)
+	return target, err
+}
+
+// resolve is Resolve, also reporting whether the import map remapped the
+// specifier
+func (l *ModuleLoader) resolve(specifier, referrer string) (string, bool, error) {
+	if l.importMap != nil && specifier != "" {
+		target, ok, err := l.importMap.Resolve(specifier, referrer)
+		if err != nil {
+			return "", false, errors.Wrap(err, "from "+referrer)
+		}
+		if ok {
+			target, err = l.resolveSpecifier(target, referrer)
+			return target, true, err
+		}
+	}
+	target, err := l.resolveSpecifier(specifier, referrer)
+	return target, false, err
+}
+
+// resolveSpecifier resolves specifier without consulting the import map
+func (l *ModuleLoader) resolveSpecifier(specifier, referrer string) (string, error) {
+	switch {
+	case specifier == "":
+		return "", errors.ErrEmptyURL
+
+	case strings.HasPrefix(specifier, "#"):
+		if isRemoteURL(referrer) {
+			return "", errors.Wrap(errors.ErrUnresolvedImport, fmt.Sprintf("%q from %s", specifier, referrer))
+		}
+		target, err := ResolvePackageImport(specifier, referrer, l.conditions)
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			return target, nil
+		}
+		return l.resolveSpecifier(target, referrer)
+
+	case strings.HasPrefix(specifier, "./"), strings.HasPrefix(specifier, "../"), strings.HasPrefix(specifier, "/"):
+		if isRemoteURL(referrer) {
+			base, err := url.Parse(referrer)
+			if err != nil {
+				return "", errors.Wrap(errors.ErrInvalidURL, err.Error())
+			}
+			ref, err := url.Parse(specifier)
+			if err != nil {
+				return "", errors.Wrap(errors.ErrInvalidURL, err.Error())
+			}
+			return base.ResolveReference(ref).String(), nil
+		}
+		if filepath.IsAbs(specifier) {
+			return filepath.Clean(specifier), nil
+		}
+		return filepath.Join(filepath.Dir(referrer), filepath.FromSlash(specifier)), nil
+
+	case strings.HasPrefix(specifier, "file://"):
+		// Remote modules must not reach into the local filesystem
+		if isRemoteURL(referrer) {
+			return "", errors.Wrap(errors.ErrUnresolvedImport, fmt.Sprintf("%q from %s", specifier, referrer))
+		}
+		parsed, err := url.Parse(specifier)
+		if err != nil {
+			return "", errors.Wrap(errors.ErrInvalidURL, err.Error())
+		}
+		return filepath.FromSlash(parsed.Path), nil
+
+	case strings.Contains(specifier, ":"):
+		return specifier, nil
+	}
+
+	// Built-in modules may be imported without the node: prefix, and take
+	// precedence over npm packages of the same name
+	if node.IsBuiltin(specifier) {
+		return "node:" + specifier, nil
+	}
+
+	// A bare specifier maps onto the npm dependency the importing package
+	// declares for it
+	if !isRemoteURL(referrer) {
+		if spec, err := ParseNPMSpecifier(specifier); err == nil {
+			if pkg := FindPackageScope(referrer); pkg != nil {
+				if version, ok := pkg.DependencyRange(spec.Name); ok {
+					target := "npm:" + spec.Name + "@" + version
+					if spec.Subpath != "." {
+						target += strings.TrimPrefix(spec.Subpath, ".")
+					}
+					return target, nil
+				}
+			}
+		}
+	}
+
+	return "", errors.Wrap(errors.ErrUnresolvedImport, fmt.Sprintf("bare specifier %q from %s", specifier, referrer))
+}
+
+func isRemoteURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
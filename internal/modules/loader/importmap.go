@@ -0,0 +1,207 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// ImportMap remaps import specifiers following the WICG import maps
+// proposal: https://github.com/WICG/import-maps
+type ImportMap struct {
+	// Source names where the map came from, for error messages
+	Source string
+
+	imports specifierMap
+	scopes  []scopeMap
+}
+
+// specifierMap is a normalized "imports" object, longest keys first. A nil
+// target blocks the specifier.
+type specifierMap []specifierMapping
+
+type specifierMapping struct {
+	key    string
+	target *url.URL
+}
+
+type scopeMap struct {
+	prefix  string
+	imports specifierMap
+}
+
+// LoadImportMap reads an import map file. Relative URLs in it resolve
+// against the file's location.
+func LoadImportMap(path string) (*ImportMap, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrFileRead, err.Error())
+	}
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrFileRead, err.Error())
+	}
+	return ParseImportMap(data, FileURL(absPath), absPath)
+}
+
+// ParseImportMap parses the JSON text of an import map. baseURL is what
+// relative URLs in the map resolve against; source names the map in errors.
+func ParseImportMap(data []byte, baseURL, source string) (*ImportMap, error) {
+	var raw struct {
+		Imports map[string]json.RawMessage            `json:"imports"`
+		Scopes  map[string]map[string]json.RawMessage `json:"scopes"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidImportMap, fmt.Sprintf("%s: %v", source, err))
+	}
+
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidImportMap, fmt.Sprintf("%s: %v", source, err))
+	}
+
+	m := &ImportMap{Source: source}
+	if m.imports, err = parseSpecifierMap(raw.Imports, base, source); err != nil {
+		return nil, err
+	}
+	for prefix, imports := range raw.Scopes {
+		scopeURL, err := base.Parse(prefix)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInvalidImportMap, fmt.Sprintf("%s: scope %q: %v", source, prefix, err))
+		}
+		scope := scopeMap{prefix: scopeURL.String()}
+		if scope.imports, err = parseSpecifierMap(imports, base, source); err != nil {
+			return nil, err
+		}
+		m.scopes = append(m.scopes, scope)
+	}
+	sort.Slice(m.scopes, func(i, j int) bool {
+		return m.scopes[i].prefix > m.scopes[j].prefix
+	})
+	return m, nil
+}
+
+func parseSpecifierMap(raw map[string]json.RawMessage, base *url.URL, source string) (specifierMap, error) {
+	m := make(specifierMap, 0, len(raw))
+	for key, value := range raw {
+		if key == "" {
+			return nil, errors.Wrap(errors.ErrInvalidImportMap, fmt.Sprintf("%s: empty specifier key", source))
+		}
+		if normalized, ok := urlLikeSpecifier(key, base); ok {
+			key = normalized.String()
+		}
+
+		var target *string
+		if err := json.Unmarshal(value, &target); err != nil {
+			return nil, errors.Wrap(errors.ErrInvalidImportMap, fmt.Sprintf("%s: %q must map to a string or null", source, key))
+		}
+		mapping := specifierMapping{key: key}
+		if target != nil {
+			parsed, ok := urlLikeSpecifier(*target, base)
+			if !ok {
+				return nil, errors.Wrap(errors.ErrInvalidImportMap, fmt.Sprintf("%s: %q maps to %q, which is not a URL", source, key, *target))
+			}
+			if strings.HasSuffix(key, "/") && !strings.HasSuffix(parsed.String(), "/") {
+				return nil, errors.Wrap(errors.ErrInvalidImportMap, fmt.Sprintf("%s: %q must map to a URL ending in a slash", source, key))
+			}
+			mapping.target = parsed
+		}
+		m = append(m, mapping)
+	}
+	sort.Slice(m, func(i, j int) bool {
+		return m[i].key > m[j].key
+	})
+	return m, nil
+}
+
+// Resolve applies the map to specifier as imported from referrer. It reports
+// whether the map had an entry for it.
+func (m *ImportMap) Resolve(specifier, referrer string) (string, bool, error) {
+	referrerURL, err := url.Parse(referrer)
+	if err != nil || referrerURL.Scheme == "" || len(referrerURL.Scheme) == 1 {
+		referrerURL, _ = url.Parse(FileURL(referrer))
+	}
+
+	normalized := specifier
+	if asURL, ok := urlLikeSpecifier(specifier, referrerURL); ok {
+		normalized = asURL.String()
+	}
+
+	referrerStr := referrerURL.String()
+	for _, scope := range m.scopes {
+		if scope.prefix == referrerStr || (strings.HasSuffix(scope.prefix, "/") && strings.HasPrefix(referrerStr, scope.prefix)) {
+			if target, ok, err := m.resolveIn(scope.imports, specifier, normalized); ok || err != nil {
+				return target, ok, err
+			}
+		}
+	}
+	return m.resolveIn(m.imports, specifier, normalized)
+}
+
+func (m *ImportMap) resolveIn(imports specifierMap, specifier, normalized string) (string, bool, error) {
+	for _, mapping := range imports {
+		var target *url.URL
+		switch {
+		case mapping.key == normalized:
+			if mapping.target == nil {
+				return "", false, m.blocked(specifier)
+			}
+			target = mapping.target
+
+		case strings.HasSuffix(mapping.key, "/") && strings.HasPrefix(normalized, mapping.key):
+			if mapping.target == nil {
+				return "", false, m.blocked(specifier)
+			}
+			rest := strings.TrimPrefix(normalized, mapping.key)
+			resolved, err := mapping.target.Parse(rest)
+			if mapping.target.Opaque != "" {
+				// npm: and jsr: targets have no hierarchy to resolve in
+				resolved, err = url.Parse(mapping.target.String() + rest)
+			}
+			// The rest of the specifier may not climb out of the target
+			if err != nil || !strings.HasPrefix(resolved.String(), mapping.target.String()) {
+				return "", false, m.blocked(specifier)
+			}
+			target = resolved
+
+		default:
+			continue
+		}
+
+		if target.Scheme == "file" {
+			return filepath.FromSlash(target.Path), true, nil
+		}
+		return target.String(), true, nil
+	}
+	return "", false, nil
+}
+
+func (m *ImportMap) blocked(specifier string) error {
+	return errors.Wrap(errors.ErrUnresolvedImport, fmt.Sprintf("%q is blocked by import map %s", specifier, m.Source))
+}
+
+// urlLikeSpecifier parses specifier as a URL if it is relative ("./", "../",
+// "/") or absolute, following the import maps rules. Bare specifiers report
+// false.
+func urlLikeSpecifier(specifier string, base *url.URL) (*url.URL, bool) {
+	if strings.HasPrefix(specifier, "/") || strings.HasPrefix(specifier, "./") || strings.HasPrefix(specifier, "../") {
+		parsed, err := base.Parse(specifier)
+		return parsed, err == nil
+	}
+	parsed, err := url.Parse(specifier)
+	if err != nil || parsed.Scheme == "" || len(parsed.Scheme) == 1 {
+		return nil, false
+	}
+	return parsed, true
+}
+
+// FileURL turns a local path into a file:// URL
+func FileURL(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
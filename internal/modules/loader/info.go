@@ -0,0 +1,207 @@
+package loader
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// GraphInfo describes a module graph for tools and for edon info
+type GraphInfo struct {
+	Root string `json:"root"`
+	// Order is the order modules are evaluated in, dependencies first
+	Order   []string      `json:"order"`
+	Modules []*ModuleInfo `json:"modules"`
+	// Cycles lists circular imports, each starting and ending with the same
+	// module
+	Cycles [][]string `json:"cycles,omitempty"`
+
+	byID map[string]*ModuleInfo
+}
+
+// ModuleInfo describes one module of a graph
+type ModuleInfo struct {
+	ID   string      `json:"id"`
+	Type PackageType `json:"type"`
+	// ImportType is set for JSON, text and bytes imports
+	ImportType ImportType `json:"importType,omitempty"`
+	// Location is the file the module is read from: the source file, the
+	// npm cache entry or the disk cache entry of a remote module
+	Location     string            `json:"location,omitempty"`
+	Size         int64             `json:"size"`
+	Dependencies []*DependencyInfo `json:"dependencies"`
+}
+
+// DependencyInfo is an import of a module
+type DependencyInfo struct {
+	Specifier string `json:"specifier"`
+	ID        string `json:"id"`
+	Dynamic   bool   `json:"dynamic,omitempty"`
+}
+
+// Info describes graph, with modules in evaluation order
+func (l *ModuleLoader) Info(graph *ModuleGraph) (*GraphInfo, error) {
+	order, err := graph.Order()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &GraphInfo{
+		Root:    graph.Root,
+		Order:   order,
+		Modules: make([]*ModuleInfo, 0, len(graph.Modules)),
+		Cycles:  graph.Cycles(),
+		byID:    make(map[string]*ModuleInfo, len(graph.Modules)),
+	}
+
+	// Modules only reached through dynamic imports are not in the order
+	dynamicOnly := make([]string, 0)
+	for id := range graph.Modules {
+		if !slices.Contains(order, id) {
+			dynamicOnly = append(dynamicOnly, id)
+		}
+	}
+	sort.Strings(dynamicOnly)
+	ids := append(append([]string{}, order...), dynamicOnly...)
+
+	for _, id := range ids {
+		module, ok := graph.Modules[id]
+		if !ok {
+			continue
+		}
+
+		location := module.Path
+		if location == "" {
+			location = l.CachePath(module.URL)
+		}
+		size := int64(len(module.Content))
+		if stat, err := os.Stat(location); err == nil && location != "" {
+			size = stat.Size()
+		}
+
+		mi := &ModuleInfo{
+			ID:           id,
+			Type:         module.Type,
+			ImportType:   module.ImportType,
+			Location:     location,
+			Size:         size,
+			Dependencies: make([]*DependencyInfo, 0),
+		}
+		// Imports in the order they are written
+		seen := make(map[string]bool)
+		for _, imp := range ScanImports(module.JS()) {
+			target, ok := module.Specifiers[imp.Key()]
+			if !ok || seen[imp.Key()] {
+				continue
+			}
+			seen[imp.Key()] = true
+			mi.Dependencies = append(mi.Dependencies, &DependencyInfo{Specifier: imp.Specifier, ID: target, Dynamic: imp.Dynamic})
+		}
+
+		info.Modules = append(info.Modules, mi)
+		info.byID[id] = mi
+	}
+	return info, nil
+}
+
+// TotalSize returns the size of every module in the graph
+func (i *GraphInfo) TotalSize() int64 {
+	var total int64
+	for _, module := range i.Modules {
+		total += module.Size
+	}
+	return total
+}
+
+// WriteTree prints the graph as a tree rooted at the entry module. Modules
+// that were already printed are marked with a "*" and not expanded again.
+func (i *GraphInfo) WriteTree(w io.Writer) error {
+	root := i.byID[i.Root]
+	if root == nil {
+		return fmt.Errorf("root module %s is not in the graph", i.Root)
+	}
+
+	fmt.Fprintf(w, "%s (%s, %s)\n", root.ID, root.Type, FormatSize(root.Size))
+	printed := map[string]bool{root.ID: true}
+	duplicates := false
+
+	var walk func(module *ModuleInfo, prefix string)
+	walk = func(module *ModuleInfo, prefix string) {
+		for n, dep := range module.Dependencies {
+			branch, indent := "├── ", "│   "
+			if n == len(module.Dependencies)-1 {
+				branch, indent = "└── ", "    "
+			}
+
+			child := i.byID[dep.ID]
+			line := dep.Specifier
+			if dep.Specifier != dep.ID {
+				line += " -> " + dep.ID
+			}
+			details := []string{string(child.Type), FormatSize(child.Size)}
+			if child.ImportType != ImportJavaScript {
+				details = append(details, string(child.ImportType))
+			}
+			if dep.Dynamic {
+				details = append(details, "dynamic")
+			}
+			if child.Location != "" && child.Location != child.ID {
+				details = append(details, child.Location)
+			}
+			line += " (" + strings.Join(details, ", ") + ")"
+
+			if printed[child.ID] {
+				duplicates = true
+				fmt.Fprintf(w, "%s%s%s *\n", prefix, branch, line)
+				continue
+			}
+			printed[child.ID] = true
+			fmt.Fprintf(w, "%s%s%s\n", prefix, branch, line)
+			walk(child, prefix+indent)
+		}
+	}
+	walk(root, "")
+
+	fmt.Fprintf(w, "\n%d modules, %s\n", len(i.Modules), FormatSize(i.TotalSize()))
+	if duplicates {
+		fmt.Fprintln(w, "* already listed above")
+	}
+	for _, cycle := range i.Cycles {
+		fmt.Fprintf(w, "cycle: %s\n", FormatCycle(cycle))
+	}
+	return nil
+}
+
+// WriteDot prints the graph in Graphviz dot format
+func (i *GraphInfo) WriteDot(w io.Writer) error {
+	fmt.Fprintln(w, "digraph modules {")
+	fmt.Fprintln(w, "  node [shape=box];")
+	for _, module := range i.Modules {
+		fmt.Fprintf(w, "  %q [label=%q];\n", module.ID, fmt.Sprintf("%s\n%s, %s", module.ID, module.Type, FormatSize(module.Size)))
+	}
+	for _, module := range i.Modules {
+		for _, dep := range module.Dependencies {
+			style := ""
+			if dep.Dynamic {
+				style = ", style=dashed"
+			}
+			fmt.Fprintf(w, "  %q -> %q [label=%q%s];\n", module.ID, dep.ID, dep.Specifier, style)
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// FormatSize formats a byte count for people
+func FormatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", size)
+}
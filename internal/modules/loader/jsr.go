@@ -0,0 +1,179 @@
+package loader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/semver"
+)
+
+// DefaultJSRURL is the registry jsr: specifiers load from unless JSR_URL is set
+const DefaultJSRURL = "https://jsr.io"
+
+// jsrPackageMeta is the package-level meta.json document
+type jsrPackageMeta struct {
+	Latest   string `json:"latest"`
+	Versions map[string]struct {
+		Yanked bool `json:"yanked"`
+	} `json:"versions"`
+}
+
+// jsrVersionMeta is the <version>_meta.json document listing a version's
+// files with their checksums and its exports
+type jsrVersionMeta struct {
+	Manifest map[string]struct {
+		Size     int64  `json:"size"`
+		Checksum string `json:"checksum"`
+	} `json:"manifest"`
+	Exports map[string]string `json:"exports"`
+}
+
+// loadJSRModule loads a module from JSR registry. It accepts jsr: specifiers
+// as well as the registry URLs of files inside a package, which is what
+// relative imports between a package's files resolve to.
+func (l *ModuleLoader) loadJSRModule(ctx context.Context, url string) (*Module, error) {
+	var name, version, file string
+
+	if strings.HasPrefix(url, "jsr:") {
+		spec, err := ParseNPMSpecifier(strings.TrimPrefix(url, "jsr:"))
+		if err != nil || !strings.HasPrefix(spec.Name, "@") {
+			return nil, errors.Wrap(errors.ErrInvalidSpecifier, url)
+		}
+
+		version, err = l.resolveJSRVersion(ctx, spec.Name, spec.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		meta, err := l.jsrVersionMeta(ctx, spec.Name, version)
+		if err != nil {
+			return nil, err
+		}
+		target, ok := meta.Exports[spec.Subpath]
+		if !ok {
+			return nil, errors.Wrap(errors.ErrPackagePathNotExported, fmt.Sprintf("package %q subpath %q", spec.Name+"@"+version, spec.Subpath))
+		}
+		name, file = spec.Name, "/"+strings.TrimPrefix(target, "./")
+	} else {
+		var ok bool
+		name, version, file, ok = l.splitJSRURL(url)
+		if !ok {
+			return nil, errors.Wrap(errors.ErrInvalidSpecifier, url)
+		}
+	}
+
+	meta, err := l.jsrVersionMeta(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := meta.Manifest[file]
+	if !ok {
+		return nil, errors.Wrap(errors.ErrModuleNotFound, fmt.Sprintf("%s@%s%s", name, version, file))
+	}
+
+	fileURL := fmt.Sprintf("%s/%s/%s%s", l.jsrURL, name, version, file)
+	_, content, err := l.fetchRemote(ctx, fileURL)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(content)
+	if want := strings.TrimPrefix(entry.Checksum, "sha256-"); hex.EncodeToString(sum[:]) != want {
+		return nil, errors.Wrap(errors.ErrPackageIntegrity, fmt.Sprintf("%s: expected sha256-%s", fileURL, want))
+	}
+
+	return &Module{
+		URL:     fileURL,
+		Content: string(content),
+		Type:    TypeJSR,
+	}, nil
+}
+
+// resolveJSRVersion picks the newest non-yanked version matching versionRange
+func (l *ModuleLoader) resolveJSRVersion(ctx context.Context, name, versionRange string) (string, error) {
+	// The package meta changes with every publish, so it is revalidated
+	// unless running from the cache only
+	_, data, err := l.fetchRemoteCached(ctx, fmt.Sprintf("%s/%s/meta.json", l.jsrURL, name), true)
+	if err != nil {
+		return "", err
+	}
+
+	var meta jsrPackageMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return "", errors.Wrap(errors.ErrPackageFetch, fmt.Sprintf("%s: %v", name, err))
+	}
+
+	if versionRange == "latest" && meta.Latest != "" {
+		return meta.Latest, nil
+	}
+
+	versions := make([]string, 0, len(meta.Versions))
+	for v, info := range meta.Versions {
+		// Yanked versions stay loadable when pinned exactly
+		if !info.Yanked || v == versionRange {
+			versions = append(versions, v)
+		}
+	}
+	sort.Strings(versions)
+
+	if versionRange == "latest" {
+		versionRange = "*"
+	}
+	version, ok := semver.MaxSatisfying(versions, versionRange)
+	if !ok {
+		return "", errors.Wrap(errors.ErrPackageNotFound, fmt.Sprintf("jsr:%s@%s", name, versionRange))
+	}
+	return version, nil
+}
+
+// jsrVersionMeta fetches and memoizes a version's metadata
+func (l *ModuleLoader) jsrVersionMeta(ctx context.Context, name, version string) (*jsrVersionMeta, error) {
+	key := name + "@" + version
+
+	l.jsrMu.Lock()
+	meta, ok := l.jsrMeta[key]
+	l.jsrMu.Unlock()
+	if ok {
+		return meta, nil
+	}
+
+	// Published versions never change, so their metadata is cached on disk
+	_, data, err := l.fetchRemote(ctx, fmt.Sprintf("%s/%s/%s_meta.json", l.jsrURL, name, version))
+	if err != nil {
+		return nil, err
+	}
+	meta = &jsrVersionMeta{}
+	if err := json.Unmarshal(data, meta); err != nil {
+		return nil, errors.Wrap(errors.ErrPackageFetch, fmt.Sprintf("%s: %v", key, err))
+	}
+
+	l.jsrMu.Lock()
+	l.jsrMeta[key] = meta
+	l.jsrMu.Unlock()
+	return meta, nil
+}
+
+// splitJSRURL splits <registry>/@scope/name/version/path into its parts
+func (l *ModuleLoader) splitJSRURL(url string) (name, version, file string, ok bool) {
+	rest, found := strings.CutPrefix(url, l.jsrURL+"/")
+	if !found {
+		return "", "", "", false
+	}
+	parts := strings.SplitN(rest, "/", 4)
+	if len(parts) != 4 || !strings.HasPrefix(parts[0], "@") {
+		return "", "", "", false
+	}
+	return parts[0] + "/" + parts[1], parts[2], "/" + parts[3], true
+}
+
+// isJSRURL reports whether url points at a file inside the JSR registry
+func (l *ModuleLoader) isJSRURL(url string) bool {
+	_, _, _, ok := l.splitJSRURL(url)
+	return ok
+}
@@ -2,6 +2,8 @@ package loader
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -10,59 +12,304 @@ import (
 	"sync"
 	"time"
 
+	"github.com/evanw/esbuild/pkg/api"
 	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/edon"
+	"github.com/katungi/edon/internal/modules/node"
 )
 
-// ModuleCache represents a thread-safe cache for loaded modules
-type ModuleCache struct {
-	mu      sync.RWMutex
-	modules map[string]*Module
-}
-
 // Module represents a loaded module with its content and metadata
 type Module struct {
 	URL     string
 	Content string
 	Type    PackageType
+	// Path is the file the module was read from, for modules that live on disk
+	Path string
+
+	// ImportType is the type attribute the module was imported with.
+	// Content then holds the file as is, and JS the module built from it.
+	ImportType ImportType
+
+	// modTime and size are those of a local module's file when it was read
+	modTime time.Time
+	size    int64
+	// contentType is the Content-Type a remote module was served with
+	contentType string
+	// source is the JavaScript module of a typed or WebAssembly import
+	source string
+	// original is the TypeScript or JSX a module was compiled from, and
+	// sourceMap maps Content back to it
+	original  string
+	sourceMap *sourceMap
 }
 
 // ModuleLoader handles the loading of modules from various sources
 type ModuleLoader struct {
 	cache      *ModuleCache
 	httpClient *http.Client
+	conditions []string
+	policy     *ImportPolicy
+	deps       *DiskCache
+	code       *CodeCache
+	// tsconfig holds the compilerOptions TypeScript and JSX compile with
+	tsconfig   string
+	reload     []string
+	importMap  *ImportMap
+	lock       *Lockfile
+	cachedOnly bool
+	// strictCycles rejects graphs with circular imports
+	strictCycles bool
+	// concurrency is how many modules LoadGraph fetches at once
+	concurrency int
+	// imports collects the edges of every graph loaded, so changed modules
+	// can be traced to the modules that import them
+	imports *DependencyGraph
+	watchMu sync.Mutex
+	watcher *Watcher
+
+	jsrURL  string
+	jsrMu   sync.Mutex
+	jsrMeta map[string]*jsrVersionMeta
+}
+
+// Option configures a ModuleLoader
+type Option func(*ModuleLoader)
+
+// WithJSRURL sets the registry jsr: specifiers are loaded from
+func WithJSRURL(url string) Option {
+	return func(l *ModuleLoader) {
+		l.jsrURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithImportPolicy sets the hosts remote modules may be imported from
+func WithImportPolicy(policy *ImportPolicy) Option {
+	return func(l *ModuleLoader) {
+		l.policy = policy
+	}
+}
+
+// WithDiskCache sets where remote modules are cached between runs. A nil
+// cache disables caching on disk.
+func WithDiskCache(cache *DiskCache) Option {
+	return func(l *ModuleLoader) {
+		l.deps = cache
+	}
+}
+
+// WithCodeCache sets where compiled bytecode is cached between runs. A nil
+// cache compiles every module from source.
+func WithCodeCache(cache *CodeCache) Option {
+	return func(l *ModuleLoader) {
+		l.code = cache
+	}
+}
+
+// WithCompilerOptions sets the tsconfig compilerOptions, such as jsx and
+// jsxImportSource, that TypeScript and JSX modules are compiled with
+func WithCompilerOptions(options json.RawMessage) Option {
+	return func(l *ModuleLoader) {
+		l.tsconfig = ""
+		if len(options) > 0 {
+			l.tsconfig = `{"compilerOptions":` + string(options) + `}`
+		}
+	}
+}
+
+// WithReload refetches remote modules whose URL starts with one of prefixes
+// instead of reading them from the disk cache. An empty prefix reloads
+// everything.
+func WithReload(prefixes ...string) Option {
+	return func(l *ModuleLoader) {
+		l.reload = prefixes
+	}
+}
+
+// WithImportMap remaps import specifiers through m before they are resolved
+func WithImportMap(m *ImportMap) Option {
+	return func(l *ModuleLoader) {
+		l.importMap = m
+	}
+}
+
+// WithLockfile checks every remote module against the hashes in lock
+func WithLockfile(lock *Lockfile) Option {
+	return func(l *ModuleLoader) {
+		l.lock = lock
+	}
+}
+
+// WithCachedOnly loads remote and npm modules from the caches only, failing
+// with ErrNotCached instead of touching the network
+func WithCachedOnly(cachedOnly bool) Option {
+	return func(l *ModuleLoader) {
+		l.cachedOnly = cachedOnly
+	}
+}
+
+// WithStrictCycles makes LoadGraph fail with a *CycleError on the first
+// circular import instead of allowing it
+func WithStrictCycles(strict bool) Option {
+	return func(l *ModuleLoader) {
+		l.strictCycles = strict
+	}
+}
+
+// WithConcurrency sets how many modules LoadGraph fetches at once. One loads
+// the graph strictly in sequence.
+func WithConcurrency(n int) Option {
+	return func(l *ModuleLoader) {
+		l.concurrency = max(n, 1)
+	}
+}
+
+// WithCacheLimit keeps at most n modules in memory, evicting the least
+// recently used ones. Zero, the default, keeps every module.
+func WithCacheLimit(n int) Option {
+	return func(l *ModuleLoader) {
+		l.cache = NewModuleCache(n)
+	}
 }
 
 // NewModuleLoader creates a new instance of ModuleLoader
-func NewModuleLoader() *ModuleLoader {
+func NewModuleLoader(opts ...Option) *ModuleLoader {
+	jsrURL := os.Getenv("JSR_URL")
+	if jsrURL == "" {
+		jsrURL = DefaultJSRURL
+	}
+
 	// #81: Don't use default HTTP client - configure timeouts
-	return &ModuleLoader{
-		cache: &ModuleCache{
-			modules: make(map[string]*Module),
-		},
+	l := &ModuleLoader{
+		cache:   NewModuleCache(0),
+		imports: NewDependencyGraph(),
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		conditions:  DefaultConditions,
+		concurrency: DefaultConcurrency,
+		policy:      DefaultImportPolicy(),
+		jsrURL:      strings.TrimSuffix(jsrURL, "/"),
+		jsrMeta:     make(map[string]*jsrVersionMeta),
+	}
+	if dir, err := DefaultDepsDir(); err == nil {
+		l.deps = NewDiskCache(dir)
+	}
+	if dir, err := DefaultGenDir(); err == nil {
+		l.code = NewCodeCache(dir)
 	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.httpClient.CheckRedirect = l.checkRedirect
+	return l
+}
+
+// Cache returns the in-memory cache of loaded modules
+func (l *ModuleLoader) Cache() *ModuleCache {
+	return l.cache
+}
+
+// CodeCache returns the bytecode cache, or nil when there is none
+func (l *ModuleLoader) CodeCache() *CodeCache {
+	return l.code
+}
+
+// InvalidateModule removes the module registered under id, and every module
+// that imports it directly or indirectly, from the cache. It returns their
+// IDs, or nil if id is neither cached nor imported by anything.
+func (l *ModuleLoader) InvalidateModule(id string) []string {
+	stale := l.imports.DependentsOf(id)
+	if l.cache.invalidateIDs(stale...) == 0 && len(stale) == 1 {
+		return nil
+	}
+	return stale
+}
+
+// checkRedirect keeps redirects of remote imports within the import policy.
+// The JSR registry is trusted on its own and only gets the default limit.
+func (l *ModuleLoader) checkRedirect(req *http.Request, via []*http.Request) error {
+	if strings.HasPrefix(via[0].URL.String(), l.jsrURL+"/") {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return l.policy.CheckRedirect(req, via)
+}
+
+// ID returns the name the module is registered under in a module graph: its
+// file path when it lives on disk, otherwise its URL
+func (m *Module) ID() string {
+	if m.Path != "" {
+		return typedID(m.Path, m.ImportType)
+	}
+	return typedID(m.URL, m.ImportType)
+}
+
+// JS returns the module's JavaScript source: Content for JavaScript modules,
+// the generated module for JSON, text, bytes and WebAssembly imports
+func (m *Module) JS() string {
+	if m.source == "" {
+		return m.Content
+	}
+	return m.source
+}
+
+// Original returns the module as written along with the esbuild loader that
+// compiles it: the TypeScript or JSX Content was compiled from, or else JS
+func (m *Module) Original() (string, api.Loader) {
+	if m.sourceMap == nil || m.source != "" {
+		return m.JS(), api.LoaderJS
+	}
+	loader, _ := sourceLoader(m.ID())
+	return m.original, loader
+}
+
+// OriginalPosition maps a 1-based line and column of JS, counted in
+// characters, to the source as written. Modules that were not compiled
+// keep their positions.
+func (m *Module) OriginalPosition(line, column int) (int, int) {
+	if m.sourceMap == nil || m.source != "" || line < 1 || column < 1 {
+		return line, column
+	}
+	genColumn := utf16Column(lineText(m.Content, line-1), column-1)
+	origLine, origColumn, ok := m.sourceMap.lookup(line-1, genColumn)
+	if !ok {
+		return line, column
+	}
+	return origLine + 1, runeColumn(lineText(m.original, origLine), origColumn) + 1
 }
 
 // LoadModule loads a module from the given URL, using cache if available
 func (l *ModuleLoader) LoadModule(ctx context.Context, urlStr string) (*Module, error) {
-	// Validate the URL first
-	validation := ValidateURL(urlStr)
+	return l.LoadModuleAs(ctx, urlStr, ImportJavaScript)
+}
+
+// LoadModuleAs loads a module imported with the given type attribute. Typed
+// modules are cached apart from the same URL imported as JavaScript.
+func (l *ModuleLoader) LoadModuleAs(ctx context.Context, urlStr string, as ImportType) (*Module, error) {
+	// Validate the URL first. Files inside JSR packages are addressed by
+	// their registry URL, which only the loader knows.
+	validation := ValidationResult{IsValid: true, PackageType: TypeJSR}
+	if !l.isJSRURL(urlStr) {
+		validation = ValidateURLWithPolicy(urlStr, l.policy)
+	}
 	if !validation.IsValid {
 		return nil, validation.Error
 	}
 
-	// Check cache first
-	if module := l.getFromCache(urlStr); module != nil {
-		return module, nil
-	}
+	// Concurrent loads of the same URL wait for the first one
+	return l.cache.load(ctx, typedID(urlStr, as), func() (*Module, error) {
+		return l.loadUncached(ctx, urlStr, validation.PackageType, as)
+	})
+}
 
-	// Load module based on its type
+// loadUncached loads a module based on its type
+func (l *ModuleLoader) loadUncached(ctx context.Context, urlStr string, packageType PackageType, as ImportType) (*Module, error) {
 	var module *Module
 	var err error
 
-	switch validation.PackageType {
+	switch packageType {
 	case TypeLocal:
 		module, err = l.loadLocalModule(urlStr)
 	case TypeCDN:
@@ -71,6 +318,10 @@ func (l *ModuleLoader) LoadModule(ctx context.Context, urlStr string) (*Module,
 		module, err = l.loadNPMModule(ctx, urlStr)
 	case TypeJSR:
 		module, err = l.loadJSRModule(ctx, urlStr)
+	case TypeNode:
+		module, err = loadNodeModule(urlStr)
+	case TypeEdon:
+		module, err = loadEdonModule(urlStr)
 	default:
 		return nil, errors.ErrUnsupportedModule
 	}
@@ -78,20 +329,60 @@ func (l *ModuleLoader) LoadModule(ctx context.Context, urlStr string) (*Module,
 	if err != nil {
 		return nil, err
 	}
+	if err := checkImportType(module, as); err != nil {
+		return nil, err
+	}
 
-	// Cache the loaded module
-	l.cache.mu.Lock()
-	l.cache.modules[urlStr] = module
-	l.cache.mu.Unlock()
+	// JSON, text and bytes become JavaScript modules once, at load time
+	if as != ImportJavaScript {
+		module.ImportType = as
+		module.source, err = typedSource(module, as)
+		if err != nil {
+			return nil, err
+		}
+		return module, nil
+	}
+
+	// WebAssembly is wrapped in a module that instantiates it
+	if module.isWasm() {
+		module.source, err = wasmSource(module)
+		if err != nil {
+			return nil, err
+		}
+		return module, nil
+	}
 
+	// TypeScript and JSX are compiled to JavaScript once, at load time
+	if err := transpileModule(module, l.tsconfig); err != nil {
+		return nil, err
+	}
 	return module, nil
 }
 
-// getFromCache retrieves a module from the cache if it exists
-func (l *ModuleLoader) getFromCache(url string) *Module {
-	l.cache.mu.RLock()
-	defer l.cache.mu.RUnlock()
-	return l.cache.modules[url]
+// loadNodeModule loads a built-in node: module
+func loadNodeModule(urlStr string) (*Module, error) {
+	source, ok := node.Source(urlStr)
+	if !ok {
+		return nil, errors.Wrap(errors.ErrModuleNotFound, urlStr)
+	}
+	return &Module{
+		URL:     urlStr,
+		Content: source,
+		Type:    TypeNode,
+	}, nil
+}
+
+// loadEdonModule loads a built-in edon: module
+func loadEdonModule(urlStr string) (*Module, error) {
+	source, ok := edon.Source(urlStr)
+	if !ok {
+		return nil, errors.Wrap(errors.ErrModuleNotFound, urlStr)
+	}
+	return &Module{
+		URL:     urlStr,
+		Content: source,
+		Type:    TypeEdon,
+	}, nil
 }
 
 // loadLocalModule loads a module from the local filesystem
@@ -101,63 +392,186 @@ func (l *ModuleLoader) loadLocalModule(path string) (*Module, error) {
 		return nil, errors.Wrap(errors.ErrModuleNotFound, err.Error())
 	}
 
+	// Stat first so a write during the read shows up as a change later
+	stat, err := os.Stat(absPath)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrFileRead, err.Error())
+	}
 	content, err := os.ReadFile(absPath)
 	if err != nil {
 		return nil, errors.Wrap(errors.ErrFileRead, err.Error())
 	}
 
+	if w := l.currentWatcher(); w != nil {
+		w.add(absPath)
+	}
+
 	return &Module{
 		URL:     path,
 		Content: string(content),
 		Type:    TypeLocal,
+		Path:    absPath,
+		modTime: stat.ModTime(),
+		size:    stat.Size(),
 	}, nil
 }
 
 // loadCDNModule loads a module from a CDN
 func (l *ModuleLoader) loadCDNModule(ctx context.Context, url string) (*Module, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	entry, content, err := l.fetchRemote(ctx, url)
 	if err != nil {
-		return nil, errors.Wrap(errors.ErrModuleNotFound, err.Error())
+		return nil, err
+	}
+
+	// Relative imports resolve against where a redirect ended up
+	return &Module{
+		URL:         entry.FinalURL,
+		Content:     string(content),
+		Type:        TypeCDN,
+		contentType: entry.ContentType,
+	}, nil
+}
+
+// fetchRemote GETs a remote module, reading through the disk cache and
+// checking the content against the lockfile
+func (l *ModuleLoader) fetchRemote(ctx context.Context, url string) (*CacheEntry, []byte, error) {
+	entry, content, err := l.fetchRemoteCached(ctx, url, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if l.lock != nil {
+		if err := l.lock.Check(url, content); err != nil {
+			return nil, nil, err
+		}
+	}
+	return entry, content, nil
+}
+
+// fetchRemoteCached reads url from the disk cache, fetching it when it is
+// missing or asked to be reloaded. Documents that change over time set
+// revalidate so a cached copy is only used offline or when still current.
+func (l *ModuleLoader) fetchRemoteCached(ctx context.Context, url string, revalidate bool) (*CacheEntry, []byte, error) {
+	var cached *CacheEntry
+	var cachedContent []byte
+	if l.deps != nil {
+		if entry, content, ok := l.deps.Get(url); ok {
+			if l.cachedOnly || (!revalidate && !l.shouldReload(url)) {
+				return entry, content, nil
+			}
+			cached, cachedContent = entry, content
+		}
+	}
+	if l.cachedOnly {
+		return nil, nil, errors.Wrap(errors.ErrNotCached, url)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(errors.ErrModuleNotFound, err.Error())
+	}
+	for name, value := range l.policy.Headers(req.URL) {
+		req.Header.Set(name, value)
+	}
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
 	}
 
 	resp, err := l.httpClient.Do(req)
 	if err != nil {
-		return nil, errors.Wrap(errors.ErrModuleNotFound, err.Error())
+		return nil, nil, errors.Wrap(errors.ErrModuleNotFound, err.Error())
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return cached, cachedContent, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, errors.Wrap(errors.ErrModuleNotFound, fmt.Sprintf("%s: %s", url, resp.Status))
+	}
+
 	content, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, errors.Wrap(errors.ErrFileRead, err.Error())
+		return nil, nil, errors.Wrap(errors.ErrFileRead, err.Error())
 	}
 
-	return &Module{
-		URL:     url,
-		Content: string(content),
-		Type:    TypeCDN,
-	}, nil
+	entry := &CacheEntry{
+		URL:         url,
+		FinalURL:    resp.Request.URL.String(),
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        resp.Header.Get("ETag"),
+		FetchedAt:   time.Now().UTC(),
+	}
+	if l.deps != nil {
+		if err := l.deps.Put(entry, content); err != nil {
+			return nil, nil, err
+		}
+		// Redirect targets are modules in their own right
+		if entry.FinalURL != url {
+			final := *entry
+			final.URL = entry.FinalURL
+			if err := l.deps.Put(&final, content); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return entry, content, nil
+}
+
+// CachePath returns where the disk cache keeps a remote module, or "" when
+// caching is disabled
+func (l *ModuleLoader) CachePath(url string) string {
+	if l.deps == nil {
+		return ""
+	}
+	return l.deps.Path(url)
+}
+
+// shouldReload reports whether url was asked to be refetched
+func (l *ModuleLoader) shouldReload(url string) bool {
+	for _, prefix := range l.reload {
+		if strings.HasPrefix(url, prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 // loadNPMModule loads a module from NPM registry
 func (l *ModuleLoader) loadNPMModule(ctx context.Context, url string) (*Module, error) {
-	// Extract package name from npm: URL
-	packageName := strings.TrimPrefix(url, "npm:")
+	// Split npm:name@version/subpath into its parts
+	spec, err := ParseNPMSpecifier(url)
+	if err != nil {
+		return nil, err
+	}
 
 	// Initialize NPM package manager
-	pm, err := NewNPMPackageManager()
+	pm, err := NewNPMPackageManager(NPMCachedOnly(l.cachedOnly))
 	if err != nil {
 		return nil, errors.Wrap(errors.ErrPackageInstall, err.Error())
 	}
 
-	// Install the package
-	packagePath, err := pm.InstallPackage(ctx, packageName)
+	// Install the package, at the version edon install locked it to
+	version := spec.Version
+	if l.lock != nil {
+		if locked, ok := l.lock.NPMVersion(spec.Name, spec.Version); ok {
+			version = locked
+		}
+	}
+	packagePath, err := pm.InstallPackage(ctx, spec.Name+"@"+version)
+	if errors.Is(err, errors.ErrNotCached) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, errors.Wrap(errors.ErrPackageInstall, err.Error())
 	}
 
-	// Read the package's main file
-	mainFile := filepath.Join(packagePath, "index.js")
-	content, err := os.ReadFile(mainFile)
+	// Find the file the specifier points at through the package.json fields
+	entry, err := ResolvePackageEntry(packagePath, spec.Subpath, l.conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(entry)
 	if err != nil {
 		return nil, errors.Wrap(errors.ErrFileRead, err.Error())
 	}
@@ -166,11 +580,6 @@ func (l *ModuleLoader) loadNPMModule(ctx context.Context, url string) (*Module,
 		URL:     url,
 		Content: string(content),
 		Type:    TypeNPM,
+		Path:    entry,
 	}, nil
 }
-
-// loadJSRModule loads a module from JSR registry
-func (l *ModuleLoader) loadJSRModule(ctx context.Context, url string) (*Module, error) {
-	// TODO: Implement JSR module loading
-	return nil, errors.ErrJSRNotImplemented
-}
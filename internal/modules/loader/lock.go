@@ -0,0 +1,173 @@
+package loader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// LockfileName is the name of the lockfile kept next to edon.json
+const LockfileName = "edon.lock"
+
+const lockfileVersion = "1"
+
+// Lockfile records the SHA-256 of every remote module the first time it is
+// fetched, and checks later fetches and cache reads against it
+type Lockfile struct {
+	Version string `json:"version"`
+	// Remote maps module URLs to the hex SHA-256 of their content
+	Remote map[string]string `json:"remote"`
+	// NPM records the versions npm dependencies were installed at
+	NPM *NPMLock `json:"npm,omitempty"`
+
+	path  string
+	write bool
+	mu    sync.Mutex
+	dirty bool
+}
+
+// NPMLock is the npm section of the lockfile, written by edon install
+type NPMLock struct {
+	// Specifiers maps every name@range that was asked for, by the project
+	// or by a package, to the version installed for it
+	Specifiers map[string]string `json:"specifiers"`
+	// Packages maps name@version to what was installed
+	Packages map[string]NPMLockedPackage `json:"packages"`
+}
+
+// NPMLockedPackage is an installed npm package version
+type NPMLockedPackage struct {
+	Integrity string `json:"integrity,omitempty"`
+	// Dependencies maps the names of the package's dependencies to their
+	// ranges, each of which has an entry in Specifiers
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+// NewNPMLock returns an empty npm section
+func NewNPMLock() *NPMLock {
+	return &NPMLock{Specifiers: make(map[string]string), Packages: make(map[string]NPMLockedPackage)}
+}
+
+// LoadLockfile reads the lockfile at path, starting an empty one if it does
+// not exist. In write mode mismatching hashes are replaced instead of
+// rejected.
+func LoadLockfile(path string, write bool) (*Lockfile, error) {
+	lock := &Lockfile{
+		Version: lockfileVersion,
+		Remote:  make(map[string]string),
+		path:    path,
+		write:   write,
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrFileRead, err.Error())
+	}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidLockfile, fmt.Sprintf("%s: %v", path, err))
+	}
+	if lock.Version != lockfileVersion {
+		return nil, errors.Wrap(errors.ErrInvalidLockfile, fmt.Sprintf("%s: unsupported version %q", path, lock.Version))
+	}
+	if lock.Remote == nil {
+		lock.Remote = make(map[string]string)
+	}
+	return lock, nil
+}
+
+// Path returns the file the lockfile is saved to
+func (l *Lockfile) Path() string {
+	return l.path
+}
+
+// Check verifies content against the hash recorded for url, recording it if
+// there is none yet
+func (l *Lockfile) Check(url string, content []byte) error {
+	sum := sha256.Sum256(content)
+	got := hex.EncodeToString(sum[:])
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	want, ok := l.Remote[url]
+	if ok && want == got {
+		return nil
+	}
+	if ok && !l.write {
+		return errors.Wrap(errors.ErrModuleIntegrity, fmt.Sprintf("%s: %s has sha256 %s, got %s (run with -lock-write if the change is expected)", url, l.path, want, got))
+	}
+
+	l.Remote[url] = got
+	l.dirty = true
+	return nil
+}
+
+// NPMVersion returns the version installed for the npm dependency
+// name@versionRange
+func (l *Lockfile) NPMVersion(name, versionRange string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.NPM == nil {
+		return "", false
+	}
+	version, ok := l.NPM.Specifiers[name+"@"+versionRange]
+	return version, ok
+}
+
+// NPMPackages returns a copy of the npm section, empty when there is none
+func (l *Lockfile) NPMPackages() *NPMLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	npm := NewNPMLock()
+	if l.NPM != nil {
+		maps.Copy(npm.Specifiers, l.NPM.Specifiers)
+		maps.Copy(npm.Packages, l.NPM.Packages)
+	}
+	return npm
+}
+
+// SetNPMPackages replaces the npm section, dropping it when npm is empty
+func (l *Lockfile) SetNPMPackages(npm *NPMLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if npm != nil && len(npm.Specifiers) == 0 && len(npm.Packages) == 0 {
+		npm = nil
+	}
+	if reflect.DeepEqual(l.NPM, npm) {
+		return
+	}
+	l.NPM = npm
+	l.dirty = true
+}
+
+// Save writes the lockfile if anything was recorded since it was loaded
+func (l *Lockfile) Save() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return errors.Wrap(errors.ErrInvalidLockfile, err.Error())
+	}
+	if err := os.WriteFile(l.path, append(data, '\n'), 0644); err != nil {
+		return errors.Wrap(errors.ErrInvalidLockfile, err.Error())
+	}
+	l.dirty = false
+	return nil
+}
@@ -1,89 +1,479 @@
 package loader
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"hash"
+	"io"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/semver"
 )
 
+// DefaultNPMRegistry is used unless NPM_CONFIG_REGISTRY is set
+const DefaultNPMRegistry = "https://registry.npmjs.org"
+
 // NPMPackageManager handles NPM package installation and caching
 type NPMPackageManager struct {
 	cacheDir   string
+	registry   string
 	httpClient *http.Client
+	cachedOnly bool
+}
+
+// NPMOption configures an NPMPackageManager
+type NPMOption func(*NPMPackageManager)
+
+// NPMCachedOnly makes the package manager install only from its cache and
+// never touch the network
+func NPMCachedOnly(cachedOnly bool) NPMOption {
+	return func(pm *NPMPackageManager) {
+		pm.cachedOnly = cachedOnly
+	}
+}
+
+// NPMSpecifier is a parsed npm package specifier such as
+// "npm:@scope/pkg@^1.2.0/sub/path"
+type NPMSpecifier struct {
+	Name    string
+	Version string // version, range or dist-tag; "latest" when omitted
+	Subpath string // "." for the package root, otherwise "./sub/path"
+}
+
+// NPMManifest is the subset of a registry version document we use
+type NPMManifest struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+	Dist         struct {
+		Tarball   string `json:"tarball"`
+		Shasum    string `json:"shasum"`
+		Integrity string `json:"integrity"`
+	} `json:"dist"`
+}
+
+// NPMPackument is the registry document listing every version of a package
+type NPMPackument struct {
+	Name     string                  `json:"name"`
+	DistTags map[string]string       `json:"dist-tags"`
+	Versions map[string]*NPMManifest `json:"versions"`
 }
 
 // NewNPMPackageManager creates a new instance of NPMPackageManager
-func NewNPMPackageManager() (*NPMPackageManager, error) {
-	homeDir, err := os.UserHomeDir()
+func NewNPMPackageManager(opts ...NPMOption) (*NPMPackageManager, error) {
+	cacheDir, err := NPMCacheDir()
 	if err != nil {
-		return nil, errors.Wrap(errors.ErrCacheDir, err.Error())
+		return nil, err
 	}
-
-	cacheDir := filepath.Join(homeDir, ".edon", "npm-cache")
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		return nil, errors.Wrap(errors.ErrCacheDir, err.Error())
 	}
 
+	registry := os.Getenv("NPM_CONFIG_REGISTRY")
+	if registry == "" {
+		registry = DefaultNPMRegistry
+	}
+
 	// #81: Don't use default HTTP client - configure timeouts
-	return &NPMPackageManager{
+	pm := &NPMPackageManager{
 		cacheDir: cacheDir,
+		registry: strings.TrimSuffix(registry, "/"),
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(pm)
+	}
+	return pm, nil
+}
+
+// NPMCacheDir returns ~/.edon/npm-cache, where packages are installed
+func NPMCacheDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(errors.ErrCacheDir, err.Error())
+	}
+	return filepath.Join(homeDir, ".edon", "npm-cache"), nil
+}
+
+// BinDir returns ~/.edon/bin, where the executables of installed packages
+// are linked
+func BinDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(errors.ErrCacheDir, err.Error())
+	}
+	return filepath.Join(homeDir, ".edon", "bin"), nil
+}
+
+// ParseNPMSpecifier parses a package specifier with or without the npm: prefix
+func ParseNPMSpecifier(spec string) (NPMSpecifier, error) {
+	rest := strings.TrimPrefix(strings.TrimPrefix(spec, "npm:"), "/")
+	if rest == "" {
+		return NPMSpecifier{}, errors.Wrap(errors.ErrInvalidSpecifier, spec)
+	}
+
+	// The package part ends at the first "/" after the (optional) scope
+	nameEnd := len(rest)
+	searchFrom := 0
+	if strings.HasPrefix(rest, "@") {
+		slash := strings.Index(rest, "/")
+		if slash < 0 {
+			return NPMSpecifier{}, errors.Wrap(errors.ErrInvalidSpecifier, spec)
+		}
+		searchFrom = slash + 1
+	}
+	if slash := strings.Index(rest[searchFrom:], "/"); slash >= 0 {
+		nameEnd = searchFrom + slash
+	}
+
+	pkgPart, subpath := rest[:nameEnd], "."
+	if nameEnd < len(rest) {
+		subpath = "./" + rest[nameEnd+1:]
+	}
+
+	name, version := pkgPart, "latest"
+	if at := strings.LastIndex(pkgPart, "@"); at > 0 {
+		name, version = pkgPart[:at], pkgPart[at+1:]
+	}
+	if name == "" || version == "" || strings.HasSuffix(name, "/") {
+		return NPMSpecifier{}, errors.Wrap(errors.ErrInvalidSpecifier, spec)
+	}
+
+	return NPMSpecifier{Name: name, Version: version, Subpath: subpath}, nil
 }
 
 // InstallPackage installs an NPM package and returns its local path
 func (pm *NPMPackageManager) InstallPackage(ctx context.Context, packageName string) (string, error) {
 	// Parse package name and version
-	parts := strings.Split(packageName, "@")
-	name := parts[0]
-	version := "latest"
-	if len(parts) > 1 {
-		version = parts[1]
+	spec, err := ParseNPMSpecifier(packageName)
+	if err != nil {
+		return "", err
 	}
 
 	// Check if package is already cached
-	cachePath := filepath.Join(pm.cacheDir, name, version)
+	cachePath := filepath.Join(pm.cacheDir, filepath.FromSlash(spec.Name), spec.Version)
 	if _, err := os.Stat(cachePath); err == nil {
 		return cachePath, nil
 	}
+	if pm.cachedOnly {
+		if path, ok := pm.findCachedVersion(spec); ok {
+			return path, nil
+		}
+		return "", errors.Wrap(errors.ErrNotCached, "npm:"+spec.Name+"@"+spec.Version)
+	}
 
 	// Fetch package metadata from NPM registry
-	registryURL := fmt.Sprintf("https://registry.npmjs.org/%s/%s", name, version)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, registryURL, nil)
+	registryURL := fmt.Sprintf("%s/%s/%s", pm.registry, escapePackageName(spec.Name), url.PathEscape(spec.Version))
+	var manifest NPMManifest
+	if err := pm.fetchJSON(ctx, registryURL, fmt.Sprintf("%s@%s", spec.Name, spec.Version), &manifest); err != nil {
+		return "", err
+	}
+	return pm.install(ctx, &manifest, cachePath)
+}
+
+// InstallManifest installs the version a registry manifest describes and
+// returns its local path, which is shared with InstallPackage(name@version)
+func (pm *NPMPackageManager) InstallManifest(ctx context.Context, manifest *NPMManifest) (string, error) {
+	if path, ok := pm.CachedPackage(manifest.Name, manifest.Version); ok {
+		return path, nil
+	}
+	if pm.cachedOnly {
+		return "", errors.Wrap(errors.ErrNotCached, "npm:"+manifest.Name+"@"+manifest.Version)
+	}
+	return pm.install(ctx, manifest, filepath.Join(pm.cacheDir, filepath.FromSlash(manifest.Name), manifest.Version))
+}
+
+// CachedPackage returns the local path of an installed package version
+func (pm *NPMPackageManager) CachedPackage(name, version string) (string, bool) {
+	path := filepath.Join(pm.cacheDir, filepath.FromSlash(name), version)
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// Packument fetches the document listing every published version of name
+func (pm *NPMPackageManager) Packument(ctx context.Context, name string) (*NPMPackument, error) {
+	if pm.cachedOnly {
+		return nil, errors.Wrap(errors.ErrNotCached, "npm:"+name)
+	}
+	var doc NPMPackument
+	if err := pm.fetchJSON(ctx, pm.registry+"/"+escapePackageName(name), name, &doc); err != nil {
+		return nil, err
+	}
+	for version, manifest := range doc.Versions {
+		if manifest == nil {
+			delete(doc.Versions, version)
+		}
+	}
+	return &doc, nil
+}
+
+// Resolve returns the version a range or dist-tag picks: the version the
+// tag points at, or the highest one satisfying the range, preferring the
+// latest tag when it does
+func (d *NPMPackument) Resolve(versionRange string) (*NPMManifest, bool) {
+	if version, ok := d.DistTags[versionRange]; ok {
+		manifest, ok := d.Versions[version]
+		return manifest, ok
+	}
+	if versionRange == "" {
+		versionRange = "*"
+	}
+	if latest, ok := d.DistTags["latest"]; ok {
+		if _, ok := semver.MaxSatisfying([]string{latest}, versionRange); ok && d.Versions[latest] != nil {
+			return d.Versions[latest], true
+		}
+	}
+	version, ok := semver.MaxSatisfying(slices.Collect(maps.Keys(d.Versions)), versionRange)
+	if !ok {
+		return nil, false
+	}
+	return d.Versions[version], true
+}
+
+// Latest returns the version the latest dist-tag points at
+func (d *NPMPackument) Latest() (*NPMManifest, bool) {
+	return d.Resolve("latest")
+}
+
+// fetchJSON decodes the registry document at url, naming it what in errors
+func (pm *NPMPackageManager) fetchJSON(ctx context.Context, url, what string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return "", errors.Wrap(errors.ErrPackageFetch, err.Error())
+		return errors.Wrap(errors.ErrPackageFetch, err.Error())
 	}
 
 	resp, err := pm.httpClient.Do(req)
 	if err != nil {
-		return "", errors.Wrap(errors.ErrPackageFetch, err.Error())
+		return errors.Wrap(errors.ErrPackageFetch, err.Error())
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", errors.ErrPackageNotFound
+		return errors.Wrap(errors.ErrPackageNotFound, what)
 	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return errors.Wrap(errors.ErrPackageFetch, err.Error())
+	}
+	return nil
+}
 
-	// Create cache directory for package
-	if err := os.MkdirAll(cachePath, 0755); err != nil {
+// install downloads the version manifest describes into cachePath
+func (pm *NPMPackageManager) install(ctx context.Context, manifest *NPMManifest, cachePath string) (string, error) {
+	if manifest.Dist.Tarball == "" {
+		return "", errors.Wrap(errors.ErrPackageFetch, fmt.Sprintf("%s@%s has no tarball", manifest.Name, manifest.Version))
+	}
+
+	// Download into a temporary directory so a failed install never looks cached
+	parentDir := filepath.Dir(cachePath)
+	if err := os.MkdirAll(parentDir, 0755); err != nil {
 		return "", errors.Wrap(errors.ErrCacheDir, err.Error())
 	}
+	tmpDir, err := os.MkdirTemp(parentDir, ".install-")
+	if err != nil {
+		return "", errors.Wrap(errors.ErrCacheDir, err.Error())
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := pm.downloadTarball(ctx, manifest, tmpDir); err != nil {
+		return "", err
+	}
 
-	// Download and extract package
-	// TODO: Implement package download and extraction
-	// For now, just create a placeholder file
-	placeholder := filepath.Join(cachePath, "index.js")
-	if err := os.WriteFile(placeholder, []byte("// TODO: Implement package content"), 0644); err != nil {
+	if err := os.Rename(tmpDir, cachePath); err != nil {
+		// Another process may have finished the same install first
+		if _, statErr := os.Stat(cachePath); statErr == nil {
+			return cachePath, nil
+		}
 		return "", errors.Wrap(errors.ErrPackageInstall, err.Error())
 	}
 
 	return cachePath, nil
 }
+
+// findCachedVersion looks for an install made under a different specifier
+// whose version satisfies spec, such as "1.2.3" for "^1.0.0"
+func (pm *NPMPackageManager) findCachedVersion(spec NPMSpecifier) (string, bool) {
+	entries, err := os.ReadDir(filepath.Join(pm.cacheDir, filepath.FromSlash(spec.Name)))
+	if err != nil {
+		return "", false
+	}
+
+	versionRange := spec.Version
+	if versionRange == "latest" {
+		versionRange = "*"
+	}
+
+	dirs := make(map[string]string)
+	versions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(pm.cacheDir, filepath.FromSlash(spec.Name), entry.Name())
+		pkg, err := ReadPackageJSON(dir)
+		if err != nil || pkg.Version == "" {
+			continue
+		}
+		dirs[pkg.Version] = dir
+		versions = append(versions, pkg.Version)
+	}
+
+	version, ok := semver.MaxSatisfying(versions, versionRange)
+	if !ok {
+		return "", false
+	}
+	return dirs[version], true
+}
+
+// downloadTarball fetches the package tarball, verifies it against the
+// registry's integrity data and extracts it into dir
+func (pm *NPMPackageManager) downloadTarball(ctx context.Context, manifest *NPMManifest, dir string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manifest.Dist.Tarball, nil)
+	if err != nil {
+		return errors.Wrap(errors.ErrPackageFetch, err.Error())
+	}
+
+	resp, err := pm.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(errors.ErrPackageFetch, err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrap(errors.ErrPackageFetch, fmt.Sprintf("%s: %s", manifest.Dist.Tarball, resp.Status))
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(errors.ErrPackageFetch, err.Error())
+	}
+
+	if err := verifyIntegrity(data, manifest.Dist.Integrity, manifest.Dist.Shasum); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("%s@%s", manifest.Name, manifest.Version))
+	}
+
+	if err := extractTarball(data, dir); err != nil {
+		return errors.Wrap(errors.ErrPackageInstall, err.Error())
+	}
+	return nil
+}
+
+// verifyIntegrity checks data against an SRI string, falling back to the
+// legacy sha1 shasum field
+func verifyIntegrity(data []byte, integrity, shasum string) error {
+	if integrity != "" {
+		algo, expected, ok := strings.Cut(strings.Fields(integrity)[0], "-")
+		if !ok {
+			return errors.Wrap(errors.ErrPackageIntegrity, "malformed integrity "+integrity)
+		}
+
+		var h hash.Hash
+		switch algo {
+		case "sha512":
+			h = sha512.New()
+		case "sha256":
+			h = sha256.New()
+		case "sha1":
+			h = sha1.New()
+		default:
+			return errors.Wrap(errors.ErrPackageIntegrity, "unsupported algorithm "+algo)
+		}
+		h.Write(data)
+		if base64.StdEncoding.EncodeToString(h.Sum(nil)) != expected {
+			return errors.ErrPackageIntegrity
+		}
+		return nil
+	}
+
+	if shasum != "" {
+		sum := sha1.Sum(data)
+		if hex.EncodeToString(sum[:]) != strings.ToLower(shasum) {
+			return errors.ErrPackageIntegrity
+		}
+	}
+	return nil
+}
+
+// extractTarball unpacks a gzipped npm tarball into dir, dropping the leading
+// "package/" directory every npm tarball wraps its files in
+func extractTarball(data []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(hdr.Name)
+		if _, rest, ok := strings.Cut(name, "/"); ok {
+			name = rest
+		}
+		if name == "" {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("tarball entry %q escapes package directory", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			mode := os.FileMode(0644)
+			if hdr.Mode&0111 != 0 {
+				mode = 0755
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// escapePackageName encodes the "/" of a scoped package for registry URLs
+func escapePackageName(name string) string {
+	return strings.Replace(name, "/", "%2F", 1)
+}
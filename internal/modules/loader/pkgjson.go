@@ -0,0 +1,438 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// DefaultConditions are the export conditions matched when loading ES modules
+var DefaultConditions = []string{"edon", "import", "default"}
+
+// PackageJSON holds the package.json fields used for module resolution
+type PackageJSON struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Type                 string            `json:"type"`
+	Main                 string            `json:"main"`
+	Module               string            `json:"module"`
+	Browser              json.RawMessage   `json:"browser"`
+	Exports              json.RawMessage   `json:"exports"`
+	Imports              json.RawMessage   `json:"imports"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+
+	// Dir is the directory containing the package.json file
+	Dir string `json:"-"`
+}
+
+// orderedObject is a decoded JSON object that keeps its key order, which
+// conditional exports depend on
+type orderedObject struct {
+	keys   []string
+	values map[string]any
+}
+
+// ReadPackageJSON reads and parses the package.json in dir
+func ReadPackageJSON(dir string) (*PackageJSON, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	return ParsePackageJSON(data, dir)
+}
+
+// ParsePackageJSON parses the contents of the package.json in dir
+func ParsePackageJSON(data []byte, dir string) (*PackageJSON, error) {
+	var pkg PackageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidPackageConfig, fmt.Sprintf("%s: %v", filepath.Join(dir, "package.json"), err))
+	}
+	pkg.Dir = dir
+	return &pkg, nil
+}
+
+// DependencyRange returns the version range the package declares for name
+func (p *PackageJSON) DependencyRange(name string) (string, bool) {
+	for _, deps := range []map[string]string{p.Dependencies, p.PeerDependencies, p.OptionalDependencies, p.DevDependencies} {
+		if r, ok := deps[name]; ok {
+			return r, true
+		}
+	}
+	return "", false
+}
+
+// FindPackageScope returns the nearest package.json at or above the
+// directory containing path, or nil if there is none
+func FindPackageScope(path string) *PackageJSON {
+	dir := filepath.Dir(path)
+	for {
+		if pkg, err := ReadPackageJSON(dir); err == nil {
+			return pkg
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+}
+
+// ResolvePackageEntry resolves subpath ("." or "./some/path") within the
+// package rooted at pkgDir to a file, following the exports, browser, module
+// and main fields in that order
+func ResolvePackageEntry(pkgDir, subpath string, conditions []string) (string, error) {
+	pkg, err := ReadPackageJSON(pkgDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		pkg = &PackageJSON{Name: filepath.Base(pkgDir), Dir: pkgDir}
+	}
+
+	if len(pkg.Exports) > 0 && !bytes.Equal(pkg.Exports, []byte("null")) {
+		exports, err := decodeOrdered(pkg.Exports)
+		if err != nil {
+			return "", errors.Wrap(errors.ErrInvalidPackageConfig, fmt.Sprintf("package %q exports: %v", pkg.Name, err))
+		}
+		resolved, err := pkg.resolveExports(subpath, exports, conditions)
+		if err != nil {
+			return "", err
+		}
+		if !isFile(resolved) {
+			return "", errors.Wrap(errors.ErrModuleNotFound, fmt.Sprintf("package %q subpath %q: %s", pkg.Name, subpath, resolved))
+		}
+		return resolved, nil
+	}
+
+	if subpath == "." {
+		return pkg.legacyMain(conditions)
+	}
+	if resolved := legacyFile(filepath.Join(pkgDir, filepath.FromSlash(subpath))); resolved != "" {
+		return resolved, nil
+	}
+	return "", errors.Wrap(errors.ErrModuleNotFound, fmt.Sprintf("package %q subpath %q", pkg.Name, subpath))
+}
+
+// ResolvePackageImport resolves a "#" specifier against the imports field of
+// the package scope containing referrer. The result is either an absolute file
+// path or a bare specifier naming another package.
+func ResolvePackageImport(specifier, referrer string, conditions []string) (string, error) {
+	if specifier == "#" || strings.HasPrefix(specifier, "#/") {
+		return "", errors.Wrap(errors.ErrInvalidSpecifier, specifier)
+	}
+
+	pkg := FindPackageScope(referrer)
+	if pkg == nil || len(pkg.Imports) == 0 {
+		return "", errors.Wrap(errors.ErrPackageImportNotDefined, fmt.Sprintf("%q imported from %s", specifier, referrer))
+	}
+
+	imports, err := decodeOrdered(pkg.Imports)
+	if err != nil {
+		return "", errors.Wrap(errors.ErrInvalidPackageConfig, fmt.Sprintf("package %q imports: %v", pkg.Name, err))
+	}
+	obj, ok := imports.(*orderedObject)
+	if !ok {
+		return "", errors.Wrap(errors.ErrInvalidPackageConfig, fmt.Sprintf("package %q imports must be an object", pkg.Name))
+	}
+
+	resolved, found, err := pkg.resolveImportsExports(specifier, obj, true, conditions)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", errors.Wrap(errors.ErrPackageImportNotDefined, fmt.Sprintf("package %q specifier %q", pkg.Name, specifier))
+	}
+	return resolved, nil
+}
+
+// resolveExports implements PACKAGE_EXPORTS_RESOLVE from the Node.js ESM spec
+func (p *PackageJSON) resolveExports(subpath string, exports any, conditions []string) (string, error) {
+	if obj, ok := exports.(*orderedObject); ok {
+		dotKeys := 0
+		for _, key := range obj.keys {
+			if strings.HasPrefix(key, ".") {
+				dotKeys++
+			}
+		}
+		if dotKeys > 0 && dotKeys != len(obj.keys) {
+			return "", errors.Wrap(errors.ErrInvalidPackageConfig, fmt.Sprintf("package %q exports mixes subpaths and conditions", p.Name))
+		}
+		if dotKeys > 0 {
+			resolved, found, err := p.resolveImportsExports(subpath, obj, false, conditions)
+			if err != nil {
+				return "", err
+			}
+			if found {
+				return resolved, nil
+			}
+			return "", p.notExported(subpath)
+		}
+	}
+
+	// A string, array or conditions object is shorthand for {".": exports}
+	if subpath == "." {
+		resolved, found, err := p.resolveTarget(exports, "", false, false, conditions)
+		if err != nil {
+			return "", err
+		}
+		if found {
+			return resolved, nil
+		}
+	}
+	return "", p.notExported(subpath)
+}
+
+// resolveImportsExports implements PACKAGE_IMPORTS_EXPORTS_RESOLVE, matching
+// matchKey against exact keys first and then against "*" patterns
+func (p *PackageJSON) resolveImportsExports(matchKey string, obj *orderedObject, isImports bool, conditions []string) (string, bool, error) {
+	if target, ok := obj.values[matchKey]; ok && !strings.Contains(matchKey, "*") {
+		return p.resolveTarget(target, "", false, isImports, conditions)
+	}
+
+	patterns := make([]string, 0)
+	for _, key := range obj.keys {
+		if strings.Count(key, "*") == 1 {
+			patterns = append(patterns, key)
+		}
+	}
+	sort.SliceStable(patterns, func(i, j int) bool {
+		return patternKeyLess(patterns[i], patterns[j])
+	})
+
+	for _, key := range patterns {
+		star := strings.Index(key, "*")
+		base, trailer := key[:star], key[star+1:]
+		if !strings.HasPrefix(matchKey, base) || matchKey == base {
+			continue
+		}
+		if trailer != "" && (!strings.HasSuffix(matchKey, trailer) || len(matchKey) < len(key)) {
+			continue
+		}
+		match := matchKey[len(base) : len(matchKey)-len(trailer)]
+		return p.resolveTarget(obj.values[key], match, true, isImports, conditions)
+	}
+
+	return "", false, nil
+}
+
+// resolveTarget implements PACKAGE_TARGET_RESOLVE. found is false when the
+// target is null or no condition matched.
+func (p *PackageJSON) resolveTarget(target any, patternMatch string, isPattern, isImports bool, conditions []string) (string, bool, error) {
+	switch t := target.(type) {
+	case string:
+		if !strings.HasPrefix(t, "./") {
+			if !isImports || strings.HasPrefix(t, "../") || strings.HasPrefix(t, "/") || strings.Contains(t, ":") {
+				return "", false, p.invalidTarget(t)
+			}
+			if isPattern {
+				return strings.ReplaceAll(t, "*", patternMatch), true, nil
+			}
+			return t, true, nil
+		}
+		if hasInvalidSegment(t[2:]) {
+			return "", false, p.invalidTarget(t)
+		}
+		if isPattern {
+			if hasInvalidSegment(patternMatch) {
+				return "", false, errors.Wrap(errors.ErrInvalidSpecifier, fmt.Sprintf("package %q pattern match %q", p.Name, patternMatch))
+			}
+			t = strings.ReplaceAll(t, "*", patternMatch)
+		}
+		return filepath.Join(p.Dir, filepath.FromSlash(t)), true, nil
+
+	case *orderedObject:
+		for _, key := range t.keys {
+			if key == "default" || containsString(conditions, key) {
+				resolved, found, err := p.resolveTarget(t.values[key], patternMatch, isPattern, isImports, conditions)
+				if err != nil {
+					return "", false, err
+				}
+				if found {
+					return resolved, true, nil
+				}
+			}
+		}
+		return "", false, nil
+
+	case []any:
+		var lastErr error
+		for _, item := range t {
+			resolved, found, err := p.resolveTarget(item, patternMatch, isPattern, isImports, conditions)
+			if err != nil {
+				if errors.Is(err, errors.ErrInvalidPackageTarget) {
+					lastErr = err
+					continue
+				}
+				return "", false, err
+			}
+			if found {
+				return resolved, true, nil
+			}
+		}
+		return "", false, lastErr
+
+	case nil:
+		return "", false, nil
+	}
+
+	return "", false, p.invalidTarget(fmt.Sprint(target))
+}
+
+// legacyMain resolves the package root when there is no exports field
+func (p *PackageJSON) legacyMain(conditions []string) (string, error) {
+	candidates := make([]string, 0, 3)
+	if containsString(conditions, "browser") && len(p.Browser) > 0 {
+		var browser string
+		if json.Unmarshal(p.Browser, &browser) == nil && browser != "" {
+			candidates = append(candidates, browser)
+		}
+	}
+	if containsString(conditions, "import") && p.Module != "" {
+		candidates = append(candidates, p.Module)
+	}
+	if p.Main != "" {
+		candidates = append(candidates, p.Main)
+	}
+
+	for _, candidate := range candidates {
+		if resolved := legacyFile(filepath.Join(p.Dir, filepath.FromSlash(candidate))); resolved != "" {
+			return resolved, nil
+		}
+	}
+	for _, index := range []string{"index.js", "index.mjs"} {
+		if path := filepath.Join(p.Dir, index); isFile(path) {
+			return path, nil
+		}
+	}
+
+	return "", errors.Wrap(errors.ErrModuleNotFound, fmt.Sprintf("package %q has no entry point", p.Name))
+}
+
+func (p *PackageJSON) notExported(subpath string) error {
+	return errors.Wrap(errors.ErrPackagePathNotExported, fmt.Sprintf("package %q subpath %q", p.Name, subpath))
+}
+
+func (p *PackageJSON) invalidTarget(target string) error {
+	return errors.Wrap(errors.ErrInvalidPackageTarget, fmt.Sprintf("package %q target %q", p.Name, target))
+}
+
+// legacyFile resolves a path without exports using the CommonJS-era rules:
+// the exact file, common extensions, then a directory's main or index file
+func legacyFile(path string) string {
+	for _, candidate := range []string{path, path + ".js", path + ".mjs", path + ".json"} {
+		if isFile(candidate) {
+			return candidate
+		}
+	}
+	if pkg, err := ReadPackageJSON(path); err == nil && pkg.Main != "" {
+		if resolved := legacyFile(filepath.Join(path, filepath.FromSlash(pkg.Main))); resolved != "" {
+			return resolved
+		}
+	}
+	for _, index := range []string{"index.js", "index.mjs"} {
+		if candidate := filepath.Join(path, index); isFile(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// patternKeyLess implements PATTERN_KEY_COMPARE: longer prefixes before the
+// "*" win, then longer keys
+func patternKeyLess(a, b string) bool {
+	baseA, baseB := strings.Index(a, "*")+1, strings.Index(b, "*")+1
+	if baseA != baseB {
+		return baseA > baseB
+	}
+	return len(a) > len(b)
+}
+
+// hasInvalidSegment reports whether a target path contains "", ".", ".." or
+// "node_modules" segments
+func hasInvalidSegment(path string) bool {
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		switch strings.ToLower(segment) {
+		case ".", "..", "node_modules":
+			return true
+		}
+	}
+	return strings.Contains(path, "//")
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// decodeOrdered decodes JSON keeping object key order
+func decodeOrdered(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := decodeOrderedValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func decodeOrderedValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch delim := tok.(type) {
+	case json.Delim:
+		switch delim {
+		case '{':
+			obj := &orderedObject{values: make(map[string]any)}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key := keyTok.(string)
+				value, err := decodeOrderedValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				if _, dup := obj.values[key]; !dup {
+					obj.keys = append(obj.keys, key)
+				}
+				obj.values[key] = value
+			}
+			_, err := dec.Token()
+			return obj, err
+		case '[':
+			list := make([]any, 0)
+			for dec.More() {
+				value, err := decodeOrderedValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			_, err := dec.Token()
+			return list, err
+		}
+	}
+	return tok, nil
+}
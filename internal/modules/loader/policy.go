@@ -0,0 +1,183 @@
+package loader
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// Rules an ImportPolicy can reject a URL under
+const (
+	RuleScheme = "scheme"
+	RuleHost   = "host"
+)
+
+// DefaultAllowedHosts are the hosts remote modules may be imported from when
+// no policy is configured
+var DefaultAllowedHosts = []string{
+	"cdn.jsdelivr.net",
+	"unpkg.com",
+	"cdnjs.cloudflare.com",
+	"esm.sh",
+}
+
+// HostRule allows imports from hosts matching Pattern. A pattern is either an
+// exact host ("esm.sh"), optionally with a port ("localhost:8000"), or a
+// suffix match on subdomains ("*.example.com").
+type HostRule struct {
+	Pattern string
+	// Headers are sent with every request to a matching host, e.g. an
+	// Authorization header for a private mirror
+	Headers map[string]string
+}
+
+// ImportPolicy decides which remote URLs modules may be imported from.
+// Remote imports must use https, except for localhost where http is allowed.
+type ImportPolicy struct {
+	Rules []HostRule
+}
+
+// PolicyError describes which rule rejected a URL
+type PolicyError struct {
+	URL    string
+	Rule   string
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s: rejected by %s rule: %s", e.URL, e.Rule, e.Reason)
+}
+
+func (e *PolicyError) Unwrap() error {
+	return errors.ErrImportNotAllowed
+}
+
+// DefaultImportPolicy allows the DefaultAllowedHosts
+func DefaultImportPolicy() *ImportPolicy {
+	return NewImportPolicy(DefaultAllowedHosts...)
+}
+
+// NewImportPolicy creates a policy allowing the given host patterns
+func NewImportPolicy(patterns ...string) *ImportPolicy {
+	p := &ImportPolicy{}
+	p.Allow(patterns...)
+	return p
+}
+
+// Allow adds host patterns to the policy
+func (p *ImportPolicy) Allow(patterns ...string) {
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			p.Rules = append(p.Rules, HostRule{Pattern: strings.ToLower(pattern)})
+		}
+	}
+}
+
+// SetHeaders attaches headers to requests for hosts matching pattern, adding
+// the pattern to the allow list if it is not there yet
+func (p *ImportPolicy) SetHeaders(pattern string, headers map[string]string) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	for i := range p.Rules {
+		if p.Rules[i].Pattern == pattern {
+			p.Rules[i].Headers = headers
+			return
+		}
+	}
+	p.Rules = append(p.Rules, HostRule{Pattern: pattern, Headers: headers})
+}
+
+// ParseAllowImport splits an --allow-import value into host patterns
+func ParseAllowImport(value string) []string {
+	patterns := make([]string, 0)
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// Check returns a *PolicyError if u may not be imported
+func (p *ImportPolicy) Check(u *url.URL) error {
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !isLocalhost(host) {
+			return &PolicyError{URL: u.String(), Rule: RuleScheme, Reason: "http is only allowed for localhost, use https"}
+		}
+	default:
+		return &PolicyError{URL: u.String(), Rule: RuleScheme, Reason: fmt.Sprintf("unsupported scheme %q", u.Scheme)}
+	}
+
+	if p.match(u) == nil {
+		return &PolicyError{URL: u.String(), Rule: RuleHost, Reason: fmt.Sprintf("%s is not an allowed import host", u.Host)}
+	}
+	return nil
+}
+
+// Headers returns the headers to send with a request for u
+func (p *ImportPolicy) Headers(u *url.URL) map[string]string {
+	if rule := p.match(u); rule != nil {
+		return rule.Headers
+	}
+	return nil
+}
+
+// CheckRedirect enforces the policy on every hop of a redirect chain and
+// keeps one host's headers from leaking to another
+func (p *ImportPolicy) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after 10 redirects")
+	}
+	if err := p.Check(req.URL); err != nil {
+		return err
+	}
+	for name := range p.Headers(via[len(via)-1].URL) {
+		req.Header.Del(name)
+	}
+	for name, value := range p.Headers(req.URL) {
+		req.Header.Set(name, value)
+	}
+	return nil
+}
+
+func (p *ImportPolicy) match(u *url.URL) *HostRule {
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	port := u.Port()
+
+	for i := range p.Rules {
+		pattern := p.Rules[i].Pattern
+		patternHost, patternPort := pattern, ""
+		if h, pt, err := net.SplitHostPort(pattern); err == nil {
+			patternHost, patternPort = h, pt
+		}
+		if patternPort != "" && patternPort != port {
+			continue
+		}
+
+		if suffix, ok := strings.CutPrefix(patternHost, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return &p.Rules[i]
+			}
+			continue
+		}
+		if host == patternHost {
+			return &p.Rules[i]
+		}
+	}
+	return nil
+}
+
+func isLocalhost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
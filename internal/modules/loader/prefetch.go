@@ -0,0 +1,50 @@
+package loader
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultConcurrency is how many modules LoadGraph fetches at once
+const DefaultConcurrency = 8
+
+// prefetch loads every module reachable from root into the cache, scanning
+// each one for imports as soon as it arrives and fetching up to
+// l.concurrency modules at once. Errors are left for the walk in
+// addToGraph to report, in import order.
+func (l *ModuleLoader) prefetch(ctx context.Context, root *Module) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		scanned = map[string]bool{root.ID(): true}
+		workers = make(chan struct{}, l.concurrency)
+	)
+
+	var scan func(module *Module)
+	scan = func(module *Module) {
+		for _, imp := range ScanImports(module.JS()) {
+			wg.Add(1)
+			go func(specifier, referrer string, as ImportType) {
+				defer wg.Done()
+
+				workers <- struct{}{}
+				child, err := l.loadImport(ctx, specifier, referrer, as)
+				<-workers
+				if err != nil {
+					return
+				}
+
+				mu.Lock()
+				seen := scanned[child.ID()]
+				scanned[child.ID()] = true
+				mu.Unlock()
+				if !seen {
+					scan(child)
+				}
+			}(imp.Specifier, module.ID(), imp.Type)
+		}
+	}
+
+	scan(root)
+	wg.Wait()
+}
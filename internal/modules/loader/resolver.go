@@ -1,15 +1,42 @@
 package loader
 
 import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/katungi/edon/internal/errors"
 )
 
-// DependencyGraph represents a directed graph of module dependencies
+// DependencyGraph represents a directed graph of module dependencies. Cycles
+// are allowed, as they are in ES modules, unless the graph is strict.
 type DependencyGraph struct {
-	mu    sync.RWMutex
-	edges map[string][]string // maps module URL to its dependencies
+	mu     sync.RWMutex
+	edges  map[string][]string // maps module URL to its dependencies
+	strict bool
+}
+
+// CycleError reports a circular import in a strict graph
+type CycleError struct {
+	// Path starts and ends with the same module, the one of the cycle that
+	// was imported first, and follows the imports in between, e.g.
+	// a.js -> b.js -> a.js
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("%s: %s", errors.ErrCircularDependency, FormatCycle(e.Path))
+}
+
+func (e *CycleError) Unwrap() error {
+	return errors.ErrCircularDependency
+}
+
+// FormatCycle joins a cycle path with arrows
+func FormatCycle(path []string) string {
+	return strings.Join(path, " -> ")
 }
 
 // NewDependencyGraph creates a new instance of DependencyGraph
@@ -19,39 +46,123 @@ func NewDependencyGraph() *DependencyGraph {
 	}
 }
 
-// AddDependency adds a dependency edge from parent to child module
+// SetStrict makes AddDependency reject edges that close a cycle
+func (g *DependencyGraph) SetStrict(strict bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.strict = strict
+}
+
+// AddDependency adds a dependency edge from parent to child module. In a
+// strict graph an edge that closes a cycle is rejected with a *CycleError.
+// Edges are added as imports are walked, so child, which already leads back
+// to parent, is the module of the cycle reached first.
 func (g *DependencyGraph) AddDependency(parent, child string) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	// Check for circular dependency before adding
-	if g.wouldCreateCycle(parent, child, make(map[string]bool)) {
-		return errors.ErrCircularDependency
+	if g.strict {
+		if path := g.path(child, parent, make(map[string]bool)); path != nil {
+			return &CycleError{Path: append(path, child)}
+		}
 	}
 
-	// Add the dependency
+	// Add the dependency once
+	if slices.Contains(g.edges[parent], child) {
+		return nil
+	}
 	g.edges[parent] = append(g.edges[parent], child)
 	return nil
 }
 
-// wouldCreateCycle checks if adding a new dependency would create a cycle
-func (g *DependencyGraph) wouldCreateCycle(start, current string, visited map[string]bool) bool {
-	if start == current {
-		return true
+// path returns the modules on a dependency path from current to target,
+// both included, or nil if there is none
+func (g *DependencyGraph) path(current, target string, visited map[string]bool) []string {
+	if current == target {
+		return []string{current}
 	}
 
 	if visited[current] {
-		return false
+		return nil
 	}
 	visited[current] = true
 
 	for _, dep := range g.edges[current] {
-		if g.wouldCreateCycle(start, dep, visited) {
-			return true
+		if rest := g.path(dep, target, visited); rest != nil {
+			return append([]string{current}, rest...)
 		}
 	}
 
-	return false
+	return nil
+}
+
+// Cycles returns every distinct cycle found by walking the graph, each as a
+// path that starts and ends with the same module
+func (g *DependencyGraph) Cycles() [][]string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	nodes := make([]string, 0, len(g.edges))
+	for node := range g.edges {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := make(map[string]int)
+	stack := make([]string, 0)
+	seen := make(map[string]bool)
+	cycles := make([][]string, 0)
+
+	var visit func(string)
+	visit = func(current string) {
+		state[current] = onStack
+		stack = append(stack, current)
+
+		for _, dep := range g.edges[current] {
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case onStack:
+				// A back edge closes the cycle from dep to here
+				start := len(stack) - 1
+				for stack[start] != dep {
+					start--
+				}
+				cycle := append(append([]string{}, stack[start:]...), dep)
+				if key := cycleKey(cycle); !seen[key] {
+					seen[key] = true
+					cycles = append(cycles, cycle)
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[current] = done
+	}
+
+	for _, node := range nodes {
+		if state[node] == unvisited {
+			visit(node)
+		}
+	}
+	return cycles
+}
+
+// cycleKey identifies a cycle regardless of the module it starts at
+func cycleKey(cycle []string) string {
+	nodes := cycle[:len(cycle)-1]
+	first := 0
+	for i, node := range nodes {
+		if node < nodes[first] {
+			first = i
+		}
+	}
+	return strings.Join(append(append([]string{}, nodes[first:]...), nodes[:first]...), "\x00")
 }
 
 // GetDependencies returns all dependencies for a given module
@@ -61,6 +172,66 @@ func (g *DependencyGraph) GetDependencies(moduleURL string) []string {
 	return g.edges[moduleURL]
 }
 
+// DependentsOf returns moduleURL followed by every module that imports it,
+// directly or indirectly
+func (g *DependencyGraph) DependentsOf(moduleURL string) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	reverse := make(map[string][]string)
+	for parent, deps := range g.edges {
+		for _, dep := range deps {
+			reverse[dep] = append(reverse[dep], parent)
+		}
+	}
+
+	result := []string{moduleURL}
+	seen := map[string]bool{moduleURL: true}
+	for i := 0; i < len(result); i++ {
+		parents := reverse[result[i]]
+		sort.Strings(parents)
+		for _, parent := range parents {
+			if !seen[parent] {
+				seen[parent] = true
+				result = append(result, parent)
+			}
+		}
+	}
+	return result
+}
+
+// PathsTo returns every dependency path from moduleURL to the modules match
+// accepts, in edge order. A path never visits a module twice, so cycles are
+// not followed around, and it ends at the first module accepted.
+func (g *DependencyGraph) PathsTo(moduleURL string, match func(string) bool) [][]string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var paths [][]string
+	path := make([]string, 0)
+	onPath := make(map[string]bool)
+
+	var visit func(string)
+	visit = func(current string) {
+		path = append(path, current)
+		onPath[current] = true
+		if match(current) && len(path) > 1 {
+			paths = append(paths, slices.Clone(path))
+		} else {
+			for _, dep := range g.edges[current] {
+				if !onPath[dep] {
+					visit(dep)
+				}
+			}
+		}
+		onPath[current] = false
+		path = path[:len(path)-1]
+	}
+
+	visit(moduleURL)
+	return paths
+}
+
 // ResolveDependencies returns a topologically sorted list of modules to load
 func (g *DependencyGraph) ResolveDependencies(moduleURL string) ([]string, error) {
 	g.mu.RLock()
@@ -0,0 +1,515 @@
+package loader
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Import is a module specifier found in a module's source
+type Import struct {
+	Specifier string
+	Start     int // byte offset of the opening quote
+	End       int // byte offset just past the closing quote
+	Dynamic   bool
+	// Type is the type import attribute, as in with { type: "json" }, or
+	// ImportJavaScript when there is none
+	Type ImportType
+	// AttrStart and AttrEnd span the import attributes, from the with
+	// keyword to the closing brace, or from the comma before the options
+	// of a dynamic import. Both are 0 without attributes.
+	AttrStart int
+	AttrEnd   int
+}
+
+// Key identifies the import among the imports of a module: its specifier,
+// with the type of a typed import appended as in module IDs, since one file
+// may be imported both as JSON and as text
+func (imp Import) Key() string {
+	return typedID(imp.Specifier, imp.Type)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokTemplate
+	tokPunct
+	tokOther
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	start int
+	end   int
+}
+
+// ScanImports finds the static imports, re-exports and string-literal
+// dynamic imports in source. It lexes just enough JavaScript to skip
+// comments, strings, template literals and regular expressions.
+func ScanImports(source string) []Import {
+	s := &scanner{src: source}
+	tokens := s.tokens()
+	imports := make([]Import, 0)
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok.kind != tokIdent || (tok.text != "import" && tok.text != "export") {
+			continue
+		}
+		if i > 0 && tokens[i-1].kind == tokPunct && (tokens[i-1].text == "." || tokens[i-1].text == "?.") {
+			continue
+		}
+
+		var imp *Import
+		var next int
+		if tok.text == "import" {
+			imp, next = parseImport(tokens, i+1)
+		} else {
+			imp, next = parseExportFrom(tokens, i+1)
+		}
+		if imp != nil {
+			imports = append(imports, *imp)
+			i = next - 1
+		}
+	}
+
+	return imports
+}
+
+// IsModule reports whether source has import or export declarations, and
+// so has to be compiled as an ES module
+func IsModule(source string) bool {
+	s := &scanner{src: source}
+	tokens := s.tokens()
+	for i, tok := range tokens {
+		if tok.kind != tokIdent || (tok.text != "import" && tok.text != "export") {
+			continue
+		}
+		// Skip property names and accesses, import() and import.meta
+		prev, next := token{}, at(tokens, i+1)
+		if i > 0 {
+			prev = tokens[i-1]
+		}
+		if prev.kind == tokPunct && (prev.text == "." || prev.text == "?.") {
+			continue
+		}
+		if next.kind == tokPunct && (next.text == ":" || (tok.text == "import" && (next.text == "(" || next.text == "."))) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// parseImport parses what follows an import keyword
+func parseImport(tokens []token, i int) (*Import, int) {
+	tok := at(tokens, i)
+	switch {
+	case tok.kind == tokString:
+		imp := stringImport(tok, false)
+		return imp, parseAttributes(tokens, i+1, imp)
+	case tok.kind == tokPunct && tok.text == "(":
+		spec := at(tokens, i+1)
+		closing := at(tokens, i+2)
+		if spec.kind == tokString && closing.kind == tokPunct && (closing.text == ")" || closing.text == ",") {
+			imp := stringImport(spec, true)
+			if closing.text == "," {
+				return imp, parseImportOptions(tokens, i+2, imp)
+			}
+			return imp, i + 2
+		}
+		return nil, i
+	case tok.kind == tokPunct && tok.text == ".":
+		return nil, i
+	}
+	return parseFromClause(tokens, i)
+}
+
+// parseExportFrom parses "export * from", "export * as ns from" and
+// "export { ... } from"
+func parseExportFrom(tokens []token, i int) (*Import, int) {
+	tok := at(tokens, i)
+	if tok.kind == tokIdent && tok.text == "type" {
+		i++
+		tok = at(tokens, i)
+	}
+	if tok.kind != tokPunct || (tok.text != "*" && tok.text != "{") {
+		return nil, i
+	}
+	return parseFromClause(tokens, i)
+}
+
+// parseFromClause skips an import/export binding list and returns the
+// specifier after its "from" keyword
+func parseFromClause(tokens []token, i int) (*Import, int) {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.kind {
+		case tokIdent:
+			if tok.text == "from" && depth == 0 {
+				if spec := at(tokens, i+1); spec.kind == tokString {
+					imp := stringImport(spec, false)
+					return imp, parseAttributes(tokens, i+2, imp)
+				}
+			}
+		case tokString:
+			if depth == 0 {
+				return nil, i
+			}
+		case tokPunct:
+			switch tok.text {
+			case "{":
+				depth++
+			case "}":
+				depth--
+				if depth < 0 {
+					return nil, i
+				}
+			case ",", "*":
+			default:
+				return nil, i
+			}
+		default:
+			return nil, i
+		}
+	}
+	return nil, i
+}
+
+// parseAttributes parses a with { ... } clause (or the older assert
+// { ... }) at tokens[i] into imp and returns the index after it
+func parseAttributes(tokens []token, i int, imp *Import) int {
+	keyword := at(tokens, i)
+	if keyword.kind != tokIdent || (keyword.text != "with" && keyword.text != "assert") {
+		return i
+	}
+	typ, end, next, ok := parseAttributeList(tokens, i+1)
+	if !ok {
+		return i
+	}
+	imp.Type, imp.AttrStart, imp.AttrEnd = typ, keyword.start, end
+	return next
+}
+
+// parseImportOptions parses the options of a dynamic import, as in
+// import(specifier, { with: { type: "json" } }), starting at the comma
+func parseImportOptions(tokens []token, i int, imp *Import) int {
+	comma := at(tokens, i)
+	open, key, colon := at(tokens, i+1), at(tokens, i+2), at(tokens, i+3)
+	if open.text != "{" || (key.text != "with" && key.text != "assert") || colon.text != ":" {
+		return i
+	}
+	typ, _, next, ok := parseAttributeList(tokens, i+4)
+	if !ok {
+		return i
+	}
+	if tok := at(tokens, next); tok.kind == tokPunct && tok.text == "," {
+		next++
+	}
+	closing := at(tokens, next)
+	if closing.kind != tokPunct || closing.text != "}" {
+		return i
+	}
+	imp.Type, imp.AttrStart, imp.AttrEnd = typ, comma.start, closing.end
+	return next + 1
+}
+
+// parseAttributeList parses { key: "value", ... } at tokens[i], returning
+// the type attribute, the byte offset past the closing brace and the index
+// after it
+func parseAttributeList(tokens []token, i int) (ImportType, int, int, bool) {
+	if tok := at(tokens, i); tok.kind != tokPunct || tok.text != "{" {
+		return "", 0, i, false
+	}
+	var typ ImportType
+	for i++; ; i++ {
+		tok := at(tokens, i)
+		if tok.kind == tokPunct && tok.text == "}" {
+			return typ, tok.end, i + 1, true
+		}
+		colon, value := at(tokens, i+1), at(tokens, i+2)
+		if (tok.kind != tokIdent && tok.kind != tokString) || colon.text != ":" || value.kind != tokString {
+			return "", 0, i, false
+		}
+		if tok.text == "type" {
+			typ = ImportType(value.text)
+		}
+		i += 2
+		if next := at(tokens, i+1); next.kind == tokPunct && next.text == "," {
+			i++
+		}
+	}
+}
+
+func stringImport(tok token, dynamic bool) *Import {
+	return &Import{
+		Specifier: tok.text,
+		Start:     tok.start,
+		End:       tok.end,
+		Dynamic:   dynamic,
+	}
+}
+
+func at(tokens []token, i int) token {
+	if i < len(tokens) {
+		return tokens[i]
+	}
+	return token{kind: tokEOF}
+}
+
+// RewriteImports replaces the specifier of every import in source for which
+// replace returns ok, quoting the replacement as a JavaScript string. The
+// import attributes of replaced imports are removed, as the replacement
+// names a module that is already of the right type.
+func RewriteImports(source string, imports []Import, replace func(Import) (string, bool)) string {
+	var b strings.Builder
+	last := 0
+	for _, imp := range imports {
+		replacement, ok := replace(imp)
+		if !ok {
+			continue
+		}
+		quoted, _ := json.Marshal(replacement)
+		b.WriteString(source[last:imp.Start])
+		b.Write(quoted)
+		last = imp.End
+		if imp.AttrEnd > 0 {
+			b.WriteString(source[last:imp.AttrStart])
+			last = imp.AttrEnd
+		}
+	}
+	b.WriteString(source[last:])
+	return b.String()
+}
+
+type scanner struct {
+	src string
+	pos int
+	// templateDepth records the brace depth at which each open template
+	// literal substitution (${ ... }) resumes the template
+	templateDepth []int
+	braceDepth    int
+}
+
+func (s *scanner) tokens() []token {
+	tokens := make([]token, 0)
+	for {
+		tok := s.next(tokens)
+		if tok.kind == tokEOF {
+			return tokens
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+func (s *scanner) next(prev []token) token {
+	s.skipSpaceAndComments()
+	if s.pos >= len(s.src) {
+		return token{kind: tokEOF}
+	}
+
+	start := s.pos
+	c := s.src[s.pos]
+	switch {
+	case c == '"' || c == '\'':
+		value, ok := s.readString(c)
+		if !ok {
+			return token{kind: tokOther, start: start, end: s.pos}
+		}
+		return token{kind: tokString, text: value, start: start, end: s.pos}
+
+	case c == '`':
+		s.pos++
+		s.readTemplate()
+		return token{kind: tokTemplate, start: start, end: s.pos}
+
+	case isIdentStart(c):
+		for s.pos < len(s.src) && isIdentPart(s.src[s.pos]) {
+			s.pos++
+		}
+		return token{kind: tokIdent, text: s.src[start:s.pos], start: start, end: s.pos}
+
+	case c >= '0' && c <= '9':
+		for s.pos < len(s.src) && (isIdentPart(s.src[s.pos]) || s.src[s.pos] == '.') {
+			s.pos++
+		}
+		return token{kind: tokOther, start: start, end: s.pos}
+
+	case c == '/':
+		if regexAllowed(prev) && s.readRegex() {
+			return token{kind: tokOther, start: start, end: s.pos}
+		}
+		s.pos++
+		return token{kind: tokPunct, text: "/", start: start, end: s.pos}
+
+	case c == '{':
+		s.braceDepth++
+	case c == '}':
+		if n := len(s.templateDepth); n > 0 && s.templateDepth[n-1] == s.braceDepth {
+			s.templateDepth = s.templateDepth[:n-1]
+			s.pos++
+			s.readTemplate()
+			return token{kind: tokTemplate, start: start, end: s.pos}
+		}
+		s.braceDepth--
+	case c == '?' && s.pos+1 < len(s.src) && s.src[s.pos+1] == '.':
+		s.pos += 2
+		return token{kind: tokPunct, text: "?.", start: start, end: s.pos}
+	}
+
+	s.pos++
+	return token{kind: tokPunct, text: string(c), start: start, end: s.pos}
+}
+
+func (s *scanner) skipSpaceAndComments() {
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			s.pos++
+		case strings.HasPrefix(s.src[s.pos:], "//"):
+			if end := strings.IndexByte(s.src[s.pos:], '\n'); end >= 0 {
+				s.pos += end + 1
+			} else {
+				s.pos = len(s.src)
+			}
+		case strings.HasPrefix(s.src[s.pos:], "/*"):
+			if end := strings.Index(s.src[s.pos+2:], "*/"); end >= 0 {
+				s.pos += end + 4
+			} else {
+				s.pos = len(s.src)
+			}
+		case strings.HasPrefix(s.src[s.pos:], "#!") && s.pos == 0:
+			if end := strings.IndexByte(s.src, '\n'); end >= 0 {
+				s.pos = end + 1
+			} else {
+				s.pos = len(s.src)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// readString reads a quoted string literal and returns its decoded value
+func (s *scanner) readString(quote byte) (string, bool) {
+	s.pos++
+	var b strings.Builder
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch c {
+		case quote:
+			s.pos++
+			return b.String(), true
+		case '\\':
+			if s.pos+1 < len(s.src) {
+				b.WriteByte(unescape(s.src[s.pos+1]))
+			}
+			s.pos += 2
+			continue
+		case '\n':
+			return b.String(), false
+		}
+		b.WriteByte(c)
+		s.pos++
+	}
+	return b.String(), false
+}
+
+// readTemplate skips template literal text up to the closing backtick or the
+// start of a substitution
+func (s *scanner) readTemplate() {
+	for s.pos < len(s.src) {
+		switch s.src[s.pos] {
+		case '\\':
+			s.pos += 2
+			continue
+		case '`':
+			s.pos++
+			return
+		case '$':
+			if s.pos+1 < len(s.src) && s.src[s.pos+1] == '{' {
+				s.pos += 2
+				s.templateDepth = append(s.templateDepth, s.braceDepth)
+				return
+			}
+		}
+		s.pos++
+	}
+}
+
+// readRegex skips a regular expression literal. Regexes cannot span lines,
+// so a missing terminator on the same line means the slash was division.
+func (s *scanner) readRegex() bool {
+	pos := s.pos + 1
+	inClass := false
+	for pos < len(s.src) {
+		switch s.src[pos] {
+		case '\\':
+			pos += 2
+			continue
+		case '\n':
+			return false
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if !inClass {
+				pos++
+				for pos < len(s.src) && isIdentPart(s.src[pos]) {
+					pos++
+				}
+				s.pos = pos
+				return true
+			}
+		}
+		pos++
+	}
+	return false
+}
+
+// regexAllowed reports whether a "/" after prev starts a regular expression
+func regexAllowed(prev []token) bool {
+	if len(prev) == 0 {
+		return true
+	}
+	last := prev[len(prev)-1]
+	switch last.kind {
+	case tokIdent:
+		switch last.text {
+		case "return", "typeof", "instanceof", "in", "of", "new", "delete", "void", "throw", "case", "do", "else", "yield", "await":
+			return true
+		}
+		return false
+	case tokPunct:
+		return last.text != ")" && last.text != "]" && last.text != "}"
+	case tokString, tokTemplate, tokOther:
+		return false
+	}
+	return true
+}
+
+func unescape(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	}
+	return c
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c == '#' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,150 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// sourceMap maps positions in compiled code back to the source it was
+// compiled from. Only the single source esbuild's transform produces is
+// tracked.
+type sourceMap struct {
+	// lines holds the mappings of each generated line, by column
+	lines [][]mapping
+}
+
+// mapping is a source map segment; lines are 0-based and columns are
+// 0-based UTF-16 offsets, as in the source map format
+type mapping struct {
+	genColumn int
+	line      int
+	column    int
+}
+
+// parseSourceMap decodes the mappings of a version 3 source map
+func parseSourceMap(data []byte) (*sourceMap, error) {
+	var raw struct {
+		Mappings string `json:"mappings"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	m := &sourceMap{}
+	var line, column int
+	for _, group := range strings.Split(raw.Mappings, ";") {
+		var segments []mapping
+		genColumn := 0
+		for _, segment := range strings.Split(group, ",") {
+			if segment == "" {
+				continue
+			}
+			fields, err := decodeVLQ(segment)
+			if err != nil {
+				return nil, err
+			}
+			genColumn += fields[0]
+			// Segments without a source position map to nothing
+			if len(fields) < 4 {
+				continue
+			}
+			line += fields[2]
+			column += fields[3]
+			segments = append(segments, mapping{genColumn: genColumn, line: line, column: column})
+		}
+		m.lines = append(m.lines, segments)
+	}
+	return m, nil
+}
+
+// lookup returns the source position of a generated one, all 0-based with
+// UTF-16 columns. A column before the first mapping of its line takes that
+// mapping.
+func (m *sourceMap) lookup(line, column int) (int, int, bool) {
+	if line < 0 || line >= len(m.lines) || len(m.lines[line]) == 0 {
+		return 0, 0, false
+	}
+	segments := m.lines[line]
+	found := segments[0]
+	for _, s := range segments {
+		if s.genColumn > column {
+			break
+		}
+		found = s
+	}
+	return found.line, found.column, true
+}
+
+const vlqChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// decodeVLQ decodes the base64 VLQ fields of a source map segment
+func decodeVLQ(segment string) ([]int, error) {
+	var fields []int
+	value, shift := 0, 0
+	for i := 0; i < len(segment); i++ {
+		digit := strings.IndexByte(vlqChars, segment[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid source map character %q", segment[i])
+		}
+		value += (digit & 31) << shift
+		if digit&32 != 0 {
+			shift += 5
+			continue
+		}
+		if value&1 != 0 {
+			fields = append(fields, -(value >> 1))
+		} else {
+			fields = append(fields, value>>1)
+		}
+		value, shift = 0, 0
+	}
+	if shift != 0 || len(fields) == 0 {
+		return nil, fmt.Errorf("truncated source map segment %q", segment)
+	}
+	return fields, nil
+}
+
+// lineText returns the 0-based line of source
+func lineText(source string, line int) string {
+	for ; line > 0; line-- {
+		i := strings.IndexByte(source, '\n')
+		if i < 0 {
+			return ""
+		}
+		source = source[i+1:]
+	}
+	if i := strings.IndexByte(source, '\n'); i >= 0 {
+		return source[:i]
+	}
+	return source
+}
+
+// utf16Column converts a 0-based column counted in characters to UTF-16
+// code units
+func utf16Column(text string, column int) int {
+	units := 0
+	for _, r := range text {
+		if column == 0 {
+			break
+		}
+		units += utf16.RuneLen(r)
+		column--
+	}
+	return units + column
+}
+
+// runeColumn converts a 0-based column counted in UTF-16 code units to
+// characters
+func runeColumn(text string, units int) int {
+	column := 0
+	for _, r := range text {
+		if units <= 0 {
+			break
+		}
+		units -= utf16.RuneLen(r)
+		column++
+	}
+	return column + max(units, 0)
+}
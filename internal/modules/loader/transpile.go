@@ -0,0 +1,68 @@
+package loader
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/katungi/edon/internal/errors"
+)
+
+// sourceLoader picks the esbuild loader for a module from its file
+// extension. JavaScript needs no transformation and returns false.
+func sourceLoader(id string) (api.Loader, bool) {
+	if parsed, err := url.Parse(id); err == nil && parsed.Scheme != "" && len(parsed.Scheme) > 1 {
+		id = parsed.Path
+	}
+
+	switch strings.ToLower(path.Ext(strings.ReplaceAll(id, "\\", "/"))) {
+	case ".ts", ".mts", ".cts":
+		return api.LoaderTS, true
+	case ".tsx":
+		return api.LoaderTSX, true
+	case ".jsx":
+		return api.LoaderJSX, true
+	}
+	return api.LoaderNone, false
+}
+
+// transpileModule compiles TypeScript and JSX modules down to JavaScript the
+// engine can run, with the options of a tsconfig file, keeping the source
+// as written and a map back to it. Other modules are left untouched.
+func transpileModule(module *Module, tsconfig string) error {
+	loader, ok := sourceLoader(module.ID())
+	if !ok {
+		return nil
+	}
+
+	result := api.Transform(module.Content, api.TransformOptions{
+		Loader:     loader,
+		Sourcefile: module.ID(),
+		Target:     api.ESNext,
+		Format:     api.FormatDefault,
+		// esbuild reads the options that affect compiled output and
+		// ignores type checking ones
+		TsconfigRaw: tsconfig,
+		Sourcemap:   api.SourceMapExternal,
+	})
+	if len(result.Errors) > 0 {
+		errs := make([]error, len(result.Errors))
+		for i, msg := range result.Errors {
+			e := &SourceError{File: module.ID(), Message: msg.Text, Err: errors.ErrInvalidScript}
+			if msg.Location != nil {
+				e.Line, e.Column = msg.Location.Line, msg.Location.Column+1
+			}
+			errs[i] = e
+		}
+		return errors.Join(errs...)
+	}
+
+	sourceMap, err := parseSourceMap(result.Map)
+	if err != nil {
+		return errors.WrapWith(errors.ErrInvalidScript, err, module.ID()+": source map")
+	}
+	module.original, module.sourceMap = module.Content, sourceMap
+	module.Content = string(result.Code)
+	return nil
+}
@@ -1,11 +1,14 @@
 package loader
 
 import (
+	"fmt"
 	"net/url"
 	"path/filepath"
 	"strings"
 
 	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/edon"
+	"github.com/katungi/edon/internal/modules/node"
 )
 
 type PackageType string
@@ -15,15 +18,25 @@ const (
 	TypeNPM   PackageType = "NPM"
 	TypeCDN   PackageType = "CDN"
 	TypeLocal PackageType = "Local"
+	TypeNode  PackageType = "Node"
+	TypeEdon  PackageType = "Edon"
 )
 
 type ValidationResult struct {
 	IsValid     bool
 	PackageType PackageType
 	Error       error
+	// Rule names the import policy rule that rejected a remote URL
+	Rule string
 }
 
+// ValidateURL validates urlStr against the default import policy
 func ValidateURL(urlStr string) ValidationResult {
+	return ValidateURLWithPolicy(urlStr, DefaultImportPolicy())
+}
+
+// ValidateURLWithPolicy validates urlStr, checking remote URLs against policy
+func ValidateURLWithPolicy(urlStr string, policy *ImportPolicy) ValidationResult {
 	// Handle empty input
 	if urlStr == "" {
 		return ValidationResult{
@@ -40,6 +53,32 @@ func ValidateURL(urlStr string) ValidationResult {
 		}
 	}
 
+	if strings.HasPrefix(urlStr, "node:") {
+		if !node.IsBuiltin(urlStr) {
+			return ValidationResult{
+				IsValid: false,
+				Error:   errors.Wrap(errors.ErrModuleNotFound, fmt.Sprintf("no built-in module %q", urlStr)),
+			}
+		}
+		return ValidationResult{
+			IsValid:     true,
+			PackageType: TypeNode,
+		}
+	}
+
+	if strings.HasPrefix(urlStr, "edon:") {
+		if !edon.IsBuiltin(urlStr) {
+			return ValidationResult{
+				IsValid: false,
+				Error:   errors.Wrap(errors.ErrModuleNotFound, fmt.Sprintf("no built-in module %q", urlStr)),
+			}
+		}
+		return ValidationResult{
+			IsValid:     true,
+			PackageType: TypeEdon,
+		}
+	}
+
 	if strings.HasPrefix(urlStr, "jsr:") {
 		return ValidationResult{
 			IsValid:     true,
@@ -55,7 +94,7 @@ func ValidateURL(urlStr string) ValidationResult {
 		}
 	}
 
-	// Parse URL for CDN validation
+	// Parse URL for remote validation
 	parsedURL, err := url.Parse(urlStr)
 	if err != nil {
 		return ValidationResult{
@@ -64,17 +103,26 @@ func ValidateURL(urlStr string) ValidationResult {
 		}
 	}
 
-	// Validate CDN URLs
-	if isCDNURL(parsedURL) {
+	if parsedURL.Scheme == "" || parsedURL.Host == "" {
 		return ValidationResult{
-			IsValid:     true,
-			PackageType: TypeCDN,
+			IsValid: false,
+			Error:   errors.ErrUnsupportedModule,
 		}
 	}
 
+	// Validate remote URLs against the trusted hosts
+	if err := policy.Check(parsedURL); err != nil {
+		result := ValidationResult{IsValid: false, Error: err}
+		var policyErr *PolicyError
+		if errors.As(err, &policyErr) {
+			result.Rule = policyErr.Rule
+		}
+		return result
+	}
+
 	return ValidationResult{
-		IsValid: false,
-		Error:   errors.ErrUnsupportedModule,
+		IsValid:     true,
+		PackageType: TypeCDN,
 	}
 }
 
@@ -96,20 +144,3 @@ func isLocalPath(path string) bool {
 
 	return false
 }
-
-func isCDNURL(parsedURL *url.URL) bool {
-	// List of known CDN domains
-	cdnDomains := []string{
-		"cdn.jsdelivr.net",
-		"unpkg.com",
-		"cdnjs.cloudflare.com",
-	}
-
-	for _, domain := range cdnDomains {
-		if strings.Contains(parsedURL.Host, domain) {
-			return true
-		}
-	}
-
-	return false
-}
@@ -0,0 +1,230 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// VendorImportMap is the name of the import map Vendor writes
+const VendorImportMap = "import_map.json"
+
+// vendoredFile is where a module of the graph is copied to, relative to the
+// vendor directory
+type vendoredFile struct {
+	path string
+	// scope is the directory of the package the file belongs to: the host
+	// for remote modules, name@version for npm packages
+	scope string
+}
+
+// vendorImportMap is the import map Vendor writes
+type vendorImportMap struct {
+	Imports map[string]string            `json:"imports"`
+	Scopes  map[string]map[string]string `json:"scopes,omitempty"`
+}
+
+// Vendor copies every remote and npm module of graphs into dir, as
+// <host>/<path> for remote modules and npm/<name>@<version>/<path> for npm
+// packages. It writes an import map into dir that points the original
+// specifiers at the copies and returns its path.
+func Vendor(dir string, graphs ...*ModuleGraph) (string, error) {
+	npmCacheDir, err := NPMCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", errors.Wrap(errors.ErrFileRead, err.Error())
+	}
+
+	modules := make(map[string]*GraphModule)
+	for _, graph := range graphs {
+		for id, module := range graph.Modules {
+			modules[id] = module
+		}
+	}
+	ids := make([]string, 0, len(modules))
+	for id := range modules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// Work out where everything goes first, the import map is written in
+	// terms of those locations
+	files := make(map[string]vendoredFile)
+	packageRoots := make(map[string]string)
+	for _, id := range ids {
+		file, root, ok, err := vendorLocation(modules[id].Module, npmCacheDir)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			files[id] = file
+			if root != "" {
+				packageRoots[file.scope] = root
+			}
+		}
+	}
+
+	for _, id := range ids {
+		file, ok := files[id]
+		if !ok {
+			continue
+		}
+		if err := writeVendored(filepath.Join(dir, filepath.FromSlash(file.path)), []byte(modules[id].Content)); err != nil {
+			return "", err
+		}
+	}
+
+	// package.json carries "#imports" and the module type of npm packages
+	for scope, root := range packageRoots {
+		data, err := os.ReadFile(filepath.Join(root, "package.json"))
+		if err != nil {
+			continue
+		}
+		if err := writeVendored(filepath.Join(dir, filepath.FromSlash(scope), "package.json"), data); err != nil {
+			return "", err
+		}
+	}
+
+	importMap := vendorImportMap{
+		Imports: make(map[string]string),
+		Scopes:  make(map[string]map[string]string),
+	}
+	for _, id := range ids {
+		module := modules[id]
+		referrer, vendored := files[id]
+
+		// An import map only tells imports apart by specifier, which is
+		// fine: typed imports of a file all point at the one copy of it
+		targets := make(map[string]string)
+		for _, imp := range ScanImports(module.JS()) {
+			if target, ok := module.Specifiers[imp.Key()]; ok {
+				targets[imp.Specifier] = target
+			}
+		}
+		specifiers := make([]string, 0, len(targets))
+		for specifier := range targets {
+			specifiers = append(specifiers, specifier)
+		}
+		sort.Strings(specifiers)
+
+		for _, specifier := range specifiers {
+			target, ok := files[targets[specifier]]
+			if !ok {
+				continue
+			}
+
+			key := specifier
+			if isPathSpecifier(specifier) {
+				// Relative imports between copies mostly resolve on their
+				// own; only those that ended up elsewhere need an entry
+				if !vendored {
+					continue
+				}
+				natural := path.Join(path.Dir(referrer.path), specifier)
+				if strings.HasPrefix(specifier, "/") {
+					natural = ""
+				}
+				if natural == target.path {
+					continue
+				}
+				if natural != "" {
+					key = "./" + natural
+				}
+			}
+
+			entries := importMap.Imports
+			if vendored {
+				scope := "./" + referrer.scope
+				if importMap.Scopes[scope] == nil {
+					importMap.Scopes[scope] = make(map[string]string)
+				}
+				entries = importMap.Scopes[scope]
+			}
+			if _, exists := entries[key]; !exists {
+				entries[key] = "./" + target.path
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(importMap, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(errors.ErrInvalidImportMap, err.Error())
+	}
+	mapPath := filepath.Join(dir, VendorImportMap)
+	if err := writeVendored(mapPath, append(data, '\n')); err != nil {
+		return "", err
+	}
+	return mapPath, nil
+}
+
+// vendorLocation works out where module is vendored to. Local modules are
+// not vendored. For npm modules it also returns the package's directory in
+// the npm cache.
+func vendorLocation(module *Module, npmCacheDir string) (vendoredFile, string, bool, error) {
+	if module.Path == "" {
+		parsed, err := url.Parse(module.URL)
+		if err != nil || parsed.Host == "" {
+			return vendoredFile{}, "", false, nil
+		}
+		host := strings.ReplaceAll(parsed.Host, ":", "_")
+		file := parsed.Path
+		if file == "" || strings.HasSuffix(file, "/") {
+			file += "index.js"
+		}
+		return vendoredFile{path: host + path.Clean(file), scope: host + "/"}, "", true, nil
+	}
+
+	rel, err := filepath.Rel(npmCacheDir, module.Path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return vendoredFile{}, "", false, nil
+	}
+
+	// <name>/<version spec>/<file>, where scoped names take two parts
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	nameParts := 1
+	if strings.HasPrefix(parts[0], "@") {
+		nameParts = 2
+	}
+	if len(parts) < nameParts+2 {
+		return vendoredFile{}, "", false, nil
+	}
+	name := strings.Join(parts[:nameParts], "/")
+	root := filepath.Join(npmCacheDir, filepath.FromSlash(strings.Join(parts[:nameParts+1], "/")))
+
+	pkg, err := ReadPackageJSON(root)
+	if err != nil {
+		return vendoredFile{}, "", false, err
+	}
+	version := pkg.Version
+	if version == "" {
+		version = parts[nameParts]
+	}
+
+	scope := fmt.Sprintf("npm/%s@%s/", name, version)
+	return vendoredFile{path: scope + strings.Join(parts[nameParts+1:], "/"), scope: scope}, root, true, nil
+}
+
+// isPathSpecifier reports whether specifier is a relative or absolute path
+func isPathSpecifier(specifier string) bool {
+	return strings.HasPrefix(specifier, "./") || strings.HasPrefix(specifier, "../") || strings.HasPrefix(specifier, "/")
+}
+
+func writeVendored(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(errors.ErrFileWrite, err.Error())
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return errors.Wrap(errors.ErrFileWrite, err.Error())
+	}
+	return nil
+}
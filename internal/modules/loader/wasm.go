@@ -0,0 +1,74 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/wasm"
+)
+
+// identifierName matches export names that need no quotes
+var identifierName = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// isWasm reports whether module holds a WebAssembly binary, going by the
+// content type it was served with or else by its file extension
+func (m *Module) isWasm() bool {
+	if m.contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(m.contentType)
+		return err == nil && mediaType == "application/wasm"
+	}
+	return m.extension() == ".wasm"
+}
+
+// wasmSource builds the JavaScript module a .wasm import evaluates to. The
+// modules the binary imports from are imported like any other specifier,
+// and the instance's exports become the module's exports.
+func wasmSource(module *Module) (string, error) {
+	info, err := wasm.Parse([]byte(module.Content))
+	if err != nil {
+		return "", errors.Wrap(err, module.ID())
+	}
+
+	var b strings.Builder
+	var imports strings.Builder
+	seen := make(map[string]bool)
+	for _, imp := range info.Imports {
+		if seen[imp.Module] {
+			continue
+		}
+		specifier, _ := json.Marshal(imp.Module)
+		fmt.Fprintf(&b, "import * as __edon_import%d from %s;\n", len(seen), specifier)
+		fmt.Fprintf(&imports, "%s: __edon_import%d, ", specifier, len(seen))
+		seen[imp.Module] = true
+	}
+
+	fmt.Fprintf(&b, "const __edon_imports = { %s};\n", imports.String())
+	fmt.Fprintf(&b, "const __edon_bytes = Uint8Array.from(%s, (c) => c.charCodeAt(0));\n", latin1Literal(module.Content))
+	b.WriteString("const __edon_exports = new WebAssembly.Instance(new WebAssembly.Module(__edon_bytes), __edon_imports).exports;\n")
+
+	for i, exp := range info.Exports {
+		key, _ := json.Marshal(exp.Name)
+		fmt.Fprintf(&b, "const __edon_export%d = __edon_exports[%s];\n", i, key)
+		name := exp.Name
+		if !identifierName.MatchString(name) {
+			name = string(key)
+		}
+		fmt.Fprintf(&b, "export { __edon_export%d as %s };\n", i, name)
+	}
+	return b.String(), nil
+}
+
+// latin1Literal is a string literal with one character per byte of data,
+// which keeps binary content compact
+func latin1Literal(data string) string {
+	var latin1 strings.Builder
+	for i := 0; i < len(data); i++ {
+		latin1.WriteRune(rune(data[i]))
+	}
+	literal, _ := json.Marshal(latin1.String())
+	return string(literal)
+}
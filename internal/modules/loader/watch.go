@@ -0,0 +1,131 @@
+package loader
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/katungi/edon/internal/errors"
+)
+
+// Watcher drops local modules from the loader's cache as soon as their files
+// change, along with every module that imports them
+type Watcher struct {
+	loader  *ModuleLoader
+	fs      *fsnotify.Watcher
+	changes chan Change
+
+	mu   sync.Mutex
+	dirs map[string]bool
+}
+
+// Watch starts watching the files of local modules, those already cached and
+// those loaded from now on. Only one watcher is active per loader; starting
+// a new one closes the old.
+func (l *ModuleLoader) Watch() (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrFileRead, err.Error())
+	}
+	w := &Watcher{
+		loader:  l,
+		fs:      fsw,
+		changes: make(chan Change, 64),
+		dirs:    make(map[string]bool),
+	}
+
+	l.watchMu.Lock()
+	if l.watcher != nil {
+		_ = l.watcher.fs.Close()
+	}
+	l.watcher = w
+	l.watchMu.Unlock()
+
+	for _, module := range l.cache.all() {
+		if module.Type == TypeLocal {
+			w.add(module.Path)
+		}
+	}
+	go w.run()
+	return w, nil
+}
+
+// currentWatcher returns the active watcher, if any
+func (l *ModuleLoader) currentWatcher() *Watcher {
+	l.watchMu.Lock()
+	defer l.watchMu.Unlock()
+	return l.watcher
+}
+
+// Change is a change to a file in a watched directory
+type Change struct {
+	// Path is the file that changed
+	Path string
+	// Stale lists the IDs of the modules the change made stale: the changed
+	// module first, then the modules importing it. It is empty for files
+	// that are not cached modules.
+	Stale []string
+}
+
+// Changes delivers the changes to files in the watched directories, which
+// are those of local modules and those passed to AddDir. Changes are dropped
+// when nobody keeps up with the channel; the cache is updated regardless.
+func (w *Watcher) Changes() <-chan Change {
+	return w.changes
+}
+
+// AddDir watches dir as well, for changes to files that are not modules
+func (w *Watcher) AddDir(dir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.dirs[dir] {
+		return
+	}
+	if err := w.fs.Add(dir); err == nil {
+		w.dirs[dir] = true
+	}
+}
+
+// Close stops watching and closes the Changes channel
+func (w *Watcher) Close() error {
+	w.loader.watchMu.Lock()
+	if w.loader.watcher == w {
+		w.loader.watcher = nil
+	}
+	w.loader.watchMu.Unlock()
+	return w.fs.Close()
+}
+
+// add watches the directory of path. Directories are watched rather than
+// files so editors that save by replacing the file are noticed too.
+func (w *Watcher) add(path string) {
+	w.AddDir(filepath.Dir(path))
+}
+
+func (w *Watcher) run() {
+	defer close(w.changes)
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			path := filepath.Clean(event.Name)
+			stale := w.loader.InvalidateModule(path)
+			for _, as := range []ImportType{ImportJSON, ImportText, ImportBytes} {
+				stale = append(stale, w.loader.InvalidateModule(typedID(path, as))...)
+			}
+			select {
+			case w.changes <- Change{Path: path, Stale: stale}:
+			default:
+			}
+		case _, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
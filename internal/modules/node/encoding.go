@@ -0,0 +1,167 @@
+package node
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/buke/quickjs-go"
+)
+
+// Strings cross into Go as their UTF-16 code units in an ArrayBuffer:
+// the engine's string conversions stop at NUL characters and cannot carry
+// lone surrogates.
+
+// encodeString converts UTF-16 code units to bytes in one of Buffer's
+// encodings, which the JavaScript half has already normalized
+func encodeString(units []uint16, encoding string) ([]byte, error) {
+	switch encoding {
+	case "utf8":
+		// Lone surrogates become replacement characters
+		return []byte(string(utf16.Decode(units))), nil
+	case "hex":
+		// Like Node, stop at the first character that is not a hex digit
+		s := lowBytes(units)
+		n := 0
+		for n+1 < len(s) && isHex(s[n]) && isHex(s[n+1]) {
+			n += 2
+		}
+		return hex.DecodeString(s[:n])
+	case "base64", "base64url":
+		return decodeBase64(lowBytes(units)), nil
+	case "latin1", "ascii":
+		return []byte(lowBytes(units)), nil
+	case "utf16le":
+		out := make([]byte, 2*len(units))
+		for i, unit := range units {
+			binary.LittleEndian.PutUint16(out[2*i:], unit)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("TypeError: Unknown encoding: %s", encoding)
+}
+
+// decodeBytes converts bytes in one of Buffer's encodings to UTF-16 code
+// units
+func decodeBytes(data []byte, encoding string) ([]uint16, error) {
+	switch encoding {
+	case "utf8":
+		// Every invalid byte becomes a replacement character
+		runes := make([]rune, 0, len(data))
+		for len(data) > 0 {
+			r, size := utf8.DecodeRune(data)
+			runes = append(runes, r)
+			data = data[size:]
+		}
+		return utf16.Encode(runes), nil
+	case "hex":
+		return widen([]byte(hex.EncodeToString(data)), 0xff), nil
+	case "base64":
+		return widen([]byte(base64.StdEncoding.EncodeToString(data)), 0xff), nil
+	case "base64url":
+		return widen([]byte(base64.RawURLEncoding.EncodeToString(data)), 0xff), nil
+	case "latin1":
+		return widen(data, 0xff), nil
+	case "ascii":
+		return widen(data, 0x7f), nil
+	case "utf16le":
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(data[2*i:])
+		}
+		return units, nil
+	}
+	return nil, fmt.Errorf("TypeError: Unknown encoding: %s", encoding)
+}
+
+// lowBytes keeps the low byte of every code unit
+func lowBytes(units []uint16) string {
+	out := make([]byte, len(units))
+	for i, unit := range units {
+		out[i] = byte(unit)
+	}
+	return string(out)
+}
+
+func widen(data []byte, mask byte) []uint16 {
+	units := make([]uint16, len(data))
+	for i, c := range data {
+		units[i] = uint16(c & mask)
+	}
+	return units
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// decodeBase64 accepts both alphabets, with or without padding, skipping
+// anything else the way Node does
+func decodeBase64(s string) []byte {
+	var clean strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '-':
+			clean.WriteByte('+')
+		case c == '_':
+			clean.WriteByte('/')
+		case c == '=':
+			i = len(s)
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '+', c == '/':
+			clean.WriteByte(c)
+		}
+	}
+	data := clean.String()
+	if len(data)%4 == 1 {
+		data = data[:len(data)-1]
+	}
+	out, _ := base64.RawStdEncoding.DecodeString(data)
+	return out
+}
+
+// unitsArg reads the code units of a string passed as an ArrayBuffer
+func (b *bindings) unitsArg(args []*quickjs.Value, i int) ([]uint16, error) {
+	data, err := b.bytesArg(args, i)
+	if err != nil {
+		return nil, err
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[2*i:])
+	}
+	return units, nil
+}
+
+// encode converts a string to bytes: encode(units, encoding)
+func (b *bindings) encode(args []*quickjs.Value) (*quickjs.Value, error) {
+	units, err := b.unitsArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	data, err := encodeString(units, b.arg(args, 1).String())
+	if err != nil {
+		return nil, err
+	}
+	return b.ctx.NewArrayBuffer(data), nil
+}
+
+// decode converts bytes to the code units of a string: decode(bytes, encoding)
+func (b *bindings) decode(args []*quickjs.Value) (*quickjs.Value, error) {
+	data, err := b.bytesArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	units, err := decodeBytes(data, b.arg(args, 1).String())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 2*len(units))
+	for i, unit := range units {
+		binary.LittleEndian.PutUint16(out[2*i:], unit)
+	}
+	return b.ctx.NewArrayBuffer(out), nil
+}
@@ -0,0 +1,428 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/buke/quickjs-go"
+)
+
+// errnoCodes are the error codes Node reports for system errors
+var errnoCodes = map[syscall.Errno]string{
+	syscall.ENOENT:       "ENOENT",
+	syscall.EEXIST:       "EEXIST",
+	syscall.EACCES:       "EACCES",
+	syscall.EPERM:        "EPERM",
+	syscall.ENOTDIR:      "ENOTDIR",
+	syscall.EISDIR:       "EISDIR",
+	syscall.ENOTEMPTY:    "ENOTEMPTY",
+	syscall.EBUSY:        "EBUSY",
+	syscall.EXDEV:        "EXDEV",
+	syscall.EINVAL:       "EINVAL",
+	syscall.ELOOP:        "ELOOP",
+	syscall.ENAMETOOLONG: "ENAMETOOLONG",
+	syscall.EMFILE:       "EMFILE",
+	syscall.EROFS:        "EROFS",
+	syscall.ENOSPC:       "ENOSPC",
+}
+
+// fsError formats err the way Node describes system errors, which the
+// JavaScript half parses back into code, syscall and path properties:
+// "ENOENT: no such file or directory, open '/missing'"
+func fsError(err error, syscallName string, paths ...string) error {
+	code, description := "EIO", err.Error()
+	var errno syscall.Errno
+	switch {
+	case errors.As(err, &errno) && errnoCodes[errno] != "":
+		code, description = errnoCodes[errno], errno.Error()
+	case errors.Is(err, fs.ErrNotExist):
+		code, description = "ENOENT", "no such file or directory"
+	case errors.Is(err, fs.ErrExist):
+		code, description = "EEXIST", "file already exists"
+	case errors.Is(err, fs.ErrPermission):
+		code, description = "EACCES", "permission denied"
+	}
+
+	message := fmt.Sprintf("%s: %s, %s", code, description, syscallName)
+	for i, path := range paths {
+		if path == "" {
+			continue
+		}
+		if i > 0 {
+			message += " ->"
+		}
+		message += fmt.Sprintf(" '%s'", path)
+	}
+	return errors.New(message)
+}
+
+// fileStat is what fs.Stats is built from
+type fileStat struct {
+	Type    string  `json:"type"`
+	Mode    uint32  `json:"mode"`
+	Size    int64   `json:"size"`
+	MtimeMs float64 `json:"mtimeMs"`
+}
+
+// fileType names the type of a directory entry for fs.Dirent and fs.Stats
+func fileType(mode fs.FileMode) string {
+	switch {
+	case mode.IsRegular():
+		return "file"
+	case mode.IsDir():
+		return "directory"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	case mode&fs.ModeNamedPipe != 0:
+		return "fifo"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "char"
+	case mode&fs.ModeDevice != 0:
+		return "block"
+	}
+	return "unknown"
+}
+
+// unixMode converts a Go file mode to the st_mode bits fs.Stats reports
+func unixMode(mode fs.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if mode&fs.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+	switch fileType(mode) {
+	case "file":
+		bits |= 0o100000
+	case "directory":
+		bits |= 0o040000
+	case "symlink":
+		bits |= 0o120000
+	case "fifo":
+		bits |= 0o010000
+	case "socket":
+		bits |= 0o140000
+	case "char":
+		bits |= 0o020000
+	case "block":
+		bits |= 0o060000
+	}
+	return bits
+}
+
+// readFile reads a whole file: readFile(path)
+func (b *bindings) readFile(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fsError(err, "open", path)
+	}
+	return b.ctx.NewArrayBuffer(data), nil
+}
+
+// writeFile writes or appends data to a file: writeFile(path, data, append, mode)
+func (b *bindings) writeFile(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	data, err := b.bytesArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if b.arg(args, 2).ToBool() {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	mode := fs.FileMode(0o666)
+	if m := b.arg(args, 3); m.IsNumber() {
+		mode = fs.FileMode(m.ToUint32())
+	}
+
+	file, err := os.OpenFile(path, flags, mode)
+	if err != nil {
+		return nil, fsError(err, "open", path)
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fsError(err, "write", path)
+	}
+	return b.ctx.NewUndefined(), nil
+}
+
+// stat describes a file, not following a final symlink when lstat is set:
+// stat(path, lstat)
+func (b *bindings) stat(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	stat, name := os.Stat, "stat"
+	if b.arg(args, 1).ToBool() {
+		stat, name = os.Lstat, "lstat"
+	}
+	info, err := stat(path)
+	if err != nil {
+		return nil, fsError(err, name, path)
+	}
+	return b.toJS(fileStat{
+		Type:    fileType(info.Mode()),
+		Mode:    unixMode(info.Mode()),
+		Size:    info.Size(),
+		MtimeMs: float64(info.ModTime().UnixNano()) / 1e6,
+	})
+}
+
+// readdir lists a directory as names and types: readdir(path)
+func (b *bindings) readdir(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fsError(err, "scandir", path)
+	}
+	type dirent struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	list := make([]dirent, len(entries))
+	for i, entry := range entries {
+		list[i] = dirent{Name: entry.Name(), Type: fileType(entry.Type())}
+	}
+	return b.toJS(list)
+}
+
+// mkdir creates a directory, returning the first directory a recursive
+// call created: mkdir(path, recursive, mode)
+func (b *bindings) mkdir(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	mode := fs.FileMode(0o777)
+	if m := b.arg(args, 2); m.IsNumber() {
+		mode = fs.FileMode(m.ToUint32())
+	}
+
+	if !b.arg(args, 1).ToBool() {
+		if err := os.Mkdir(path, mode); err != nil {
+			return nil, fsError(err, "mkdir", path)
+		}
+		return b.ctx.NewUndefined(), nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fsError(err, "mkdir", path)
+	}
+	first := ""
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil || dir == filepath.Dir(dir) {
+			break
+		}
+		first = dir
+	}
+	if err := os.MkdirAll(abs, mode); err != nil {
+		return nil, fsError(err, "mkdir", path)
+	}
+	if first == "" {
+		return b.ctx.NewUndefined(), nil
+	}
+	return b.ctx.String(first), nil
+}
+
+// mkdtemp creates a uniquely named directory: mkdtemp(prefix)
+func (b *bindings) mkdtemp(args []*quickjs.Value) (*quickjs.Value, error) {
+	prefix := b.arg(args, 0).String()
+	dir, err := os.MkdirTemp(filepath.Dir(prefix), filepath.Base(prefix)+"*")
+	if err != nil {
+		return nil, fsError(err, "mkdtemp", prefix+"XXXXXX")
+	}
+	return b.ctx.String(dir), nil
+}
+
+// rm removes a file, or a directory tree when recursive is set:
+// rm(path, recursive, force)
+func (b *bindings) rm(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	recursive, force := b.arg(args, 1).ToBool(), b.arg(args, 2).ToBool()
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		if force && errors.Is(err, fs.ErrNotExist) {
+			return b.ctx.NewUndefined(), nil
+		}
+		return nil, fsError(err, "rm", path)
+	}
+	if info.IsDir() && !recursive {
+		return nil, fsError(syscall.EISDIR, "rm", path)
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return nil, fsError(err, "rm", path)
+	}
+	return b.ctx.NewUndefined(), nil
+}
+
+// rmdir removes an empty directory: rmdir(path)
+func (b *bindings) rmdir(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	info, err := os.Lstat(path)
+	if err == nil && !info.IsDir() {
+		err = syscall.ENOTDIR
+	}
+	if err == nil {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		return nil, fsError(err, "rmdir", path)
+	}
+	return b.ctx.NewUndefined(), nil
+}
+
+// unlink removes a file or symlink: unlink(path)
+func (b *bindings) unlink(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	info, err := os.Lstat(path)
+	if err == nil && info.IsDir() {
+		err = syscall.EISDIR
+	}
+	if err == nil {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		return nil, fsError(err, "unlink", path)
+	}
+	return b.ctx.NewUndefined(), nil
+}
+
+func (b *bindings) rename(args []*quickjs.Value) (*quickjs.Value, error) {
+	from, to := b.arg(args, 0).String(), b.arg(args, 1).String()
+	if err := os.Rename(from, to); err != nil {
+		return nil, fsError(err, "rename", from, to)
+	}
+	return b.ctx.NewUndefined(), nil
+}
+
+// copyFile copies a file, failing if the destination exists when exclusive
+// is set: copyFile(src, dest, exclusive)
+func (b *bindings) copyFile(args []*quickjs.Value) (*quickjs.Value, error) {
+	src, dest := b.arg(args, 0).String(), b.arg(args, 1).String()
+	fail := func(err error) (*quickjs.Value, error) {
+		return nil, fsError(err, "copyfile", src, dest)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fail(err)
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return fail(err)
+	}
+	if info.IsDir() {
+		return fail(syscall.EISDIR)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if b.arg(args, 2).ToBool() {
+		flags |= os.O_EXCL
+	}
+	out, err := os.OpenFile(dest, flags, info.Mode().Perm())
+	if err != nil {
+		return fail(err)
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fail(err)
+	}
+	return b.ctx.NewUndefined(), nil
+}
+
+func (b *bindings) realpath(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	abs, err := filepath.Abs(path)
+	if err == nil {
+		abs, err = filepath.EvalSymlinks(abs)
+	}
+	if err != nil {
+		return nil, fsError(err, "realpath", path)
+	}
+	return b.ctx.String(abs), nil
+}
+
+func (b *bindings) readlink(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	target, err := os.Readlink(path)
+	if err != nil {
+		return nil, fsError(err, "readlink", path)
+	}
+	return b.ctx.String(target), nil
+}
+
+// symlink creates path pointing at target: symlink(target, path)
+func (b *bindings) symlink(args []*quickjs.Value) (*quickjs.Value, error) {
+	target, path := b.arg(args, 0).String(), b.arg(args, 1).String()
+	if err := os.Symlink(target, path); err != nil {
+		return nil, fsError(err, "symlink", target, path)
+	}
+	return b.ctx.NewUndefined(), nil
+}
+
+func (b *bindings) chmod(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	if err := os.Chmod(path, fs.FileMode(b.arg(args, 1).ToUint32())); err != nil {
+		return nil, fsError(err, "chmod", path)
+	}
+	return b.ctx.NewUndefined(), nil
+}
+
+// access checks that a file exists and, going by its permission bits, may
+// be read (4), written (2) or executed (1): access(path, mode)
+func (b *bindings) access(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fsError(err, "access", path)
+	}
+	mode := b.arg(args, 1).ToUint32() & 7
+	perm := uint32(info.Mode().Perm())
+	if mode != 0 && (perm|perm>>3|perm>>6)&mode != mode {
+		return nil, fsError(syscall.EACCES, "access", path)
+	}
+	return b.ctx.NewUndefined(), nil
+}
+
+func (b *bindings) exists(args []*quickjs.Value) (*quickjs.Value, error) {
+	_, err := os.Stat(b.arg(args, 0).String())
+	return b.ctx.NewBool(err == nil), nil
+}
+
+// truncate cuts or extends a file to a length: truncate(path, length)
+func (b *bindings) truncate(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	if err := os.Truncate(path, b.arg(args, 1).ToInt64()); err != nil {
+		return nil, fsError(err, "open", path)
+	}
+	return b.ctx.NewUndefined(), nil
+}
+
+// setTimestamp sets the access and modification times, in milliseconds:
+// setTimestamp(path, atimeMs, mtimeMs)
+func (b *bindings) setTimestamp(args []*quickjs.Value) (*quickjs.Value, error) {
+	path := b.arg(args, 0).String()
+	atime := time.UnixMilli(b.arg(args, 1).ToInt64())
+	mtime := time.UnixMilli(b.arg(args, 2).ToInt64())
+	if err := os.Chtimes(path, atime, mtime); err != nil {
+		return nil, fsError(err, "utime", path)
+	}
+	return b.ctx.NewUndefined(), nil
+}
@@ -0,0 +1,19 @@
+// node:buffer
+const { Buffer } = globalThis.__edon_node;
+
+export const kMaxLength = 2 ** 32;
+export const kStringMaxLength = 2 ** 29 - 24;
+export const constants = { MAX_LENGTH: kMaxLength, MAX_STRING_LENGTH: kStringMaxLength };
+export const INSPECT_MAX_BYTES = 50;
+export const SlowBuffer = Buffer;
+
+export function btoa(data) {
+  return Buffer.from(String(data), "latin1").toString("base64");
+}
+
+export function atob(data) {
+  return Buffer.from(String(data), "base64").toString("latin1");
+}
+
+export { Buffer };
+export default { Buffer, SlowBuffer, kMaxLength, kStringMaxLength, constants, INSPECT_MAX_BYTES, btoa, atob };
@@ -0,0 +1,9 @@
+// node:events
+const { EventEmitter } = globalThis.__edon_node;
+
+export const once = EventEmitter.once;
+export const listenerCount = EventEmitter.listenerCount;
+export const getEventListeners = EventEmitter.getEventListeners;
+export const errorMonitor = EventEmitter.errorMonitor;
+export { EventEmitter };
+export default EventEmitter;
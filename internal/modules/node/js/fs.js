@@ -0,0 +1,461 @@
+// node:fs, synchronous on top of the native operations; the callback and
+// promise forms settle on a later turn of the event loop
+const ops = globalThis.__edon_node;
+const { Buffer, encode, decode, argTypeError } = ops;
+
+const systemError = /^(E[A-Z0-9]+): (.*?), (\w+)(?: '(.*?)')?(?: -> '(.*)')?$/;
+
+// native calls an operation, turning the errors it throws into Node's
+// system errors with code, syscall and path properties
+function native(name, ...args) {
+  try {
+    return ops[name](...args);
+  } catch (error) {
+    const match = systemError.exec(error?.message ?? "");
+    if (match === null) {
+      if (error?.message?.startsWith("TypeError: ")) {
+        throw new TypeError(error.message.slice("TypeError: ".length));
+      }
+      throw error;
+    }
+    const [message, code, , syscall, path, dest] = match;
+    const err = new Error(message);
+    err.errno = -(errnos[code] ?? 0);
+    err.code = code;
+    err.syscall = syscall;
+    if (path !== undefined && path !== "") err.path = path;
+    if (dest !== undefined) err.dest = dest;
+    throw err;
+  }
+}
+
+const errnos = { EPERM: 1, ENOENT: 2, EIO: 5, EBADF: 9, EACCES: 13, EEXIST: 17, ENOTDIR: 20, EISDIR: 21, EINVAL: 22, EMFILE: 24, ENOSPC: 28, EROFS: 30, ENOTEMPTY: 39, ELOOP: 40 };
+
+export const constants = {
+  F_OK: 0,
+  R_OK: 4,
+  W_OK: 2,
+  X_OK: 1,
+  COPYFILE_EXCL: 1,
+  COPYFILE_FICLONE: 2,
+  COPYFILE_FICLONE_FORCE: 4,
+  S_IFMT: 0o170000,
+  S_IFREG: 0o100000,
+  S_IFDIR: 0o040000,
+  S_IFLNK: 0o120000,
+  S_IFIFO: 0o010000,
+  S_IFSOCK: 0o140000,
+  S_IFCHR: 0o020000,
+  S_IFBLK: 0o060000,
+};
+export const { F_OK, R_OK, W_OK, X_OK } = constants;
+
+function toPath(path, name = "path") {
+  if (typeof URL === "function" && path instanceof URL) {
+    if (path.protocol !== "file:") {
+      throw argTypeError(name, "a file URL", path);
+    }
+    return decodeURIComponent(path.pathname);
+  }
+  if (path instanceof Uint8Array) {
+    return decode(path, "utf8");
+  }
+  if (typeof path !== "string") {
+    throw argTypeError(name, "of type string or an instance of Buffer or URL", path);
+  }
+  return path;
+}
+
+function toBytes(data, encoding) {
+  if (typeof data === "string") {
+    return encode(data, encoding);
+  }
+  if (ArrayBuffer.isView(data)) {
+    return data.buffer.slice(data.byteOffset, data.byteOffset + data.byteLength);
+  }
+  throw argTypeError("data", "of type string or an instance of Buffer, TypedArray, or DataView", data);
+}
+
+function options(value, defaults) {
+  if (value === undefined || value === null || typeof value === "function") {
+    return { ...defaults };
+  }
+  if (typeof value === "string") {
+    return { ...defaults, encoding: value };
+  }
+  return { ...defaults, ...value };
+}
+
+export class Stats {
+  constructor(stat) {
+    this.mode = stat.mode;
+    this.size = stat.size;
+    this.mtimeMs = stat.mtimeMs;
+    this.atimeMs = stat.mtimeMs;
+    this.ctimeMs = stat.mtimeMs;
+    this.birthtimeMs = stat.mtimeMs;
+    this.mtime = new Date(stat.mtimeMs);
+    this.atime = new Date(stat.mtimeMs);
+    this.ctime = new Date(stat.mtimeMs);
+    this.birthtime = new Date(stat.mtimeMs);
+  }
+
+  #is(type) {
+    return (this.mode & constants.S_IFMT) === type;
+  }
+
+  isFile() {
+    return this.#is(constants.S_IFREG);
+  }
+
+  isDirectory() {
+    return this.#is(constants.S_IFDIR);
+  }
+
+  isSymbolicLink() {
+    return this.#is(constants.S_IFLNK);
+  }
+
+  isFIFO() {
+    return this.#is(constants.S_IFIFO);
+  }
+
+  isSocket() {
+    return this.#is(constants.S_IFSOCK);
+  }
+
+  isCharacterDevice() {
+    return this.#is(constants.S_IFCHR);
+  }
+
+  isBlockDevice() {
+    return this.#is(constants.S_IFBLK);
+  }
+}
+
+export class Dirent {
+  #type;
+
+  constructor(name, type, parentPath) {
+    this.name = name;
+    this.parentPath = parentPath;
+    this.path = parentPath;
+    this.#type = type;
+  }
+
+  isFile() {
+    return this.#type === "file";
+  }
+
+  isDirectory() {
+    return this.#type === "directory";
+  }
+
+  isSymbolicLink() {
+    return this.#type === "symlink";
+  }
+
+  isFIFO() {
+    return this.#type === "fifo";
+  }
+
+  isSocket() {
+    return this.#type === "socket";
+  }
+
+  isCharacterDevice() {
+    return this.#type === "char";
+  }
+
+  isBlockDevice() {
+    return this.#type === "block";
+  }
+}
+
+export function existsSync(path) {
+  try {
+    return ops.exists(toPath(path));
+  } catch {
+    return false;
+  }
+}
+
+export function readFileSync(path, opts) {
+  const { encoding } = options(opts, { encoding: null });
+  const data = Buffer.from(native("readFile", toPath(path)));
+  return encoding === null ? data : data.toString(encoding);
+}
+
+export function writeFileSync(path, data, opts) {
+  const { encoding, mode, flag } = options(opts, { encoding: "utf8", mode: 0o666, flag: "w" });
+  native("writeFile", toPath(path), toBytes(data, encoding), flag.startsWith("a"), mode);
+}
+
+export function appendFileSync(path, data, opts) {
+  const { encoding, mode } = options(opts, { encoding: "utf8", mode: 0o666 });
+  native("writeFile", toPath(path), toBytes(data, encoding), true, mode);
+}
+
+export function statSync(path, opts) {
+  try {
+    return new Stats(native("stat", toPath(path), false));
+  } catch (error) {
+    if (opts?.throwIfNoEntry === false && error.code === "ENOENT") {
+      return undefined;
+    }
+    throw error;
+  }
+}
+
+export function lstatSync(path, opts) {
+  try {
+    return new Stats(native("stat", toPath(path), true));
+  } catch (error) {
+    if (opts?.throwIfNoEntry === false && error.code === "ENOENT") {
+      return undefined;
+    }
+    throw error;
+  }
+}
+
+export function readdirSync(path, opts) {
+  const { encoding, withFileTypes, recursive } = options(opts, { encoding: "utf8", withFileTypes: false, recursive: false });
+  const root = toPath(path);
+  const result = [];
+  const walk = (dir, prefix) => {
+    for (const { name, type } of native("readdir", dir)) {
+      const relative = prefix === "" ? name : `${prefix}/${name}`;
+      if (withFileTypes) {
+        result.push(new Dirent(name, type, dir));
+      } else {
+        result.push(encoding === "buffer" ? Buffer.from(relative) : relative);
+      }
+      if (recursive && type === "directory") {
+        walk(`${dir}/${name}`, relative);
+      }
+    }
+  };
+  walk(root, "");
+  return result;
+}
+
+export function mkdirSync(path, opts) {
+  const { recursive, mode } = typeof opts === "number" ? { recursive: false, mode: opts } : options(opts, { recursive: false, mode: 0o777 });
+  return native("mkdir", toPath(path), recursive, mode);
+}
+
+export function mkdtempSync(prefix) {
+  return native("mkdtemp", toPath(prefix, "prefix"));
+}
+
+export function rmSync(path, opts) {
+  const { recursive, force } = options(opts, { recursive: false, force: false });
+  native("rm", toPath(path), recursive, force);
+}
+
+export function rmdirSync(path, opts) {
+  if (opts?.recursive) {
+    native("rm", toPath(path), true, false);
+    return;
+  }
+  native("rmdir", toPath(path));
+}
+
+export function unlinkSync(path) {
+  native("unlink", toPath(path));
+}
+
+export function renameSync(oldPath, newPath) {
+  native("rename", toPath(oldPath, "oldPath"), toPath(newPath, "newPath"));
+}
+
+export function copyFileSync(src, dest, mode = 0) {
+  native("copyFile", toPath(src, "src"), toPath(dest, "dest"), (mode & constants.COPYFILE_EXCL) !== 0);
+}
+
+export function cpSync(src, dest, opts) {
+  const { recursive, force, errorOnExist } = options(opts, { recursive: false, force: true, errorOnExist: false });
+  src = toPath(src, "src");
+  dest = toPath(dest, "dest");
+  if (!statSync(src).isDirectory()) {
+    if (!force && existsSync(dest)) {
+      if (errorOnExist) {
+        native("copyFile", src, dest, true);
+      }
+      return;
+    }
+    copyFileSync(src, dest);
+    return;
+  }
+  if (!recursive) {
+    const error = new Error(`Recursive option is required to copy a directory: ${src}`);
+    error.code = "ERR_FS_EISDIR";
+    throw error;
+  }
+  mkdirSync(dest, { recursive: true });
+  for (const { name } of native("readdir", src)) {
+    cpSync(`${src}/${name}`, `${dest}/${name}`, opts);
+  }
+}
+
+export function realpathSync(path) {
+  return native("realpath", toPath(path));
+}
+realpathSync.native = realpathSync;
+
+export function readlinkSync(path) {
+  return native("readlink", toPath(path));
+}
+
+export function symlinkSync(target, path) {
+  native("symlink", toPath(target, "target"), toPath(path));
+}
+
+export function chmodSync(path, mode) {
+  native("chmod", toPath(path), typeof mode === "string" ? parseInt(mode, 8) : mode);
+}
+
+export function accessSync(path, mode = F_OK) {
+  native("access", toPath(path), mode);
+}
+
+export function truncateSync(path, len = 0) {
+  native("truncate", toPath(path), len);
+}
+
+export function utimesSync(path, atime, mtime) {
+  const ms = (time) => (time instanceof Date ? time.getTime() : Number(time) * 1000);
+  native("setTimestamp", toPath(path), ms(atime), ms(mtime));
+}
+
+// callbackify makes the callback form of a synchronous function
+function callbackify(fn) {
+  return (...args) => {
+    const callback = args.pop();
+    if (typeof callback !== "function") {
+      throw argTypeError("cb", "of type function", callback);
+    }
+    Promise.resolve().then(() => {
+      let result;
+      try {
+        result = fn(...args);
+      } catch (error) {
+        callback(error);
+        return;
+      }
+      callback(null, result);
+    });
+  };
+}
+
+// promisify makes the promise form of a synchronous function
+function promisify(fn) {
+  return async (...args) => fn(...args);
+}
+
+export const readFile = callbackify(readFileSync);
+export const writeFile = callbackify(writeFileSync);
+export const appendFile = callbackify(appendFileSync);
+export const stat = callbackify(statSync);
+export const lstat = callbackify(lstatSync);
+export const readdir = callbackify(readdirSync);
+export const mkdir = callbackify(mkdirSync);
+export const mkdtemp = callbackify(mkdtempSync);
+export const rm = callbackify(rmSync);
+export const rmdir = callbackify(rmdirSync);
+export const unlink = callbackify(unlinkSync);
+export const rename = callbackify(renameSync);
+export const copyFile = callbackify(copyFileSync);
+export const cp = callbackify(cpSync);
+export const realpath = callbackify(realpathSync);
+export const readlink = callbackify(readlinkSync);
+export const symlink = callbackify(symlinkSync);
+export const chmod = callbackify(chmodSync);
+export const access = callbackify(accessSync);
+export const truncate = callbackify(truncateSync);
+export const utimes = callbackify(utimesSync);
+
+// exists predates error-first callbacks and passes only the result
+export function exists(path, callback) {
+  Promise.resolve().then(() => callback(existsSync(path)));
+}
+
+export const promises = {
+  readFile: promisify(readFileSync),
+  writeFile: promisify(writeFileSync),
+  appendFile: promisify(appendFileSync),
+  stat: promisify(statSync),
+  lstat: promisify(lstatSync),
+  readdir: promisify(readdirSync),
+  mkdir: promisify(mkdirSync),
+  mkdtemp: promisify(mkdtempSync),
+  rm: promisify(rmSync),
+  rmdir: promisify(rmdirSync),
+  unlink: promisify(unlinkSync),
+  rename: promisify(renameSync),
+  copyFile: promisify(copyFileSync),
+  cp: promisify(cpSync),
+  realpath: promisify(realpathSync),
+  readlink: promisify(readlinkSync),
+  symlink: promisify(symlinkSync),
+  chmod: promisify(chmodSync),
+  access: promisify(accessSync),
+  truncate: promisify(truncateSync),
+  utimes: promisify(utimesSync),
+  constants,
+};
+
+export default {
+  constants,
+  F_OK,
+  R_OK,
+  W_OK,
+  X_OK,
+  Stats,
+  Dirent,
+  existsSync,
+  readFileSync,
+  writeFileSync,
+  appendFileSync,
+  statSync,
+  lstatSync,
+  readdirSync,
+  mkdirSync,
+  mkdtempSync,
+  rmSync,
+  rmdirSync,
+  unlinkSync,
+  renameSync,
+  copyFileSync,
+  cpSync,
+  realpathSync,
+  readlinkSync,
+  symlinkSync,
+  chmodSync,
+  accessSync,
+  truncateSync,
+  utimesSync,
+  exists,
+  readFile,
+  writeFile,
+  appendFile,
+  stat,
+  lstat,
+  readdir,
+  mkdir,
+  mkdtemp,
+  rm,
+  rmdir,
+  unlink,
+  rename,
+  copyFile,
+  cp,
+  realpath,
+  readlink,
+  symlink,
+  chmod,
+  access,
+  truncate,
+  utimes,
+  promises,
+};
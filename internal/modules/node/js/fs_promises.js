@@ -0,0 +1,29 @@
+// node:fs/promises
+import fs from "node:fs";
+
+export const {
+  readFile,
+  writeFile,
+  appendFile,
+  stat,
+  lstat,
+  readdir,
+  mkdir,
+  mkdtemp,
+  rm,
+  rmdir,
+  unlink,
+  rename,
+  copyFile,
+  cp,
+  realpath,
+  readlink,
+  symlink,
+  chmod,
+  access,
+  truncate,
+  utimes,
+  constants,
+} = fs.promises;
+
+export default fs.promises;
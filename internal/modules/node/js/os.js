@@ -0,0 +1,45 @@
+// node:os
+const ops = globalThis.__edon_node;
+
+let info;
+const machine = () => (info ??= ops.osInfo());
+
+export const EOL = machine().eol;
+export const devNull = machine().platform === "win32" ? "\\\\.\\nul" : "/dev/null";
+export const constants = { signals: {}, errno: {} };
+
+export const platform = () => machine().platform;
+export const type = () => machine().type;
+export const arch = () => machine().arch;
+export const hostname = () => machine().hostname;
+export const homedir = () => machine().homedir;
+export const tmpdir = () => machine().tmpdir;
+export const endianness = () => (new Uint8Array(new Uint16Array([1]).buffer)[0] === 1 ? "LE" : "BE");
+export const uptime = () => ops.uptime();
+export const availableParallelism = () => machine().cpus;
+export const userInfo = () => ops.userInfo();
+
+export function cpus() {
+  return Array.from({ length: machine().cpus }, () => ({
+    model: "unknown",
+    speed: 0,
+    times: { user: 0, nice: 0, sys: 0, idle: 0, irq: 0 },
+  }));
+}
+
+export default {
+  EOL,
+  devNull,
+  constants,
+  platform,
+  type,
+  arch,
+  hostname,
+  homedir,
+  tmpdir,
+  endianness,
+  uptime,
+  availableParallelism,
+  userInfo,
+  cpus,
+};
@@ -0,0 +1,265 @@
+// node:path, with POSIX semantics on every platform
+const { argTypeError } = globalThis.__edon_node;
+
+function checkString(value, name) {
+  if (typeof value !== "string") {
+    throw argTypeError(name, "of type string", value);
+  }
+}
+
+// normalizeString resolves . and .. segments
+function normalizeString(path, allowAboveRoot) {
+  let result = "";
+  let lastSegmentLength = 0;
+  let lastSlash = -1;
+  let dots = 0;
+  let code;
+  for (let i = 0; i <= path.length; i++) {
+    if (i < path.length) {
+      code = path[i];
+    } else if (code === "/") {
+      break;
+    } else {
+      code = "/";
+    }
+
+    if (code === "/") {
+      if (lastSlash === i - 1 || dots === 1) {
+        // Empty or . segment
+      } else if (dots === 2) {
+        if (result.length < 2 || lastSegmentLength !== 2 || !result.endsWith("..")) {
+          if (result.length > 2) {
+            const lastSlashIndex = result.lastIndexOf("/");
+            if (lastSlashIndex === -1) {
+              result = "";
+              lastSegmentLength = 0;
+            } else {
+              result = result.slice(0, lastSlashIndex);
+              lastSegmentLength = result.length - 1 - result.lastIndexOf("/");
+            }
+            lastSlash = i;
+            dots = 0;
+            continue;
+          } else if (result.length !== 0) {
+            result = "";
+            lastSegmentLength = 0;
+            lastSlash = i;
+            dots = 0;
+            continue;
+          }
+        }
+        if (allowAboveRoot) {
+          result += result.length > 0 ? "/.." : "..";
+          lastSegmentLength = 2;
+        }
+      } else {
+        const segment = path.slice(lastSlash + 1, i);
+        result += result.length > 0 ? "/" + segment : segment;
+        lastSegmentLength = i - lastSlash - 1;
+      }
+      lastSlash = i;
+      dots = 0;
+    } else if (code === "." && dots !== -1) {
+      dots++;
+    } else {
+      dots = -1;
+    }
+  }
+  return result;
+}
+
+export const sep = "/";
+export const delimiter = ":";
+
+export function resolve(...paths) {
+  let resolved = "";
+  let absolute = false;
+  for (let i = paths.length - 1; i >= -1 && !absolute; i--) {
+    const path = i >= 0 ? paths[i] : globalThis.process.cwd();
+    checkString(path, `paths[${i}]`);
+    if (path.length === 0) {
+      continue;
+    }
+    resolved = `${path}/${resolved}`;
+    absolute = path[0] === "/";
+  }
+  resolved = normalizeString(resolved, !absolute);
+  if (absolute) {
+    return `/${resolved}`;
+  }
+  return resolved.length > 0 ? resolved : ".";
+}
+
+export function normalize(path) {
+  checkString(path, "path");
+  if (path.length === 0) {
+    return ".";
+  }
+  const absolute = path[0] === "/";
+  const trailingSlash = path[path.length - 1] === "/";
+  path = normalizeString(path, !absolute);
+  if (path.length === 0) {
+    if (absolute) {
+      return "/";
+    }
+    return trailingSlash ? "./" : ".";
+  }
+  if (trailingSlash) {
+    path += "/";
+  }
+  return absolute ? `/${path}` : path;
+}
+
+export function isAbsolute(path) {
+  checkString(path, "path");
+  return path.length > 0 && path[0] === "/";
+}
+
+export function join(...paths) {
+  let joined;
+  for (const path of paths) {
+    checkString(path, "path");
+    if (path.length > 0) {
+      joined = joined === undefined ? path : `${joined}/${path}`;
+    }
+  }
+  return joined === undefined ? "." : normalize(joined);
+}
+
+export function relative(from, to) {
+  checkString(from, "from");
+  checkString(to, "to");
+  if (from === to) {
+    return "";
+  }
+  from = resolve(from);
+  to = resolve(to);
+  if (from === to) {
+    return "";
+  }
+
+  const fromParts = from === "/" ? [] : from.slice(1).split("/");
+  const toParts = to === "/" ? [] : to.slice(1).split("/");
+  let common = 0;
+  while (common < fromParts.length && common < toParts.length && fromParts[common] === toParts[common]) {
+    common++;
+  }
+  const up = fromParts.slice(common).map(() => "..");
+  return [...up, ...toParts.slice(common)].join("/");
+}
+
+export function toNamespacedPath(path) {
+  return path;
+}
+
+export function dirname(path) {
+  checkString(path, "path");
+  if (path.length === 0) {
+    return ".";
+  }
+  const absolute = path[0] === "/";
+  let end = -1;
+  let matchedSlash = true;
+  for (let i = path.length - 1; i >= 1; i--) {
+    if (path[i] === "/") {
+      if (!matchedSlash) {
+        end = i;
+        break;
+      }
+    } else {
+      matchedSlash = false;
+    }
+  }
+  if (end === -1) {
+    return absolute ? "/" : ".";
+  }
+  if (absolute && end === 1) {
+    return "//";
+  }
+  return path.slice(0, end);
+}
+
+export function basename(path, suffix) {
+  checkString(path, "path");
+  if (suffix !== undefined) {
+    checkString(suffix, "suffix");
+  }
+  let end = path.length;
+  while (end > 0 && path[end - 1] === "/") {
+    end--;
+  }
+  const start = path.lastIndexOf("/", end - 1) + 1;
+  let base = path.slice(start, end);
+  if (suffix !== undefined && suffix !== base && base.endsWith(suffix)) {
+    base = base.slice(0, base.length - suffix.length);
+  }
+  return base;
+}
+
+export function extname(path) {
+  checkString(path, "path");
+  const base = basename(path);
+  const dot = base.lastIndexOf(".");
+  if (dot <= 0 || (dot === 1 && base === "..")) {
+    return "";
+  }
+  return base.slice(dot);
+}
+
+export function format(object) {
+  if (object === null || typeof object !== "object") {
+    throw argTypeError("pathObject", "of type object", object);
+  }
+  const dir = object.dir || object.root;
+  const base = object.base || `${object.name || ""}${object.ext ? (object.ext[0] === "." ? "" : ".") + object.ext : ""}`;
+  if (!dir) {
+    return base;
+  }
+  return dir === object.root ? `${dir}${base}` : `${dir}/${base}`;
+}
+
+export function parse(path) {
+  checkString(path, "path");
+  const result = { root: "", dir: "", base: "", ext: "", name: "" };
+  if (path.length === 0) {
+    return result;
+  }
+  if (path[0] === "/") {
+    result.root = "/";
+  }
+  result.base = basename(path);
+  result.ext = extname(path);
+  result.name = result.ext ? result.base.slice(0, -result.ext.length) : result.base;
+
+  let end = path.length;
+  while (end > 1 && path[end - 1] === "/") {
+    end--;
+  }
+  const slash = path.lastIndexOf("/", end - 1);
+  if (slash > 0) {
+    result.dir = path.slice(0, slash);
+  } else if (slash === 0) {
+    result.dir = "/";
+  }
+  return result;
+}
+
+const path = {
+  sep,
+  delimiter,
+  resolve,
+  normalize,
+  isAbsolute,
+  join,
+  relative,
+  toNamespacedPath,
+  dirname,
+  basename,
+  extname,
+  format,
+  parse,
+};
+path.posix = path;
+
+export const posix = path;
+export default path;
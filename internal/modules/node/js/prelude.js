@@ -0,0 +1,806 @@
+// Sets up the process, Buffer and global globals, and the pieces of the
+// built-in modules they share. The modules read them back from the ops
+// object, which is hidden from scripts.
+((ops) => {
+  "use strict";
+
+  delete globalThis.__edon_node;
+  Object.defineProperty(globalThis, "__edon_node", { value: ops });
+
+  const inspectCustom = Symbol.for("nodejs.util.inspect.custom");
+
+  // The JavaScript encode and decode below replace the native ones on ops
+  const native = { encode: ops.encode, decode: ops.decode };
+
+  // Strings cross into Go as UTF-16 code units, which keeps NUL characters
+  // and lone surrogates intact
+  function toUnits(string) {
+    const units = new Uint16Array(string.length);
+    for (let i = 0; i < string.length; i++) {
+      units[i] = string.charCodeAt(i);
+    }
+    return units.buffer;
+  }
+
+  function fromUnits(buffer) {
+    const units = new Uint16Array(buffer);
+    let string = "";
+    for (let i = 0; i < units.length; i += 8192) {
+      string += String.fromCharCode.apply(null, units.subarray(i, i + 8192));
+    }
+    return string;
+  }
+
+  const encodings = {
+    utf8: "utf8",
+    "utf-8": "utf8",
+    hex: "hex",
+    base64: "base64",
+    base64url: "base64url",
+    latin1: "latin1",
+    binary: "latin1",
+    ascii: "ascii",
+    utf16le: "utf16le",
+    "utf-16le": "utf16le",
+    ucs2: "utf16le",
+    "ucs-2": "utf16le",
+  };
+
+  function normalizeEncoding(encoding) {
+    if (encoding === undefined || encoding === null) {
+      return "utf8";
+    }
+    const name = encodings[String(encoding).toLowerCase()];
+    if (name === undefined) {
+      throw codeError(TypeError, "ERR_UNKNOWN_ENCODING", `Unknown encoding: ${encoding}`);
+    }
+    return name;
+  }
+
+  function encode(string, encoding) {
+    return native.encode(toUnits(String(string)), normalizeEncoding(encoding));
+  }
+
+  function decode(bytes, encoding) {
+    const copy = bytes.buffer.slice(bytes.byteOffset, bytes.byteOffset + bytes.byteLength);
+    return fromUnits(native.decode(copy, normalizeEncoding(encoding)));
+  }
+
+  function codeError(Type, code, message) {
+    const error = new Type(message);
+    error.code = code;
+    return error;
+  }
+
+  function argTypeError(name, expected, value) {
+    const actual = value === null ? "null" : typeof value === "object" ? `an instance of ${value.constructor?.name ?? "Object"}` : `type ${typeof value} (${String(value)})`;
+    return codeError(TypeError, "ERR_INVALID_ARG_TYPE", `The "${name}" argument must be ${expected}. Received ${actual}`);
+  }
+
+  // EventEmitter is a plain constructor function, so that subclasses made
+  // with util.inherits can call it without new
+  function EventEmitter(options) {
+    EventEmitter.init.call(this, options);
+  }
+
+  EventEmitter.prototype._events = undefined;
+  EventEmitter.prototype._eventsCount = 0;
+  EventEmitter.prototype._maxListeners = undefined;
+  EventEmitter.defaultMaxListeners = 10;
+  EventEmitter.errorMonitor = Symbol("events.errorMonitor");
+  EventEmitter.captureRejections = false;
+
+  EventEmitter.init = function (options) {
+    if (this._events === undefined || this._events === Object.getPrototypeOf(this)._events) {
+      this._events = Object.create(null);
+      this._eventsCount = 0;
+    }
+    this._maxListeners = this._maxListeners || undefined;
+    if (options?.captureRejections) {
+      this[Symbol.for("nodejs.rejection")] = undefined;
+    }
+  };
+
+  function checkListener(listener) {
+    if (typeof listener !== "function") {
+      throw argTypeError("listener", "of type function", listener);
+    }
+  }
+
+  function addListener(target, type, listener, prepend) {
+    checkListener(listener);
+    if (target._events === undefined) {
+      EventEmitter.init.call(target);
+    }
+    if (target._events.newListener !== undefined) {
+      target.emit("newListener", type, listener.listener ?? listener);
+    }
+
+    let listeners = target._events[type];
+    if (listeners === undefined) {
+      listeners = target._events[type] = [];
+      target._eventsCount++;
+    }
+    if (prepend) {
+      listeners.unshift(listener);
+    } else {
+      listeners.push(listener);
+    }
+
+    const max = target.getMaxListeners();
+    if (max > 0 && listeners.length > max && !listeners.warned) {
+      listeners.warned = true;
+      process.emitWarning(
+        `Possible EventEmitter memory leak detected. ${listeners.length} ${String(type)} listeners added to ${target.constructor?.name ?? "EventEmitter"}. MaxListeners is ${max}. Use emitter.setMaxListeners() to increase limit`,
+        "MaxListenersExceededWarning",
+      );
+    }
+    return target;
+  }
+
+  function onceWrapper(target, type, listener) {
+    const state = { fired: false, target, type, listener };
+    const wrapped = function (...args) {
+      if (!state.fired) {
+        state.target.removeListener(state.type, wrapped);
+        state.fired = true;
+        return state.listener.apply(state.target, args);
+      }
+    };
+    wrapped.listener = listener;
+    return wrapped;
+  }
+
+  Object.assign(EventEmitter.prototype, {
+    setMaxListeners(n) {
+      if (typeof n !== "number" || n < 0 || Number.isNaN(n)) {
+        throw codeError(RangeError, "ERR_OUT_OF_RANGE", `The value of "n" is out of range. It must be a non-negative number. Received ${n}`);
+      }
+      this._maxListeners = n;
+      return this;
+    },
+
+    getMaxListeners() {
+      return this._maxListeners === undefined ? EventEmitter.defaultMaxListeners : this._maxListeners;
+    },
+
+    emit(type, ...args) {
+      const events = this._events;
+      if (type === "error" && events?.[EventEmitter.errorMonitor] !== undefined) {
+        this.emit(EventEmitter.errorMonitor, ...args);
+      }
+
+      const listeners = events?.[type];
+      if (listeners === undefined) {
+        if (type === "error") {
+          const error = args[0];
+          if (error instanceof Error) {
+            throw error;
+          }
+          throw codeError(Error, "ERR_UNHANDLED_ERROR", `Unhandled error. (${String(error)})`);
+        }
+        return false;
+      }
+
+      for (const listener of listeners.slice()) {
+        listener.apply(this, args);
+      }
+      return true;
+    },
+
+    addListener(type, listener) {
+      return addListener(this, type, listener, false);
+    },
+
+    prependListener(type, listener) {
+      return addListener(this, type, listener, true);
+    },
+
+    once(type, listener) {
+      checkListener(listener);
+      return this.on(type, onceWrapper(this, type, listener));
+    },
+
+    prependOnceListener(type, listener) {
+      checkListener(listener);
+      return this.prependListener(type, onceWrapper(this, type, listener));
+    },
+
+    removeListener(type, listener) {
+      checkListener(listener);
+      const listeners = this._events?.[type];
+      if (listeners === undefined) {
+        return this;
+      }
+      for (let i = listeners.length - 1; i >= 0; i--) {
+        if (listeners[i] === listener || listeners[i].listener === listener) {
+          listeners.splice(i, 1);
+          if (listeners.length === 0) {
+            delete this._events[type];
+            this._eventsCount--;
+          }
+          if (this._events.removeListener !== undefined) {
+            this.emit("removeListener", type, listener);
+          }
+          break;
+        }
+      }
+      return this;
+    },
+
+    removeAllListeners(type) {
+      if (this._events === undefined) {
+        return this;
+      }
+      const types = type === undefined ? Reflect.ownKeys(this._events).filter((t) => t !== "removeListener") : [type];
+      for (const t of types) {
+        const listeners = this._events[t];
+        if (listeners === undefined) {
+          continue;
+        }
+        for (let i = listeners.length - 1; i >= 0; i--) {
+          this.removeListener(t, listeners[i]);
+        }
+      }
+      if (type === undefined) {
+        this._events = Object.create(null);
+        this._eventsCount = 0;
+      }
+      return this;
+    },
+
+    listeners(type) {
+      return (this._events?.[type] ?? []).map((listener) => listener.listener ?? listener);
+    },
+
+    rawListeners(type) {
+      return (this._events?.[type] ?? []).slice();
+    },
+
+    listenerCount(type, listener) {
+      const listeners = this._events?.[type] ?? [];
+      if (listener === undefined) {
+        return listeners.length;
+      }
+      return listeners.filter((l) => l === listener || l.listener === listener).length;
+    },
+
+    eventNames() {
+      return this._events === undefined ? [] : Reflect.ownKeys(this._events);
+    },
+  });
+
+  EventEmitter.prototype.on = EventEmitter.prototype.addListener;
+  EventEmitter.prototype.off = EventEmitter.prototype.removeListener;
+  EventEmitter.EventEmitter = EventEmitter;
+
+  EventEmitter.once = function (emitter, name, options = {}) {
+    return new Promise((resolve, reject) => {
+      const signal = options.signal;
+      if (signal?.aborted) {
+        reject(codeError(Error, "ABORT_ERR", "The operation was aborted"));
+        return;
+      }
+      const onError = (error) => {
+        emitter.removeListener(name, onEvent);
+        reject(error);
+      };
+      const onEvent = (...args) => {
+        if (name !== "error") {
+          emitter.removeListener("error", onError);
+        }
+        resolve(args);
+      };
+      emitter.once(name, onEvent);
+      if (name !== "error") {
+        emitter.once("error", onError);
+      }
+    });
+  };
+
+  EventEmitter.listenerCount = (emitter, type) => emitter.listenerCount(type);
+  EventEmitter.getEventListeners = (emitter, type) => emitter.listeners(type);
+
+  // Buffer is a Uint8Array with Node's encodings and binary accessors
+  class Buffer extends Uint8Array {
+    constructor(value, encodingOrOffset, length) {
+      if (typeof value === "string") {
+        super(encode(value, encodingOrOffset));
+      } else if (value instanceof ArrayBuffer) {
+        super(value, encodingOrOffset ?? 0, length ?? value.byteLength - (encodingOrOffset ?? 0));
+      } else {
+        super(value);
+      }
+    }
+
+    static from(value, encodingOrOffset, length) {
+      if (typeof value === "string") {
+        return new Buffer(value, encodingOrOffset);
+      }
+      if (value instanceof ArrayBuffer) {
+        return new Buffer(value, encodingOrOffset, length);
+      }
+      if (ArrayBuffer.isView(value) || Array.isArray(value)) {
+        return new Buffer(value);
+      }
+      if (value !== null && typeof value === "object") {
+        const primitive = value[Symbol.toPrimitive]?.("string") ?? value.valueOf();
+        if (primitive !== value && primitive !== null && primitive !== undefined) {
+          return Buffer.from(primitive, encodingOrOffset, length);
+        }
+        if (value.type === "Buffer" && Array.isArray(value.data)) {
+          return new Buffer(value.data);
+        }
+        if (typeof value.length === "number") {
+          return new Buffer(Array.from(value));
+        }
+      }
+      throw argTypeError("first", "of type string or an instance of Buffer, ArrayBuffer, or Array or an Array-like Object", value);
+    }
+
+    static alloc(size, fill, encoding) {
+      checkSize(size);
+      const buffer = new Buffer(size);
+      if (fill !== undefined && fill !== 0) {
+        buffer.fill(fill, 0, size, encoding);
+      }
+      return buffer;
+    }
+
+    static allocUnsafe(size) {
+      checkSize(size);
+      return new Buffer(size);
+    }
+
+    static allocUnsafeSlow(size) {
+      return Buffer.allocUnsafe(size);
+    }
+
+    static byteLength(value, encoding) {
+      if (typeof value === "string") {
+        return encode(value, encoding).byteLength;
+      }
+      if (ArrayBuffer.isView(value) || value instanceof ArrayBuffer) {
+        return value.byteLength;
+      }
+      throw argTypeError("string", "of type string or an instance of Buffer or ArrayBuffer", value);
+    }
+
+    static concat(list, totalLength) {
+      if (!Array.isArray(list)) {
+        throw argTypeError("list", "an instance of Array", list);
+      }
+      if (totalLength === undefined) {
+        totalLength = list.reduce((n, item) => n + item.length, 0);
+      }
+      const result = Buffer.alloc(totalLength);
+      let offset = 0;
+      for (const item of list) {
+        if (!(item instanceof Uint8Array)) {
+          throw argTypeError("list", "an instance of Buffer or Uint8Array", item);
+        }
+        const n = Math.min(item.length, totalLength - offset);
+        result.set(item.subarray(0, n), offset);
+        offset += n;
+        if (offset >= totalLength) {
+          break;
+        }
+      }
+      return result;
+    }
+
+    static isBuffer(value) {
+      return value instanceof Buffer;
+    }
+
+    static isEncoding(encoding) {
+      return typeof encoding === "string" && encodings[encoding.toLowerCase()] !== undefined;
+    }
+
+    static compare(a, b) {
+      return compareBytes(a, b);
+    }
+
+    toString(encoding, start = 0, end = this.length) {
+      start = Math.max(0, start | 0);
+      end = Math.min(this.length, end === undefined ? this.length : end | 0);
+      if (end <= start) {
+        return "";
+      }
+      return decode(this.subarray(start, end), encoding);
+    }
+
+    toLocaleString(encoding, start, end) {
+      return this.toString(encoding, start, end);
+    }
+
+    toJSON() {
+      return { type: "Buffer", data: Array.from(this) };
+    }
+
+    equals(other) {
+      if (!(other instanceof Uint8Array)) {
+        throw argTypeError("otherBuffer", "an instance of Buffer or Uint8Array", other);
+      }
+      return compareBytes(this, other) === 0;
+    }
+
+    compare(target, targetStart = 0, targetEnd = target.length, sourceStart = 0, sourceEnd = this.length) {
+      return compareBytes(this.subarray(sourceStart, sourceEnd), target.subarray(targetStart, targetEnd));
+    }
+
+    copy(target, targetStart = 0, sourceStart = 0, sourceEnd = this.length) {
+      const source = this.subarray(sourceStart, Math.min(sourceEnd, sourceStart + target.length - targetStart));
+      target.set(source, targetStart);
+      return source.length;
+    }
+
+    // Unlike Uint8Array, slice shares memory with the original
+    slice(start, end) {
+      return this.subarray(start, end);
+    }
+
+    write(string, offset, length, encoding) {
+      if (typeof offset === "string") {
+        [offset, length, encoding] = [0, undefined, offset];
+      } else if (typeof length === "string") {
+        [length, encoding] = [undefined, length];
+      }
+      offset = offset === undefined ? 0 : offset >>> 0;
+      const bytes = new Uint8Array(encode(string, encoding));
+      const n = Math.min(bytes.length, this.length - offset, length === undefined ? Infinity : length);
+      this.set(bytes.subarray(0, n), offset);
+      return n;
+    }
+
+    fill(value, offset = 0, end = this.length, encoding) {
+      if (typeof offset === "string") {
+        [offset, end, encoding] = [0, this.length, offset];
+      } else if (typeof end === "string") {
+        [end, encoding] = [this.length, end];
+      }
+      if (typeof value === "number" || typeof value === "boolean") {
+        return super.fill(Number(value) & 255, offset, end);
+      }
+      const bytes = typeof value === "string" ? new Uint8Array(encode(value, encoding)) : value;
+      if (bytes.length === 0) {
+        return super.fill(0, offset, end);
+      }
+      for (let i = offset, j = 0; i < end; i++, j = (j + 1) % bytes.length) {
+        this[i] = bytes[j];
+      }
+      return this;
+    }
+
+    indexOf(value, byteOffset = 0, encoding) {
+      return indexOf(this, value, byteOffset, encoding, false);
+    }
+
+    lastIndexOf(value, byteOffset = this.length, encoding) {
+      return indexOf(this, value, byteOffset, encoding, true);
+    }
+
+    includes(value, byteOffset, encoding) {
+      return this.indexOf(value, byteOffset, encoding) !== -1;
+    }
+
+    swap16() {
+      return swap(this, 2);
+    }
+
+    swap32() {
+      return swap(this, 4);
+    }
+
+    swap64() {
+      return swap(this, 8);
+    }
+
+    readUIntLE(offset, byteLength) {
+      let value = 0;
+      for (let i = byteLength - 1; i >= 0; i--) {
+        value = value * 256 + this[checkOffset(this, offset + i, 1)];
+      }
+      return value;
+    }
+
+    readUIntBE(offset, byteLength) {
+      let value = 0;
+      for (let i = 0; i < byteLength; i++) {
+        value = value * 256 + this[checkOffset(this, offset + i, 1)];
+      }
+      return value;
+    }
+
+    readIntLE(offset, byteLength) {
+      return signed(this.readUIntLE(offset, byteLength), byteLength);
+    }
+
+    readIntBE(offset, byteLength) {
+      return signed(this.readUIntBE(offset, byteLength), byteLength);
+    }
+
+    writeUIntLE(value, offset, byteLength) {
+      for (let i = 0; i < byteLength; i++) {
+        this[checkOffset(this, offset + i, 1)] = value & 255;
+        value = Math.floor(value / 256);
+      }
+      return offset + byteLength;
+    }
+
+    writeUIntBE(value, offset, byteLength) {
+      for (let i = byteLength - 1; i >= 0; i--) {
+        this[checkOffset(this, offset + i, 1)] = value & 255;
+        value = Math.floor(value / 256);
+      }
+      return offset + byteLength;
+    }
+
+    writeIntLE(value, offset, byteLength) {
+      return this.writeUIntLE(value < 0 ? value + 2 ** (8 * byteLength) : value, offset, byteLength);
+    }
+
+    writeIntBE(value, offset, byteLength) {
+      return this.writeUIntBE(value < 0 ? value + 2 ** (8 * byteLength) : value, offset, byteLength);
+    }
+
+    [inspectCustom]() {
+      const bytes = Array.from(this.subarray(0, 50), (b) => b.toString(16).padStart(2, "0"));
+      const more = this.length > 50 ? ` ... ${this.length - 50} more bytes` : "";
+      return `<Buffer${bytes.length ? " " + bytes.join(" ") : ""}${more}>`;
+    }
+  }
+
+  function checkSize(size) {
+    if (typeof size !== "number" || !(size >= 0) || size > 2 ** 32) {
+      throw codeError(RangeError, "ERR_OUT_OF_RANGE", `The value of "size" is out of range. Received ${size}`);
+    }
+  }
+
+  function checkOffset(buffer, offset, size) {
+    if (typeof offset !== "number" || offset < 0 || offset + size > buffer.length || offset % 1 !== 0) {
+      throw codeError(RangeError, "ERR_OUT_OF_RANGE", `The value of "offset" is out of range. It must be >= 0 and <= ${buffer.length - size}. Received ${offset}`);
+    }
+    return offset;
+  }
+
+  function signed(value, byteLength) {
+    const limit = 2 ** (8 * byteLength - 1);
+    return value >= limit ? value - 2 * limit : value;
+  }
+
+  function compareBytes(a, b) {
+    const n = Math.min(a.length, b.length);
+    for (let i = 0; i < n; i++) {
+      if (a[i] !== b[i]) {
+        return a[i] < b[i] ? -1 : 1;
+      }
+    }
+    return a.length === b.length ? 0 : a.length < b.length ? -1 : 1;
+  }
+
+  function indexOf(buffer, value, byteOffset, encoding, last) {
+    if (typeof byteOffset === "string") {
+      [byteOffset, encoding] = [last ? buffer.length : 0, byteOffset];
+    }
+    if (byteOffset < 0) {
+      byteOffset += buffer.length;
+    }
+    if (typeof value === "number") {
+      return last ? Uint8Array.prototype.lastIndexOf.call(buffer, value & 255, byteOffset) : Uint8Array.prototype.indexOf.call(buffer, value & 255, byteOffset);
+    }
+    const needle = typeof value === "string" ? new Uint8Array(encode(value, encoding)) : value;
+    const matches = (i) => {
+      for (let j = 0; j < needle.length; j++) {
+        if (buffer[i + j] !== needle[j]) {
+          return false;
+        }
+      }
+      return true;
+    };
+    if (last) {
+      for (let i = Math.min(byteOffset, buffer.length - needle.length); i >= 0; i--) {
+        if (matches(i)) return i;
+      }
+    } else {
+      for (let i = Math.max(byteOffset, 0); i <= buffer.length - needle.length; i++) {
+        if (matches(i)) return i;
+      }
+    }
+    return -1;
+  }
+
+  function swap(buffer, size) {
+    if (buffer.length % size !== 0) {
+      throw codeError(RangeError, "ERR_INVALID_BUFFER_SIZE", `Buffer size must be a multiple of ${size * 8}-bits`);
+    }
+    for (let i = 0; i < buffer.length; i += size) {
+      buffer.subarray(i, i + size).reverse();
+    }
+    return buffer;
+  }
+
+  // The fixed-size accessors go through a DataView
+  const accessors = {
+    UInt8: ["Uint8", 1],
+    UInt16: ["Uint16", 2],
+    UInt32: ["Uint32", 4],
+    Int8: ["Int8", 1],
+    Int16: ["Int16", 2],
+    Int32: ["Int32", 4],
+    Float: ["Float32", 4],
+    Double: ["Float64", 8],
+    BigUInt64: ["BigUint64", 8],
+    BigInt64: ["BigInt64", 8],
+  };
+  for (const [name, [view, size]] of Object.entries(accessors)) {
+    const endians = size === 1 ? [["", false]] : [["LE", true], ["BE", false]];
+    for (const [suffix, little] of endians) {
+      const read = function (offset = 0) {
+        checkOffset(this, offset, size);
+        return new DataView(this.buffer, this.byteOffset, this.byteLength)[`get${view}`](offset, little);
+      };
+      const write = function (value, offset = 0) {
+        checkOffset(this, offset, size);
+        new DataView(this.buffer, this.byteOffset, this.byteLength)[`set${view}`](offset, value, little);
+        return offset + size;
+      };
+      for (const alias of new Set([name, name.replace("UInt", "Uint")])) {
+        Buffer.prototype[`read${alias}${suffix}`] = read;
+        Buffer.prototype[`write${alias}${suffix}`] = write;
+      }
+    }
+  }
+  for (const name of ["readUIntLE", "readUIntBE", "writeUIntLE", "writeUIntBE"]) {
+    Buffer.prototype[name.replace("UInt", "Uint")] = Buffer.prototype[name];
+  }
+  Buffer.poolSize = 8192;
+
+  // process is an EventEmitter with the state of the running program
+  const info = ops.processInfo();
+  const process = Object.create(EventEmitter.prototype);
+  EventEmitter.init.call(process);
+
+  const env = new Proxy(Object.create(null), {
+    get: (_, name) => (typeof name === "string" ? ops.getenv(name) : undefined),
+    set: (_, name, value) => {
+      ops.setenv(String(name), String(value));
+      return true;
+    },
+    has: (_, name) => typeof name === "string" && ops.getenv(name) !== undefined,
+    deleteProperty: (_, name) => {
+      ops.unsetenv(String(name));
+      return true;
+    },
+    ownKeys: () => ops.environ(),
+    getOwnPropertyDescriptor: (_, name) => {
+      const value = typeof name === "string" ? ops.getenv(name) : undefined;
+      return value === undefined ? undefined : { value, writable: true, enumerable: true, configurable: true };
+    },
+  });
+
+  function stream(fd) {
+    const out = Object.create(EventEmitter.prototype);
+    EventEmitter.init.call(out);
+    return Object.assign(out, {
+      fd,
+      isTTY: false,
+      writable: true,
+      write(chunk, encoding, callback) {
+        if (typeof encoding === "function") {
+          [encoding, callback] = [undefined, encoding];
+        }
+        const bytes = typeof chunk === "string" ? Buffer.from(chunk, encoding) : chunk;
+        ops.write(fd, bytes.buffer.slice(bytes.byteOffset, bytes.byteOffset + bytes.byteLength));
+        if (typeof callback === "function") {
+          Promise.resolve().then(callback);
+        }
+        return true;
+      },
+      end(chunk, encoding, callback) {
+        if (chunk !== undefined && typeof chunk !== "function") {
+          this.write(chunk, encoding);
+        }
+        return this;
+      },
+    });
+  }
+
+  let argv;
+  let exitCode;
+  Object.assign(process, {
+    title: "edon",
+    version: info.version,
+    versions: info.versions,
+    platform: info.platform,
+    arch: info.arch,
+    pid: info.pid,
+    ppid: info.ppid,
+    execPath: info.execPath,
+    execArgv: [],
+    argv0: "edon",
+    release: { name: "node" },
+    config: { variables: {} },
+    features: {},
+    env,
+    stdout: stream(1),
+    stderr: stream(2),
+
+    cwd: () => ops.cwd(),
+    chdir: (directory) => ops.chdir(String(directory)),
+    uptime: () => ops.uptime(),
+
+    hrtime(previous) {
+      const now = ops.hrtime();
+      let seconds = Number(now / 1000000000n);
+      let nanoseconds = Number(now % 1000000000n);
+      if (previous !== undefined) {
+        seconds -= previous[0];
+        nanoseconds -= previous[1];
+        if (nanoseconds < 0) {
+          seconds--;
+          nanoseconds += 1e9;
+        }
+      }
+      return [seconds, nanoseconds];
+    },
+
+    nextTick(callback, ...args) {
+      if (typeof callback !== "function") {
+        throw argTypeError("callback", "of type function", callback);
+      }
+      Promise.resolve().then(() => callback(...args));
+    },
+
+    emitWarning(warning, type = "Warning", code) {
+      if (typeof type === "object" && type !== null) {
+        ({ type = "Warning", code } = type);
+      }
+      if (typeof warning === "string") {
+        warning = Object.assign(new Error(warning), { name: type });
+        if (code !== undefined) {
+          warning.code = code;
+        }
+      }
+      process.stderr.write(`(node:${info.pid}) ${code ? `[${code}] ` : ""}${warning.name}: ${warning.message}\n`);
+      process.emit("warning", warning);
+    },
+
+    exit(code) {
+      if (code !== undefined) {
+        process.exitCode = code;
+      }
+      process.emit("exit", exitCode ?? 0);
+      ops.exit(exitCode ?? 0);
+    },
+  });
+
+  process.hrtime.bigint = () => ops.hrtime();
+
+  Object.defineProperties(process, {
+    argv: {
+      get: () => (argv ??= ops.argv()),
+      set: (value) => {
+        argv = value;
+      },
+      enumerable: true,
+      configurable: true,
+    },
+    exitCode: {
+      get: () => exitCode,
+      set: (value) => {
+        exitCode = value;
+        ops.setExitCode(value === undefined ? 0 : Number(value));
+      },
+      enumerable: true,
+      configurable: true,
+    },
+    [Symbol.toStringTag]: { value: "process" },
+  });
+
+  Object.assign(ops, { EventEmitter, Buffer, process, encode, decode, normalizeEncoding, codeError, argTypeError, inspectCustom });
+
+  for (const [name, value] of Object.entries({ process, Buffer, global: globalThis })) {
+    Object.defineProperty(globalThis, name, { value, writable: true, configurable: true });
+  }
+})(globalThis.__edon_node);
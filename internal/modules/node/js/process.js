@@ -0,0 +1,10 @@
+// node:process
+const { process } = globalThis.__edon_node;
+
+export const { argv, env, platform, arch, pid, version, versions, stdout, stderr } = process;
+export const cwd = () => process.cwd();
+export const exit = (code) => process.exit(code);
+export const nextTick = (callback, ...args) => process.nextTick(callback, ...args);
+export const hrtime = process.hrtime;
+export const emitWarning = (...args) => process.emitWarning(...args);
+export default process;
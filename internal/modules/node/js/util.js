@@ -0,0 +1,529 @@
+// node:util
+const { Buffer, process, encode, decode, normalizeEncoding, argTypeError, codeError, inspectCustom } = globalThis.__edon_node;
+
+const defaultOptions = {
+  depth: 2,
+  colors: false,
+  showHidden: false,
+  breakLength: 80,
+  maxArrayLength: 100,
+  maxStringLength: 10000,
+  sorted: false,
+  getters: false,
+};
+
+const TypedArray = Object.getPrototypeOf(Uint8Array);
+const identifier = /^[A-Za-z_$][A-Za-z0-9_$]*$/;
+
+export function inspect(value, options) {
+  const opts = { ...defaultOptions, ...inspect.defaultOptions };
+  if (typeof options === "boolean") {
+    opts.showHidden = options;
+  } else if (options !== null && typeof options === "object") {
+    Object.assign(opts, options);
+  }
+  return formatValue({ ...opts, seen: [], circular: new Map() }, value, 0);
+}
+
+inspect.custom = inspectCustom;
+inspect.defaultOptions = { ...defaultOptions };
+
+function quote(string) {
+  let q = "'";
+  if (string.includes("'")) {
+    q = !string.includes('"') ? '"' : !string.includes("`") && !string.includes("${") ? "`" : "'";
+  }
+  const escaped = string.replace(/[\\\n\t\r\b\f\v\x00-\x1f\x7f]/g, (c) => {
+    const named = { "\\": "\\\\", "\n": "\\n", "\t": "\\t", "\r": "\\r", "\b": "\\b", "\f": "\\f", "\v": "\\v" }[c];
+    return named ?? `\\x${c.charCodeAt(0).toString(16).padStart(2, "0").toUpperCase()}`;
+  });
+  return q + (q === "'" ? escaped.replace(/'/g, "\\'") : escaped) + q;
+}
+
+function formatPrimitive(value) {
+  switch (typeof value) {
+    case "string":
+      return quote(value);
+    case "number":
+      return Object.is(value, -0) ? "-0" : String(value);
+    case "bigint":
+      return `${value}n`;
+    case "symbol":
+      return value.toString();
+    case "undefined":
+      return "undefined";
+    default:
+      return String(value);
+  }
+}
+
+function formatKey(key) {
+  if (typeof key === "symbol") {
+    return `[${key.toString()}]`;
+  }
+  return identifier.test(key) ? key : quote(key);
+}
+
+function constructorName(value) {
+  let proto = Object.getPrototypeOf(value);
+  while (proto !== null) {
+    const descriptor = Object.getOwnPropertyDescriptor(proto, "constructor");
+    if (descriptor !== undefined && typeof descriptor.value === "function" && descriptor.value.name !== "") {
+      return descriptor.value.name;
+    }
+    proto = Object.getPrototypeOf(proto);
+  }
+  return null;
+}
+
+function formatValue(ctx, value, depth) {
+  if (value === null || (typeof value !== "object" && typeof value !== "function")) {
+    return formatPrimitive(value);
+  }
+
+  const custom = value[inspectCustom];
+  if (typeof custom === "function" && custom !== inspect) {
+    const result = custom.call(value, ctx.depth - depth, { ...ctx }, inspect);
+    if (result !== value) {
+      return typeof result === "string" ? result : formatValue(ctx, result, depth);
+    }
+  }
+
+  if (ctx.seen.includes(value)) {
+    if (!ctx.circular.has(value)) {
+      ctx.circular.set(value, ctx.circular.size + 1);
+    }
+    return `[Circular *${ctx.circular.get(value)}]`;
+  }
+
+  const name = constructorName(value);
+  let prefix = name === null ? "[Object: null prototype] " : name === "Object" ? "" : `${name} `;
+  let braces = ["{", "}"];
+  let entries = [];
+  let base = "";
+  let keys = Object.keys(value);
+  if (ctx.showHidden) {
+    keys = Object.getOwnPropertyNames(value);
+  }
+  keys = keys.concat(Object.getOwnPropertySymbols(value).filter((s) => ctx.showHidden || Object.prototype.propertyIsEnumerable.call(value, s)));
+
+  if (typeof value === "function") {
+    const source = Function.prototype.toString.call(value);
+    const fnName = value.name ? `: ${value.name}` : " (anonymous)";
+    base = source.startsWith("class") ? `[class ${value.name || "(anonymous)"}]` : `[Function${fnName}]`;
+    keys = keys.filter((k) => k !== "prototype");
+    if (keys.length === 0) {
+      return base;
+    }
+    prefix = "";
+  } else if (value instanceof Error) {
+    base = value.stack && value.stack.includes(value.message) ? String(value.stack).trimEnd() : `${value.name}: ${value.message}`;
+    if (!base.startsWith(value.name)) {
+      base = `${value.name}: ${value.message}\n${base}`;
+    }
+    keys = keys.filter((k) => k !== "stack" && k !== "message");
+    if (keys.length === 0) {
+      return base;
+    }
+    prefix = "";
+  } else if (value instanceof Date) {
+    base = Number.isNaN(value.getTime()) ? "Invalid Date" : value.toISOString();
+    if (keys.length === 0) {
+      return base;
+    }
+    prefix = "";
+  } else if (value instanceof RegExp) {
+    base = RegExp.prototype.toString.call(value);
+    if (keys.length === 0) {
+      return base;
+    }
+    prefix = "";
+  }
+
+  if (depth > ctx.depth) {
+    if (Array.isArray(value)) {
+      return "[Array]";
+    }
+    return `[${name ?? "Object"}]`;
+  }
+
+  ctx.seen.push(value);
+  try {
+    if (Array.isArray(value) || value instanceof TypedArray) {
+      braces = ["[", "]"];
+      if (value instanceof TypedArray) {
+        prefix = `${name}(${value.length}) `;
+      } else if (name !== "Array") {
+        prefix = `${name}(${value.length}) `;
+      } else {
+        prefix = "";
+      }
+      entries = formatArray(ctx, value, depth);
+      keys = keys.filter((k) => typeof k === "symbol" || !/^(0|[1-9][0-9]*)$/.test(k));
+    } else if (value instanceof Map) {
+      prefix = `${name}(${value.size}) `;
+      for (const [k, v] of value) {
+        entries.push(`${formatValue(ctx, k, depth + 1)} => ${formatValue(ctx, v, depth + 1)}`);
+      }
+    } else if (value instanceof Set) {
+      prefix = `${name}(${value.size}) `;
+      for (const v of value) {
+        entries.push(formatValue(ctx, v, depth + 1));
+      }
+    } else if (value instanceof ArrayBuffer) {
+      const bytes = Array.from(new Uint8Array(value, 0, Math.min(value.byteLength, 50)), (b) => b.toString(16).padStart(2, "0"));
+      entries.push(`[Uint8Contents]: <${bytes.join(" ")}${value.byteLength > 50 ? ` ... ${value.byteLength - 50} more bytes` : ""}>`);
+      entries.push(`byteLength: ${value.byteLength}`);
+    } else if (value instanceof WeakMap || value instanceof WeakSet) {
+      entries.push("<items unknown>");
+    }
+
+    if (ctx.sorted) {
+      keys.sort(typeof ctx.sorted === "function" ? ctx.sorted : undefined);
+    }
+    for (const key of keys) {
+      entries.push(formatProperty(ctx, value, key, depth));
+    }
+  } finally {
+    ctx.seen.pop();
+  }
+
+  if (base !== "") {
+    entries.unshift(base);
+    prefix = "";
+  }
+  let reference = "";
+  if (ctx.circular.has(value)) {
+    reference = `<ref *${ctx.circular.get(value)}> `;
+  }
+  return reference + reduceToSingleString(ctx, prefix, entries, braces, depth);
+}
+
+function formatArray(ctx, array, depth) {
+  const entries = [];
+  const length = Math.min(array.length, ctx.maxArrayLength);
+  let holes = 0;
+  for (let i = 0; i < length; i++) {
+    if (!Array.isArray(array) || Object.prototype.hasOwnProperty.call(array, i)) {
+      if (holes > 0) {
+        entries.push(`<${holes} empty item${holes > 1 ? "s" : ""}>`);
+        holes = 0;
+      }
+      entries.push(formatValue(ctx, array[i], depth + 1));
+    } else {
+      holes++;
+    }
+  }
+  if (holes > 0) {
+    entries.push(`<${holes} empty item${holes > 1 ? "s" : ""}>`);
+  }
+  if (array.length > length) {
+    const more = array.length - length;
+    entries.push(`... ${more} more item${more > 1 ? "s" : ""}`);
+  }
+  return entries;
+}
+
+function formatProperty(ctx, object, key, depth) {
+  const descriptor = Object.getOwnPropertyDescriptor(object, key) ?? { value: object[key] };
+  let formatted;
+  if (descriptor.get !== undefined || descriptor.set !== undefined) {
+    formatted = descriptor.get && descriptor.set ? "[Getter/Setter]" : descriptor.get ? "[Getter]" : "[Setter]";
+  } else {
+    formatted = formatValue(ctx, descriptor.value, depth + 1);
+  }
+  const name = descriptor.enumerable === false ? `[${formatKey(key)}]` : formatKey(key);
+  return `${name}: ${formatted}`;
+}
+
+function reduceToSingleString(ctx, prefix, entries, braces, depth) {
+  if (entries.length === 0) {
+    return `${prefix}${braces[0]}${braces[1]}`;
+  }
+  const single = `${prefix}${braces[0]} ${entries.join(", ")} ${braces[1]}`;
+  if (single.length + depth * 2 <= ctx.breakLength && !single.includes("\n")) {
+    return single;
+  }
+  const indent = "  ".repeat(depth + 1);
+  return `${prefix}${braces[0]}\n${indent}${entries.join(`,\n${indent}`)}\n${"  ".repeat(depth)}${braces[1]}`;
+}
+
+function formatArgument(value) {
+  return typeof value === "string" ? value : inspect(value);
+}
+
+export function formatWithOptions(options, format, ...args) {
+  if (typeof format !== "string") {
+    return [format, ...args].map((value) => (typeof value === "string" ? value : inspect(value, options))).join(" ");
+  }
+
+  let index = 0;
+  let result = format.replace(/%([sdifjoOc%])/g, (match, directive) => {
+    if (directive === "%") {
+      return "%";
+    }
+    if (index >= args.length) {
+      return match;
+    }
+    const value = args[index++];
+    switch (directive) {
+      case "s":
+        if (typeof value === "bigint") return `${value}n`;
+        if (typeof value === "symbol") return value.toString();
+        if (value !== null && typeof value === "object") return inspect(value, { ...options, depth: 0 });
+        return String(value);
+      case "d":
+        if (typeof value === "bigint") return `${value}n`;
+        if (typeof value === "symbol") return "NaN";
+        return formatPrimitive(Number(value));
+      case "i":
+        if (typeof value === "bigint") return `${value}n`;
+        if (typeof value === "symbol") return "NaN";
+        return formatPrimitive(parseInt(value));
+      case "f":
+        return typeof value === "symbol" ? "NaN" : formatPrimitive(parseFloat(value));
+      case "j":
+        try {
+          return JSON.stringify(value);
+        } catch {
+          return "[Circular]";
+        }
+      case "o":
+        return inspect(value, { ...options, showHidden: true, depth: 4 });
+      case "O":
+        return inspect(value, options);
+      case "c":
+        return "";
+    }
+    return match;
+  });
+  for (; index < args.length; index++) {
+    result += " " + (typeof args[index] === "string" ? args[index] : inspect(args[index], options));
+  }
+  return result;
+}
+
+export function format(...args) {
+  return formatWithOptions(undefined, ...args);
+}
+
+const customPromisify = Symbol.for("nodejs.util.promisify.custom");
+
+export function promisify(original) {
+  if (typeof original !== "function") {
+    throw argTypeError("original", "of type function", original);
+  }
+  if (typeof original[customPromisify] === "function") {
+    return original[customPromisify];
+  }
+  function promisified(...args) {
+    return new Promise((resolve, reject) => {
+      original.call(this, ...args, (error, value) => (error ? reject(error) : resolve(value)));
+    });
+  }
+  Object.setPrototypeOf(promisified, Object.getPrototypeOf(original));
+  Object.defineProperty(promisified, customPromisify, { value: promisified });
+  return Object.defineProperties(promisified, Object.getOwnPropertyDescriptors(original));
+}
+
+promisify.custom = customPromisify;
+
+export function callbackify(original) {
+  if (typeof original !== "function") {
+    throw argTypeError("original", "of type function", original);
+  }
+  return function (...args) {
+    const callback = args.pop();
+    if (typeof callback !== "function") {
+      throw argTypeError("last argument", "of type function", callback);
+    }
+    original.apply(this, args).then(
+      (value) => process.nextTick(callback, null, value),
+      (reason) => {
+        if (!reason) {
+          reason = Object.assign(codeError(Error, "ERR_FALSY_VALUE_REJECTION", "Promise was rejected with falsy value"), { reason });
+        }
+        process.nextTick(callback, reason);
+      },
+    );
+  };
+}
+
+export function inherits(ctor, superCtor) {
+  if (typeof ctor !== "function") {
+    throw argTypeError("ctor", "of type function", ctor);
+  }
+  if (typeof superCtor !== "function" || superCtor.prototype === undefined) {
+    throw argTypeError("superCtor", "of type function", superCtor);
+  }
+  Object.defineProperty(ctor, "super_", { value: superCtor, writable: true, configurable: true });
+  Object.setPrototypeOf(ctor.prototype, superCtor.prototype);
+}
+
+const warned = new Set();
+
+export function deprecate(fn, message, code) {
+  return function (...args) {
+    const key = code ?? message;
+    if (!warned.has(key)) {
+      warned.add(key);
+      process.emitWarning(message, "DeprecationWarning", code);
+    }
+    return new.target ? Reflect.construct(fn, args, new.target) : fn.apply(this, args);
+  };
+}
+
+export function debuglog() {
+  const log = () => {};
+  log.enabled = false;
+  return log;
+}
+
+export function isDeepStrictEqual(a, b) {
+  return deepEqual(a, b, new Map());
+}
+
+function deepEqual(a, b, memo) {
+  if (Object.is(a, b)) {
+    return true;
+  }
+  if (a === null || b === null || typeof a !== "object" || typeof b !== "object") {
+    return false;
+  }
+  if (Object.getPrototypeOf(a) !== Object.getPrototypeOf(b)) {
+    return false;
+  }
+  if (memo.get(a) === b) {
+    return true;
+  }
+  memo.set(a, b);
+
+  if (a instanceof Date) {
+    return Object.is(a.getTime(), b.getTime());
+  }
+  if (a instanceof RegExp) {
+    return String(a) === String(b) && a.lastIndex === b.lastIndex;
+  }
+  if (a instanceof Error && (a.message !== b.message || a.name !== b.name)) {
+    return false;
+  }
+  if (ArrayBuffer.isView(a)) {
+    if (a.byteLength !== b.byteLength) return false;
+    const x = new Uint8Array(a.buffer, a.byteOffset, a.byteLength);
+    const y = new Uint8Array(b.buffer, b.byteOffset, b.byteLength);
+    return x.every((byte, i) => byte === y[i]);
+  }
+  if (a instanceof Map) {
+    if (a.size !== b.size) return false;
+    for (const [key, value] of a) {
+      if (!b.has(key) || !deepEqual(value, b.get(key), memo)) return false;
+    }
+  }
+  if (a instanceof Set) {
+    if (a.size !== b.size) return false;
+    outer: for (const value of a) {
+      if (b.has(value)) continue;
+      if (value !== null && typeof value === "object") {
+        for (const other of b) {
+          if (deepEqual(value, other, memo)) continue outer;
+        }
+      }
+      return false;
+    }
+  }
+
+  const keys = Reflect.ownKeys(a).filter((k) => Object.prototype.propertyIsEnumerable.call(a, k));
+  const otherKeys = Reflect.ownKeys(b).filter((k) => Object.prototype.propertyIsEnumerable.call(b, k));
+  if (keys.length !== otherKeys.length) {
+    return false;
+  }
+  for (const key of keys) {
+    if (!Object.prototype.propertyIsEnumerable.call(b, key) || !deepEqual(a[key], b[key], memo)) {
+      return false;
+    }
+  }
+  return true;
+}
+
+const toStringTag = (value) => Object.prototype.toString.call(value).slice(8, -1);
+
+export const types = {
+  isPromise: (value) => value instanceof Promise,
+  isDate: (value) => value instanceof Date,
+  isRegExp: (value) => value instanceof RegExp,
+  isMap: (value) => value instanceof Map,
+  isSet: (value) => value instanceof Set,
+  isWeakMap: (value) => value instanceof WeakMap,
+  isWeakSet: (value) => value instanceof WeakSet,
+  isNativeError: (value) => value instanceof Error,
+  isArrayBuffer: (value) => value instanceof ArrayBuffer,
+  isAnyArrayBuffer: (value) => value instanceof ArrayBuffer || toStringTag(value) === "SharedArrayBuffer",
+  isArrayBufferView: (value) => ArrayBuffer.isView(value),
+  isTypedArray: (value) => value instanceof TypedArray,
+  isUint8Array: (value) => value instanceof Uint8Array,
+  isDataView: (value) => value instanceof DataView,
+  isAsyncFunction: (value) => typeof value === "function" && toStringTag(value) === "AsyncFunction",
+  isGeneratorFunction: (value) => typeof value === "function" && /GeneratorFunction$/.test(toStringTag(value)),
+  isGeneratorObject: (value) => toStringTag(value) === "Generator",
+  isBoxedPrimitive: (value) => ["Number", "String", "Boolean", "BigInt", "Symbol"].includes(toStringTag(value)) && typeof value === "object",
+};
+
+export class TextEncoder {
+  get encoding() {
+    return "utf-8";
+  }
+
+  encode(input = "") {
+    return new Uint8Array(encode(String(input), "utf8"));
+  }
+}
+
+export class TextDecoder {
+  #encoding;
+
+  constructor(label = "utf-8") {
+    const name = String(label).toLowerCase();
+    this.#encoding = name === "latin1" || name === "iso-8859-1" ? "latin1" : normalizeEncoding(name);
+  }
+
+  get encoding() {
+    return { utf8: "utf-8", utf16le: "utf-16le", latin1: "windows-1252" }[this.#encoding] ?? this.#encoding;
+  }
+
+  decode(input) {
+    if (input === undefined) {
+      return "";
+    }
+    const bytes = input instanceof ArrayBuffer ? new Uint8Array(input) : new Uint8Array(input.buffer, input.byteOffset, input.byteLength);
+    let text = decode(bytes, this.#encoding);
+    if (text.charCodeAt(0) === 0xfeff) {
+      text = text.slice(1);
+    }
+    return text;
+  }
+}
+
+export function stripVTControlCharacters(string) {
+  return String(string).replace(/\x1b\[[0-9;]*[A-Za-z]/g, "");
+}
+
+export const isArray = Array.isArray;
+export const isBuffer = Buffer.isBuffer;
+
+export default {
+  inspect,
+  format,
+  formatWithOptions,
+  promisify,
+  callbackify,
+  inherits,
+  deprecate,
+  debuglog,
+  isDeepStrictEqual,
+  types,
+  TextEncoder,
+  TextDecoder,
+  stripVTControlCharacters,
+  isArray,
+  isBuffer,
+};
@@ -0,0 +1,172 @@
+// Package node provides the Node.js built-in modules npm packages import
+// with node: specifiers, and the process and Buffer globals.
+//
+// The modules are JavaScript, embedded below js/, on top of a small set of
+// native operations for the filesystem, the environment and encodings.
+package node
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/buke/quickjs-go"
+)
+
+//go:embed js
+var sources embed.FS
+
+// opsName is the global the native operations are reachable under
+const opsName = "__edon_node"
+
+// builtins maps each built-in module name to its source file
+var builtins = map[string]string{
+	"buffer":      "js/buffer.js",
+	"events":      "js/events.js",
+	"fs":          "js/fs.js",
+	"fs/promises": "js/fs_promises.js",
+	"os":          "js/os.js",
+	"path":        "js/path.js",
+	"path/posix":  "js/path.js",
+	"process":     "js/process.js",
+	"util":        "js/util.js",
+}
+
+// Builtins returns the names of the built-in modules
+func Builtins() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsBuiltin reports whether name, with or without the node: prefix, is a
+// built-in module
+func IsBuiltin(name string) bool {
+	_, ok := builtins[strings.TrimPrefix(name, "node:")]
+	return ok
+}
+
+// Source returns the JavaScript source of a built-in module
+func Source(name string) (string, bool) {
+	file, ok := builtins[strings.TrimPrefix(name, "node:")]
+	if !ok {
+		return "", false
+	}
+	data, err := sources.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// bindings are the native operations of a context
+type bindings struct {
+	ctx     *quickjs.Context
+	process *Process
+}
+
+// op is a native operation; errors are thrown as JavaScript errors
+type op func(args []*quickjs.Value) (*quickjs.Value, error)
+
+// Init installs the native operations and the process and Buffer globals
+// in ctx
+func Init(ctx *quickjs.Context, process *Process) error {
+	b := &bindings{ctx: ctx, process: process}
+
+	ops := ctx.Object()
+	for name, fn := range b.ops() {
+		ops.Set(name, ctx.Function(func(ctx *quickjs.Context, this *quickjs.Value, args []*quickjs.Value) *quickjs.Value {
+			if err := process.Permissions.check(name); err != nil {
+				return ctx.ThrowError(err)
+			}
+			result, err := fn(args)
+			if err != nil {
+				return ctx.ThrowError(err)
+			}
+			return result
+		}))
+	}
+	ctx.Globals().Set(opsName, ops)
+
+	prelude, err := sources.ReadFile("js/prelude.js")
+	if err != nil {
+		return err
+	}
+	result := ctx.Eval(string(prelude), quickjs.EvalFileName("node:prelude"))
+	defer result.Free()
+	if result.IsException() {
+		return ctx.Exception()
+	}
+	return nil
+}
+
+func (b *bindings) ops() map[string]op {
+	return map[string]op{
+		"encode":       b.encode,
+		"decode":       b.decode,
+		"processInfo":  b.processInfo,
+		"argv":         b.argv,
+		"cwd":          b.cwd,
+		"chdir":        b.chdir,
+		"getenv":       b.getenv,
+		"setenv":       b.setenv,
+		"unsetenv":     b.unsetenv,
+		"environ":      b.environ,
+		"exit":         b.exit,
+		"setExitCode":  b.setExitCode,
+		"hrtime":       b.hrtime,
+		"uptime":       b.uptime,
+		"write":        b.write,
+		"osInfo":       b.osInfo,
+		"userInfo":     b.userInfo,
+		"readFile":     b.readFile,
+		"writeFile":    b.writeFile,
+		"stat":         b.stat,
+		"readdir":      b.readdir,
+		"mkdir":        b.mkdir,
+		"mkdtemp":      b.mkdtemp,
+		"rm":           b.rm,
+		"rmdir":        b.rmdir,
+		"unlink":       b.unlink,
+		"rename":       b.rename,
+		"copyFile":     b.copyFile,
+		"realpath":     b.realpath,
+		"readlink":     b.readlink,
+		"symlink":      b.symlink,
+		"chmod":        b.chmod,
+		"access":       b.access,
+		"exists":       b.exists,
+		"truncate":     b.truncate,
+		"setTimestamp": b.setTimestamp,
+	}
+}
+
+// arg returns args[i], or undefined when it is missing
+func (b *bindings) arg(args []*quickjs.Value, i int) *quickjs.Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return b.ctx.NewUndefined()
+}
+
+// bytesArg copies the ArrayBuffer at args[i]
+func (b *bindings) bytesArg(args []*quickjs.Value, i int) ([]byte, error) {
+	v := b.arg(args, i)
+	if !v.IsByteArray() {
+		return nil, fmt.Errorf("TypeError: expected an ArrayBuffer")
+	}
+	return v.ToByteArray(uint(v.ByteLen()))
+}
+
+func (b *bindings) toJS(v any) (*quickjs.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return b.ctx.ParseJSON(string(data)), nil
+}
@@ -0,0 +1,57 @@
+package node
+
+import (
+	"os"
+	"os/user"
+	"runtime"
+	"strconv"
+
+	"github.com/buke/quickjs-go"
+)
+
+// osInfo returns what the os module reports about the machine
+func (b *bindings) osInfo(args []*quickjs.Value) (*quickjs.Value, error) {
+	hostname, _ := os.Hostname()
+	home, _ := os.UserHomeDir()
+	eol := "\n"
+	if runtime.GOOS == "windows" {
+		eol = "\r\n"
+	}
+	osType := map[string]string{"linux": "Linux", "darwin": "Darwin", "windows": "Windows_NT", "freebsd": "FreeBSD"}[runtime.GOOS]
+	if osType == "" {
+		osType = runtime.GOOS
+	}
+	return b.toJS(map[string]any{
+		"hostname": hostname,
+		"homedir":  home,
+		"tmpdir":   os.TempDir(),
+		"platform": platform(),
+		"arch":     arch(),
+		"type":     osType,
+		"eol":      eol,
+		"cpus":     runtime.NumCPU(),
+	})
+}
+
+// userInfo returns the current user as os.userInfo() describes it
+func (b *bindings) userInfo(args []*quickjs.Value) (*quickjs.Value, error) {
+	u, err := user.Current()
+	if err != nil {
+		return nil, fsError(err, "uv_os_get_passwd", "")
+	}
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		uid = -1
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		gid = -1
+	}
+	return b.toJS(map[string]any{
+		"username": u.Username,
+		"uid":      uid,
+		"gid":      gid,
+		"homedir":  u.HomeDir,
+		"shell":    os.Getenv("SHELL"),
+	})
+}
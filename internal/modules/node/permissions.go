@@ -0,0 +1,83 @@
+package node
+
+import "fmt"
+
+// Permissions are the kinds of access the built-ins grant scripts. The zero
+// value grants none; edon itself runs scripts with AllowAll.
+type Permissions struct {
+	Read  bool `json:"read,omitempty"`
+	Write bool `json:"write,omitempty"`
+	Env   bool `json:"env,omitempty"`
+}
+
+// AllowAll grants every permission
+var AllowAll = Permissions{Read: true, Write: true, Env: true}
+
+// permission names a kind of access and the flag that grants it
+type permission string
+
+const (
+	permRead  permission = "read"
+	permWrite permission = "write"
+	permEnv   permission = "env"
+)
+
+// opPermissions lists what each guarded operation needs
+var opPermissions = map[string][]permission{
+	"readFile":     {permRead},
+	"stat":         {permRead},
+	"readdir":      {permRead},
+	"realpath":     {permRead},
+	"readlink":     {permRead},
+	"access":       {permRead},
+	"exists":       {permRead},
+	"writeFile":    {permWrite},
+	"mkdir":        {permWrite},
+	"mkdtemp":      {permWrite},
+	"rm":           {permWrite},
+	"rmdir":        {permWrite},
+	"unlink":       {permWrite},
+	"rename":       {permWrite},
+	"symlink":      {permWrite},
+	"chmod":        {permWrite},
+	"truncate":     {permWrite},
+	"setTimestamp": {permWrite},
+	"copyFile":     {permRead, permWrite},
+	"getenv":       {permEnv},
+	"setenv":       {permEnv},
+	"unsetenv":     {permEnv},
+	"environ":      {permEnv},
+}
+
+func (p Permissions) granted(perm permission) bool {
+	switch perm {
+	case permRead:
+		return p.Read
+	case permWrite:
+		return p.Write
+	case permEnv:
+		return p.Env
+	}
+	return false
+}
+
+// check fails when the operation name needs a permission p does not grant
+func (p Permissions) check(name string) error {
+	for _, perm := range opPermissions[name] {
+		if !p.granted(perm) {
+			return fmt.Errorf("PermissionDenied: %s needs %s access, granted with -allow-%s", name, perm, perm)
+		}
+	}
+	return nil
+}
+
+// Flags returns the command-line flags that grant p
+func (p Permissions) Flags() []string {
+	var flags []string
+	for _, perm := range []permission{permRead, permWrite, permEnv} {
+		if p.granted(perm) {
+			flags = append(flags, "-allow-"+string(perm))
+		}
+	}
+	return flags
+}
@@ -0,0 +1,182 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/buke/quickjs-go"
+)
+
+// Version is the Node.js version process.version reports, the one whose
+// APIs the built-in modules follow
+const Version = "v20.0.0"
+
+// ErrExited is thrown into JavaScript by process.exit() to unwind the stack
+var ErrExited = errors.New("process.exit() called")
+
+// Process is the state behind the process global
+type Process struct {
+	// Args are the arguments after the main module
+	Args []string
+	// Main is the path of the main module, process.argv[1]
+	Main string
+	// Permissions limit what the built-ins let the script reach
+	Permissions Permissions
+
+	start    time.Time
+	exitCode atomic.Int32
+	exiting  atomic.Bool
+}
+
+// NewProcess returns a process with the given arguments after the main
+// module
+func NewProcess(args ...string) *Process {
+	return &Process{Args: args, Permissions: AllowAll, start: time.Now()}
+}
+
+// ExitCode returns the code set with process.exit() or process.exitCode
+func (p *Process) ExitCode() int {
+	return int(p.exitCode.Load())
+}
+
+// Exiting reports whether process.exit() was called. The runtime stops
+// evaluating JavaScript once it is.
+func (p *Process) Exiting() bool {
+	return p.exiting.Load()
+}
+
+// platform and arch use Node's names for Go's GOOS and GOARCH
+func platform() string {
+	if runtime.GOOS == "windows" {
+		return "win32"
+	}
+	return runtime.GOOS
+}
+
+func arch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x64"
+	case "386":
+		return "ia32"
+	}
+	return runtime.GOARCH
+}
+
+// processInfo returns the constant properties of process
+func (b *bindings) processInfo(args []*quickjs.Value) (*quickjs.Value, error) {
+	exe, _ := os.Executable()
+	return b.toJS(map[string]any{
+		"platform": platform(),
+		"arch":     arch(),
+		"pid":      os.Getpid(),
+		"ppid":     os.Getppid(),
+		"execPath": exe,
+		"version":  Version,
+		"versions": map[string]string{"node": strings.TrimPrefix(Version, "v"), "go": runtime.Version()},
+	})
+}
+
+// argv returns process.argv: the executable, the main module and the
+// arguments after it
+func (b *bindings) argv(args []*quickjs.Value) (*quickjs.Value, error) {
+	exe, _ := os.Executable()
+	argv := []string{exe}
+	if b.process.Main != "" {
+		argv = append(argv, b.process.Main)
+	}
+	return b.toJS(append(argv, b.process.Args...))
+}
+
+func (b *bindings) cwd(args []*quickjs.Value) (*quickjs.Value, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, fsError(err, "uv_cwd", "")
+	}
+	return b.ctx.String(dir), nil
+}
+
+func (b *bindings) chdir(args []*quickjs.Value) (*quickjs.Value, error) {
+	dir := b.arg(args, 0).String()
+	if err := os.Chdir(dir); err != nil {
+		return nil, fsError(err, "chdir", dir)
+	}
+	return b.ctx.NewUndefined(), nil
+}
+
+func (b *bindings) getenv(args []*quickjs.Value) (*quickjs.Value, error) {
+	value, ok := os.LookupEnv(b.arg(args, 0).String())
+	if !ok {
+		return b.ctx.NewUndefined(), nil
+	}
+	return b.ctx.String(value), nil
+}
+
+func (b *bindings) setenv(args []*quickjs.Value) (*quickjs.Value, error) {
+	if err := os.Setenv(b.arg(args, 0).String(), b.arg(args, 1).String()); err != nil {
+		return nil, fmt.Errorf("TypeError: %v", err)
+	}
+	return b.ctx.NewUndefined(), nil
+}
+
+func (b *bindings) unsetenv(args []*quickjs.Value) (*quickjs.Value, error) {
+	_ = os.Unsetenv(b.arg(args, 0).String())
+	return b.ctx.NewUndefined(), nil
+}
+
+// environ returns the names of the environment variables
+func (b *bindings) environ(args []*quickjs.Value) (*quickjs.Value, error) {
+	env := os.Environ()
+	names := make([]string, 0, len(env))
+	for _, entry := range env {
+		if name, _, ok := strings.Cut(entry, "="); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return b.toJS(names)
+}
+
+// exit records the exit code and throws to unwind the stack; the runtime
+// interrupts anything that catches it
+func (b *bindings) exit(args []*quickjs.Value) (*quickjs.Value, error) {
+	if code := b.arg(args, 0); !code.IsUndefined() {
+		b.process.exitCode.Store(code.ToInt32())
+	}
+	b.process.exiting.Store(true)
+	return nil, ErrExited
+}
+
+func (b *bindings) setExitCode(args []*quickjs.Value) (*quickjs.Value, error) {
+	b.process.exitCode.Store(b.arg(args, 0).ToInt32())
+	return b.ctx.NewUndefined(), nil
+}
+
+// hrtime returns the nanoseconds since the process started, as a BigInt
+func (b *bindings) hrtime(args []*quickjs.Value) (*quickjs.Value, error) {
+	return b.ctx.NewBigInt64(time.Since(b.process.start).Nanoseconds()), nil
+}
+
+func (b *bindings) uptime(args []*quickjs.Value) (*quickjs.Value, error) {
+	return b.ctx.NewFloat64(time.Since(b.process.start).Seconds()), nil
+}
+
+// write writes bytes to stdout (fd 1) or stderr (fd 2): write(fd, data)
+func (b *bindings) write(args []*quickjs.Value) (*quickjs.Value, error) {
+	out := os.Stdout
+	if b.arg(args, 0).ToInt32() == 2 {
+		out = os.Stderr
+	}
+	data, err := b.bytesArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := out.Write(data); err != nil {
+		return nil, fsError(err, "write")
+	}
+	return b.ctx.NewUndefined(), nil
+}
@@ -0,0 +1,120 @@
+package webassembly
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/buke/quickjs-go"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// valueTypes are the value types by the names the JavaScript API uses
+var valueTypes = map[string]api.ValueType{
+	"i32": api.ValueTypeI32,
+	"i64": api.ValueTypeI64,
+	"f32": api.ValueTypeF32,
+	"f64": api.ValueTypeF64,
+}
+
+var mask64 = new(big.Int).SetUint64(math.MaxUint64)
+
+func valueTypeName(typ api.ValueType) string {
+	for name, t := range valueTypes {
+		if t == typ {
+			return name
+		}
+	}
+	return api.ValueTypeName(typ)
+}
+
+func elementName(elem byte) string {
+	if elem == api.ValueTypeExternref {
+		return "externref"
+	}
+	return "anyfunc"
+}
+
+// toWasm converts a JavaScript value to the bits of a wasm value: numbers
+// for i32, f32 and f64, a BigInt for i64
+func toWasm(v *quickjs.Value, typ api.ValueType) (uint64, error) {
+	switch typ {
+	case api.ValueTypeI32:
+		if v.IsBigInt() {
+			return 0, typeError.errorf("cannot convert a BigInt to i32")
+		}
+		return uint64(uint32(v.ToInt32())), nil
+	case api.ValueTypeI64:
+		if !v.IsBigInt() {
+			return 0, typeError.errorf("i64 values must be a BigInt")
+		}
+		return new(big.Int).And(v.ToBigInt(), mask64).Uint64(), nil
+	case api.ValueTypeF32:
+		if v.IsBigInt() {
+			return 0, typeError.errorf("cannot convert a BigInt to f32")
+		}
+		return api.EncodeF32(float32(v.ToFloat64())), nil
+	case api.ValueTypeF64:
+		if v.IsBigInt() {
+			return 0, typeError.errorf("cannot convert a BigInt to f64")
+		}
+		return api.EncodeF64(v.ToFloat64()), nil
+	}
+	return 0, typeError.errorf("%s values cannot cross into JavaScript", api.ValueTypeName(typ))
+}
+
+// fromWasm converts the bits of a wasm value to a JavaScript value
+func fromWasm(ctx *quickjs.Context, bits uint64, typ api.ValueType) *quickjs.Value {
+	switch typ {
+	case api.ValueTypeI32:
+		return ctx.NewInt32(int32(uint32(bits)))
+	case api.ValueTypeI64:
+		return ctx.NewBigInt64(int64(bits))
+	case api.ValueTypeF32:
+		return ctx.NewFloat64(float64(api.DecodeF32(bits)))
+	case api.ValueTypeF64:
+		return ctx.NewFloat64(api.DecodeF64(bits))
+	}
+	return ctx.NewUndefined()
+}
+
+// resultsToJS returns nothing as undefined, one result as is and several
+// as an array
+func resultsToJS(ctx *quickjs.Context, results []uint64, types []api.ValueType) *quickjs.Value {
+	switch len(types) {
+	case 0:
+		return ctx.NewUndefined()
+	case 1:
+		return fromWasm(ctx, results[0], types[0])
+	}
+	array := ctx.ParseJSON("[]")
+	for i, typ := range types {
+		array.SetIdx(int64(i), fromWasm(ctx, results[i], typ))
+	}
+	return array
+}
+
+// resultsToWasm stores what a JavaScript import returned on the stack,
+// reading an array when the import has several results
+func resultsToWasm(result *quickjs.Value, types []api.ValueType, stack []uint64) error {
+	switch len(types) {
+	case 0:
+		return nil
+	case 1:
+		bits, err := toWasm(result, types[0])
+		stack[0] = bits
+		return err
+	}
+	if !result.IsArray() || result.Len() != int64(len(types)) {
+		return typeError.errorf("expected an array of %d results", len(types))
+	}
+	for i, typ := range types {
+		v := result.GetIdx(int64(i))
+		bits, err := toWasm(v, typ)
+		v.Free()
+		if err != nil {
+			return err
+		}
+		stack[i] = bits
+	}
+	return nil
+}
@@ -0,0 +1,663 @@
+// Package webassembly provides the WebAssembly JavaScript API, backed by the
+// wazero engine.
+//
+// wazero links a module's imports by module name within one runtime, so every
+// instance, and every memory, table or global created from JavaScript, is a
+// uniquely named wazero module. Before a module is instantiated its import
+// section is rewritten to point each import at the module that provides it:
+// a host module for JavaScript functions, or the module exporting the
+// memory, table, global or function that was passed in.
+package webassembly
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/buke/quickjs-go"
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/wasm"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+//go:embed webassembly.js
+var prelude string
+
+// opsName is the global the native half of the API is reachable under
+const opsName = "__edon_wasm"
+
+// Engine holds the wasm modules, instances and objects of a context
+type Engine struct {
+	ctx     *quickjs.Context
+	runtime wazero.Runtime
+	objects map[int32]any
+	next    int32
+	names   int
+}
+
+// compiledModule is a WebAssembly.Module
+type compiledModule struct {
+	binary []byte
+	info   *wasm.Module
+	// functions are the imported functions, in import order
+	functions []api.FunctionDefinition
+}
+
+// extern is an exported function, memory, table or global, addressed by
+// the wazero module exporting it and its export name
+type extern struct {
+	kind   wasm.ExternKind
+	module api.Module
+	name   string
+
+	function api.Function
+	memory   api.Memory
+	global   api.Global
+	// table is the helper module reading and growing a table
+	table api.Module
+}
+
+// Init installs the WebAssembly global in ctx
+func Init(ctx *quickjs.Context) (*Engine, error) {
+	cfg := wazero.NewRuntimeConfig().WithCoreFeatures(api.CoreFeaturesV2)
+	e := &Engine{
+		ctx:     ctx,
+		runtime: wazero.NewRuntimeWithConfig(context.Background(), cfg),
+		objects: make(map[int32]any),
+	}
+
+	ops := ctx.Object()
+	for name, op := range map[string]func([]*quickjs.Value) (*quickjs.Value, error){
+		"compile":     e.compile,
+		"instantiate": e.instantiate,
+		"call":        e.call,
+		"memoryNew":   e.memoryNew,
+		"memoryRead":  e.memoryRead,
+		"memoryWrite": e.memoryWrite,
+		"memoryGrow":  e.memoryGrow,
+		"tableNew":    e.tableNew,
+		"tableSize":   e.tableSize,
+		"tableGrow":   e.tableGrow,
+		"globalNew":   e.globalNew,
+		"globalGet":   e.globalGet,
+		"globalSet":   e.globalSet,
+	} {
+		ops.Set(name, ctx.Function(func(ctx *quickjs.Context, this *quickjs.Value, args []*quickjs.Value) *quickjs.Value {
+			result, err := op(args)
+			if err != nil {
+				return ctx.ThrowError(err)
+			}
+			return result
+		}))
+	}
+	ctx.Globals().Set(opsName, ops)
+
+	result := ctx.Eval(prelude, quickjs.EvalFileName("webassembly.js"))
+	defer result.Free()
+	if result.IsException() {
+		e.Close()
+		return nil, ctx.Exception()
+	}
+	return e, nil
+}
+
+// Close releases every module and instance
+func (e *Engine) Close() error {
+	return e.runtime.Close(context.Background())
+}
+
+// errorKind is the error type JavaScript throws for an engine error
+type errorKind string
+
+const (
+	compileError errorKind = "CompileError"
+	linkError    errorKind = "LinkError"
+	runtimeError errorKind = "RuntimeError"
+	typeError    errorKind = "TypeError"
+	rangeError   errorKind = "RangeError"
+)
+
+// engineError is an error tagged with its kind, which the JavaScript half
+// reads back from the message
+type engineError struct {
+	kind    errorKind
+	message string
+}
+
+func (e *engineError) Error() string {
+	return string(e.kind) + ": " + e.message
+}
+
+func (k errorKind) errorf(format string, args ...any) error {
+	return &engineError{kind: k, message: fmt.Sprintf(format, args...)}
+}
+
+// wrap tags an engine error, keeping only its first line: wazero appends
+// the wasm stack trace. Errors that are already tagged, such as a bad value
+// returned by an import, keep their kind.
+func (k errorKind) wrap(err error) error {
+	var tagged *engineError
+	if errors.As(err, &tagged) {
+		return tagged
+	}
+	message, _, _ := strings.Cut(err.Error(), "\n")
+	message = strings.TrimPrefix(message, "wasm error: ")
+	message = strings.TrimSuffix(message, " (recovered by wazero)")
+	return k.errorf("%s", message)
+}
+
+func (e *Engine) store(object any) int32 {
+	e.next++
+	e.objects[e.next] = object
+	return e.next
+}
+
+func (e *Engine) uniqueName(prefix string) string {
+	e.names++
+	return fmt.Sprintf("edon:%s#%d", prefix, e.names)
+}
+
+func (e *Engine) lookup(v *quickjs.Value) (any, error) {
+	object, ok := e.objects[v.ToInt32()]
+	if !ok {
+		return nil, typeError.errorf("invalid WebAssembly object")
+	}
+	return object, nil
+}
+
+func (e *Engine) lookupExtern(v *quickjs.Value, kind wasm.ExternKind) (*extern, error) {
+	object, err := e.lookup(v)
+	if err != nil {
+		return nil, err
+	}
+	ref, ok := object.(*extern)
+	if !ok || ref.kind != kind {
+		return nil, typeError.errorf("not a WebAssembly %s", kind)
+	}
+	return ref, nil
+}
+
+// instantiateGenerated instantiates one of the small modules that hold
+// standalone memories, tables and globals
+func (e *Engine) instantiateGenerated(prefix string, binary []byte) (api.Module, error) {
+	ctx := context.Background()
+	compiled, err := e.runtime.CompileModule(ctx, binary)
+	if err != nil {
+		return nil, err
+	}
+	return e.runtime.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(e.uniqueName(prefix)).WithStartFunctions())
+}
+
+// descriptor is how modules, instances and their exports are described to
+// the JavaScript half
+type descriptor struct {
+	Handle  int32         `json:"handle,omitempty"`
+	Module  string        `json:"module,omitempty"`
+	Name    string        `json:"name,omitempty"`
+	Kind    string        `json:"kind,omitempty"`
+	Type    string        `json:"type,omitempty"`
+	Mutable bool          `json:"mutable,omitempty"`
+	Imports []*descriptor `json:"imports,omitempty"`
+	Exports []*descriptor `json:"exports,omitempty"`
+}
+
+func (e *Engine) toJS(v any) (*quickjs.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return e.ctx.ParseJSON(string(data)), nil
+}
+
+// compile validates a binary module: compile(bytes)
+func (e *Engine) compile(args []*quickjs.Value) (*quickjs.Value, error) {
+	binary, err := e.argBytes(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	info, err := wasm.Parse(binary)
+	if err != nil {
+		return nil, compileError.wrap(err)
+	}
+	compiled, err := e.runtime.CompileModule(context.Background(), binary)
+	if err != nil {
+		return nil, compileError.wrap(err)
+	}
+	defer compiled.Close(context.Background())
+
+	module := &compiledModule{binary: binary, info: info, functions: compiled.ImportedFunctions()}
+	desc := &descriptor{Handle: e.store(module), Imports: make([]*descriptor, 0), Exports: make([]*descriptor, 0)}
+	for _, imp := range info.Imports {
+		desc.Imports = append(desc.Imports, &descriptor{Module: imp.Module, Name: imp.Name, Kind: imp.Kind.String()})
+	}
+	for _, exp := range info.Exports {
+		desc.Exports = append(desc.Exports, &descriptor{Name: exp.Name, Kind: exp.Kind.String()})
+	}
+	return e.toJS(desc)
+}
+
+// instantiate links and instantiates a module: instantiate(module, links).
+// links has an entry per import: {fn: index} for JavaScript functions,
+// {ref: handle} for wasm objects and {value: v} for plain global values.
+func (e *Engine) instantiate(args []*quickjs.Value) (*quickjs.Value, error) {
+	if len(args) < 2 {
+		return nil, typeError.errorf("instantiate needs a module and its imports")
+	}
+	object, err := e.lookup(args[0])
+	if err != nil {
+		return nil, err
+	}
+	module, ok := object.(*compiledModule)
+	if !ok {
+		return nil, typeError.errorf("not a WebAssembly.Module")
+	}
+
+	ctx := context.Background()
+	hostName := e.uniqueName("host")
+	host := e.runtime.NewHostModuleBuilder(hostName)
+	hosted := false
+	targets := make([][2]string, len(module.info.Imports))
+	functions := 0
+
+	for i, imp := range module.info.Imports {
+		link := args[1].GetIdx(int64(i))
+		target, err := e.link(host, hostName, &hosted, imp, link, module, &functions, i)
+		link.Free()
+		if err != nil {
+			return nil, err
+		}
+		targets[i] = target
+	}
+	if hosted {
+		if _, err := host.Instantiate(ctx); err != nil {
+			return nil, linkError.wrap(err)
+		}
+	}
+
+	compiled, err := e.runtime.CompileModule(ctx, module.info.Relink(targets))
+	if err != nil {
+		return nil, linkError.wrap(err)
+	}
+	instance, err := e.runtime.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(e.uniqueName("instance")).WithStartFunctions())
+	if err != nil {
+		if strings.Contains(err.Error(), "wasm error") {
+			return nil, runtimeError.wrap(err)
+		}
+		return nil, linkError.wrap(err)
+	}
+
+	exports := make([]*descriptor, 0, len(module.info.Exports))
+	for _, exp := range module.info.Exports {
+		desc, err := e.export(instance, module.info, exp)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, desc)
+	}
+	return e.toJS(exports)
+}
+
+// link works out which wazero module provides the import and under what
+// name, adding JavaScript functions to the host module
+func (e *Engine) link(host wazero.HostModuleBuilder, hostName string, hosted *bool, imp wasm.Import, link *quickjs.Value, module *compiledModule, functions *int, index int) ([2]string, error) {
+	fn, ref, value := link.Get("fn"), link.Get("ref"), link.Get("value")
+	defer fn.Free()
+	defer ref.Free()
+	defer value.Free()
+
+	where := fmt.Sprintf("import %s.%s", imp.Module, imp.Name)
+	var def api.FunctionDefinition
+	if imp.Kind == wasm.KindFunction {
+		def = module.functions[*functions]
+		*functions++
+	}
+
+	switch {
+	case !fn.IsUndefined():
+		if imp.Kind != wasm.KindFunction {
+			return [2]string{}, linkError.errorf("%s must be a %s", where, imp.Kind)
+		}
+		name := fmt.Sprintf("f%d", index)
+		host.NewFunctionBuilder().
+			WithGoModuleFunction(e.hostFunction(fn.ToInt32(), def.ParamTypes(), def.ResultTypes()), def.ParamTypes(), def.ResultTypes()).
+			Export(name)
+		*hosted = true
+		return [2]string{hostName, name}, nil
+
+	case !ref.IsUndefined():
+		object, err := e.lookup(ref)
+		if err != nil {
+			return [2]string{}, err
+		}
+		target, ok := object.(*extern)
+		if !ok || target.kind != imp.Kind {
+			return [2]string{}, linkError.errorf("%s must be a %s", where, imp.Kind)
+		}
+		return [2]string{target.module.Name(), target.name}, nil
+
+	case !value.IsUndefined() && imp.Kind == wasm.KindGlobal:
+		if imp.Global.Mutable {
+			return [2]string{}, linkError.errorf("%s is mutable and must be a WebAssembly.Global", where)
+		}
+		bits, err := toWasm(value, imp.Global.Value)
+		if err != nil {
+			return [2]string{}, linkError.errorf("%s: %v", where, err)
+		}
+		global, err := e.instantiateGenerated("global", wasm.GlobalModule(imp.Global, bits))
+		if err != nil {
+			return [2]string{}, linkError.wrap(err)
+		}
+		return [2]string{global.Name(), wasm.GlobalExport}, nil
+	}
+	return [2]string{}, linkError.errorf("%s must be a %s", where, imp.Kind)
+}
+
+// hostFunction calls the JavaScript function registered at index through
+// the callImport op of the JavaScript half
+func (e *Engine) hostFunction(index int32, params, results []api.ValueType) api.GoModuleFunction {
+	return api.GoModuleFunc(func(_ context.Context, _ api.Module, stack []uint64) {
+		ops := e.ctx.Globals().Get(opsName)
+		defer ops.Free()
+		callImport := ops.Get("callImport")
+		defer callImport.Free()
+
+		args := make([]*quickjs.Value, 0, len(params)+1)
+		args = append(args, e.ctx.NewInt32(index))
+		for i, typ := range params {
+			args = append(args, fromWasm(e.ctx, stack[i], typ))
+		}
+		result := callImport.Execute(e.ctx.Undefined(), args...)
+		for _, arg := range args {
+			arg.Free()
+		}
+		defer result.Free()
+
+		// The JavaScript half keeps the exception to rethrow it as is
+		if result.IsException() {
+			panic(runtimeError.errorf("%v", e.ctx.Exception()))
+		}
+		if err := resultsToWasm(result, results, stack); err != nil {
+			panic(err)
+		}
+	})
+}
+
+// export describes an export of instance, registering it as an extern
+func (e *Engine) export(instance api.Module, info *wasm.Module, exp wasm.Export) (*descriptor, error) {
+	ref := &extern{kind: exp.Kind, module: instance, name: exp.Name}
+	desc := &descriptor{Name: exp.Name, Kind: exp.Kind.String()}
+
+	switch exp.Kind {
+	case wasm.KindFunction:
+		ref.function = instance.ExportedFunction(exp.Name)
+	case wasm.KindMemory:
+		ref.memory = instance.ExportedMemory(exp.Name)
+		// A memory that was imported and exported again keeps its object
+		if handle, ok := e.findMemory(ref.memory); ok {
+			desc.Handle = handle
+			return desc, nil
+		}
+	case wasm.KindGlobal:
+		ref.global = instance.ExportedGlobal(exp.Name)
+		desc.Type = valueTypeName(ref.global.Type())
+		_, desc.Mutable = ref.global.(api.MutableGlobal)
+	case wasm.KindTable:
+		table, ok := info.TableType(exp.Index)
+		if !ok {
+			return nil, linkError.errorf("export %s names a missing table", exp.Name)
+		}
+		helper, err := e.instantiateGenerated("table", wasm.TableHelperModule(instance.Name(), exp.Name, table.Elem))
+		if err != nil {
+			return nil, linkError.wrap(err)
+		}
+		ref.table = helper
+		desc.Type = elementName(table.Elem)
+	}
+	desc.Handle = e.store(ref)
+	return desc, nil
+}
+
+// findMemory returns the handle of an extern for the same memory as memory
+func (e *Engine) findMemory(memory api.Memory) (int32, bool) {
+	for handle, object := range e.objects {
+		if ref, ok := object.(*extern); ok && ref.kind == wasm.KindMemory && sameMemory(ref.memory, memory) {
+			return handle, true
+		}
+	}
+	return 0, false
+}
+
+// sameMemory compares the storage of two memories, which wazero hands out
+// under different names when a memory is imported
+func sameMemory(a, b api.Memory) bool {
+	if a.Size() == 0 || a.Size() != b.Size() {
+		return false
+	}
+	x, _ := a.Read(0, 1)
+	y, _ := b.Read(0, 1)
+	return &x[0] == &y[0]
+}
+
+// call calls an exported function: call(handle, args)
+func (e *Engine) call(args []*quickjs.Value) (*quickjs.Value, error) {
+	if len(args) < 2 {
+		return nil, typeError.errorf("call needs a function and its arguments")
+	}
+	ref, err := e.lookupExtern(args[0], wasm.KindFunction)
+	if err != nil {
+		return nil, err
+	}
+
+	def := ref.function.Definition()
+	params := make([]uint64, len(def.ParamTypes()))
+	for i, typ := range def.ParamTypes() {
+		arg := args[1].GetIdx(int64(i))
+		params[i], err = toWasm(arg, typ)
+		arg.Free()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results, err := ref.function.Call(context.Background(), params...)
+	if err != nil {
+		return nil, runtimeError.wrap(err)
+	}
+	return resultsToJS(e.ctx, results, def.ResultTypes()), nil
+}
+
+// memoryNew creates a memory: memoryNew(initial, maximum)
+func (e *Engine) memoryNew(args []*quickjs.Value) (*quickjs.Value, error) {
+	limits, err := e.argLimits(args)
+	if err != nil {
+		return nil, err
+	}
+	if limits.Min > 65536 || (limits.HasMax && limits.Max > 65536) {
+		return nil, rangeError.errorf("memory size is limited to 65536 pages")
+	}
+	instance, err := e.instantiateGenerated("memory", wasm.MemoryModule(limits))
+	if err != nil {
+		return nil, rangeError.wrap(err)
+	}
+	ref := &extern{kind: wasm.KindMemory, module: instance, name: wasm.MemoryExport, memory: instance.ExportedMemory(wasm.MemoryExport)}
+	return e.ctx.NewInt32(e.store(ref)), nil
+}
+
+// memoryRead copies a memory into a new ArrayBuffer: memoryRead(handle)
+func (e *Engine) memoryRead(args []*quickjs.Value) (*quickjs.Value, error) {
+	ref, err := e.lookupExtern(e.arg(args, 0), wasm.KindMemory)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := ref.memory.Read(0, ref.memory.Size())
+	return e.ctx.NewArrayBuffer(data), nil
+}
+
+// memoryWrite copies an ArrayBuffer into a memory: memoryWrite(handle, buffer)
+func (e *Engine) memoryWrite(args []*quickjs.Value) (*quickjs.Value, error) {
+	ref, err := e.lookupExtern(e.arg(args, 0), wasm.KindMemory)
+	if err != nil {
+		return nil, err
+	}
+	data, err := e.argBytes(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	ref.memory.Write(0, data[:min(len(data), int(ref.memory.Size()))])
+	return e.ctx.NewUndefined(), nil
+}
+
+// memoryGrow grows a memory by a number of pages, returning the previous
+// size or -1: memoryGrow(handle, delta)
+func (e *Engine) memoryGrow(args []*quickjs.Value) (*quickjs.Value, error) {
+	ref, err := e.lookupExtern(e.arg(args, 0), wasm.KindMemory)
+	if err != nil {
+		return nil, err
+	}
+	previous, ok := ref.memory.Grow(e.arg(args, 1).ToUint32())
+	if !ok {
+		return e.ctx.NewInt32(-1), nil
+	}
+	return e.ctx.NewInt64(int64(previous)), nil
+}
+
+// tableNew creates a table: tableNew(element, initial, maximum)
+func (e *Engine) tableNew(args []*quickjs.Value) (*quickjs.Value, error) {
+	var elem byte
+	switch e.arg(args, 0).String() {
+	case "anyfunc", "funcref":
+		elem = wasm.FuncRef
+	case "externref":
+		elem = wasm.ExternRef
+	default:
+		return nil, typeError.errorf("unsupported table element type %q", e.arg(args, 0).String())
+	}
+	limits, err := e.argLimits(args[min(1, len(args)):])
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := e.instantiateGenerated("table", wasm.TableModule(wasm.TableType{Elem: elem, Limits: limits}))
+	if err != nil {
+		return nil, rangeError.wrap(err)
+	}
+	helper, err := e.instantiateGenerated("table", wasm.TableHelperModule(instance.Name(), wasm.TableExport, elem))
+	if err != nil {
+		return nil, rangeError.wrap(err)
+	}
+	ref := &extern{kind: wasm.KindTable, module: instance, name: wasm.TableExport, table: helper}
+	return e.ctx.NewInt32(e.store(ref)), nil
+}
+
+// tableSize returns the length of a table: tableSize(handle)
+func (e *Engine) tableSize(args []*quickjs.Value) (*quickjs.Value, error) {
+	ref, err := e.lookupExtern(e.arg(args, 0), wasm.KindTable)
+	if err != nil {
+		return nil, err
+	}
+	results, err := ref.table.ExportedFunction(wasm.SizeExport).Call(context.Background())
+	if err != nil {
+		return nil, runtimeError.wrap(err)
+	}
+	return e.ctx.NewInt64(int64(uint32(results[0]))), nil
+}
+
+// tableGrow grows a table with null elements, returning the previous length
+// or -1: tableGrow(handle, delta)
+func (e *Engine) tableGrow(args []*quickjs.Value) (*quickjs.Value, error) {
+	ref, err := e.lookupExtern(e.arg(args, 0), wasm.KindTable)
+	if err != nil {
+		return nil, err
+	}
+	results, err := ref.table.ExportedFunction(wasm.GrowExport).Call(context.Background(), uint64(e.arg(args, 1).ToUint32()))
+	if err != nil {
+		return nil, runtimeError.wrap(err)
+	}
+	return e.ctx.NewInt32(int32(uint32(results[0]))), nil
+}
+
+// globalNew creates a global: globalNew(type, mutable, value)
+func (e *Engine) globalNew(args []*quickjs.Value) (*quickjs.Value, error) {
+	typ, ok := valueTypes[e.arg(args, 0).String()]
+	if !ok {
+		return nil, typeError.errorf("unsupported global type %q", e.arg(args, 0).String())
+	}
+	bits, err := toWasm(e.arg(args, 2), typ)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := e.instantiateGenerated("global", wasm.GlobalModule(wasm.GlobalType{Value: typ, Mutable: e.arg(args, 1).ToBool()}, bits))
+	if err != nil {
+		return nil, linkError.wrap(err)
+	}
+	ref := &extern{kind: wasm.KindGlobal, module: instance, name: wasm.GlobalExport, global: instance.ExportedGlobal(wasm.GlobalExport)}
+	return e.ctx.NewInt32(e.store(ref)), nil
+}
+
+// globalGet reads a global: globalGet(handle)
+func (e *Engine) globalGet(args []*quickjs.Value) (*quickjs.Value, error) {
+	ref, err := e.lookupExtern(e.arg(args, 0), wasm.KindGlobal)
+	if err != nil {
+		return nil, err
+	}
+	return fromWasm(e.ctx, ref.global.Get(), ref.global.Type()), nil
+}
+
+// globalSet writes a mutable global: globalSet(handle, value)
+func (e *Engine) globalSet(args []*quickjs.Value) (*quickjs.Value, error) {
+	ref, err := e.lookupExtern(e.arg(args, 0), wasm.KindGlobal)
+	if err != nil {
+		return nil, err
+	}
+	global, ok := ref.global.(api.MutableGlobal)
+	if !ok {
+		return nil, typeError.errorf("cannot set an immutable global")
+	}
+	bits, err := toWasm(e.arg(args, 1), global.Type())
+	if err != nil {
+		return nil, err
+	}
+	global.Set(bits)
+	return e.ctx.NewUndefined(), nil
+}
+
+// arg returns args[i], or undefined when it is missing
+func (e *Engine) arg(args []*quickjs.Value, i int) *quickjs.Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return e.ctx.NewUndefined()
+}
+
+// argBytes copies the ArrayBuffer at args[i]
+func (e *Engine) argBytes(args []*quickjs.Value, i int) ([]byte, error) {
+	v := e.arg(args, i)
+	if !v.IsByteArray() {
+		return nil, typeError.errorf("expected an ArrayBuffer")
+	}
+	data, err := v.ToByteArray(uint(v.ByteLen()))
+	if err != nil {
+		return nil, typeError.wrap(err)
+	}
+	return data, nil
+}
+
+// argLimits reads initial and maximum sizes, where a negative maximum means
+// there is none
+func (e *Engine) argLimits(args []*quickjs.Value) (wasm.Limits, error) {
+	initial, maximum := e.arg(args, 0), e.arg(args, 1)
+	if !initial.IsNumber() || initial.ToFloat64() < 0 || initial.ToFloat64() > 0xffffffff {
+		return wasm.Limits{}, typeError.errorf("initial size must be a non-negative integer")
+	}
+	limits := wasm.Limits{Min: initial.ToUint32()}
+	if maximum.IsNumber() && maximum.ToFloat64() >= 0 {
+		limits.Max, limits.HasMax = maximum.ToUint32(), true
+		if limits.Max < limits.Min {
+			return wasm.Limits{}, rangeError.errorf("maximum size is below the initial size")
+		}
+	}
+	return limits, nil
+}
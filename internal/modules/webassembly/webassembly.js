@@ -0,0 +1,453 @@
+// The JavaScript half of the WebAssembly API. Modules, instances and their
+// exports live in the Go engine and are referred to by handle; this file
+// wraps them in the standard classes and keeps memory buffers in sync.
+((ops) => {
+  "use strict";
+
+  // The ops are only for this file
+  delete globalThis.__edon_wasm;
+  Object.defineProperty(globalThis, "__edon_wasm", { value: ops });
+
+  class CompileError extends Error {
+    constructor(message) {
+      super(message);
+      this.name = "CompileError";
+    }
+  }
+
+  class LinkError extends Error {
+    constructor(message) {
+      super(message);
+      this.name = "LinkError";
+    }
+  }
+
+  class RuntimeError extends Error {
+    constructor(message) {
+      super(message);
+      this.name = "RuntimeError";
+    }
+  }
+
+  const errors = { CompileError, LinkError, RuntimeError, TypeError, RangeError };
+
+  // pending holds an exception thrown by an imported function while wasm
+  // unwinds, so it reaches the caller unchanged
+  let pending = null;
+
+  function native(op, ...args) {
+    try {
+      return ops[op](...args);
+    } catch (e) {
+      if (pending !== null) {
+        const { error } = pending;
+        pending = null;
+        throw error;
+      }
+      const match = /^(\w+): ([\s\S]*)$/.exec(e && e.message);
+      if (match && errors[match[1]]) {
+        throw new errors[match[1]](match[2]);
+      }
+      throw e;
+    }
+  }
+
+  // internal marks constructor calls that wrap an existing handle
+  const internal = Symbol("internal");
+  const handles = new WeakMap();
+  const modules = new WeakMap();
+  const memories = new Map();
+  const exportedFunctions = new WeakMap();
+  const importedFunctions = [];
+  const importIndexes = new Map();
+
+  // Memories whose buffer has been handed out are copied into wasm before
+  // it runs and back out after, since an ArrayBuffer cannot share Go memory
+  const live = new Map();
+
+  function syncToWasm() {
+    for (const sync of live.values()) sync.toWasm();
+  }
+
+  function syncFromWasm() {
+    for (const sync of live.values()) sync.fromWasm();
+  }
+
+  function detach(buffer) {
+    if (typeof buffer.transfer === "function") {
+      buffer.transfer();
+    }
+  }
+
+  ops.callImport = (index, ...args) => {
+    syncFromWasm();
+    try {
+      const result = importedFunctions[index](...args);
+      syncToWasm();
+      return result;
+    } catch (error) {
+      pending = { error };
+      throw error;
+    }
+  };
+
+  function toBuffer(source, where) {
+    if (source instanceof ArrayBuffer) {
+      return source;
+    }
+    if (ArrayBuffer.isView(source)) {
+      return source.buffer.slice(source.byteOffset, source.byteOffset + source.byteLength);
+    }
+    throw new TypeError(`${where}: Argument 0 must be a buffer source`);
+  }
+
+  function toSize(value, where) {
+    const size = Number(value);
+    if (!Number.isFinite(size) || size < 0 || size > 0xffffffff) {
+      throw new TypeError(`${where} must be a non-negative integer`);
+    }
+    return Math.floor(size);
+  }
+
+  class Module {
+    constructor(bytes) {
+      if (new.target === undefined) {
+        throw new TypeError("WebAssembly.Module must be invoked with 'new'");
+      }
+      const info = native("compile", toBuffer(bytes, "WebAssembly.Module()"));
+      modules.set(this, {
+        handle: info.handle,
+        imports: (info.imports || []).map(({ module = "", name = "", kind }) => ({ module, name, kind })),
+        exports: (info.exports || []).map(({ name = "", kind }) => ({ name, kind })),
+      });
+    }
+
+    static imports(module) {
+      return moduleInfo(module, "WebAssembly.Module.imports()").imports.map((imp) => ({ ...imp }));
+    }
+
+    static exports(module) {
+      return moduleInfo(module, "WebAssembly.Module.exports()").exports.map((exp) => ({ ...exp }));
+    }
+
+    static customSections(module) {
+      moduleInfo(module, "WebAssembly.Module.customSections()");
+      return [];
+    }
+  }
+
+  function moduleInfo(module, where) {
+    const info = modules.get(module);
+    if (info === undefined) {
+      throw new TypeError(`${where}: Argument 0 must be a WebAssembly.Module`);
+    }
+    return info;
+  }
+
+  class Instance {
+    constructor(module, importObject) {
+      if (new.target === undefined) {
+        throw new TypeError("WebAssembly.Instance must be invoked with 'new'");
+      }
+      const info = moduleInfo(module, "WebAssembly.Instance()");
+      if (info.imports.length > 0 && (importObject === null || typeof importObject !== "object")) {
+        throw new TypeError("WebAssembly.Instance(): Imports argument must be present and must be an object");
+      }
+
+      const links = info.imports.map((imp, i) => link(imp, i, importObject));
+      syncToWasm();
+      let descriptors;
+      try {
+        descriptors = native("instantiate", info.handle, links);
+      } finally {
+        syncFromWasm();
+      }
+
+      const exports = Object.create(null);
+      for (const desc of descriptors) {
+        exports[desc.name || ""] = wrapExport(desc);
+      }
+      Object.defineProperty(this, "exports", { value: Object.freeze(exports), enumerable: true });
+    }
+  }
+
+  function link(imp, index, importObject) {
+    const where = `WebAssembly.Instance(): Import #${index} "${imp.module}" "${imp.name}"`;
+    const namespace = importObject[imp.module];
+    if (namespace === null || (typeof namespace !== "object" && typeof namespace !== "function")) {
+      throw new TypeError(`${where}: module is not an object or function`);
+    }
+    const value = namespace[imp.name];
+
+    switch (imp.kind) {
+      case "function": {
+        if (typeof value !== "function") {
+          throw new LinkError(`${where}: function import requires a callable`);
+        }
+        if (exportedFunctions.has(value)) {
+          return { ref: exportedFunctions.get(value) };
+        }
+        if (!importIndexes.has(value)) {
+          importIndexes.set(value, importedFunctions.push(value) - 1);
+        }
+        return { fn: importIndexes.get(value) };
+      }
+      case "memory":
+        if (!(value instanceof Memory)) {
+          throw new LinkError(`${where}: memory import must be a WebAssembly.Memory object`);
+        }
+        return { ref: handles.get(value) };
+      case "table":
+        if (!(value instanceof Table)) {
+          throw new LinkError(`${where}: table import requires a WebAssembly.Table`);
+        }
+        return { ref: handles.get(value) };
+      case "global":
+        if (value instanceof Global) {
+          return { ref: handles.get(value) };
+        }
+        if (typeof value === "number" || typeof value === "bigint") {
+          return { value };
+        }
+        throw new LinkError(`${where}: global import must be a number, valid Wasm reference, or WebAssembly.Global object`);
+    }
+    throw new LinkError(`${where}: unsupported import kind ${imp.kind}`);
+  }
+
+  function wrapExport(desc) {
+    switch (desc.kind) {
+      case "function":
+        return wrapFunction(desc.handle, desc.name || "");
+      case "memory":
+        return memories.get(desc.handle) || new Memory(desc.handle, internal);
+      case "table":
+        return new Table(desc, internal);
+      case "global":
+        return new Global(desc, internal);
+    }
+    return undefined;
+  }
+
+  function wrapFunction(handle, name) {
+    const fn = {
+      [name](...args) {
+        syncToWasm();
+        try {
+          return native("call", handle, args);
+        } finally {
+          syncFromWasm();
+        }
+      },
+    }[name];
+    exportedFunctions.set(fn, handle);
+    return fn;
+  }
+
+  class Memory {
+    #handle;
+    #buffer = null;
+
+    constructor(descriptor, token) {
+      if (new.target === undefined) {
+        throw new TypeError("WebAssembly.Memory must be invoked with 'new'");
+      }
+      if (token === internal) {
+        this.#handle = descriptor;
+      } else {
+        if (descriptor === null || typeof descriptor !== "object") {
+          throw new TypeError("WebAssembly.Memory(): Argument 0 must be a memory descriptor");
+        }
+        const initial = toSize(descriptor.initial, "WebAssembly.Memory(): Property 'initial'");
+        const maximum = descriptor.maximum === undefined ? -1 : toSize(descriptor.maximum, "WebAssembly.Memory(): Property 'maximum'");
+        this.#handle = native("memoryNew", initial, maximum);
+      }
+      handles.set(this, this.#handle);
+      memories.set(this.#handle, this);
+    }
+
+    get buffer() {
+      if (this.#buffer === null) {
+        this.#buffer = native("memoryRead", this.#handle);
+        live.set(this, {
+          toWasm: () => native("memoryWrite", this.#handle, this.#buffer),
+          fromWasm: () => this.#refresh(),
+        });
+      }
+      return this.#buffer;
+    }
+
+    grow(delta) {
+      delta = toSize(delta, "WebAssembly.Memory.grow(): Argument 0");
+      if (this.#buffer !== null) {
+        native("memoryWrite", this.#handle, this.#buffer);
+      }
+      const previous = native("memoryGrow", this.#handle, delta);
+      if (previous < 0) {
+        throw new RangeError("WebAssembly.Memory.grow(): Maximum memory size exceeded");
+      }
+      if (this.#buffer !== null) {
+        detach(this.#buffer);
+        this.#buffer = native("memoryRead", this.#handle);
+      }
+      return previous;
+    }
+
+    #refresh() {
+      const current = native("memoryRead", this.#handle);
+      if (current.byteLength === this.#buffer.byteLength) {
+        new Uint8Array(this.#buffer).set(new Uint8Array(current));
+        return;
+      }
+      // wasm grew the memory
+      detach(this.#buffer);
+      this.#buffer = current;
+    }
+  }
+
+  class Table {
+    #handle;
+
+    constructor(descriptor, token) {
+      if (new.target === undefined) {
+        throw new TypeError("WebAssembly.Table must be invoked with 'new'");
+      }
+      if (token === internal) {
+        this.#handle = descriptor.handle;
+      } else {
+        if (descriptor === null || typeof descriptor !== "object") {
+          throw new TypeError("WebAssembly.Table(): Argument 0 must be a table descriptor");
+        }
+        const initial = toSize(descriptor.initial, "WebAssembly.Table(): Property 'initial'");
+        const maximum = descriptor.maximum === undefined ? -1 : toSize(descriptor.maximum, "WebAssembly.Table(): Property 'maximum'");
+        this.#handle = native("tableNew", String(descriptor.element), initial, maximum);
+      }
+      handles.set(this, this.#handle);
+    }
+
+    get length() {
+      return native("tableSize", this.#handle);
+    }
+
+    grow(delta) {
+      const previous = native("tableGrow", this.#handle, toSize(delta, "WebAssembly.Table.grow(): Argument 0"));
+      if (previous < 0) {
+        throw new RangeError("WebAssembly.Table.grow(): failed to grow table");
+      }
+      return previous;
+    }
+
+    get() {
+      throw new TypeError("WebAssembly.Table.get() is not supported");
+    }
+
+    set() {
+      throw new TypeError("WebAssembly.Table.set() is not supported");
+    }
+  }
+
+  class Global {
+    #handle;
+    #mutable;
+
+    constructor(descriptor, value) {
+      if (new.target === undefined) {
+        throw new TypeError("WebAssembly.Global must be invoked with 'new'");
+      }
+      if (value === internal) {
+        this.#handle = descriptor.handle;
+        this.#mutable = !!descriptor.mutable;
+      } else {
+        if (descriptor === null || typeof descriptor !== "object") {
+          throw new TypeError("WebAssembly.Global(): Argument 0 must be a global descriptor");
+        }
+        const type = String(descriptor.value);
+        if (value === undefined) {
+          value = type === "i64" ? 0n : 0;
+        }
+        this.#mutable = !!descriptor.mutable;
+        this.#handle = native("globalNew", type, this.#mutable, value);
+      }
+      handles.set(this, this.#handle);
+    }
+
+    get value() {
+      return native("globalGet", this.#handle);
+    }
+
+    set value(value) {
+      if (!this.#mutable) {
+        throw new TypeError("WebAssembly.Global: Can't set the value of an immutable global");
+      }
+      native("globalSet", this.#handle, value);
+    }
+
+    valueOf() {
+      return this.value;
+    }
+  }
+
+  async function compile(bytes) {
+    return new Module(bytes);
+  }
+
+  async function instantiate(source, importObject) {
+    if (source instanceof Module) {
+      return new Instance(source, importObject);
+    }
+    const module = new Module(source);
+    return { module, instance: new Instance(module, importObject) };
+  }
+
+  function validate(bytes) {
+    try {
+      new Module(bytes);
+      return true;
+    } catch (e) {
+      if (e instanceof CompileError) {
+        return false;
+      }
+      throw e;
+    }
+  }
+
+  async function compileStreaming(source) {
+    const response = await source;
+    if (response === null || typeof response !== "object" || typeof response.arrayBuffer !== "function") {
+      throw new TypeError("WebAssembly.compileStreaming(): Argument 0 must be a Response");
+    }
+    const type = response.headers && typeof response.headers.get === "function" ? response.headers.get("content-type") : null;
+    if (type !== null && type !== undefined && !/^application\/wasm\s*(;|$)/i.test(type)) {
+      throw new TypeError(`WebAssembly.compileStreaming(): Incorrect response MIME type "${type}". Expected 'application/wasm'.`);
+    }
+    if (response.ok === false) {
+      throw new TypeError(`WebAssembly.compileStreaming(): HTTP status code is not ok: ${response.status}`);
+    }
+    return new Module(await response.arrayBuffer());
+  }
+
+  async function instantiateStreaming(source, importObject) {
+    const module = await compileStreaming(source);
+    return { module, instance: new Instance(module, importObject) };
+  }
+
+  const WebAssembly = {};
+  for (const [name, value] of Object.entries({
+    Module,
+    Instance,
+    Memory,
+    Table,
+    Global,
+    CompileError,
+    LinkError,
+    RuntimeError,
+    compile,
+    instantiate,
+    validate,
+    compileStreaming,
+    instantiateStreaming,
+  })) {
+    Object.defineProperty(WebAssembly, name, { value, writable: true, configurable: true });
+  }
+  Object.defineProperty(WebAssembly, Symbol.toStringTag, { value: "WebAssembly", configurable: true });
+  Object.defineProperty(globalThis, "WebAssembly", { value: WebAssembly, writable: true, configurable: true });
+})(globalThis.__edon_wasm);
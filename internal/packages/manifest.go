@@ -0,0 +1,315 @@
+package packages
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// Dependency fields of package.json that edon add and edon remove edit
+const (
+	Dependencies    = "dependencies"
+	DevDependencies = "devDependencies"
+)
+
+// SetDependency returns package.json data with name set to value in the
+// given dependency object. Everything else is kept byte for byte: an
+// existing entry has its value replaced, a new one is inserted in order
+// when the object is sorted and appended otherwise, and a missing object
+// is added at the end, all indented like their surroundings.
+func SetDependency(data []byte, field, name, value string) ([]byte, error) {
+	root, err := parseObject(data)
+	if err != nil {
+		return nil, err
+	}
+	entry := quote(name) + root.separator(data) + quote(value)
+
+	i := root.index(field)
+	if i < 0 {
+		// The new object is indented one level deeper than its key
+		line := root.inner(data)
+		inner := line + strings.TrimPrefix(line, root.indent(data))
+		object := "{" + inner + entry + line + "}"
+		return root.insert(data, len(root.members), quote(field)+root.separator(data)+object), nil
+	}
+
+	m := root.members[i]
+	deps, err := parseObjectAt(data, m.valueStart)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidPackageConfig, fmt.Sprintf("%s: %v", field, err))
+	}
+	deps.unit = root.unit
+	if j := deps.index(name); j >= 0 {
+		d := deps.members[j]
+		return splice(data, d.valueStart, d.valueEnd, quote(value)), nil
+	}
+
+	// Keep a sorted object sorted, as npm does
+	at := len(deps.members)
+	if deps.sorted() {
+		at, _ = slices.BinarySearchFunc(deps.members, name, func(m member, name string) int {
+			return strings.Compare(m.name, name)
+		})
+	}
+	return deps.insert(data, at, entry), nil
+}
+
+// RemoveDependency returns package.json data without name in the given
+// dependency object, and whether it was there
+func RemoveDependency(data []byte, field, name string) ([]byte, bool, error) {
+	root, err := parseObject(data)
+	if err != nil {
+		return nil, false, err
+	}
+	i := root.index(field)
+	if i < 0 {
+		return data, false, nil
+	}
+	deps, err := parseObjectAt(data, root.members[i].valueStart)
+	if err != nil {
+		return nil, false, errors.Wrap(errors.ErrInvalidPackageConfig, fmt.Sprintf("%s: %v", field, err))
+	}
+	j := deps.index(name)
+	if j < 0 {
+		return data, false, nil
+	}
+	return deps.remove(data, j), true, nil
+}
+
+// object is the layout of a JSON object within a document
+type object struct {
+	// start is the offset of "{", end the offset just past "}"
+	start, end int
+	members    []member
+	// unit is one level of indentation, as the document indents its
+	// top-level members
+	unit string
+}
+
+// member is a key and value; the offsets are of the opening quote of the
+// key and the value's first byte, and just past both
+type member struct {
+	name                 string
+	keyStart, keyEnd     int
+	valueStart, valueEnd int
+}
+
+func parseObject(data []byte) (*object, error) {
+	start := skipSpace(data, 0)
+	obj, err := parseObjectAt(data, start)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidPackageConfig, err.Error())
+	}
+	if rest := skipSpace(data, obj.end); rest != len(data) {
+		return nil, errors.Wrap(errors.ErrInvalidPackageConfig, fmt.Sprintf("unexpected data at offset %d", rest))
+	}
+	obj.unit = "  "
+	if len(obj.members) > 0 {
+		if unit := strings.TrimPrefix(obj.inner(data), obj.indent(data)); unit != "" && strings.Trim(unit, " \t") == "" {
+			obj.unit = unit
+		}
+	}
+	return obj, nil
+}
+
+// parseObjectAt scans the object starting at offset start
+func parseObjectAt(data []byte, start int) (*object, error) {
+	if start >= len(data) || data[start] != '{' {
+		return nil, fmt.Errorf("expected an object at offset %d", start)
+	}
+	obj := &object{start: start}
+	pos := skipSpace(data, start+1)
+	if pos < len(data) && data[pos] == '}' {
+		obj.end = pos + 1
+		return obj, nil
+	}
+	for {
+		m := member{keyStart: pos}
+		end, err := scanValue(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		if data[pos] != '"' {
+			return nil, fmt.Errorf("expected a key at offset %d", pos)
+		}
+		if err := json.Unmarshal(data[pos:end], &m.name); err != nil {
+			return nil, err
+		}
+		m.keyEnd = end
+
+		pos = skipSpace(data, end)
+		if pos >= len(data) || data[pos] != ':' {
+			return nil, fmt.Errorf("expected ':' at offset %d", pos)
+		}
+		m.valueStart = skipSpace(data, pos+1)
+		if m.valueEnd, err = scanValue(data, m.valueStart); err != nil {
+			return nil, err
+		}
+		obj.members = append(obj.members, m)
+
+		pos = skipSpace(data, m.valueEnd)
+		if pos >= len(data) {
+			return nil, fmt.Errorf("unterminated object at offset %d", start)
+		}
+		switch data[pos] {
+		case ',':
+			pos = skipSpace(data, pos+1)
+		case '}':
+			obj.end = pos + 1
+			return obj, nil
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d", data[pos], pos)
+		}
+	}
+}
+
+// scanValue returns the offset just past the JSON value starting at pos
+func scanValue(data []byte, pos int) (int, error) {
+	if pos >= len(data) {
+		return 0, fmt.Errorf("unexpected end of data")
+	}
+	switch data[pos] {
+	case '"':
+		for i := pos + 1; i < len(data); i++ {
+			switch data[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, nil
+			}
+		}
+		return 0, fmt.Errorf("unterminated string at offset %d", pos)
+	case '{', '[':
+		depth := 0
+		for i := pos; i < len(data); i++ {
+			switch data[i] {
+			case '"':
+				end, err := scanValue(data, i)
+				if err != nil {
+					return 0, err
+				}
+				i = end - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				if depth--; depth == 0 {
+					return i + 1, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("unterminated value at offset %d", pos)
+	}
+	end := pos
+	for end < len(data) && !bytes.ContainsRune([]byte(",}] \t\r\n"), rune(data[end])) {
+		end++
+	}
+	if end == pos {
+		return 0, fmt.Errorf("unexpected %q at offset %d", data[pos], pos)
+	}
+	return end, nil
+}
+
+func skipSpace(data []byte, pos int) int {
+	for pos < len(data) && bytes.IndexByte([]byte(" \t\r\n"), data[pos]) >= 0 {
+		pos++
+	}
+	return pos
+}
+
+func (o *object) index(name string) int {
+	return slices.IndexFunc(o.members, func(m member) bool { return m.name == name })
+}
+
+func (o *object) sorted() bool {
+	return slices.IsSortedFunc(o.members, func(a, b member) int { return strings.Compare(a.name, b.name) })
+}
+
+// inner is the whitespace in front of the object's members
+func (o *object) inner(data []byte) string {
+	if len(o.members) > 0 {
+		return string(data[skipBack(data, o.members[0].keyStart):o.members[0].keyStart])
+	}
+	return o.indent(data) + o.unit
+}
+
+// gap is the whitespace after the commas between the object's members
+func (o *object) gap(data []byte) string {
+	if len(o.members) < 2 {
+		return o.inner(data)
+	}
+	between := data[o.members[0].valueEnd:o.members[1].keyStart]
+	return string(between[bytes.IndexByte(between, ',')+1:])
+}
+
+// indent is the whitespace in front of the object's closing brace, the
+// newline and indentation of the line the object starts on
+func (o *object) indent(data []byte) string {
+	if len(o.members) > 0 {
+		last := o.members[len(o.members)-1]
+		if ws := string(data[last.valueEnd : o.end-1]); strings.TrimSpace(ws) == "" {
+			return ws
+		}
+	}
+	lineStart := bytes.LastIndexByte(data[:o.start], '\n') + 1
+	return "\n" + string(data[lineStart:skipSpace(data, lineStart)])
+}
+
+// separator is what stands between the object's keys and values
+func (o *object) separator(data []byte) string {
+	if len(o.members) > 0 {
+		return string(data[o.members[0].keyEnd:o.members[0].valueStart])
+	}
+	return ": "
+}
+
+// insert adds entry as member i
+func (o *object) insert(data []byte, i int, entry string) []byte {
+	if len(o.members) == 0 {
+		return splice(data, o.start+1, o.end-1, o.inner(data)+entry+o.indent(data))
+	}
+	if i < len(o.members) {
+		at := o.members[i].keyStart
+		return splice(data, at, at, entry+","+o.gap(data))
+	}
+	at := o.members[len(o.members)-1].valueEnd
+	return splice(data, at, at, ","+o.gap(data)+entry)
+}
+
+// remove drops member i along with the comma and whitespace that go with it
+func (o *object) remove(data []byte, i int) []byte {
+	switch {
+	case len(o.members) == 1:
+		return splice(data, o.start+1, o.end-1, "")
+	case i < len(o.members)-1:
+		return splice(data, o.members[i].keyStart, o.members[i+1].keyStart, "")
+	default:
+		return splice(data, o.members[i-1].valueEnd, o.members[i].valueEnd, "")
+	}
+}
+
+// skipBack returns the offset of the whitespace run ending at pos
+func skipBack(data []byte, pos int) int {
+	for pos > 0 && bytes.IndexByte([]byte(" \t\r\n"), data[pos-1]) >= 0 {
+		pos--
+	}
+	return pos
+}
+
+func splice(data []byte, start, end int, s string) []byte {
+	out := make([]byte, 0, len(data)-(end-start)+len(s))
+	out = append(out, data[:start]...)
+	out = append(out, s...)
+	return append(out, data[end:]...)
+}
+
+func quote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,417 @@
+// Package packages installs the npm dependencies a package.json declares,
+// and edits them for edon add and edon remove.
+//
+// Packages are installed into the shared npm cache, where the module loader
+// finds them. The version picked for every range, the project's and those
+// of the packages it depends on, is recorded in the npm section of
+// edon.lock, so later installs and runs use the same versions.
+package packages
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/semver"
+)
+
+// Project is a package.json whose dependencies are installed
+type Project struct {
+	// Dir is the directory containing package.json
+	Dir      string
+	Manifest *loader.PackageJSON
+
+	data []byte
+	pm   *loader.NPMPackageManager
+	lock *loader.Lockfile
+}
+
+// Installed is a package version put in place by an install
+type Installed struct {
+	Name    string
+	Version string
+	// Range is what the project asked for
+	Range string
+	Dev   bool
+	Path  string
+}
+
+// Result reports what an install did
+type Result struct {
+	// Direct are the project's own dependencies, sorted by name
+	Direct []Installed
+	// Packages counts the package versions installed, direct or not
+	Packages int
+	// Downloaded counts those that were not in the cache yet
+	Downloaded int
+}
+
+// FindDir returns the directory of the nearest package.json at or above dir
+func FindDir(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "package.json")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.ErrNoPackageJSON
+		}
+		dir = parent
+	}
+}
+
+// Open opens the package.json in dir. Versions are picked from lock and
+// recorded in it; saving it is up to the caller.
+func Open(dir string, pm *loader.NPMPackageManager, lock *loader.Lockfile) (*Project, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	p := &Project{Dir: dir, pm: pm, lock: lock}
+	if err := p.setData(data); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Project) setData(data []byte) error {
+	manifest, err := loader.ParsePackageJSON(data, p.Dir)
+	if err != nil {
+		return err
+	}
+	p.data, p.Manifest = data, manifest
+	return nil
+}
+
+// Install installs every dependency and devDependency of the project, at
+// the versions in the lockfile while they still satisfy their ranges, and
+// records what was installed in the lockfile
+func (p *Project) Install(ctx context.Context) (*Result, error) {
+	return p.install(ctx, nil)
+}
+
+// Add adds packages, given as name or name@range, to dependencies, or to
+// devDependencies when dev is set, and installs everything. A package
+// without a range, or with a dist-tag, is added with a caret range on the
+// version it resolves to. package.json is only written once the install
+// has succeeded.
+func (p *Project) Add(ctx context.Context, packages []string, dev bool) (*Result, error) {
+	if len(packages) == 0 {
+		return nil, errors.ErrPackageRequired
+	}
+	field, other := Dependencies, DevDependencies
+	if dev {
+		field, other = other, field
+	}
+
+	data := p.data
+	for _, arg := range packages {
+		spec, err := loader.ParseNPMSpecifier(arg)
+		if err != nil {
+			return nil, err
+		}
+		if spec.Subpath != "." {
+			return nil, errors.Wrap(errors.ErrInvalidSpecifier, arg)
+		}
+
+		versionRange := spec.Version
+		if _, err := semver.ParseRange(versionRange); err != nil {
+			version, err := p.resolveTag(ctx, spec.Name, versionRange)
+			if err != nil {
+				return nil, err
+			}
+			versionRange = "^" + version
+		}
+
+		if data, err = SetDependency(data, field, spec.Name, versionRange); err != nil {
+			return nil, err
+		}
+		if data, _, err = RemoveDependency(data, other, spec.Name); err != nil {
+			return nil, err
+		}
+	}
+	return p.update(ctx, data, nil)
+}
+
+// Remove removes packages from dependencies and devDependencies, and
+// installs what is left so the lockfile no longer lists them
+func (p *Project) Remove(ctx context.Context, names []string) (*Result, error) {
+	if len(names) == 0 {
+		return nil, errors.ErrPackageRequired
+	}
+
+	data := p.data
+	for _, name := range names {
+		found := false
+		for _, field := range []string{Dependencies, DevDependencies} {
+			var removed bool
+			var err error
+			if data, removed, err = RemoveDependency(data, field, name); err != nil {
+				return nil, err
+			}
+			found = found || removed
+		}
+		if !found {
+			return nil, errors.Wrap(errors.ErrNotDependency, name)
+		}
+	}
+	return p.update(ctx, data, nil)
+}
+
+// update installs the dependencies of the edited package.json data, and
+// writes it once they are in place
+func (p *Project) update(ctx context.Context, data []byte, refresh func(name string) bool) (*Result, error) {
+	previous, previousManifest := p.data, p.Manifest
+	if err := p.setData(data); err != nil {
+		return nil, err
+	}
+	result, err := p.install(ctx, refresh)
+	if err != nil {
+		p.data, p.Manifest = previous, previousManifest
+		return nil, err
+	}
+	if err := p.write(previous); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// write writes package.json if it changed from previous
+func (p *Project) write(previous []byte) error {
+	if bytes.Equal(p.data, previous) {
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(p.Dir, "package.json"), p.data, 0644); err != nil {
+		return errors.WrapWith(errors.ErrFileWrite, err, "package.json")
+	}
+	return nil
+}
+
+// install installs the project's dependencies. Packages that refresh
+// reports true for are resolved again rather than taken from the lockfile.
+func (p *Project) install(ctx context.Context, refresh func(name string) bool) (*Result, error) {
+	in := &installer{
+		pm:         p.pm,
+		locked:     p.lock.NPMPackages(),
+		npm:        loader.NewNPMLock(),
+		refresh:    refresh,
+		packuments: make(map[string]*loader.NPMPackument),
+		result:     &Result{},
+	}
+
+	for _, dep := range p.Dependencies() {
+		installed, err := in.add(ctx, dep.Name, dep.Range)
+		if err != nil {
+			return nil, err
+		}
+		installed.Range, installed.Dev = dep.Range, dep.Dev
+		in.result.Direct = append(in.result.Direct, installed)
+	}
+
+	p.lock.SetNPMPackages(in.npm)
+	return in.result, nil
+}
+
+// Dependency is a dependency the project declares
+type Dependency struct {
+	Name  string
+	Range string
+	Dev   bool
+}
+
+// Dependencies returns the project's dependencies and devDependencies,
+// sorted by name. A package listed in both counts as a dependency.
+func (p *Project) Dependencies() []Dependency {
+	var deps []Dependency
+	for name, versionRange := range p.Manifest.Dependencies {
+		deps = append(deps, Dependency{Name: name, Range: versionRange})
+	}
+	for name, versionRange := range p.Manifest.DevDependencies {
+		if _, ok := p.Manifest.Dependencies[name]; !ok {
+			deps = append(deps, Dependency{Name: name, Range: versionRange, Dev: true})
+		}
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	return deps
+}
+
+// resolveTag returns the version a dist-tag, such as latest, points at
+func (p *Project) resolveTag(ctx context.Context, name, tag string) (string, error) {
+	doc, err := p.pm.Packument(ctx, name)
+	if errors.Is(err, errors.ErrNotCached) {
+		// Offline, the highest cached version stands in for the tag
+		path, err := p.pm.InstallPackage(ctx, name+"@"+tag)
+		if err != nil {
+			return "", err
+		}
+		return installedVersion(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	manifest, ok := doc.Resolve(tag)
+	if !ok {
+		return "", errors.Wrap(errors.ErrPackageNotFound, name+"@"+tag)
+	}
+	return manifest.Version, nil
+}
+
+// installer installs a dependency tree, recording it in npm
+type installer struct {
+	pm         *loader.NPMPackageManager
+	locked     *loader.NPMLock
+	npm        *loader.NPMLock
+	refresh    func(name string) bool
+	packuments map[string]*loader.NPMPackument
+	result     *Result
+}
+
+// add installs name@versionRange and, once per version, its dependencies
+func (in *installer) add(ctx context.Context, name, versionRange string) (Installed, error) {
+	if strings.ContainsAny(versionRange, ":/") {
+		return Installed{}, errors.Wrap(errors.ErrInvalidPackageTarget, fmt.Sprintf("%s@%s: only registry versions can be installed", name, versionRange))
+	}
+
+	key := name + "@" + versionRange
+	if version, ok := in.npm.Specifiers[key]; ok {
+		path, _ := in.pm.CachedPackage(name, version)
+		return Installed{Name: name, Version: version, Path: path}, nil
+	}
+
+	installed, integrity, err := in.fetch(ctx, name, versionRange)
+	if err != nil {
+		return Installed{}, err
+	}
+	in.npm.Specifiers[key] = installed.Version
+
+	id := name + "@" + installed.Version
+	if _, ok := in.npm.Packages[id]; ok {
+		return installed, nil
+	}
+	manifest, err := loader.ReadPackageJSON(installed.Path)
+	if err != nil {
+		return Installed{}, errors.Wrap(errors.ErrInvalidPackageConfig, fmt.Sprintf("%s: %v", id, err))
+	}
+	in.npm.Packages[id] = loader.NPMLockedPackage{Integrity: integrity, Dependencies: manifest.Dependencies}
+	in.result.Packages++
+
+	for _, dep := range sortedKeys(manifest.Dependencies) {
+		if _, err := in.add(ctx, dep, manifest.Dependencies[dep]); err != nil {
+			return Installed{}, errors.Wrap(err, id)
+		}
+	}
+	return installed, nil
+}
+
+// fetch installs the version versionRange picks: the locked one while it
+// satisfies the range, otherwise the one the registry resolves it to
+func (in *installer) fetch(ctx context.Context, name, versionRange string) (Installed, string, error) {
+	if in.refresh == nil || !in.refresh(name) {
+		if version, ok := in.locked.Specifiers[name+"@"+versionRange]; ok && satisfies(version, versionRange) {
+			return in.fetchLocked(ctx, name, version)
+		}
+	}
+
+	doc, err := in.packument(ctx, name)
+	if errors.Is(err, errors.ErrNotCached) {
+		// Offline, take the highest cached version in range
+		path, err := in.pm.InstallPackage(ctx, name+"@"+versionRange)
+		if err != nil {
+			return Installed{}, "", err
+		}
+		version, err := installedVersion(path)
+		if err != nil {
+			return Installed{}, "", err
+		}
+		return Installed{Name: name, Version: version, Path: path}, in.locked.Packages[name+"@"+version].Integrity, nil
+	}
+	if err != nil {
+		return Installed{}, "", err
+	}
+	manifest, ok := doc.Resolve(versionRange)
+	if !ok {
+		return Installed{}, "", errors.Wrap(errors.ErrPackageNotFound, fmt.Sprintf("%s@%s: no version matches", name, versionRange))
+	}
+	path, err := in.installManifest(ctx, manifest)
+	if err != nil {
+		return Installed{}, "", err
+	}
+	return Installed{Name: name, Version: manifest.Version, Path: path}, manifest.Dist.Integrity, nil
+}
+
+// fetchLocked installs a version recorded in the lockfile, checking that
+// the registry still serves the tarball the lockfile recorded
+func (in *installer) fetchLocked(ctx context.Context, name, version string) (Installed, string, error) {
+	integrity := in.locked.Packages[name+"@"+version].Integrity
+	if path, ok := in.pm.CachedPackage(name, version); ok {
+		return Installed{Name: name, Version: version, Path: path}, integrity, nil
+	}
+
+	doc, err := in.packument(ctx, name)
+	if err != nil {
+		return Installed{}, "", err
+	}
+	manifest, ok := doc.Versions[version]
+	if !ok {
+		return Installed{}, "", errors.Wrap(errors.ErrPackageNotFound, fmt.Sprintf("%s@%s, the version in the lockfile", name, version))
+	}
+	if integrity != "" && manifest.Dist.Integrity != "" && manifest.Dist.Integrity != integrity {
+		return Installed{}, "", errors.Wrap(errors.ErrPackageIntegrity, fmt.Sprintf("%s@%s: the lockfile has %s, the registry %s", name, version, integrity, manifest.Dist.Integrity))
+	}
+	path, err := in.installManifest(ctx, manifest)
+	if err != nil {
+		return Installed{}, "", err
+	}
+	if integrity == "" {
+		integrity = manifest.Dist.Integrity
+	}
+	return Installed{Name: name, Version: version, Path: path}, integrity, nil
+}
+
+func (in *installer) installManifest(ctx context.Context, manifest *loader.NPMManifest) (string, error) {
+	if _, ok := in.pm.CachedPackage(manifest.Name, manifest.Version); !ok {
+		in.result.Downloaded++
+	}
+	return in.pm.InstallManifest(ctx, manifest)
+}
+
+func (in *installer) packument(ctx context.Context, name string) (*loader.NPMPackument, error) {
+	if doc, ok := in.packuments[name]; ok {
+		return doc, nil
+	}
+	doc, err := in.pm.Packument(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	in.packuments[name] = doc
+	return doc, nil
+}
+
+// satisfies reports whether version is in versionRange. A dist-tag is
+// taken to be satisfied by whatever version it was locked to.
+func satisfies(version, versionRange string) bool {
+	if _, err := semver.ParseRange(versionRange); err != nil {
+		return true
+	}
+	_, ok := semver.MaxSatisfying([]string{version}, versionRange)
+	return ok
+}
+
+// installedVersion reads the version of the package installed at path
+func installedVersion(path string) (string, error) {
+	manifest, err := loader.ReadPackageJSON(path)
+	if err != nil {
+		return "", errors.Wrap(errors.ErrInvalidPackageConfig, fmt.Sprintf("%s: %v", path, err))
+	}
+	return manifest.Version, nil
+}
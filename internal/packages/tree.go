@@ -0,0 +1,277 @@
+package packages
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/semver"
+)
+
+// Package is an installed package version
+type Package struct {
+	Name    string
+	Version string
+	// Dependencies are the versions installed for the package's
+	// dependencies, sorted by name
+	Dependencies []*Package
+}
+
+// ID is name@version, or the name alone for a project without a version
+func (p *Package) ID() string {
+	if p.Version == "" {
+		return p.Name
+	}
+	return p.Name + "@" + p.Version
+}
+
+// Tree is the installed dependency tree of a project, as recorded in the
+// lockfile. Packages depended on in several places appear once.
+type Tree struct {
+	// Root is the project, with its direct dependencies
+	Root *Package
+
+	dev   map[string]bool
+	graph *loader.DependencyGraph
+}
+
+// Tree builds the installed dependency tree. Dependencies that are not in
+// the lockfile yet are reported with ErrNotInstalled.
+func (p *Project) Tree() (*Tree, error) {
+	npm := p.lock.NPMPackages()
+	root := &Package{Name: p.Manifest.Name, Version: p.Manifest.Version}
+	if root.Name == "" {
+		root.Name = "."
+	}
+	t := &Tree{Root: root, dev: make(map[string]bool), graph: loader.NewDependencyGraph()}
+	packages := make(map[string]*Package)
+
+	var node func(name, versionRange string) (*Package, error)
+	node = func(name, versionRange string) (*Package, error) {
+		version, ok := npm.Specifiers[name+"@"+versionRange]
+		if !ok {
+			return nil, errors.Wrap(errors.ErrNotInstalled, fmt.Sprintf("%s@%s (run edon install)", name, versionRange))
+		}
+		id := name + "@" + version
+		if pkg, ok := packages[id]; ok {
+			return pkg, nil
+		}
+		pkg := &Package{Name: name, Version: version}
+		packages[id] = pkg
+
+		deps := npm.Packages[id].Dependencies
+		for _, dep := range sortedKeys(deps) {
+			child, err := node(dep, deps[dep])
+			if err != nil {
+				return nil, err
+			}
+			pkg.Dependencies = append(pkg.Dependencies, child)
+			_ = t.graph.AddDependency(id, child.ID())
+		}
+		return pkg, nil
+	}
+
+	for _, dep := range p.Dependencies() {
+		pkg, err := node(dep.Name, dep.Range)
+		if err != nil {
+			return nil, err
+		}
+		root.Dependencies = append(root.Dependencies, pkg)
+		t.dev[dep.Name] = dep.Dev
+		_ = t.graph.AddDependency(root.ID(), pkg.ID())
+	}
+	return t, nil
+}
+
+// Why returns every path through the tree from the project to an installed
+// version of name, each starting with the project
+func (t *Tree) Why(name string) ([][]string, error) {
+	paths := t.graph.PathsTo(t.Root.ID(), func(id string) bool {
+		return strings.HasPrefix(id, name+"@")
+	})
+	if len(paths) == 0 {
+		return nil, errors.Wrap(errors.ErrNotInstalled, name)
+	}
+	return paths, nil
+}
+
+// Write prints the tree down to depth levels below the direct
+// dependencies, or all of it when depth is negative. Packages whose
+// dependencies were printed earlier are marked with a star.
+func (t *Tree) Write(w io.Writer, depth int) error {
+	fmt.Fprintln(w, t.Root.ID())
+	printed := make(map[string]bool)
+	duplicates := false
+
+	var walk func(pkg *Package, prefix string, level int)
+	walk = func(pkg *Package, prefix string, level int) {
+		for n, dep := range pkg.Dependencies {
+			branch, indent := "├── ", "│   "
+			if n == len(pkg.Dependencies)-1 {
+				branch, indent = "└── ", "    "
+			}
+			line := dep.ID()
+			if level == 0 && t.dev[dep.Name] {
+				line += " (dev)"
+			}
+
+			expand := depth < 0 || level < depth
+			if expand && printed[dep.ID()] && len(dep.Dependencies) > 0 {
+				duplicates = true
+				fmt.Fprintf(w, "%s%s%s *\n", prefix, branch, line)
+				continue
+			}
+			fmt.Fprintf(w, "%s%s%s\n", prefix, branch, line)
+			if expand {
+				printed[dep.ID()] = true
+				walk(dep, prefix+indent, level+1)
+			}
+		}
+	}
+	walk(t.Root, "", 0)
+
+	if duplicates {
+		fmt.Fprintln(w, "\n* dependencies already listed above")
+	}
+	return nil
+}
+
+// Outdated is a direct dependency with a newer version available
+type Outdated struct {
+	Name  string
+	Range string
+	Dev   bool
+	// Current is the installed version, empty when it is not installed
+	Current string
+	// Wanted is the highest version in Range
+	Wanted string
+	// Latest is the version the latest dist-tag points at
+	Latest string
+}
+
+// Outdated compares the installed versions of the project's dependencies
+// with the registry, returning those that are behind the highest version
+// their range allows or the latest one
+func (p *Project) Outdated(ctx context.Context) ([]Outdated, error) {
+	var outdated []Outdated
+	for _, dep := range p.Dependencies() {
+		doc, err := p.pm.Packument(ctx, dep.Name)
+		if err != nil {
+			return nil, err
+		}
+		o := Outdated{Name: dep.Name, Range: dep.Range, Dev: dep.Dev}
+		o.Current, _ = p.lock.NPMVersion(dep.Name, dep.Range)
+		if wanted, ok := doc.Resolve(dep.Range); ok {
+			o.Wanted = wanted.Version
+		}
+		if latest, ok := doc.Latest(); ok {
+			o.Latest = latest.Version
+		}
+		if o.Current != o.Wanted || o.Current != o.Latest {
+			outdated = append(outdated, o)
+		}
+	}
+	return outdated, nil
+}
+
+// Change is a direct dependency an update moved to another version
+type Change struct {
+	Name string
+	// From is empty when the dependency was not installed before
+	From string
+	To   string
+}
+
+// Update resolves the named packages again, or every package when none
+// are named, installing the highest versions their ranges allow. With
+// latest, the named direct dependencies, or all of them, are moved to the
+// version the latest dist-tag points at and package.json gets new ranges
+// for them, keeping their ^ or ~ or exact style.
+func (p *Project) Update(ctx context.Context, names []string, latest bool) ([]Change, *Result, error) {
+	declared := make(map[string]Dependency)
+	for _, dep := range p.Dependencies() {
+		declared[dep.Name] = dep
+	}
+	installed := p.lock.NPMPackages().Packages
+	for _, name := range names {
+		if _, ok := declared[name]; ok {
+			continue
+		}
+		if latest {
+			return nil, nil, errors.Wrap(errors.ErrNotDependency, name)
+		}
+		if !slices.ContainsFunc(slices.Collect(maps.Keys(installed)), func(id string) bool { return strings.HasPrefix(id, name+"@") }) {
+			return nil, nil, errors.Wrap(errors.ErrNotInstalled, name)
+		}
+	}
+	refresh := func(name string) bool {
+		return len(names) == 0 || slices.Contains(names, name)
+	}
+
+	before := make(map[string]string)
+	for name, dep := range declared {
+		before[name], _ = p.lock.NPMVersion(name, dep.Range)
+	}
+
+	data := p.data
+	if latest {
+		for name, dep := range declared {
+			if !refresh(name) {
+				continue
+			}
+			doc, err := p.pm.Packument(ctx, name)
+			if err != nil {
+				return nil, nil, err
+			}
+			manifest, ok := doc.Latest()
+			if !ok {
+				return nil, nil, errors.Wrap(errors.ErrPackageNotFound, name+"@latest")
+			}
+			field := Dependencies
+			if dep.Dev {
+				field = DevDependencies
+			}
+			if data, err = SetDependency(data, field, name, rangeLike(dep.Range, manifest.Version)); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	result, err := p.update(ctx, data, refresh)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changes []Change
+	for _, dep := range result.Direct {
+		if from := before[dep.Name]; from != dep.Version {
+			changes = append(changes, Change{Name: dep.Name, From: from, To: dep.Version})
+		}
+	}
+	return changes, result, nil
+}
+
+// rangeLike returns a range for version in the style of versionRange
+func rangeLike(versionRange, version string) string {
+	switch {
+	case semver.IsExact(versionRange):
+		return version
+	case strings.HasPrefix(versionRange, "~"):
+		return "~" + version
+	}
+	return "^" + version
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
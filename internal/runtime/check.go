@@ -0,0 +1,117 @@
+package runtime
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/buke/quickjs-go"
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+// stackPosition matches the line and column at the end of the first frame
+// of a syntax error's stack
+var stackPosition = regexp.MustCompile(`:(\d+):(\d+)\)?\s*$`)
+
+// CheckGraph compiles every module of graph without running any of them,
+// the way ExecuteFile does, and returns a *loader.SourceError for each
+// module that fails to compile. Modules are checked in ID order, and the
+// built-in modules are skipped.
+//
+// Each module is compiled from its source as written, with its import
+// specifiers swapped for stub modules of the same length so that lines
+// and columns stay where they are in the file. Errors in TypeScript and
+// JSX are mapped back through the module's source map.
+func CheckGraph(graph *loader.ModuleGraph) []error {
+	rt := quickjs.NewRuntime()
+	defer rt.Close()
+	ctx := rt.NewContext()
+	defer ctx.Close()
+
+	ids := make([]string, 0, len(graph.Modules))
+	for id, module := range graph.Modules {
+		if module.Type != loader.TypeNode && module.Type != loader.TypeEdon {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	stubbed := make(map[string]bool)
+	var errs []error
+	for _, id := range ids {
+		module := graph.Modules[id]
+		source, stubs := stubImports(module.JS())
+		for _, stub := range stubs {
+			if !stubbed[stub] {
+				ctx.LoadModule("export {};", stub, quickjs.EvalLoadOnly(true)).Free()
+				stubbed[stub] = true
+			}
+		}
+
+		opts := []quickjs.EvalOption{quickjs.EvalFileName(id), quickjs.EvalFlagCompileOnly(true)}
+		if id != graph.Root || loader.IsModule(module.JS()) {
+			opts = append(opts, quickjs.EvalFlagModule(true))
+		}
+		result := ctx.Eval(source, opts...)
+		if result.IsException() {
+			errs = append(errs, syntaxError(module, ctx.Exception()))
+		}
+		result.Free()
+	}
+	return errs
+}
+
+// stubImports replaces the static import specifiers of source with names
+// made of underscores, as many as the specifier has characters, and blanks
+// out import attributes. It returns the new source and the stub names.
+func stubImports(source string) (string, []string) {
+	var b strings.Builder
+	var stubs []string
+	last := 0
+	for _, imp := range loader.ScanImports(source) {
+		if imp.Dynamic {
+			continue
+		}
+		stub := strings.Repeat("_", max(utf8.RuneCountInString(source[imp.Start+1:imp.End-1]), 1))
+		stubs = append(stubs, stub)
+		b.WriteString(source[last : imp.Start+1])
+		b.WriteString(stub)
+		last = imp.End - 1
+		if imp.AttrEnd > 0 {
+			b.WriteString(source[last:imp.AttrStart])
+			b.WriteString(blank(source[imp.AttrStart:imp.AttrEnd]))
+			last = imp.AttrEnd
+		}
+	}
+	b.WriteString(source[last:])
+	return b.String(), stubs
+}
+
+// blank replaces everything but line breaks in s with spaces
+func blank(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' {
+			return r
+		}
+		return ' '
+	}, s)
+}
+
+// syntaxError turns an exception thrown while compiling module into a
+// *loader.SourceError, at its position in the file as written
+func syntaxError(module *loader.GraphModule, err error) error {
+	e := &loader.SourceError{File: module.ID(), Message: err.Error(), Err: errors.ErrInvalidScript}
+	var jsErr *quickjs.Error
+	if errors.As(err, &jsErr) {
+		first, _, _ := strings.Cut(strings.TrimSpace(jsErr.Stack), "\n")
+		if m := stackPosition.FindStringSubmatch(first); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+			e.Column, _ = strconv.Atoi(m[2])
+			e.Line, e.Column = module.OriginalPosition(e.Line, e.Column)
+		}
+	}
+	return e
+}
@@ -0,0 +1,183 @@
+package runtime
+
+import (
+	"fmt"
+	goruntime "runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/buke/quickjs-go"
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+// compileGraph compiles every module of graph to QuickJS bytecode, keyed by
+// module ID, taking what it can from cache, which may be nil.
+//
+// QuickJS loads a module's imports as soon as it is compiled, which would
+// send it to the filesystem for specifiers only we know how to load. Each
+// module is therefore compiled in a scratch runtime where its imports are
+// satisfied by empty stub modules; linking against the real modules happens
+// when the bytecode is loaded into the runtime that executes it.
+func compileGraph(graph *loader.ModuleGraph, cache *loader.CodeCache) (map[string][]byte, error) {
+	var (
+		rt  *quickjs.Runtime
+		ctx *quickjs.Context
+	)
+	defer func() {
+		if ctx != nil {
+			ctx.Close()
+			rt.Close()
+		}
+	}()
+
+	stubbed := make(map[string]bool)
+	bytecode := make(map[string][]byte, len(graph.Modules))
+
+	for id, module := range graph.Modules {
+		isModule := id != graph.Root || loader.IsModule(module.Source)
+		var key string
+		if cache != nil {
+			key = loader.CodeKey(EngineVersion(), id, module.Source, isModule)
+			if code, ok := cache.Get(key); ok {
+				bytecode[id] = code
+				continue
+			}
+		}
+
+		// The scratch runtime is only needed for modules missing from the
+		// cache
+		if ctx == nil {
+			rt = quickjs.NewRuntime()
+			ctx = rt.NewContext()
+		}
+		for _, imp := range loader.ScanImports(module.Source) {
+			if stubbed[imp.Specifier] || imp.Dynamic {
+				continue
+			}
+			stub := ctx.LoadModule("export {};", imp.Specifier, quickjs.EvalLoadOnly(true))
+			stub.Free()
+			stubbed[imp.Specifier] = true
+		}
+
+		opts := []quickjs.EvalOption{quickjs.EvalFileName(id)}
+		if isModule {
+			opts = append(opts, quickjs.EvalFlagModule(true))
+		}
+		code, err := compile(ctx, module.Source, opts...)
+		if err != nil {
+			return nil, formatJSError(err)
+		}
+		bytecode[id] = code
+
+		// A cache that cannot be written to only costs the next run time
+		if cache != nil {
+			_ = cache.Put(key, code)
+		}
+	}
+
+	return bytecode, nil
+}
+
+// EngineVersion identifies the QuickJS build bytecode is compiled by.
+// Bytecode only loads into the engine that produced it.
+func EngineVersion() string {
+	engineOnce.Do(func() {
+		bindings := "unknown"
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, dep := range info.Deps {
+				if dep.Path == "github.com/buke/quickjs-go" {
+					bindings = dep.Version
+					if dep.Replace != nil {
+						bindings = dep.Replace.Path + "@" + dep.Replace.Version
+					}
+				}
+			}
+		}
+		engineVersion = fmt.Sprintf("quickjs-go %s %s/%s", bindings, goruntime.GOOS, goruntime.GOARCH)
+	})
+	return engineVersion
+}
+
+var (
+	engineOnce    sync.Once
+	engineVersion string
+)
+
+// compile compiles source to bytecode. ctx.Compile loses the error of
+// source that does not compile, so the source is parsed again to report it.
+func compile(ctx *quickjs.Context, source string, opts ...quickjs.EvalOption) ([]byte, error) {
+	code, err := ctx.Compile(source, opts...)
+	if err == nil {
+		return code, nil
+	}
+	result := ctx.Eval(source, append(opts, quickjs.EvalFlagCompileOnly(true))...)
+	defer result.Free()
+	if result.IsException() {
+		return nil, ctx.Exception()
+	}
+	return nil, err
+}
+
+// runGraph registers every dependency of graph with the runtime's context and
+// then evaluates the entry module
+func (r *Runtime) runGraph(graph *loader.ModuleGraph) (*quickjs.Value, error) {
+	program, err := Compile(graph, r.loader.CodeCache())
+	if err != nil {
+		return nil, err
+	}
+	return r.runProgram(program)
+}
+
+// runProgram loads every module of a compiled program other than its entry,
+// in order, so static and dynamic imports both find them, and then
+// evaluates the entry module
+func (r *Runtime) runProgram(program *Program) (*quickjs.Value, error) {
+	for _, id := range program.Order {
+		if id == program.Root {
+			continue
+		}
+		code, ok := program.Modules[id]
+		if !ok {
+			return nil, errors.Wrap(errors.ErrInvalidProgram, "no bytecode for "+id)
+		}
+		module := r.context.LoadModuleBytecode(code, quickjs.EvalLoadOnly(true))
+		if module.IsException() {
+			module.Free()
+			return nil, formatJSError(r.context.Exception())
+		}
+		module.Free()
+	}
+
+	root, ok := program.Modules[program.Root]
+	if !ok {
+		return nil, errors.Wrap(errors.ErrInvalidProgram, "no bytecode for "+program.Root)
+	}
+	result := r.context.LoadModuleBytecode(root)
+	if result.IsException() {
+		result.Free()
+		return nil, formatJSError(r.context.Exception())
+	}
+
+	// A module evaluates to a promise, which settles once its top-level
+	// awaits are done; jobs queued after that still have to run
+	result = r.context.Await(result)
+	if result.IsException() {
+		result.Free()
+		return nil, formatJSError(r.context.Exception())
+	}
+	if !r.process.Exiting() {
+		r.context.Loop()
+	}
+	return result, nil
+}
+
+// formatJSError appends the JavaScript stack, which carries the file, line
+// and column, to an engine error
+func formatJSError(err error) error {
+	var jsErr *quickjs.Error
+	if errors.As(err, &jsErr) && jsErr.Stack != "" {
+		return fmt.Errorf("%s\n%s", jsErr.Error(), jsErr.Stack)
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/modules/node"
+)
+
+// Program is a module graph compiled to QuickJS bytecode, which runs without
+// its sources or a module loader
+type Program struct {
+	// Root is the ID of the entry module
+	Root string
+	// Order lists every module ID, dependencies before their importers,
+	// including the modules only dynamic imports reach
+	Order []string
+	// Modules maps each module ID to its bytecode
+	Modules map[string][]byte
+	// Permissions are granted to the program when it runs
+	Permissions node.Permissions
+}
+
+// Compile compiles every module of graph, reusing bytecode from cache when
+// it is not nil. The bytecode only loads into the engine version that
+// compiled it.
+func Compile(graph *loader.ModuleGraph, cache *loader.CodeCache) (*Program, error) {
+	bytecode, err := compileGraph(graph, cache)
+	if err != nil {
+		return nil, err
+	}
+	order, err := graph.LoadOrder()
+	if err != nil {
+		return nil, err
+	}
+	return &Program{Root: graph.Root, Order: order, Modules: bytecode}, nil
+}
+
+// programData is Program without its encoding methods, which gob would
+// otherwise call back into
+type programData Program
+
+// MarshalBinary encodes the program
+func (p *Program) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode((*programData)(p)); err != nil {
+		return nil, errors.WrapWith(errors.ErrInvalidProgram, err, "encode")
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a program encoded by MarshalBinary
+func (p *Program) UnmarshalBinary(data []byte) error {
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode((*programData)(p)); err != nil {
+		return errors.WrapWith(errors.ErrInvalidProgram, err, "decode")
+	}
+	return nil
+}
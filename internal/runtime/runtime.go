@@ -1,9 +1,10 @@
 package runtime
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/buke/quickjs-go"
@@ -11,11 +12,18 @@ import (
 	"github.com/fatih/color"
 	"github.com/katungi/edon/internal/errors"
 	"github.com/katungi/edon/internal/modules/console"
+	"github.com/katungi/edon/internal/modules/edon"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/modules/node"
+	"github.com/katungi/edon/internal/modules/webassembly"
 )
 
 type Runtime struct {
 	jsRuntime *quickjs.Runtime
 	context   *quickjs.Context
+	loader    *loader.ModuleLoader
+	wasm      *webassembly.Engine
+	process   *node.Process
 }
 
 const (
@@ -29,15 +37,69 @@ var (
 	ErrExit      = errors.ErrExit
 )
 
-func New() (*Runtime, error) {
+// ExitError reports a script that ended with a nonzero exit code, set with
+// process.exit() or process.exitCode
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func (e *ExitError) Unwrap() error {
+	return ErrExit
+}
+
+// Option configures a Runtime
+type Option func(*Runtime)
+
+// WithModuleLoader sets the loader ExecuteFile loads module graphs with
+func WithModuleLoader(l *loader.ModuleLoader) Option {
+	return func(r *Runtime) {
+		r.loader = l
+	}
+}
+
+// WithArgs sets the arguments scripts see after the script path in
+// process.argv
+func WithArgs(args ...string) Option {
+	return func(r *Runtime) {
+		r.process.Args = args
+	}
+}
+
+// WithPermissions limits what scripts may reach through the built-ins, which
+// grant everything by default
+func WithPermissions(p node.Permissions) Option {
+	return func(r *Runtime) {
+		r.process.Permissions = p
+	}
+}
+
+func New(opts ...Option) (*Runtime, error) {
 	rt := quickjs.NewRuntime()
 	ctx := rt.NewContext()
 
 	r := &Runtime{
 		jsRuntime: rt,
 		context:   ctx,
+		loader:    loader.NewModuleLoader(),
+		process:   node.NewProcess(),
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
 
+	// process.exit() throws to unwind the script; interrupting keeps a
+	// try/catch from swallowing it
+	rt.SetInterruptHandler(func() int {
+		if r.process.Exiting() {
+			return 1
+		}
+		return 0
+	})
+
 	// Initialize built-in modules
 	if err := r.initializeBuiltins(); err != nil {
 		ctx.Close()
@@ -52,6 +114,20 @@ func (r *Runtime) initializeBuiltins() error {
 	if err := console.Init(r.context); err != nil {
 		return errors.WrapWith(errors.ErrConsoleInit, err, "console module")
 	}
+
+	engine, err := webassembly.Init(r.context)
+	if err != nil {
+		return errors.WrapWith(errors.ErrWasmInit, err, "WebAssembly")
+	}
+	r.wasm = engine
+
+	if err := node.Init(r.context, r.process); err != nil {
+		return errors.WrapWith(errors.ErrNodeInit, err, "node built-ins")
+	}
+
+	if err := edon.Init(r.context); err != nil {
+		return errors.WrapWith(errors.ErrEdonInit, err, "Edon")
+	}
 	return nil
 }
 
@@ -67,26 +143,70 @@ func (r *Runtime) Eval(script string) error {
 }
 
 func (r *Runtime) ExecuteFile(filename string) error {
-	data, err := os.ReadFile(filename)
+	path, err := filepath.Abs(filename)
 	if err != nil {
 		return errors.WrapWith(errors.ErrFileRead, err, "")
 	}
 
-	result := r.context.Eval(string(data))
-	if result.IsException() {
-		return fmt.Errorf("%s", result.String())
+	// Load the file and everything it imports before running any of it
+	graph, err := r.loader.LoadGraph(context.Background(), path)
+	if err != nil {
+		return err
+	}
+
+	r.process.Main = path
+	return r.finish(r.runGraph(graph))
+}
+
+// ExecuteProgram runs a program compiled ahead of time. main is reported as
+// the main module in process.argv.
+func (r *Runtime) ExecuteProgram(program *Program, main string) error {
+	r.process.Main = main
+	return r.finish(r.runProgram(program))
+}
+
+// finish turns the outcome of running the main module into the error the
+// caller exits with, printing the value it evaluated to
+func (r *Runtime) finish(result *quickjs.Value, err error) error {
+	if r.process.Exiting() || r.process.ExitCode() != 0 {
+		if result != nil {
+			result.Free()
+		}
+		if code := r.process.ExitCode(); code != 0 {
+			return &ExitError{Code: code}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
 	}
+	defer result.Free()
+
 	if !result.IsUndefined() {
 		fmt.Println(result.String())
 	}
 	return nil
 }
 
+// RunTests runs the tests registered by the files executed so far with
+// Edon.test or edon:test, keeping those whose names match filter
+func (r *Runtime) RunTests(filter string) (*edon.TestReport, error) {
+	report, err := edon.RunTests(r.context, filter)
+	if r.process.Exiting() {
+		return nil, &ExitError{Code: r.process.ExitCode()}
+	}
+	return report, err
+}
+
 func (r *Runtime) Close() {
 	if r.context != nil {
 		r.context.Close()
 		r.context = nil
 	}
+	if r.wasm != nil {
+		r.wasm.Close()
+		r.wasm = nil
+	}
 	if r.jsRuntime != nil {
 		r.jsRuntime.Close()
 		r.jsRuntime = nil
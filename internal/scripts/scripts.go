@@ -0,0 +1,231 @@
+// Package scripts runs the scripts a package.json declares, npm style: with
+// the package's node_modules/.bin directories on PATH and with pre and post
+// hooks around each script.
+package scripts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/shell"
+)
+
+// Script is a named command from the scripts field
+type Script struct {
+	Name    string
+	Command string
+}
+
+// Package is a package.json and its scripts, in the order it lists them
+type Package struct {
+	Name    string
+	Version string
+	// Dir is the directory containing package.json, where scripts run
+	Dir     string
+	Scripts []Script
+}
+
+// Find reads the nearest package.json at or above dir
+func Find(dir string) (*Package, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		pkg, err := Read(dir)
+		if err == nil {
+			return pkg, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, errors.ErrNoPackageJSON
+		}
+		dir = parent
+	}
+}
+
+// Read reads the package.json in dir
+func Read(dir string) (*Package, error) {
+	path := filepath.Join(dir, "package.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields struct {
+		Name    string          `json:"name"`
+		Version string          `json:"version"`
+		Scripts json.RawMessage `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidPackageConfig, fmt.Sprintf("%s: %v", path, err))
+	}
+	pkg := &Package{Name: fields.Name, Version: fields.Version, Dir: dir}
+	if pkg.Scripts, err = decodeScripts(fields.Scripts); err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidPackageConfig, fmt.Sprintf("%s: scripts: %v", path, err))
+	}
+	return pkg, nil
+}
+
+// ParseScripts decodes an object of named commands, such as the scripts of
+// package.json or the tasks of edon.json, keeping its key order
+func ParseScripts(data json.RawMessage) ([]Script, error) {
+	return decodeScripts(data)
+}
+
+// AddTasks adds tasks ahead of the package's scripts, replacing scripts of
+// the same name
+func (p *Package) AddTasks(tasks []Script) {
+	names := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		names[task.Name] = true
+	}
+	merged := append([]Script{}, tasks...)
+	for _, s := range p.Scripts {
+		if !names[s.Name] {
+			merged = append(merged, s)
+		}
+	}
+	p.Scripts = merged
+}
+
+// decodeScripts decodes the scripts object, keeping its key order
+func decodeScripts(data json.RawMessage) ([]Script, error) {
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected an object")
+	}
+	var scripts []Script
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var command string
+		if err := dec.Decode(&command); err != nil {
+			return nil, fmt.Errorf("%q: expected a string", key)
+		}
+		scripts = append(scripts, Script{Name: key.(string), Command: command})
+	}
+	return scripts, nil
+}
+
+// Script returns the command of the named script
+func (p *Package) Script(name string) (string, bool) {
+	for _, s := range p.Scripts {
+		if s.Name == name {
+			return s.Command, true
+		}
+	}
+	return "", false
+}
+
+// Option configures how scripts run
+type Option func(*runner)
+
+type runner struct {
+	binDirs []string
+	env     []string
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+// WithBinDirs puts dirs on PATH after the node_modules/.bin directories
+func WithBinDirs(dirs ...string) Option {
+	return func(r *runner) {
+		r.binDirs = append(r.binDirs, dirs...)
+	}
+}
+
+// WithEnv sets the environment scripts start from, os.Environ() by default
+func WithEnv(env []string) Option {
+	return func(r *runner) {
+		r.env = env
+	}
+}
+
+// WithStdio sets the standard streams scripts are connected to
+func WithStdio(stdin io.Reader, stdout, stderr io.Writer) Option {
+	return func(r *runner) {
+		r.stdin, r.stdout, r.stderr = stdin, stdout, stderr
+	}
+}
+
+// Run runs the named script with args appended to its command, preceded by
+// its pre hook and followed by its post hook when the package has them. A
+// failing hook or script stops the run with its *shell.ExitError.
+func (p *Package) Run(ctx context.Context, name string, args []string, opts ...Option) error {
+	command, ok := p.Script(name)
+	if !ok {
+		return errors.Wrap(errors.ErrScriptNotFound, fmt.Sprintf("%q in %s", name, filepath.Join(p.Dir, "package.json")))
+	}
+	r := &runner{env: os.Environ(), stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	for _, arg := range args {
+		command += " " + shell.Quote(arg)
+	}
+	if pre, ok := p.Script("pre" + name); ok {
+		if err := p.run(ctx, r, "pre"+name, pre); err != nil {
+			return err
+		}
+	}
+	if err := p.run(ctx, r, name, command); err != nil {
+		return err
+	}
+	if post, ok := p.Script("post" + name); ok {
+		return p.run(ctx, r, "post"+name, post)
+	}
+	return nil
+}
+
+func (p *Package) run(ctx context.Context, r *runner, event, command string) error {
+	fmt.Fprintf(r.stderr, "> %s\n> %s\n\n", event, command)
+
+	sh := shell.New(
+		shell.WithDir(p.Dir),
+		shell.WithEnv(r.env),
+		shell.WithStdio(r.stdin, r.stdout, r.stderr),
+	)
+	path := append(p.binPath(), r.binDirs...)
+	if current := sh.Getenv("PATH"); current != "" {
+		path = append(path, current)
+	}
+	sh.Setenv("PATH", strings.Join(path, string(os.PathListSeparator)))
+	sh.Setenv("npm_lifecycle_event", event)
+	sh.Setenv("npm_lifecycle_script", command)
+	sh.Setenv("npm_package_name", p.Name)
+	sh.Setenv("npm_package_version", p.Version)
+
+	return sh.Run(ctx, command)
+}
+
+// binPath lists node_modules/.bin in the package directory and each of its
+// parents, nearest first
+func (p *Package) binPath() []string {
+	var dirs []string
+	for dir := p.Dir; ; {
+		dirs = append(dirs, filepath.Join(dir, "node_modules", ".bin"))
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dirs
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,388 @@
+// Package semver implements semantic versions and the npm range syntax used
+// by package.json files and the npm and JSR registries.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      string
+}
+
+// Parse parses a version such as "1.2.3", "v1.2.3-beta.1" or "1.2.3+build"
+func Parse(s string) (Version, error) {
+	v := Version{}
+	rest := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "="), "v")
+
+	if core, build, ok := strings.Cut(rest, "+"); ok {
+		rest, v.Build = core, build
+	}
+	if core, pre, ok := strings.Cut(rest, "-"); ok {
+		if pre == "" {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		rest, v.Prerelease = core, strings.Split(pre, ".")
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	nums := [3]*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// String formats the version without build metadata
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 as a is lower than, equal to or higher than b
+func Compare(a, b Version) int {
+	for _, pair := range [][2]uint64{{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// A release is higher than any of its prereleases
+	switch {
+	case len(a.Prerelease) == 0 && len(b.Prerelease) == 0:
+		return 0
+	case len(a.Prerelease) == 0:
+		return 1
+	case len(b.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a.Prerelease) && i < len(b.Prerelease); i++ {
+		if c := compareIdentifier(a.Prerelease[i], b.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a.Prerelease) < len(b.Prerelease):
+		return -1
+	case len(a.Prerelease) > len(b.Prerelease):
+		return 1
+	}
+	return 0
+}
+
+func compareIdentifier(a, b string) int {
+	na, errA := strconv.ParseUint(a, 10, 64)
+	nb, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		if na == nb {
+			return 0
+		}
+		if na < nb {
+			return -1
+		}
+		return 1
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// comparator is a single "<op> <version>" constraint
+type comparator struct {
+	op      string
+	version Version
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := Compare(v, c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return cmp == 0
+}
+
+// Range is a parsed npm version range such as "^1.2.0 || >=3 <4"
+type Range struct {
+	sets [][]comparator
+}
+
+// ParseRange parses an npm range. The empty string, "*" and "x" match any
+// release.
+func ParseRange(s string) (Range, error) {
+	r := Range{}
+	for _, alternative := range strings.Split(s, "||") {
+		set, err := parseComparatorSet(strings.TrimSpace(alternative))
+		if err != nil {
+			return Range{}, fmt.Errorf("invalid range %q: %w", s, err)
+		}
+		r.sets = append(r.sets, set)
+	}
+	return r, nil
+}
+
+// Contains reports whether v satisfies the range. Prereleases only match when
+// a comparator names a prerelease of the same major.minor.patch.
+func (r Range) Contains(v Version) bool {
+	for _, set := range r.sets {
+		if setContains(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func setContains(set []comparator, v Version) bool {
+	for _, c := range set {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	if len(v.Prerelease) == 0 {
+		return true
+	}
+	for _, c := range set {
+		if len(c.version.Prerelease) > 0 && !isUpperBoundMarker(c) &&
+			c.version.Major == v.Major && c.version.Minor == v.Minor && c.version.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// isUpperBoundMarker reports whether c is a synthetic "<x.y.z-0" bound
+func isUpperBoundMarker(c comparator) bool {
+	return c.op == "<" && len(c.version.Prerelease) == 1 && c.version.Prerelease[0] == "0"
+}
+
+// MaxSatisfying returns the highest of versions that satisfies rangeStr
+func MaxSatisfying(versions []string, rangeStr string) (string, bool) {
+	r, err := ParseRange(rangeStr)
+	if err != nil {
+		return "", false
+	}
+
+	best, bestVersion := "", Version{}
+	for _, s := range versions {
+		v, err := Parse(s)
+		if err != nil || !r.Contains(v) {
+			continue
+		}
+		if best == "" || Compare(v, bestVersion) > 0 {
+			best, bestVersion = s, v
+		}
+	}
+	return best, best != ""
+}
+
+// IsExact reports whether s names a single version rather than a range
+func IsExact(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// partial is a version with possibly missing or wildcard components (-1)
+type partial struct {
+	major, minor, patch int64
+	prerelease          []string
+}
+
+func parsePartial(s string) (partial, error) {
+	p := partial{major: -1, minor: -1, patch: -1}
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "="), "v")
+	if core, _, ok := strings.Cut(s, "+"); ok {
+		s = core
+	}
+	if core, pre, ok := strings.Cut(s, "-"); ok {
+		s, p.prerelease = core, strings.Split(pre, ".")
+	}
+	if s == "" {
+		return p, nil
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return p, fmt.Errorf("bad version %q", s)
+	}
+	fields := [3]*int64{&p.major, &p.minor, &p.patch}
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("bad version %q", s)
+		}
+		*fields[i] = n
+	}
+	return p, nil
+}
+
+func (p partial) floor() Version {
+	v := Version{Prerelease: p.prerelease}
+	if p.major > 0 {
+		v.Major = uint64(p.major)
+	}
+	if p.minor > 0 {
+		v.Minor = uint64(p.minor)
+	}
+	if p.patch > 0 {
+		v.Patch = uint64(p.patch)
+	}
+	return v
+}
+
+func version(major, minor, patch int64, prerelease ...string) Version {
+	return Version{Major: uint64(major), Minor: uint64(minor), Patch: uint64(patch), Prerelease: prerelease}
+}
+
+func parseComparatorSet(s string) ([]comparator, error) {
+	if s == "" || s == "*" || s == "x" || s == "X" {
+		return []comparator{{op: ">=", version: Version{}}}, nil
+	}
+
+	// Hyphen ranges: "1.2.3 - 2.3.4"
+	if lo, hi, ok := strings.Cut(s, " - "); ok {
+		from, err := parsePartial(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, err
+		}
+		to, err := parsePartial(strings.TrimSpace(hi))
+		if err != nil {
+			return nil, err
+		}
+		set := []comparator{{op: ">=", version: from.floor()}}
+		switch {
+		case to.major < 0:
+		case to.minor < 0:
+			set = append(set, comparator{op: "<", version: version(to.major+1, 0, 0, "0")})
+		case to.patch < 0:
+			set = append(set, comparator{op: "<", version: version(to.major, to.minor+1, 0, "0")})
+		default:
+			set = append(set, comparator{op: "<=", version: to.floor()})
+		}
+		return set, nil
+	}
+
+	set := make([]comparator, 0)
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		// Allow a space between an operator and its version: ">= 1.2.3"
+		if strings.Trim(field, "<>=~^") == "" && i+1 < len(fields) {
+			field += fields[i+1]
+			i++
+		}
+		comparators, err := parseSimple(field)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, comparators...)
+	}
+	return set, nil
+}
+
+func parseSimple(s string) ([]comparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "~>", "~", "^"} {
+		if strings.HasPrefix(s, prefix) {
+			op, s = prefix, s[len(prefix):]
+			break
+		}
+	}
+
+	p, err := parsePartial(s)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "~", "~>":
+		switch {
+		case p.major < 0:
+			return []comparator{{op: ">=", version: Version{}}}, nil
+		case p.minor < 0:
+			return []comparator{{op: ">=", version: p.floor()}, {op: "<", version: version(p.major+1, 0, 0, "0")}}, nil
+		}
+		return []comparator{{op: ">=", version: p.floor()}, {op: "<", version: version(p.major, p.minor+1, 0, "0")}}, nil
+
+	case "^":
+		switch {
+		case p.major < 0:
+			return []comparator{{op: ">=", version: Version{}}}, nil
+		case p.major > 0 || p.minor < 0:
+			return []comparator{{op: ">=", version: p.floor()}, {op: "<", version: version(p.major+1, 0, 0, "0")}}, nil
+		case p.minor > 0 || p.patch < 0:
+			return []comparator{{op: ">=", version: p.floor()}, {op: "<", version: version(0, p.minor+1, 0, "0")}}, nil
+		}
+		return []comparator{{op: ">=", version: p.floor()}, {op: "<", version: version(0, 0, p.patch+1, "0")}}, nil
+
+	case ">", "<", ">=", "<=":
+		if p.major < 0 {
+			if op == "<" || op == ">" {
+				// Nothing is above or below "*"
+				return []comparator{{op: "<", version: version(0, 0, 0, "0")}}, nil
+			}
+			return []comparator{{op: ">=", version: Version{}}}, nil
+		}
+		if p.patch >= 0 {
+			return []comparator{{op: op, version: p.floor()}}, nil
+		}
+		// Partial versions: ">1.2" is ">=1.3.0", "<=1.2" is "<1.3.0-0"
+		next := version(p.major+1, 0, 0, "0")
+		if p.minor >= 0 {
+			next = version(p.major, p.minor+1, 0, "0")
+		}
+		switch op {
+		case ">":
+			next.Prerelease = nil
+			return []comparator{{op: ">=", version: next}}, nil
+		case "<=":
+			return []comparator{{op: "<", version: next}}, nil
+		case "<":
+			return []comparator{{op: "<", version: version(int64(p.floor().Major), int64(p.floor().Minor), 0, "0")}}, nil
+		}
+		return []comparator{{op: ">=", version: p.floor()}}, nil
+	}
+
+	// Bare or "=" partials: "1.2.3", "1.2", "1.x"
+	switch {
+	case p.major < 0:
+		return []comparator{{op: ">=", version: Version{}}}, nil
+	case p.minor < 0:
+		return []comparator{{op: ">=", version: p.floor()}, {op: "<", version: version(p.major+1, 0, 0, "0")}}, nil
+	case p.patch < 0:
+		return []comparator{{op: ">=", version: p.floor()}, {op: "<", version: version(p.major, p.minor+1, 0, "0")}}, nil
+	}
+	return []comparator{{op: "=", version: p.floor()}}, nil
+}
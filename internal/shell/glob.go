@@ -0,0 +1,124 @@
+package shell
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+const globMeta = "*?["
+
+// globPattern turns w into a path.Match pattern with its quoted parts and
+// variable values escaped, and reports whether any unquoted part uses *, ?
+// or [
+func globPattern(w word, getenv func(string) string) (string, bool) {
+	var b strings.Builder
+	isGlob := false
+	for _, seg := range w {
+		text := seg.text
+		if seg.variable {
+			text = getenv(seg.text)
+		}
+		if seg.quoted || seg.variable {
+			for _, c := range text {
+				if strings.ContainsRune(globMeta+`\`, c) {
+					b.WriteByte('\\')
+				}
+				b.WriteRune(c)
+			}
+			continue
+		}
+		if strings.ContainsAny(text, globMeta) {
+			isGlob = true
+		}
+		b.WriteString(text)
+	}
+	return b.String(), isGlob
+}
+
+// glob returns the paths matching a slash-separated pattern, relative to
+// dir unless the pattern is absolute, in sorted order. As in sh, a * or ?
+// does not match a leading dot.
+func glob(dir, pattern string) ([]string, error) {
+	matches := []string{""}
+	if strings.HasPrefix(pattern, "/") {
+		matches = []string{"/"}
+	} else if vol := filepath.VolumeName(pattern); vol != "" {
+		matches = []string{vol + "/"}
+		pattern = pattern[len(vol):]
+	}
+
+	for _, part := range strings.Split(pattern, "/") {
+		if part == "" {
+			continue
+		}
+		if _, err := path.Match(part, ""); err != nil {
+			return nil, errors.Wrap(errors.ErrShellSyntax, "bad pattern "+pattern)
+		}
+
+		var next []string
+		for _, match := range matches {
+			if !strings.ContainsAny(part, globMeta) {
+				name := unescape(part)
+				if _, err := os.Lstat(onDisk(dir, joinMatch(match, name))); err == nil {
+					next = append(next, joinMatch(match, name))
+				}
+				continue
+			}
+			entries, err := os.ReadDir(onDisk(dir, match))
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				name := entry.Name()
+				if strings.HasPrefix(name, ".") && !strings.HasPrefix(part, ".") {
+					continue
+				}
+				if ok, _ := path.Match(part, name); ok {
+					next = append(next, joinMatch(match, name))
+				}
+			}
+		}
+		matches = next
+	}
+
+	if len(matches) == 1 && (matches[0] == "" || strings.HasSuffix(matches[0], "/")) {
+		return nil, nil
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func joinMatch(match, name string) string {
+	if match == "" || strings.HasSuffix(match, "/") {
+		return match + name
+	}
+	return match + "/" + name
+}
+
+// onDisk is the filesystem path of a match
+func onDisk(dir, match string) string {
+	if match == "" {
+		return dir
+	}
+	p := filepath.FromSlash(match)
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
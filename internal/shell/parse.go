@@ -0,0 +1,280 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// segment is a piece of a word: literal text, or a variable expanded when
+// the command runs. Quoted segments are exempt from globbing.
+type segment struct {
+	text     string
+	variable bool
+	quoted   bool
+}
+
+type word []segment
+
+// assignment is a NAME=value prefix of a command
+type assignment struct {
+	name  string
+	value word
+}
+
+type command struct {
+	assigns []assignment
+	args    []word
+}
+
+// step is a command and the operator joining it to the one before: "",
+// "&&", "||" or ";"
+type step struct {
+	op  string
+	cmd command
+}
+
+// parse splits a command line into steps
+func parse(line string) ([]step, error) {
+	p := &parser{src: []rune(line)}
+	return p.parse()
+}
+
+type parser struct {
+	src   []rune
+	pos   int
+	steps []step
+	cmd   command
+	word  word
+	// inWord is set once the current word has started, so that "" is kept
+	// as an empty argument
+	inWord bool
+	op     string
+}
+
+func (p *parser) syntaxError(format string, args ...any) error {
+	return errors.Wrap(errors.ErrShellSyntax, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parse() ([]step, error) {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.endWord()
+			p.pos++
+		case c == '#' && !p.inWord:
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == '\'':
+			if err := p.singleQuoted(); err != nil {
+				return nil, err
+			}
+		case c == '"':
+			if err := p.doubleQuoted(); err != nil {
+				return nil, err
+			}
+		case c == '\\':
+			p.pos++
+			if p.pos < len(p.src) {
+				p.literal(string(p.src[p.pos]), true)
+				p.pos++
+			}
+		case c == '$':
+			if err := p.variable(false); err != nil {
+				return nil, err
+			}
+		case c == '&' || c == '|':
+			if p.peek(1) != c {
+				return nil, p.syntaxError("%q is not supported", string(c))
+			}
+			if err := p.endCommand(string([]rune{c, c})); err != nil {
+				return nil, err
+			}
+			p.pos += 2
+		case c == ';':
+			if err := p.endCommand(";"); err != nil {
+				return nil, err
+			}
+			p.pos++
+		case strings.ContainsRune("<>()`", c):
+			return nil, p.syntaxError("%q is not supported", string(c))
+		case c == '~' && !p.inWord && (p.peek(1) == 0 || p.peek(1) == '/' || isBlank(p.peek(1))):
+			p.word = append(p.word, segment{text: "HOME", variable: true})
+			p.inWord = true
+			p.pos++
+		default:
+			p.literal(string(c), false)
+			p.pos++
+		}
+	}
+
+	p.endWord()
+	if len(p.cmd.args) == 0 && len(p.cmd.assigns) == 0 {
+		if p.op == "&&" || p.op == "||" {
+			return nil, p.syntaxError("missing command after %s", p.op)
+		}
+		return p.steps, nil
+	}
+	p.steps = append(p.steps, step{op: p.op, cmd: p.cmd})
+	return p.steps, nil
+}
+
+func (p *parser) peek(offset int) rune {
+	if p.pos+offset < len(p.src) {
+		return p.src[p.pos+offset]
+	}
+	return 0
+}
+
+func isBlank(c rune) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ';' || c == '&' || c == '|'
+}
+
+// literal appends text to the current word, merging it with a preceding
+// literal of the same kind
+func (p *parser) literal(text string, quoted bool) {
+	p.inWord = true
+	if n := len(p.word); n > 0 && !p.word[n-1].variable && p.word[n-1].quoted == quoted {
+		p.word[n-1].text += text
+		return
+	}
+	p.word = append(p.word, segment{text: text, quoted: quoted})
+}
+
+func (p *parser) singleQuoted() error {
+	end := p.pos + 1
+	for end < len(p.src) && p.src[end] != '\'' {
+		end++
+	}
+	if end == len(p.src) {
+		return p.syntaxError("unterminated single quote")
+	}
+	p.literal(string(p.src[p.pos+1:end]), true)
+	p.pos = end + 1
+	return nil
+}
+
+func (p *parser) doubleQuoted() error {
+	p.pos++
+	p.literal("", true)
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; c {
+		case '"':
+			p.pos++
+			return nil
+		case '\\':
+			// Only these characters can be escaped inside double quotes
+			if next := p.peek(1); next == '"' || next == '\\' || next == '$' || next == '`' {
+				p.literal(string(next), true)
+				p.pos += 2
+				continue
+			}
+			p.literal("\\", true)
+			p.pos++
+		case '$':
+			if err := p.variable(true); err != nil {
+				return err
+			}
+		case '`':
+			return p.syntaxError("command substitution is not supported")
+		default:
+			p.literal(string(c), true)
+			p.pos++
+		}
+	}
+	return p.syntaxError("unterminated double quote")
+}
+
+// variable reads $NAME or ${NAME}; a $ not followed by a name is literal
+func (p *parser) variable(quoted bool) error {
+	p.pos++
+	braced := p.peek(0) == '{'
+	if braced {
+		p.pos++
+	} else if p.peek(0) == '(' {
+		return p.syntaxError("command substitution is not supported")
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && isNameRune(p.src[p.pos], p.pos == start) {
+		p.pos++
+	}
+	name := string(p.src[start:p.pos])
+
+	if braced {
+		if p.peek(0) != '}' || name == "" {
+			return p.syntaxError("bad substitution")
+		}
+		p.pos++
+	}
+	if name == "" {
+		p.literal("$", quoted)
+		return nil
+	}
+	p.inWord = true
+	p.word = append(p.word, segment{text: name, variable: true, quoted: quoted})
+	return nil
+}
+
+func isNameRune(c rune, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+// endWord finishes the current word, treating NAME=value words before the
+// command name as assignments
+func (p *parser) endWord() {
+	if !p.inWord {
+		return
+	}
+	w := p.word
+	p.word, p.inWord = nil, false
+
+	if len(p.cmd.args) == 0 && len(w) > 0 && !w[0].variable && !w[0].quoted {
+		if name, value, ok := strings.Cut(w[0].text, "="); ok && isName(name) {
+			rest := append(word{}, w[1:]...)
+			if value != "" {
+				rest = append(word{{text: value}}, rest...)
+			}
+			p.cmd.assigns = append(p.cmd.assigns, assignment{name: name, value: rest})
+			return
+		}
+	}
+	p.cmd.args = append(p.cmd.args, w)
+}
+
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if !isNameRune(c, i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// endCommand finishes the current command, which op joins to the next one
+func (p *parser) endCommand(op string) error {
+	p.endWord()
+	if len(p.cmd.args) == 0 && len(p.cmd.assigns) == 0 {
+		return p.syntaxError("unexpected %s", op)
+	}
+	p.steps = append(p.steps, step{op: p.op, cmd: p.cmd})
+	p.cmd = command{}
+	p.op = op
+	return nil
+}
+
+// Quote quotes s so that the shell reads it back as a single word
+func Quote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(c rune) bool {
+		return !(isNameRune(c, false) || strings.ContainsRune("-./:=@%+,", c))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,344 @@
+// Package shell runs package.json scripts with a small subset of the POSIX
+// shell that behaves the same on every platform: commands joined by &&, ||
+// and ;, single and double quotes, $NAME expansion, NAME=value assignments
+// and filename globbing. Pipes, redirections and subshells are rejected.
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// ExitError reports a script whose last command exited with a nonzero status
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Shell runs command lines. Variables assigned and directories changed by
+// one command line are kept for the next.
+type Shell struct {
+	dir    string
+	env    map[string]string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Option configures a Shell
+type Option func(*Shell)
+
+// WithDir sets the working directory, the current one by default
+func WithDir(dir string) Option {
+	return func(s *Shell) {
+		s.dir = dir
+	}
+}
+
+// WithEnv sets the environment, in os.Environ form; later entries override
+// earlier ones. The process environment is used by default.
+func WithEnv(env []string) Option {
+	return func(s *Shell) {
+		s.env = make(map[string]string, len(env))
+		for _, entry := range env {
+			if name, value, ok := strings.Cut(entry, "="); ok {
+				s.Setenv(name, value)
+			}
+		}
+	}
+}
+
+// WithStdio sets the standard streams commands are connected to
+func WithStdio(stdin io.Reader, stdout, stderr io.Writer) Option {
+	return func(s *Shell) {
+		s.stdin, s.stdout, s.stderr = stdin, stdout, stderr
+	}
+}
+
+// New creates a shell
+func New(opts ...Option) *Shell {
+	s := &Shell{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	WithEnv(os.Environ())(s)
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.dir == "" {
+		s.dir, _ = os.Getwd()
+	}
+	return s
+}
+
+// envKey is the key a variable is stored under; names are case-insensitive
+// on Windows
+func envKey(name string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToUpper(name)
+	}
+	return name
+}
+
+// Getenv returns the value of a variable
+func (s *Shell) Getenv(name string) string {
+	return s.env[envKey(name)]
+}
+
+// Setenv sets a variable for the commands that follow
+func (s *Shell) Setenv(name, value string) {
+	s.env[envKey(name)] = value
+}
+
+// Environ returns the environment in os.Environ form
+func (s *Shell) Environ() []string {
+	env := make([]string, 0, len(s.env))
+	for name, value := range s.env {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// Dir returns the working directory
+func (s *Shell) Dir() string {
+	return s.dir
+}
+
+// Run runs a command line. It returns an *ExitError when the last command
+// that ran failed, and an error wrapping ErrShellSyntax when line uses
+// syntax outside the supported subset.
+func (s *Shell) Run(ctx context.Context, line string) error {
+	steps, err := parse(line)
+	if err != nil {
+		return err
+	}
+
+	status := 0
+	for _, st := range steps {
+		if (st.op == "&&" && status != 0) || (st.op == "||" && status == 0) {
+			continue
+		}
+		var exit bool
+		status, exit, err = s.runCommand(ctx, st.cmd)
+		if err != nil {
+			return err
+		}
+		if exit {
+			break
+		}
+	}
+	if status != 0 {
+		return &ExitError{Code: status}
+	}
+	return nil
+}
+
+// runCommand runs a single command, returning its status and whether it
+// was the exit builtin
+func (s *Shell) runCommand(ctx context.Context, cmd command) (int, bool, error) {
+	env := make(map[string]string, len(cmd.assigns))
+	for _, a := range cmd.assigns {
+		env[a.name] = s.expand(a.value)
+	}
+
+	var args []string
+	for _, w := range cmd.args {
+		expanded, err := s.expandWord(w)
+		if err != nil {
+			return 0, false, err
+		}
+		args = append(args, expanded...)
+	}
+
+	// Assignments without a command set shell variables
+	if len(args) == 0 {
+		for name, value := range env {
+			s.Setenv(name, value)
+		}
+		return 0, false, nil
+	}
+
+	if builtin, ok := builtins[args[0]]; ok {
+		status, exit := builtin(s, args[1:])
+		return status, exit, nil
+	}
+
+	path, err := s.lookPath(args[0])
+	if err != nil {
+		fmt.Fprintf(s.stderr, "edon: %s: command not found\n", args[0])
+		return 127, false, nil
+	}
+
+	c := exec.CommandContext(ctx, path, args[1:]...)
+	c.Dir = s.dir
+	c.Stdin, c.Stdout, c.Stderr = s.stdin, s.stdout, s.stderr
+	c.Env = s.Environ()
+	for name, value := range env {
+		c.Env = append(c.Env, name+"="+value)
+	}
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			if code := exitErr.ExitCode(); code >= 0 {
+				return code, false, nil
+			}
+			return 128, false, nil
+		}
+		fmt.Fprintf(s.stderr, "edon: %s: %v\n", args[0], err)
+		return 126, false, nil
+	}
+	return 0, false, nil
+}
+
+// expand joins the segments of w, substituting variables
+func (s *Shell) expand(w word) string {
+	var b strings.Builder
+	for _, seg := range w {
+		if seg.variable {
+			b.WriteString(s.Getenv(seg.text))
+		} else {
+			b.WriteString(seg.text)
+		}
+	}
+	return b.String()
+}
+
+// expandWord expands w into arguments: none for an unquoted variable that
+// is empty, the matching paths for a glob pattern that matches, and w as
+// written otherwise
+func (s *Shell) expandWord(w word) ([]string, error) {
+	value := s.expand(w)
+	quoted := false
+	for _, seg := range w {
+		quoted = quoted || seg.quoted || !seg.variable
+	}
+	if value == "" && !quoted {
+		return nil, nil
+	}
+
+	pattern, isGlob := globPattern(w, s.Getenv)
+	if !isGlob {
+		return []string{value}, nil
+	}
+	matches, err := glob(s.dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return []string{value}, nil
+	}
+	return matches, nil
+}
+
+// lookPath finds the executable for name in the shell's PATH
+func (s *Shell) lookPath(name string) (string, error) {
+	if strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(s.dir, path)
+		}
+		return findExecutable(path)
+	}
+	for _, dir := range filepath.SplitList(s.Getenv("PATH")) {
+		if dir == "" {
+			dir = "."
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(s.dir, dir)
+		}
+		if path, err := findExecutable(filepath.Join(dir, name)); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.Wrap(errors.ErrCommandNotFound, name)
+}
+
+// findExecutable returns path if it is an executable file; on Windows it
+// also tries the extensions in PATHEXT
+func findExecutable(path string) (string, error) {
+	candidates := []string{path}
+	if runtime.GOOS == "windows" && filepath.Ext(path) == "" {
+		exts := os.Getenv("PATHEXT")
+		if exts == "" {
+			exts = ".com;.exe;.bat;.cmd"
+		}
+		candidates = nil
+		for _, ext := range strings.Split(exts, ";") {
+			candidates = append(candidates, path+strings.ToLower(ext))
+		}
+	}
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if runtime.GOOS == "windows" || info.Mode()&0o111 != 0 {
+			return candidate, nil
+		}
+	}
+	return "", errors.Wrap(errors.ErrCommandNotFound, path)
+}
+
+// builtins run in the shell itself, so they work the same everywhere and
+// cd can change the directory of the commands that follow
+var builtins = map[string]func(s *Shell, args []string) (status int, exit bool){
+	"cd": func(s *Shell, args []string) (int, bool) {
+		dir := s.Getenv("HOME")
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(s.dir, dir)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			fmt.Fprintf(s.stderr, "edon: cd: %s: no such directory\n", args[0])
+			return 1, false
+		}
+		s.dir = dir
+		return 0, false
+	},
+	"echo": func(s *Shell, args []string) (int, bool) {
+		newline := "\n"
+		if len(args) > 0 && args[0] == "-n" {
+			newline, args = "", args[1:]
+		}
+		fmt.Fprint(s.stdout, strings.Join(args, " ")+newline)
+		return 0, false
+	},
+	"exit": func(s *Shell, args []string) (int, bool) {
+		if len(args) == 0 {
+			return 0, true
+		}
+		code, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Fprintf(s.stderr, "edon: exit: %s: numeric argument required\n", args[0])
+			return 2, true
+		}
+		return code, true
+	},
+	"export": func(s *Shell, args []string) (int, bool) {
+		for _, arg := range args {
+			if name, value, ok := strings.Cut(arg, "="); ok && isName(name) {
+				s.Setenv(name, value)
+			}
+		}
+		return 0, false
+	},
+	"true": func(*Shell, []string) (int, bool) {
+		return 0, false
+	},
+	"false": func(*Shell, []string) (int, bool) {
+		return 1, false
+	},
+}
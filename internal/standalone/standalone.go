@@ -0,0 +1,132 @@
+// Package standalone builds executables that carry a compiled program, and
+// finds that program again when such an executable starts.
+//
+// The program is appended to a copy of the edon binary, followed by a
+// trailer holding its length and a magic marker:
+//
+//	[edon binary][encoded program][length, 8 bytes][magic, 8 bytes]
+//
+// Executable formats ignore bytes past their last section, so the copy runs
+// as edon does and checks its own tail at startup.
+package standalone
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/runtime"
+)
+
+// magic marks an executable that carries a program
+var magic = []byte("edon\x00prg")
+
+const trailerSize = 16
+
+// Build writes an executable to out that runs program, made from the
+// executable at base. A program base already carries is replaced.
+func Build(base, out string, program *runtime.Program) error {
+	payload, err := program.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(base)
+	if err != nil {
+		return errors.WrapWith(errors.ErrFileRead, err, base)
+	}
+	defer in.Close()
+	size, _, err := locate(in)
+	if err != nil {
+		return err
+	}
+
+	tmp := out + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return errors.WrapWith(errors.ErrFileWrite, err, out)
+	}
+	trailer := make([]byte, trailerSize)
+	binary.LittleEndian.PutUint64(trailer, uint64(len(payload)))
+	copy(trailer[8:], magic)
+
+	_, err = io.Copy(f, io.NewSectionReader(in, 0, size))
+	if err == nil {
+		_, err = f.Write(append(payload, trailer...))
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, out)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return errors.WrapWith(errors.ErrFileWrite, err, out)
+	}
+	return nil
+}
+
+// Read returns the program the executable at path carries, or nil when it
+// carries none
+func Read(path string) (*runtime.Program, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WrapWith(errors.ErrFileRead, err, path)
+	}
+	defer f.Close()
+
+	size, payloadSize, err := locate(f)
+	if err != nil || payloadSize == 0 {
+		return nil, err
+	}
+	payload := make([]byte, payloadSize)
+	if _, err := f.ReadAt(payload, size); err != nil {
+		return nil, errors.WrapWith(errors.ErrInvalidProgram, err, path)
+	}
+
+	program := &runtime.Program{}
+	if err := program.UnmarshalBinary(payload); err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+// Detect returns the program the running executable carries, or nil when
+// it carries none
+func Detect() (*runtime.Program, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		// Without its own path the executable runs as plain edon
+		return nil, nil
+	}
+	return Read(exe)
+}
+
+// locate returns the size of the executable in f without a program, and
+// the size of the program it carries
+func locate(f *os.File) (size, payloadSize int64, err error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, errors.WrapWith(errors.ErrFileRead, err, f.Name())
+	}
+	size = info.Size()
+	if size < trailerSize {
+		return size, 0, nil
+	}
+
+	trailer := make([]byte, trailerSize)
+	if _, err := f.ReadAt(trailer, size-trailerSize); err != nil {
+		return 0, 0, errors.WrapWith(errors.ErrFileRead, err, f.Name())
+	}
+	if !bytes.Equal(trailer[8:], magic) {
+		return size, 0, nil
+	}
+	payloadSize = int64(binary.LittleEndian.Uint64(trailer))
+	if payloadSize <= 0 || payloadSize > size-trailerSize {
+		return 0, 0, errors.Wrap(errors.ErrInvalidProgram, f.Name()+": bad trailer")
+	}
+	return size - trailerSize - payloadSize, payloadSize, nil
+}
@@ -0,0 +1,6 @@
+import { assertEquals } from "edon:assert";
+import { greet } from "./greet.js";
+
+Edon.test("greet", () => {
+  assertEquals(greet("Edon"), "Hello from Edon!");
+});
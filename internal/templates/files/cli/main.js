@@ -0,0 +1,7 @@
+import { run } from "@/cli.js";
+
+const { output, code } = run(process.argv.slice(2), process.env);
+if (output) {
+  console.log(output);
+}
+process.exitCode = code;
@@ -0,0 +1,38 @@
+export const usage = `Usage: {{.Name}} [--shout] [name]
+
+Greets name, or the current user.
+
+Options:
+  --shout   Greet loudly
+  --help    Show this message`;
+
+// parseArgs splits argv into --flags, --options=values and positional
+// arguments
+export function parseArgs(argv) {
+  const flags = {};
+  const positional = [];
+  for (const arg of argv) {
+    if (arg.startsWith("--")) {
+      const [name, value = true] = arg.slice(2).split(/=(.*)/s);
+      flags[name] = value;
+    } else {
+      positional.push(arg);
+    }
+  }
+  return { flags, positional };
+}
+
+// run works out what the tool prints and the status it exits with
+export function run(argv, env = {}) {
+  const { flags, positional } = parseArgs(argv);
+  if (flags.help) {
+    return { output: usage, code: 0 };
+  }
+  const unknown = Object.keys(flags).filter((name) => name !== "shout");
+  if (unknown.length > 0) {
+    return { output: `Unknown option --${unknown[0]}\n\n${usage}`, code: 2 };
+  }
+
+  const greeting = `Hello, ${positional[0] ?? env.USER ?? "world"}!`;
+  return { output: flags.shout ? greeting.toUpperCase() : greeting, code: 0 };
+}
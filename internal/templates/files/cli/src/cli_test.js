@@ -0,0 +1,27 @@
+import { assertEquals, assertStringIncludes } from "edon:assert";
+import { parseArgs, run } from "@/cli.js";
+
+Edon.test("parseArgs separates flags from arguments", () => {
+  assertEquals(parseArgs(["--shout", "ada", "--color=red"]), {
+    flags: { shout: true, color: "red" },
+    positional: ["ada"],
+  });
+});
+
+Edon.test("run greets", async (t) => {
+  await t.step("by name", () => {
+    assertEquals(run(["ada"]), { output: "Hello, ada!", code: 0 });
+  });
+  await t.step("the current user", () => {
+    assertEquals(run([], { USER: "grace" }).output, "Hello, grace!");
+  });
+  await t.step("loudly", () => {
+    assertEquals(run(["--shout", "ada"]).output, "HELLO, ADA!");
+  });
+});
+
+Edon.test("run rejects unknown options", () => {
+  const { output, code } = run(["--nope"]);
+  assertEquals(code, 2);
+  assertStringIncludes(output, "Unknown option --nope");
+});
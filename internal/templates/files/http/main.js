@@ -0,0 +1,16 @@
+// edon has no network listener yet. The handler takes and returns plain
+// request and response objects, so it can be mounted on one as is; until
+// then this walks through a few requests.
+import { handle } from "@/handler.js";
+
+const requests = [
+  { method: "GET", url: "/" },
+  { method: "GET", url: "/hello/edon" },
+  { method: "POST", url: "/echo", body: JSON.stringify({ ping: "pong" }) },
+  { method: "GET", url: "/missing" },
+];
+
+for (const request of requests) {
+  const response = handle(request);
+  console.log(`${request.method} ${request.url} -> ${response.status} ${response.body}`);
+}
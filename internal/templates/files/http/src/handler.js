@@ -0,0 +1,18 @@
+import { json, Router, text } from "@/router.js";
+
+const router = new Router()
+  .get("/", () => text(200, "{{.Name}} is up"))
+  .get("/hello/:name", (_, { name }) => json(200, { message: `Hello, ${name}!` }))
+  .post("/echo", (request) => {
+    try {
+      return json(200, JSON.parse(request.body ?? ""));
+    } catch {
+      return text(400, "Body must be JSON");
+    }
+  });
+
+// handle answers a request { method, url, headers, body } with a response
+// { status, headers, body }
+export function handle(request) {
+  return router.handle(request);
+}
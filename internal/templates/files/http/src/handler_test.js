@@ -0,0 +1,24 @@
+import { describe, it } from "edon:test";
+import { assertEquals } from "edon:assert";
+import { handle } from "@/handler.js";
+
+describe("handle", () => {
+  it("greets by name", () => {
+    const response = handle({ method: "GET", url: "/hello/ada%20lovelace" });
+    assertEquals(response.status, 200);
+    assertEquals(JSON.parse(response.body), { message: "Hello, ada lovelace!" });
+  });
+
+  it("echoes JSON bodies", () => {
+    const response = handle({ method: "POST", url: "/echo", body: '{"n":1}' });
+    assertEquals([response.status, response.body], [200, '{"n":1}']);
+    assertEquals(handle({ method: "POST", url: "/echo", body: "nope" }).status, 400);
+  });
+
+  it("rejects unknown routes and methods", () => {
+    assertEquals(handle({ method: "GET", url: "/missing" }).status, 404);
+    const response = handle({ method: "DELETE", url: "/echo" });
+    assertEquals(response.status, 405);
+    assertEquals(response.headers.allow, "POST");
+  });
+});
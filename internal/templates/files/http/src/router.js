@@ -0,0 +1,51 @@
+// Router matches requests against routes such as "/hello/:name"
+export class Router {
+  #routes = [];
+
+  add(method, pattern, handler) {
+    const names = [];
+    const source = pattern.replace(/:(\w+)/g, (_, name) => {
+      names.push(name);
+      return "([^/]+)";
+    });
+    this.#routes.push({ method, regexp: new RegExp(`^${source}$`), names, handler });
+    return this;
+  }
+
+  get(pattern, handler) {
+    return this.add("GET", pattern, handler);
+  }
+
+  post(pattern, handler) {
+    return this.add("POST", pattern, handler);
+  }
+
+  handle(request) {
+    const path = request.url.split("?")[0];
+    let allowed = [];
+    for (const route of this.#routes) {
+      const match = route.regexp.exec(path);
+      if (match === null) {
+        continue;
+      }
+      if (route.method !== request.method) {
+        allowed.push(route.method);
+        continue;
+      }
+      const params = Object.fromEntries(route.names.map((name, i) => [name, decodeURIComponent(match[i + 1])]));
+      return route.handler(request, params);
+    }
+    if (allowed.length > 0) {
+      return text(405, "Method Not Allowed", { allow: allowed.join(", ") });
+    }
+    return text(404, "Not Found");
+  }
+}
+
+export function text(status, body, headers = {}) {
+  return { status, headers: { "content-type": "text/plain; charset=utf-8", ...headers }, body };
+}
+
+export function json(status, value) {
+  return { status, headers: { "content-type": "application/json" }, body: JSON.stringify(value) };
+}
@@ -0,0 +1,4 @@
+// The public API. Modules under src/ import each other relatively, so the
+// package works without the import map that the tests use.
+export { slugify } from "./src/slugify.js";
+export { truncate } from "./src/truncate.js";
@@ -0,0 +1,14 @@
+import { assertEquals } from "edon:assert";
+import { slugify, truncate } from "./mod.js";
+import { truncate as fromSource } from "@/truncate.js";
+
+Edon.test("slugify", () => {
+  assertEquals(slugify("  Hello, Wörld! "), "hello-world");
+  assertEquals(slugify("already-a-slug"), "already-a-slug");
+});
+
+Edon.test("truncate", () => {
+  assertEquals(truncate("short", 10), "short");
+  assertEquals(truncate("a long sentence", 8), "a long…");
+  assertEquals(fromSource, truncate);
+});
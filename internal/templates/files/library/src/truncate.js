@@ -0,0 +1,8 @@
+// truncate shortens text to at most length characters, ending it with
+// suffix when it was cut
+export function truncate(text, length, suffix = "…") {
+  if (text.length <= length) {
+    return text;
+  }
+  return text.slice(0, Math.max(0, length - suffix.length)).trimEnd() + suffix;
+}
@@ -0,0 +1,23 @@
+export class Stack {
+  #items = [];
+
+  get size() {
+    return this.#items.length;
+  }
+
+  push(item) {
+    this.#items.push(item);
+    return this;
+  }
+
+  pop() {
+    if (this.#items.length === 0) {
+      throw new Error("pop from an empty stack");
+    }
+    return this.#items.pop();
+  }
+
+  peek() {
+    return this.#items.at(-1);
+  }
+}
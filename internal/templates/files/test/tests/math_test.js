@@ -0,0 +1,23 @@
+import { describe, it } from "edon:test";
+import { assertAlmostEquals, assertEquals, assertThrows } from "edon:assert";
+import { mean, sum } from "@/math.js";
+
+describe("sum", () => {
+  it("adds numbers", () => {
+    assertEquals(sum(1, 2, 3), 6);
+  });
+
+  it("is zero for no numbers", () => {
+    assertEquals(sum(), 0);
+  });
+});
+
+describe("mean", () => {
+  it("averages numbers", () => {
+    assertAlmostEquals(mean([0.1, 0.2]), 0.15);
+  });
+
+  it("rejects an empty list", () => {
+    assertThrows(() => mean([]), RangeError, "no numbers");
+  });
+});
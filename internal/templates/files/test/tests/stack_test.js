@@ -0,0 +1,35 @@
+import { afterEach, beforeEach, describe, it } from "edon:test";
+import { assertEquals, assertThrows } from "edon:assert";
+import { Stack } from "@/stack.js";
+
+describe("Stack", () => {
+  let stack;
+
+  beforeEach(() => {
+    stack = new Stack().push("a").push("b");
+  });
+
+  afterEach(() => {
+    stack = undefined;
+  });
+
+  it("pops in reverse order", () => {
+    assertEquals([stack.pop(), stack.pop()], ["b", "a"]);
+  });
+
+  it("peeks without popping", () => {
+    assertEquals(stack.peek(), "b");
+    assertEquals(stack.size, 2);
+  });
+});
+
+Edon.test("an emptied stack", async (t) => {
+  const stack = new Stack().push(1);
+
+  await t.step("pops its last item", () => {
+    assertEquals(stack.pop(), 1);
+  });
+  await t.step("refuses to pop again", () => {
+    assertThrows(() => stack.pop(), Error, "empty stack");
+  });
+});
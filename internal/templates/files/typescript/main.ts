@@ -0,0 +1,5 @@
+import { greet } from "@/greet.ts";
+import { renderCard } from "@/card.tsx";
+
+console.log(greet(process.env.USER ?? "world"));
+console.log(renderCard({ title: "{{.Name}}", items: ["TypeScript", "JSX", "Tests"] }));
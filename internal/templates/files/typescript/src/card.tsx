@@ -0,0 +1,17 @@
+import { Fragment, h } from "@/jsx.ts";
+
+export interface CardProps {
+  title: string;
+  items: string[];
+}
+
+export function renderCard({ title, items }: CardProps): string {
+  return String(
+    <>
+      <h1 class="title">{title}</h1>
+      <ul>
+        {items.map((item) => <li>{item}</li>)}
+      </ul>
+    </>,
+  );
+}
@@ -0,0 +1,9 @@
+import { assertEquals } from "edon:assert";
+import { renderCard } from "@/card.tsx";
+
+Edon.test("renderCard renders and escapes", () => {
+  assertEquals(
+    renderCard({ title: "Tools", items: ["<edon>", "tsx"] }),
+    '<h1 class="title">Tools</h1><ul><li>&lt;edon&gt;</li><li>tsx</li></ul>',
+  );
+});
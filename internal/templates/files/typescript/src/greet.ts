@@ -0,0 +1,7 @@
+export interface GreetOptions {
+  excited?: boolean;
+}
+
+export function greet(name: string, options: GreetOptions = {}): string {
+  return `Hello, ${name}${options.excited ? "!" : "."}`;
+}
@@ -0,0 +1,7 @@
+import { assertEquals } from "edon:assert";
+import { greet } from "@/greet.ts";
+
+Edon.test("greet", () => {
+  assertEquals(greet("Ada"), "Hello, Ada.");
+  assertEquals(greet("Ada", { excited: true }), "Hello, Ada!");
+});
@@ -0,0 +1,45 @@
+// A small JSX factory that renders elements straight to HTML strings.
+// edon.json points compilerOptions.jsxFactory and jsxFragmentFactory here.
+
+type Child = string | number | boolean | null | undefined | Child[];
+type Props = Record<string, unknown> | null;
+type Component = (props: Record<string, unknown>) => string;
+
+const escapes: Record<string, string> = { "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;" };
+
+function escape(text: string): string {
+  return text.replace(/[&<>"]/g, (c) => escapes[c]);
+}
+
+function render(child: Child): string {
+  if (Array.isArray(child)) {
+    return child.map(render).join("");
+  }
+  if (child === null || child === undefined || typeof child === "boolean") {
+    return "";
+  }
+  // Elements are rendered already; only raw text needs escaping
+  return child instanceof Html ? child.value : escape(String(child));
+}
+
+class Html {
+  constructor(readonly value: string) {}
+  toString(): string {
+    return this.value;
+  }
+}
+
+export function h(tag: string | Component, props: Props, ...children: Child[]): Html {
+  if (typeof tag === "function") {
+    return new Html(String(tag({ ...props, children })));
+  }
+  const attrs = Object.entries(props ?? {})
+    .filter(([, value]) => value !== false && value !== null && value !== undefined)
+    .map(([name, value]) => (value === true ? ` ${name}` : ` ${name}="${escape(String(value))}"`))
+    .join("");
+  return new Html(`<${tag}${attrs}>${render(children)}</${tag}>`);
+}
+
+export function Fragment(props: { children?: Child[] }): string {
+  return render(props.children ?? []);
+}
@@ -0,0 +1,143 @@
+// Package templates holds the project templates edon init writes.
+//
+// Each template is a directory under files/. Files are rendered with
+// text/template and Data; a file named gitignore is written as .gitignore,
+// since dotfiles are awkward to embed.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+//go:embed files
+var files embed.FS
+
+// Default is the template edon init uses when none is named
+const Default = "basic"
+
+// descriptions are the templates and what they set up
+var descriptions = map[string]string{
+	"basic":      "A script with an edon.json",
+	"cli":        "A command-line tool that compiles to a standalone executable",
+	"http":       "An HTTP request handler with routing and tests",
+	"library":    "A package of ES modules with tests and a bundle task",
+	"typescript": "TypeScript with JSX compiled through compilerOptions",
+	"test":       "A project set up for edon test, with describe/it, steps and reporters",
+}
+
+// Template is a project template
+type Template struct {
+	Name        string
+	Description string
+}
+
+// List returns the templates, sorted by name
+func List() []Template {
+	list := make([]Template, 0, len(descriptions))
+	for name, description := range descriptions {
+		list = append(list, Template{Name: name, Description: description})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Data is what templates are rendered with
+type Data struct {
+	// Name is the project name, usable as a package name
+	Name string
+}
+
+// File is a rendered template file
+type File struct {
+	// Path is relative to the project directory, with slashes
+	Path     string
+	Contents []byte
+}
+
+// Render renders the named template
+func Render(name string, data Data) ([]File, error) {
+	if _, ok := descriptions[name]; !ok {
+		return nil, errors.Wrap(errors.ErrUnknownTemplate, name)
+	}
+
+	root := path.Join("files", name)
+	var out []File
+	err := fs.WalkDir(files, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		src, err := files.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New(p).Option("missingkey=error").Parse(string(src))
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return err
+		}
+
+		rel := strings.TrimPrefix(p, root+"/")
+		if path.Base(rel) == "gitignore" {
+			rel = path.Join(path.Dir(rel), ".gitignore")
+		}
+		out = append(out, File{Path: rel, Contents: buf.Bytes()})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WrapWith(errors.ErrUnknownTemplate, err, name)
+	}
+	return out, nil
+}
+
+// Write writes files into dir. Unless force is set it writes nothing when
+// any of them exists already, and returns ErrFileExists naming them.
+func Write(dir string, files []File, force bool) error {
+	if !force {
+		var existing []string
+		for _, f := range files {
+			if _, err := os.Lstat(filepath.Join(dir, filepath.FromSlash(f.Path))); err == nil {
+				existing = append(existing, f.Path)
+			}
+		}
+		if len(existing) > 0 {
+			return errors.Wrap(errors.ErrFileExists, strings.Join(existing, ", "))
+		}
+	}
+
+	for _, f := range files {
+		target := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return errors.WrapWith(errors.ErrFileWrite, err, f.Path)
+		}
+		if err := os.WriteFile(target, f.Contents, 0644); err != nil {
+			return errors.WrapWith(errors.ErrFileWrite, err, f.Path)
+		}
+	}
+	return nil
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9._~-]+`)
+
+// PackageName turns a directory name into a valid package name
+func PackageName(dir string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(filepath.Base(dir)), "-")
+	name = strings.Trim(name, "-._")
+	if name == "" {
+		return "app"
+	}
+	return name
+}
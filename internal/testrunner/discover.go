@@ -0,0 +1,80 @@
+package testrunner
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// testExtensions are the extensions a test file may have
+var testExtensions = []string{".js", ".mjs", ".ts", ".mts", ".jsx", ".tsx"}
+
+// IsTestFile reports whether name looks like a test file: name_test.ext or
+// name.test.ext, with a JavaScript or TypeScript extension
+func IsTestFile(name string) bool {
+	base := filepath.Base(name)
+	for _, ext := range testExtensions {
+		stem, ok := strings.CutSuffix(base, ext)
+		if !ok {
+			continue
+		}
+		return strings.HasSuffix(stem, "_test") || strings.HasSuffix(stem, ".test")
+	}
+	return false
+}
+
+// Discover returns the test files under paths, sorted and without
+// duplicates. Directories are searched recursively, skipping node_modules
+// and hidden directories; files are taken as given. The working directory
+// is searched when paths is empty.
+func Discover(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrFileNotFound, root)
+		}
+		if !info.IsDir() {
+			add(filepath.Clean(root))
+			continue
+		}
+		err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				name := entry.Name()
+				if path != root && (name == "node_modules" || strings.HasPrefix(name, ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if IsTestFile(path) {
+				add(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.WrapWith(errors.ErrFileRead, err, root)
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
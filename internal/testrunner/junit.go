@@ -0,0 +1,149 @@
+package testrunner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/katungi/edon/internal/modules/edon"
+)
+
+// JUnitReporter writes results as JUnit XML once every file has run, with
+// a testsuite per file. Steps become test cases of their own, named after
+// the tests they belong to.
+type JUnitReporter struct {
+	w      io.Writer
+	suites []junitSuite
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// NewJUnitReporter creates a JUnitReporter writing to w
+func NewJUnitReporter(w io.Writer) *JUnitReporter {
+	return &JUnitReporter{w: w}
+}
+
+func (j *JUnitReporter) File(result *FileResult) {
+	suite := junitSuite{Name: result.Path, Time: seconds(result.Duration)}
+	if result.Err != nil {
+		message := result.Err.Error()
+		suite.Cases = append(suite.Cases, junitCase{
+			Name:      result.Path,
+			Classname: result.Path,
+			Time:      seconds(0),
+			Error:     &junitProblem{Message: firstLine(message), Text: message},
+		})
+	}
+	for _, t := range result.Tests {
+		suite.addCase(result.Path, t, "")
+	}
+
+	for _, c := range suite.Cases {
+		suite.Tests++
+		switch {
+		case c.Failure != nil:
+			suite.Failures++
+		case c.Error != nil:
+			suite.Errors++
+		case c.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+	j.suites = append(j.suites, suite)
+}
+
+// addCase adds t, then its steps, as test cases
+func (s *junitSuite) addCase(file string, t edon.TestResult, parent string) {
+	name := t.Name
+	if parent != "" {
+		name = parent + " > " + t.Name
+	}
+	c := junitCase{Name: name, Classname: file, Time: seconds(t.Duration())}
+	switch t.Status {
+	case edon.TestFailed:
+		c.Failure = &junitProblem{Message: firstLine(t.Error), Type: errorType(t.Error), Text: t.Error}
+	case edon.TestIgnored:
+		c.Skipped = &struct{}{}
+	}
+	s.Cases = append(s.Cases, c)
+
+	for _, step := range t.Steps {
+		s.addCase(file, step, name)
+	}
+}
+
+func (j *JUnitReporter) Done(s Summary) error {
+	report := junitSuites{Name: "edon test", Time: seconds(s.Duration), Suites: j.suites}
+	for _, suite := range j.suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+	}
+
+	if _, err := io.WriteString(j.w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(j.w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(j.w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// errorType is the error class at the start of a message like
+// "AssertionError: Values are not equal"
+func errorType(message string) string {
+	name, _, ok := strings.Cut(firstLine(message), ": ")
+	if !ok || strings.ContainsAny(name, " \t") {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,149 @@
+package testrunner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/edon"
+)
+
+// Reporters lists the reporter names NewReporter accepts
+var Reporters = []string{"pretty", "tap", "junit"}
+
+// NewReporter returns the named reporter writing to w
+func NewReporter(name string, w io.Writer) (Reporter, error) {
+	switch name {
+	case "", "pretty":
+		return NewPrettyReporter(w), nil
+	case "tap":
+		return NewTAPReporter(w), nil
+	case "junit":
+		return NewJUnitReporter(w), nil
+	}
+	return nil, errors.Wrap(errors.ErrUnknownReporter, fmt.Sprintf("%q, want one of %s", name, strings.Join(Reporters, ", ")))
+}
+
+// failure is a failed test or file the pretty reporter lists at the end
+type failure struct {
+	name  string
+	file  string
+	error string
+}
+
+// PrettyReporter writes results for people to read, listing the errors
+// once every file has run
+type PrettyReporter struct {
+	w        io.Writer
+	failures []failure
+	ok       *color.Color
+	failed   *color.Color
+	ignored  *color.Color
+	dim      *color.Color
+}
+
+// NewPrettyReporter creates a PrettyReporter writing to w
+func NewPrettyReporter(w io.Writer) *PrettyReporter {
+	return &PrettyReporter{
+		w:       w,
+		ok:      color.New(color.FgGreen),
+		failed:  color.New(color.FgRed, color.Bold),
+		ignored: color.New(color.FgYellow),
+		dim:     color.New(color.Faint),
+	}
+}
+
+func (p *PrettyReporter) File(result *FileResult) {
+	if result.Err != nil {
+		fmt.Fprintf(p.w, "%s %s\n", p.failed.Sprint("error:"), result.Path)
+		p.failures = append(p.failures, failure{file: result.Path, error: result.Err.Error()})
+		return
+	}
+
+	count := len(result.Tests)
+	fmt.Fprintf(p.w, "%s\n", p.dim.Sprintf("running %d %s from %s", count, plural(count, "test", "tests"), result.Path))
+	for _, t := range result.Tests {
+		p.test(result.Path, t, "", 0)
+	}
+	fmt.Fprintln(p.w)
+}
+
+// test writes a test and its steps; parent is the name of the test or
+// step a step belongs to
+func (p *PrettyReporter) test(file string, t edon.TestResult, parent string, depth int) {
+	name := t.Name
+	if parent != "" {
+		name = parent + " > " + t.Name
+	}
+	indent := strings.Repeat("  ", depth)
+	if len(t.Steps) > 0 {
+		fmt.Fprintf(p.w, "%s%s ...\n", indent, t.Name)
+		for _, step := range t.Steps {
+			p.test(file, step, name, depth+1)
+		}
+	}
+	fmt.Fprintf(p.w, "%s%s ... %s\n", indent, t.Name, p.status(t))
+	if t.Status == edon.TestFailed {
+		p.failures = append(p.failures, failure{name: name, file: file, error: t.Error})
+	}
+}
+
+func (p *PrettyReporter) status(t edon.TestResult) string {
+	switch t.Status {
+	case edon.TestPassed:
+		return p.ok.Sprint("ok") + " " + p.dim.Sprintf("(%s)", elapsed(t.Duration()))
+	case edon.TestIgnored:
+		return p.ignored.Sprint("ignored")
+	}
+	return p.failed.Sprint("FAILED") + " " + p.dim.Sprintf("(%s)", elapsed(t.Duration()))
+}
+
+func (p *PrettyReporter) Done(s Summary) error {
+	if len(p.failures) > 0 {
+		fmt.Fprintf(p.w, "%s\n\n", p.failed.Sprint(" ERRORS "))
+		for _, f := range p.failures {
+			if f.name == "" {
+				fmt.Fprintf(p.w, "%s\n%s\n\n", f.file, f.error)
+				continue
+			}
+			fmt.Fprintf(p.w, "%s %s\n%s\n\n", f.name, p.dim.Sprintf("=> %s", f.file), f.error)
+		}
+	}
+
+	result := p.ok.Sprint("ok")
+	if !s.OK() {
+		result = p.failed.Sprint("FAILED")
+	}
+	parts := []string{result, fmt.Sprintf("%d passed", s.Passed), fmt.Sprintf("%d failed", s.Failed)}
+	if s.Ignored > 0 {
+		parts = append(parts, fmt.Sprintf("%d ignored", s.Ignored))
+	}
+	if s.Filtered > 0 {
+		parts = append(parts, fmt.Sprintf("%d filtered out", s.Filtered))
+	}
+	if s.Errors > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s failed to load", s.Errors, plural(s.Errors, "file", "files")))
+	}
+	_, err := fmt.Fprintf(p.w, "%s %s\n", strings.Join(parts, " | "), p.dim.Sprintf("(%s)", elapsed(s.Duration)))
+	if err == nil && s.Only {
+		_, err = fmt.Fprintf(p.w, "%s\n", p.ignored.Sprint("note: tests marked only left the others out"))
+	}
+	return err
+}
+
+func elapsed(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,171 @@
+// Package testrunner runs test files: each in a fresh runtime, where the
+// tests it registers with Edon.test or edon:test are then run and
+// reported.
+package testrunner
+
+import (
+	"time"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/edon"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/runtime"
+)
+
+// ErrTestsFailed is returned by Run when a test fails (re-exported from
+// errors package)
+var ErrTestsFailed = errors.ErrTestsFailed
+
+// FileResult holds the results of the tests in one file
+type FileResult struct {
+	Path  string
+	Tests []edon.TestResult
+	// Filtered counts the tests left out by the filter or by only
+	Filtered int
+	// Only is set when the file marks some tests only
+	Only bool
+	// Err is set when the file failed to load or its tests failed to run
+	Err      error
+	Duration time.Duration
+}
+
+// Failed reports whether the file failed to load or has failing tests
+func (f *FileResult) Failed() bool {
+	if f.Err != nil {
+		return true
+	}
+	for _, t := range f.Tests {
+		if t.Status == edon.TestFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary counts the results of a run
+type Summary struct {
+	Files    int
+	Passed   int
+	Failed   int
+	Ignored  int
+	Filtered int
+	// Errors counts the files that failed to load
+	Errors int
+	// Only is set when tests marked only left others out
+	Only     bool
+	Duration time.Duration
+}
+
+// OK reports whether the run passed
+func (s Summary) OK() bool {
+	return s.Failed == 0 && s.Errors == 0
+}
+
+func (s *Summary) add(f *FileResult) {
+	s.Files++
+	if f.Err != nil {
+		s.Errors++
+	}
+	for _, t := range f.Tests {
+		switch t.Status {
+		case edon.TestPassed:
+			s.Passed++
+		case edon.TestFailed:
+			s.Failed++
+		case edon.TestIgnored:
+			s.Ignored++
+		}
+	}
+	s.Filtered += f.Filtered
+	s.Only = s.Only || f.Only
+}
+
+// Reporter receives the results of each file as it finishes, then the
+// summary of the run
+type Reporter interface {
+	File(result *FileResult)
+	Done(summary Summary) error
+}
+
+// Option configures a run
+type Option func(*runner)
+
+type runner struct {
+	filter string
+	loader *loader.ModuleLoader
+}
+
+// WithFilter runs only the tests whose names contain filter or, when it is
+// written /like this/, match it as a regular expression
+func WithFilter(filter string) Option {
+	return func(r *runner) {
+		r.filter = filter
+	}
+}
+
+// WithModuleLoader sets the loader test files are loaded with
+func WithModuleLoader(l *loader.ModuleLoader) Option {
+	return func(r *runner) {
+		r.loader = l
+	}
+}
+
+// Run runs the tests in files, reporting each file to reporter. It returns
+// an error wrapping ErrTestsFailed when a test fails or a file fails to
+// load.
+func Run(files []string, reporter Reporter, opts ...Option) (Summary, error) {
+	r := &runner{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.loader == nil {
+		r.loader = loader.NewModuleLoader()
+	}
+
+	var summary Summary
+	start := time.Now()
+	for _, file := range files {
+		result := r.runFile(file)
+		summary.add(result)
+		reporter.File(result)
+	}
+	summary.Duration = time.Since(start)
+
+	if err := reporter.Done(summary); err != nil {
+		return summary, err
+	}
+	if !summary.OK() {
+		return summary, errors.ErrTestsFailed
+	}
+	return summary, nil
+}
+
+// runFile runs the tests of one file in a runtime of its own
+func (r *runner) runFile(file string) *FileResult {
+	result := &FileResult{Path: file}
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	rt, err := runtime.New(runtime.WithModuleLoader(r.loader))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer rt.Close()
+
+	if err := rt.ExecuteFile(file); err != nil {
+		result.Err = err
+		return result
+	}
+	report, err := rt.RunTests(r.filter)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Tests = report.Tests
+	result.Filtered = report.Filtered
+	result.Only = report.Only
+	return result
+}
@@ -0,0 +1,89 @@
+package testrunner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/katungi/edon/internal/modules/edon"
+)
+
+// TAPReporter writes results in the Test Anything Protocol, version 13.
+// Steps are written as subtests, and each file starts with a comment
+// naming it.
+type TAPReporter struct {
+	w      io.Writer
+	count  int
+	header bool
+}
+
+// NewTAPReporter creates a TAPReporter writing to w
+func NewTAPReporter(w io.Writer) *TAPReporter {
+	return &TAPReporter{w: w}
+}
+
+func (t *TAPReporter) File(result *FileResult) {
+	if !t.header {
+		fmt.Fprintln(t.w, "TAP version 13")
+		t.header = true
+	}
+	fmt.Fprintf(t.w, "# %s\n", result.Path)
+
+	if result.Err != nil {
+		t.count++
+		fmt.Fprintf(t.w, "not ok %d - %s\n", t.count, tapEscape(result.Path))
+		writeDiagnostic(t.w, "", result.Err.Error())
+		return
+	}
+	for _, test := range result.Tests {
+		t.count++
+		writeTAPTest(t.w, t.count, test, "")
+	}
+}
+
+func (t *TAPReporter) Done(s Summary) error {
+	if !t.header {
+		fmt.Fprintln(t.w, "TAP version 13")
+	}
+	_, err := fmt.Fprintf(t.w, "1..%d\n# tests %d\n# pass %d\n# fail %d\n# skip %d\n",
+		t.count, s.Passed+s.Failed+s.Ignored, s.Passed, s.Failed+s.Errors, s.Ignored)
+	return err
+}
+
+// writeTAPTest writes a test point, preceded by its steps as a subtest
+func writeTAPTest(w io.Writer, n int, t edon.TestResult, indent string) {
+	if len(t.Steps) > 0 {
+		sub := indent + "    "
+		fmt.Fprintf(w, "%s# Subtest: %s\n", sub, tapEscape(t.Name))
+		for i, step := range t.Steps {
+			writeTAPTest(w, i+1, step, sub)
+		}
+		fmt.Fprintf(w, "%s1..%d\n", sub, len(t.Steps))
+	}
+
+	switch t.Status {
+	case edon.TestPassed:
+		fmt.Fprintf(w, "%sok %d - %s\n", indent, n, tapEscape(t.Name))
+	case edon.TestIgnored:
+		fmt.Fprintf(w, "%sok %d - %s # SKIP\n", indent, n, tapEscape(t.Name))
+	default:
+		fmt.Fprintf(w, "%snot ok %d - %s\n", indent, n, tapEscape(t.Name))
+		writeDiagnostic(w, indent, t.Error)
+	}
+}
+
+// writeDiagnostic writes message as the YAML block following a test point
+func writeDiagnostic(w io.Writer, indent, message string) {
+	fmt.Fprintf(w, "%s  ---\n%s  message: |-\n", indent, indent)
+	for _, line := range strings.Split(message, "\n") {
+		fmt.Fprintf(w, "%s    %s\n", indent, line)
+	}
+	fmt.Fprintf(w, "%s  ...\n", indent)
+}
+
+// tapEscape escapes the characters TAP gives a meaning in descriptions
+func tapEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "#", `\#`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,177 @@
+package wasm
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Export names of the generated modules
+const (
+	MemoryExport = "memory"
+	TableExport  = "table"
+	GlobalExport = "global"
+	// SizeExport and GrowExport are the functions of a table helper module
+	SizeExport = "size"
+	GrowExport = "grow"
+)
+
+// MemoryModule returns a module that defines and exports a memory
+func MemoryModule(limits Limits) []byte {
+	var memories bytes.Buffer
+	writeU32(&memories, 1)
+	writeLimits(&memories, limits)
+
+	return encodeModule(
+		encodeSection(5, memories.Bytes()),
+		encodeSection(7, exportSection(MemoryExport, KindMemory)),
+	)
+}
+
+// TableModule returns a module that defines and exports a table
+func TableModule(table TableType) []byte {
+	var tables bytes.Buffer
+	writeU32(&tables, 1)
+	tables.WriteByte(table.Elem)
+	writeLimits(&tables, table.Limits)
+
+	return encodeModule(
+		encodeSection(4, tables.Bytes()),
+		encodeSection(7, exportSection(TableExport, KindTable)),
+	)
+}
+
+// GlobalModule returns a module that defines and exports a global holding
+// value, given as raw bits
+func GlobalModule(global GlobalType, value uint64) []byte {
+	var globals bytes.Buffer
+	writeU32(&globals, 1)
+	globals.WriteByte(global.Value)
+	if global.Mutable {
+		globals.WriteByte(1)
+	} else {
+		globals.WriteByte(0)
+	}
+	switch global.Value {
+	case I32:
+		globals.WriteByte(0x41)
+		writeS64(&globals, int64(int32(uint32(value))))
+	case I64:
+		globals.WriteByte(0x42)
+		writeS64(&globals, int64(value))
+	case F32:
+		globals.WriteByte(0x43)
+		globals.Write(binary.LittleEndian.AppendUint32(nil, uint32(value)))
+	case F64:
+		globals.WriteByte(0x44)
+		globals.Write(binary.LittleEndian.AppendUint64(nil, value))
+	}
+	globals.WriteByte(0x0b)
+
+	return encodeModule(
+		encodeSection(6, globals.Bytes()),
+		encodeSection(7, exportSection(GlobalExport, KindGlobal)),
+	)
+}
+
+// TableHelperModule returns a module that imports the table module.name and
+// exports functions reading its size and growing it with null elements
+func TableHelperModule(module, name string, elem byte) []byte {
+	// () -> i32 and (i32) -> i32
+	types := []byte{2, 0x60, 0, 1, I32, 0x60, 1, I32, 1, I32}
+
+	var imports bytes.Buffer
+	writeU32(&imports, 1)
+	writeImport(&imports, Import{Module: module, Name: name, Kind: KindTable, Table: TableType{Elem: elem}})
+
+	var exports bytes.Buffer
+	writeU32(&exports, 2)
+	writeName(&exports, SizeExport)
+	exports.Write([]byte{byte(KindFunction), 0})
+	writeName(&exports, GrowExport)
+	exports.Write([]byte{byte(KindFunction), 1})
+
+	// table.size 0 and table.grow 0 with ref.null as the fill value
+	size := []byte{0, 0xfc, 16, 0, 0x0b}
+	grow := []byte{0, 0xd0, elem, 0x20, 0, 0xfc, 15, 0, 0x0b}
+	var code bytes.Buffer
+	writeU32(&code, 2)
+	for _, body := range [][]byte{size, grow} {
+		writeU32(&code, uint32(len(body)))
+		code.Write(body)
+	}
+
+	return encodeModule(
+		encodeSection(1, types),
+		encodeSection(2, imports.Bytes()),
+		encodeSection(3, []byte{2, 0, 1}),
+		encodeSection(7, exports.Bytes()),
+		encodeSection(10, code.Bytes()),
+	)
+}
+
+func exportSection(name string, kind ExternKind) []byte {
+	var w bytes.Buffer
+	writeU32(&w, 1)
+	writeName(&w, name)
+	w.WriteByte(byte(kind))
+	writeU32(&w, 0)
+	return w.Bytes()
+}
+
+func encodeModule(sections ...[]byte) []byte {
+	out := []byte("\x00asm\x01\x00\x00\x00")
+	for _, section := range sections {
+		out = append(out, section...)
+	}
+	return out
+}
+
+func encodeSection(id byte, payload []byte) []byte {
+	var w bytes.Buffer
+	w.WriteByte(id)
+	writeU32(&w, uint32(len(payload)))
+	w.Write(payload)
+	return w.Bytes()
+}
+
+func writeLimits(w *bytes.Buffer, limits Limits) {
+	if limits.HasMax {
+		w.WriteByte(1)
+		writeU32(w, limits.Min)
+		writeU32(w, limits.Max)
+		return
+	}
+	w.WriteByte(0)
+	writeU32(w, limits.Min)
+}
+
+func writeName(w *bytes.Buffer, name string) {
+	writeU32(w, uint32(len(name)))
+	w.WriteString(name)
+}
+
+func writeU32(w *bytes.Buffer, v uint32) {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			b |= 0x80
+		}
+		w.WriteByte(b)
+		if v == 0 {
+			return
+		}
+	}
+}
+
+func writeS64(w *bytes.Buffer, v int64) {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			w.WriteByte(b)
+			return
+		}
+		w.WriteByte(b | 0x80)
+	}
+}
@@ -0,0 +1,290 @@
+// Package wasm reads and writes just enough of the WebAssembly binary format
+// to link modules together: the import, table and export sections, and the
+// small generated modules that hold standalone memories, tables and globals.
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/katungi/edon/internal/errors"
+)
+
+// ExternKind is the kind of an import or export
+type ExternKind byte
+
+const (
+	KindFunction ExternKind = 0
+	KindTable    ExternKind = 1
+	KindMemory   ExternKind = 2
+	KindGlobal   ExternKind = 3
+)
+
+// String returns the name WebAssembly.Module.imports() uses for the kind
+func (k ExternKind) String() string {
+	switch k {
+	case KindFunction:
+		return "function"
+	case KindTable:
+		return "table"
+	case KindMemory:
+		return "memory"
+	case KindGlobal:
+		return "global"
+	}
+	return fmt.Sprintf("kind(%d)", byte(k))
+}
+
+// Value and reference types
+const (
+	I32       byte = 0x7f
+	I64       byte = 0x7e
+	F32       byte = 0x7d
+	F64       byte = 0x7c
+	FuncRef   byte = 0x70
+	ExternRef byte = 0x6f
+)
+
+// Limits are the size limits of a memory, in pages, or of a table
+type Limits struct {
+	Min    uint32
+	Max    uint32
+	HasMax bool
+}
+
+// TableType is the element type and limits of a table
+type TableType struct {
+	Elem   byte
+	Limits Limits
+}
+
+// GlobalType is the value type and mutability of a global
+type GlobalType struct {
+	Value   byte
+	Mutable bool
+}
+
+// Import is an entry of the import section
+type Import struct {
+	Module string
+	Name   string
+	Kind   ExternKind
+	Table  TableType
+	Memory Limits
+	Global GlobalType
+
+	// typeIndex is the type of an imported function
+	typeIndex uint32
+}
+
+// Export is an entry of the export section
+type Export struct {
+	Name  string
+	Kind  ExternKind
+	Index uint32
+}
+
+// Module is the linking information of a binary module
+type Module struct {
+	Imports []Import
+	Exports []Export
+	// Tables are the tables the module defines, after the imported ones
+	Tables []TableType
+
+	binary []byte
+	// importStart and importEnd span the import section, header included
+	importStart int
+	importEnd   int
+}
+
+// Parse reads the linking information of a binary module
+func Parse(binary []byte) (*Module, error) {
+	if len(binary) < 8 || !bytes.Equal(binary[:4], []byte("\x00asm")) {
+		return nil, errors.Wrap(errors.ErrInvalidWasm, "missing magic header")
+	}
+	if !bytes.Equal(binary[4:8], []byte{1, 0, 0, 0}) {
+		return nil, errors.Wrap(errors.ErrInvalidWasm, "unsupported binary version")
+	}
+
+	m := &Module{binary: binary}
+	r := &reader{data: binary, pos: 8}
+	for r.pos < len(r.data) {
+		start := r.pos
+		id := r.byte()
+		size := r.u32()
+		end := r.pos + int(size)
+		if r.err != nil || end > len(r.data) {
+			return nil, errors.Wrap(errors.ErrInvalidWasm, fmt.Sprintf("section %d is truncated", id))
+		}
+
+		section := &reader{data: r.data[:end], pos: r.pos}
+		switch id {
+		case 2:
+			m.importStart, m.importEnd = start, end
+			m.Imports = readVector(section, readImport)
+		case 4:
+			m.Tables = readVector(section, readTableType)
+		case 7:
+			m.Exports = readVector(section, readExport)
+		}
+		if section.err != nil {
+			return nil, errors.Wrap(errors.ErrInvalidWasm, fmt.Sprintf("section %d: %v", id, section.err))
+		}
+		r.pos = end
+	}
+	return m, nil
+}
+
+// TableType returns the type of the table at index, counting imported
+// tables first
+func (m *Module) TableType(index uint32) (TableType, bool) {
+	for _, imp := range m.Imports {
+		if imp.Kind != KindTable {
+			continue
+		}
+		if index == 0 {
+			return imp.Table, true
+		}
+		index--
+	}
+	if int(index) < len(m.Tables) {
+		return m.Tables[index], true
+	}
+	return TableType{}, false
+}
+
+// Relink returns the binary with every import renamed to targets[i], which
+// must have an entry for each import
+func (m *Module) Relink(targets [][2]string) []byte {
+	if len(m.Imports) == 0 {
+		return m.binary
+	}
+
+	var section bytes.Buffer
+	writeU32(&section, uint32(len(m.Imports)))
+	for i, imp := range m.Imports {
+		imp.Module, imp.Name = targets[i][0], targets[i][1]
+		writeImport(&section, imp)
+	}
+
+	out := make([]byte, 0, len(m.binary)+section.Len())
+	out = append(out, m.binary[:m.importStart]...)
+	out = append(out, encodeSection(2, section.Bytes())...)
+	return append(out, m.binary[m.importEnd:]...)
+}
+
+func readImport(r *reader) Import {
+	imp := Import{Module: r.name(), Name: r.name(), Kind: ExternKind(r.byte())}
+	switch imp.Kind {
+	case KindFunction:
+		imp.typeIndex = r.u32()
+	case KindTable:
+		imp.Table = readTableType(r)
+	case KindMemory:
+		imp.Memory = readLimits(r)
+	case KindGlobal:
+		imp.Global = GlobalType{Value: r.byte(), Mutable: r.byte() == 1}
+	default:
+		r.fail("unknown import kind %d", imp.Kind)
+	}
+	return imp
+}
+
+func writeImport(w *bytes.Buffer, imp Import) {
+	writeName(w, imp.Module)
+	writeName(w, imp.Name)
+	w.WriteByte(byte(imp.Kind))
+	switch imp.Kind {
+	case KindFunction:
+		writeU32(w, imp.typeIndex)
+	case KindTable:
+		w.WriteByte(imp.Table.Elem)
+		writeLimits(w, imp.Table.Limits)
+	case KindMemory:
+		writeLimits(w, imp.Memory)
+	case KindGlobal:
+		w.WriteByte(imp.Global.Value)
+		if imp.Global.Mutable {
+			w.WriteByte(1)
+		} else {
+			w.WriteByte(0)
+		}
+	}
+}
+
+func readTableType(r *reader) TableType {
+	return TableType{Elem: r.byte(), Limits: readLimits(r)}
+}
+
+func readLimits(r *reader) Limits {
+	flags := r.byte()
+	if flags > 3 {
+		r.fail("unsupported limits flags %#x", flags)
+		return Limits{}
+	}
+	limits := Limits{Min: r.u32()}
+	if flags&1 != 0 {
+		limits.Max, limits.HasMax = r.u32(), true
+	}
+	return limits
+}
+
+func readExport(r *reader) Export {
+	return Export{Name: r.name(), Kind: ExternKind(r.byte()), Index: r.u32()}
+}
+
+func readVector[T any](r *reader, read func(*reader) T) []T {
+	n := r.u32()
+	items := make([]T, 0, min(n, 1024))
+	for i := uint32(0); i < n && r.err == nil; i++ {
+		items = append(items, read(r))
+	}
+	return items
+}
+
+// reader decodes the primitive encodings, remembering the first error
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (r *reader) byte() byte {
+	if r.pos >= len(r.data) {
+		r.fail("unexpected end")
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *reader) u32() uint32 {
+	var result uint32
+	for shift := 0; shift < 35; shift += 7 {
+		b := r.byte()
+		result |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result
+		}
+	}
+	r.fail("integer too long")
+	return 0
+}
+
+func (r *reader) name() string {
+	n := int(r.u32())
+	if r.err != nil || r.pos+n > len(r.data) {
+		r.fail("unexpected end")
+		return ""
+	}
+	s := string(r.data[r.pos : r.pos+n])
+	r.pos += n
+	return s
+}
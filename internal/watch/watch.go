@@ -0,0 +1,317 @@
+// Package watch runs a program again whenever one of its files changes, for
+// edon -watch.
+//
+// The files are those of the local modules in the program's module graph,
+// resolved again before every run so new imports are picked up, plus any
+// extra files and directories. Changes arriving in quick succession, as
+// when an editor saves several files, cause a single restart.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+// DefaultDebounce is how long the files have to stay unchanged before the
+// program restarts
+const DefaultDebounce = 200 * time.Millisecond
+
+// clearScreen clears the terminal and its scrollback, and homes the cursor
+const clearScreen = "\x1b[2J\x1b[3J\x1b[H"
+
+// Runner runs the program once. It must return soon after ctx is done.
+type Runner func(ctx context.Context) error
+
+type config struct {
+	paths    []string
+	exclude  []string
+	debounce time.Duration
+	clear    bool
+	out      io.Writer
+}
+
+// Option configures Run
+type Option func(*config)
+
+// WithPaths also watches these files and directories, directories with
+// everything below them
+func WithPaths(paths ...string) Option {
+	return func(c *config) {
+		c.paths = append(c.paths, paths...)
+	}
+}
+
+// WithExclude ignores changes to files matching these glob patterns; see
+// Excluded
+func WithExclude(patterns ...string) Option {
+	return func(c *config) {
+		c.exclude = append(c.exclude, patterns...)
+	}
+}
+
+// WithDebounce sets how long the files have to stay unchanged before the
+// program restarts
+func WithDebounce(d time.Duration) Option {
+	return func(c *config) {
+		c.debounce = d
+	}
+}
+
+// WithClearScreen clears the terminal before every restart
+func WithClearScreen(clear bool) Option {
+	return func(c *config) {
+		c.clear = clear
+	}
+}
+
+// WithOutput sets where restarts are announced, stderr by default. The
+// screen is cleared by writing to it as well.
+func WithOutput(w io.Writer) Option {
+	return func(c *config) {
+		c.out = w
+	}
+}
+
+// Run runs the program with run, and runs it again whenever a file of the
+// module graph rooted at entry, or under the extra paths, changes. A run
+// that is still going when a file changes has its context cancelled first.
+// Run returns when ctx is done.
+//
+// Changes are watched through l.Watch, which replaces any watcher l has,
+// so modules that change are dropped from its cache as well.
+func Run(ctx context.Context, l *loader.ModuleLoader, entry string, run Runner, opts ...Option) error {
+	cfg := &config{debounce: DefaultDebounce, out: os.Stderr}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	for _, pattern := range cfg.exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return errors.Wrap(errors.ErrInvalidWatchPattern, pattern)
+		}
+	}
+
+	if _, err := os.Stat(entry); err == nil {
+		if entry, err = filepath.Abs(entry); err != nil {
+			return errors.WrapWith(errors.ErrWatch, err, entry)
+		}
+	}
+
+	lw, err := l.Watch()
+	if err != nil {
+		return errors.WrapWith(errors.ErrWatch, err, "")
+	}
+	defer lw.Close()
+	w := &watcher{cfg: cfg, loader: lw}
+
+	for {
+		// Resolve the graph before starting the run so that an edit made
+		// during the run is noticed; modules that fail to load are left to
+		// the run to report
+		graph, _ := l.ResolveGraph(ctx, entry)
+		if err := w.watch(entry, graph); err != nil {
+			return err
+		}
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() {
+			done <- run(runCtx)
+		}()
+		fmt.Fprintln(cfg.out, "Watcher Process started.")
+
+		var changed []string
+		changes := w.changes(ctx)
+		select {
+		case changed = <-changes:
+			cancel()
+			<-done
+		case err := <-done:
+			if err != nil {
+				fmt.Fprintf(cfg.out, "Watcher Process failed: %v\n", err)
+			}
+			fmt.Fprintln(cfg.out, "Watcher Process finished. Restarting on file change...")
+			changed = <-changes
+		}
+		cancel()
+
+		if ctx.Err() != nil {
+			return nil
+		}
+		if cfg.clear {
+			fmt.Fprint(cfg.out, clearScreen)
+		}
+		fmt.Fprintf(cfg.out, "Watcher File change detected: %s. Restarting!\n", strings.Join(w.relative(changed), ", "))
+	}
+}
+
+// Excluded reports whether path matches one of patterns, which follow
+// filepath.Match. A pattern matches a file by its path relative to the
+// working directory, by its name, or by any directory it is in, so "dist"
+// excludes everything below a dist directory.
+func Excluded(path string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	rel := path
+	if wd, err := os.Getwd(); err == nil {
+		if r, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(r, "..") {
+			rel = r
+		}
+	}
+
+	for _, pattern := range patterns {
+		pattern = filepath.Clean(pattern)
+		for p := rel; ; p = filepath.Dir(p) {
+			if ok, _ := filepath.Match(pattern, p); ok {
+				return true
+			}
+			if ok, _ := filepath.Match(pattern, filepath.Base(p)); ok {
+				return true
+			}
+			if parent := filepath.Dir(p); parent == p || parent == "." {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// watcher picks the changes a run depends on out of those the loader's
+// watcher reports
+type watcher struct {
+	cfg    *config
+	loader *loader.Watcher
+	// files are the module files of the current graph
+	files map[string]bool
+	// trees are the extra directories and files, as absolute paths
+	trees []string
+}
+
+// watch starts watching the extra paths, and the entry in case it did not
+// load. The loader watches the local modules of graph itself.
+func (w *watcher) watch(entry string, graph *loader.ModuleGraph) error {
+	w.files = map[string]bool{entry: true}
+	if graph != nil {
+		for _, module := range graph.Modules {
+			if module.Type == loader.TypeLocal && module.Path != "" {
+				w.files[module.Path] = true
+			}
+		}
+	}
+	w.loader.AddDir(filepath.Dir(entry))
+
+	w.trees = w.trees[:0]
+	for _, path := range w.cfg.paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return errors.WrapWith(errors.ErrWatch, err, path)
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return errors.WrapWith(errors.ErrWatch, err, path)
+		}
+		w.trees = append(w.trees, abs)
+		if !info.IsDir() {
+			w.loader.AddDir(filepath.Dir(abs))
+			continue
+		}
+		err = filepath.WalkDir(abs, func(dir string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if dir != abs && Excluded(dir, w.cfg.exclude) {
+				return filepath.SkipDir
+			}
+			w.loader.AddDir(dir)
+			return nil
+		})
+		if err != nil {
+			return errors.WrapWith(errors.ErrWatch, err, path)
+		}
+	}
+	return nil
+}
+
+// relevant reports whether a change to path should restart the program
+func (w *watcher) relevant(path string) bool {
+	if Excluded(path, w.cfg.exclude) {
+		return false
+	}
+	if w.files[path] {
+		return true
+	}
+	for _, tree := range w.trees {
+		if path == tree || strings.HasPrefix(path, tree+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// changes returns a channel that delivers the files that changed, once the
+// first relevant change has been followed by a quiet period, or nil when
+// ctx is done
+func (w *watcher) changes(ctx context.Context) <-chan []string {
+	result := make(chan []string, 1)
+	go func() {
+		result <- w.wait(ctx)
+	}()
+	return result
+}
+
+func (w *watcher) wait(ctx context.Context) []string {
+	changed := make(map[string]bool)
+	var quiet <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-quiet:
+			files := make([]string, 0, len(changed))
+			for file := range changed {
+				files = append(files, file)
+			}
+			sort.Strings(files)
+			return files
+		case change, ok := <-w.loader.Changes():
+			if !ok {
+				return nil
+			}
+			if !w.relevant(change.Path) {
+				continue
+			}
+			// New directories under an extra path are watched as well
+			if info, err := os.Stat(change.Path); err == nil && info.IsDir() {
+				w.loader.AddDir(change.Path)
+			}
+			changed[change.Path] = true
+			quiet = time.After(w.cfg.debounce)
+		}
+	}
+}
+
+// relative shortens paths under the working directory for messages
+func (w *watcher) relative(paths []string) []string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return paths
+	}
+	out := make([]string, len(paths))
+	for i, path := range paths {
+		out[i] = path
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			out[i] = rel
+		}
+	}
+	return out
+}
@@ -0,0 +1,22 @@
+// Basic functionality tests, run with: edon test tests/fixtures
+import { assertEquals, assertStrictEquals } from "edon:assert";
+
+Edon.test("arithmetic", () => {
+  assertStrictEquals(2 + 2, 4);
+});
+
+Edon.test("string concatenation", () => {
+  assertStrictEquals("Hello" + " " + "World", "Hello World");
+});
+
+Edon.test("arrays", () => {
+  const arr = [1, 2, 3];
+  assertStrictEquals(arr.length, 3);
+  assertEquals(arr.map((n) => n * 2), [2, 4, 6]);
+});
+
+Edon.test("objects", () => {
+  const obj = { name: "test" };
+  assertStrictEquals(obj.name, "test");
+  assertEquals({ ...obj, id: 1 }, { name: "test", id: 1 });
+});
@@ -0,0 +1 @@
+export const format = (names) => `hello, ${names.join(" and ")}`;
@@ -0,0 +1,16 @@
+// Compiled into a standalone executable by tests/integration/compile_test.go
+import { format } from "./format.js";
+import fs from "node:fs";
+
+const names: string[] = process.argv.slice(2);
+console.log(format(names.length > 0 ? names : ["world"]));
+
+try {
+  fs.readFileSync(process.argv[1]);
+  console.log("read: allowed");
+} catch (error) {
+  console.log("read: denied");
+}
+
+await Promise.resolve();
+process.exitCode = names.length;
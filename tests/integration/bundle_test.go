@@ -0,0 +1,146 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/bundle"
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/runtime"
+)
+
+func TestBundle(t *testing.T) {
+	registry := newFakeRegistry(t, fakePackage{name: "shout", version: "1.0.0", files: map[string]string{
+		"package.json": `{"name": "shout", "version": "1.0.0", "main": "index.js"}`,
+		"index.js":     `export default (s) => s.toUpperCase() + "!";`,
+	}})
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		_, _ = w.Write([]byte(`export const twice = (n) => n * 2;`))
+	}))
+	t.Cleanup(cdn.Close)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NPM_CONFIG_REGISTRY", registry.URL)
+
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"main.ts": `
+import shout from "npm:shout@1";
+import { twice } from "` + cdn.URL + `/util.js";
+import config from "./config.json" with { type: "json" };
+import raw from "./config.json" with { type: "text" };
+import { label } from "./lib/label.js";
+const count: number = twice(config.count);
+globalThis.result = shout(label(count)) + raw.length;
+export const answer = count;
+`,
+		"lib/label.js": `export const label = (n) => "count " + n;`,
+		"config.json":  `{"count": 21}`,
+	})
+
+	graph, err := loader.NewModuleLoader(loader.WithImportPolicy(loader.NewImportPolicy(strings.TrimPrefix(cdn.URL, "http://")))).
+		LoadGraph(context.Background(), filepath.Join(dir, "main.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "dist", "app.js")
+	files, err := bundle.Bundle(graph, out, bundle.WithSourceMap(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Path != out || files[1].Path != out+".map" {
+		t.Fatalf("Bundle files = %v", files)
+	}
+	code := string(files[0].Contents)
+	for _, leftover := range []string{"import ", "import(", `from "`} {
+		if strings.Contains(code, leftover) {
+			t.Errorf("bundle still imports modules:\n%s", code)
+		}
+	}
+	if !strings.HasSuffix(strings.TrimSpace(code), "//# sourceMappingURL=app.js.map") {
+		t.Errorf("bundle does not link its source map:\n%s", code)
+	}
+	var sourceMap struct {
+		Sources        []string `json:"sources"`
+		SourcesContent []string `json:"sourcesContent"`
+	}
+	if err := json.Unmarshal(files[1].Contents, &sourceMap); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(sourceMap.Sources, " "), "../lib/label.js") {
+		t.Errorf("source map sources = %v", sourceMap.Sources)
+	}
+	// TypeScript is mapped as written, types and all
+	for i, source := range sourceMap.Sources {
+		if source == "../main.ts" && (i >= len(sourceMap.SourcesContent) || !strings.Contains(sourceMap.SourcesContent[i], "const count: number")) {
+			t.Errorf("source map does not hold main.ts as written: %v", sourceMap.SourcesContent)
+		}
+	}
+	if !strings.Contains(strings.Join(sourceMap.Sources, " "), "../main.ts") {
+		t.Errorf("source map sources = %v, want ../main.ts", sourceMap.Sources)
+	}
+
+	// The bundle runs with the registry and the CDN gone
+	registry.Close()
+	cdn.Close()
+	t.Setenv("HOME", t.TempDir())
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(out, files[0].Contents, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rt, err := runtime.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+	if err := rt.ExecuteFile(out); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Eval(`if (globalThis.result !== "COUNT 42!13") throw new Error(globalThis.result)`); err != nil {
+		t.Errorf("bundle computed the wrong result: %v", err)
+	}
+
+	// IIFE bundles are classic scripts, minified on request
+	files, err = bundle.Bundle(graph, out, bundle.WithFormat(bundle.IIFE), bundle.WithMinify(true), bundle.WithGlobalName("app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code = string(files[0].Contents)
+	if !strings.HasPrefix(code, "var app=(()=>{") || strings.Contains(code, "\n  ") {
+		t.Errorf("IIFE bundle is not a minified script:\n%s", code)
+	}
+}
+
+func TestBundleBuiltins(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{"main.js": `import { join } from "node:path"; globalThis.result = join("a", "b");`})
+	graph, err := loader.NewModuleLoader().LoadGraph(context.Background(), filepath.Join(dir, "main.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Built-in modules come with the runtime and stay imports
+	files, err := bundle.Bundle(graph, filepath.Join(dir, "out.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(files[0].Contents), `from "node:path"`) {
+		t.Errorf("ESM bundle should import node:path:\n%s", files[0].Contents)
+	}
+
+	// which a classic script cannot do
+	_, err = bundle.Bundle(graph, filepath.Join(dir, "out.js"), bundle.WithFormat(bundle.IIFE))
+	if !errors.Is(err, errors.ErrBundle) || !strings.Contains(err.Error(), "node:path") {
+		t.Errorf("IIFE bundle error = %v, want ErrBundle naming node:path", err)
+	}
+}
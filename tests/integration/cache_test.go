@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+func TestRemoteModuleDiskCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var fetches, revalidations atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest.js", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/v1/mod.js", http.StatusFound)
+	})
+	mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidations.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "text/javascript")
+		w.Header().Set("ETag", `"v1"`)
+		if strings.HasSuffix(r.URL.Path, "/mod.js") {
+			_, _ = w.Write([]byte(`export { dep } from "./dep.js";`))
+			return
+		}
+		_, _ = w.Write([]byte(`export const dep = 1;`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	entry := filepath.Join(t.TempDir(), "main.js")
+	if err := os.WriteFile(entry, []byte(`import { dep } from "`+server.URL+`/latest.js";`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := loader.NewImportPolicy(strings.TrimPrefix(server.URL, "http://"))
+	loadGraph := func(opts ...loader.Option) *loader.ModuleGraph {
+		t.Helper()
+		l := loader.NewModuleLoader(append([]loader.Option{loader.WithImportPolicy(policy)}, opts...)...)
+		graph, err := l.LoadGraph(context.Background(), entry)
+		if err != nil {
+			t.Fatalf("LoadGraph() error = %v", err)
+		}
+		return graph
+	}
+
+	graph := loadGraph()
+	if _, ok := graph.Modules[server.URL+"/v1/dep.js"]; !ok {
+		t.Errorf("relative import should resolve against the redirect target, got %d modules", len(graph.Modules))
+	}
+	if fetches.Load() != 2 {
+		t.Fatalf("fetches = %d, want 2", fetches.Load())
+	}
+
+	// A second process reads everything from disk
+	loadGraph()
+	if fetches.Load() != 2 || revalidations.Load() != 0 {
+		t.Errorf("fetches = %d, revalidations = %d after a cached run, want 2 and 0", fetches.Load(), revalidations.Load())
+	}
+
+	dir, err := loader.DefaultDepsDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, content, ok := loader.NewDiskCache(dir).Get(server.URL + "/latest.js")
+	if !ok {
+		t.Fatal("latest.js is not in the disk cache")
+	}
+	if cached.FinalURL != server.URL+"/v1/mod.js" || cached.ETag != `"v1"` || cached.ContentType != "text/javascript" {
+		t.Errorf("cache entry = %+v", cached)
+	}
+	if !strings.Contains(string(content), "./dep.js") {
+		t.Errorf("cached content = %q", content)
+	}
+
+	// Reloading a prefix revalidates only the matching entries
+	loadGraph(loader.WithReload(server.URL + "/v1/"))
+	if fetches.Load() != 2 || revalidations.Load() != 1 {
+		t.Errorf("fetches = %d, revalidations = %d after -reload, want 2 and 1", fetches.Load(), revalidations.Load())
+	}
+}
@@ -0,0 +1,86 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+func TestCachedOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	registry := newFakeRegistry(t, fakePackage{name: "pad", version: "1.3.0", files: map[string]string{
+		"package.json": `{"name": "pad", "version": "1.3.0", "main": "index.js"}`,
+		"index.js":     `export default (s) => " " + s;`,
+	}})
+	t.Setenv("NPM_CONFIG_REGISTRY", registry.URL)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a.js", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`export const a = 1;`))
+	})
+	mux.HandleFunc("/b.js", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`export const b = 2;`))
+	})
+	cdn := httptest.NewServer(mux)
+	t.Cleanup(cdn.Close)
+	policy := loader.NewImportPolicy(strings.TrimPrefix(cdn.URL, "http://"))
+
+	dir := t.TempDir()
+	entry := filepath.Join(dir, "main.js")
+	write := func(pad string) {
+		t.Helper()
+		src := `import { a } from "` + cdn.URL + `/a.js"; import { b } from "` + cdn.URL + `/b.js"; import pad from "` + pad + `";`
+		if err := os.WriteFile(entry, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func(cachedOnly bool) error {
+		t.Helper()
+		l := loader.NewModuleLoader(loader.WithImportPolicy(policy), loader.WithCachedOnly(cachedOnly))
+		_, err := l.LoadGraph(context.Background(), entry)
+		return err
+	}
+
+	// Nothing is cached yet: every missing import is reported at once
+	write("npm:pad@^1.0.0")
+	err := load(true)
+	var missErr *loader.CacheMissError
+	if !errors.As(err, &missErr) || !errors.Is(err, errors.ErrNotCached) {
+		t.Fatalf("LoadGraph() error = %v, want a CacheMissError", err)
+	}
+	if len(missErr.Missing) != 3 {
+		t.Errorf("missing = %v, want 3 entries", missErr.Missing)
+	}
+	for _, want := range []string{cdn.URL + "/a.js", cdn.URL + "/b.js", "npm:pad@^1.0.0"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not name %s", err, want)
+		}
+	}
+
+	if err := load(false); err != nil {
+		t.Fatalf("online LoadGraph() error = %v", err)
+	}
+
+	// Offline, with an npm range satisfied by what the cache holds
+	cdn.Close()
+	registry.Close()
+	write("npm:pad@1")
+	if err := load(true); err != nil {
+		t.Errorf("cached-only LoadGraph() error = %v", err)
+	}
+
+	pm, err := loader.NewNPMPackageManager(loader.NPMCachedOnly(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pm.InstallPackage(context.Background(), "pad@2"); !errors.Is(err, errors.ErrNotCached) || !strings.Contains(err.Error(), "npm:pad@2") {
+		t.Errorf("InstallPackage() error = %v, want a not in cache error naming npm:pad@2", err)
+	}
+}
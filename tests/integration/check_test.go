@@ -0,0 +1,125 @@
+package integration
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/runtime"
+)
+
+func TestCheckGraph(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"main.js": `import { a } from "./lib/a.js";
+import { gone } from "./lib/missing.js";
+import data from "./data.json" with { type: "json" };
+import { t } from "./lib/types.ts";
+import { join } from "node:path";
+const lazy = () => import("./lib/also-missing.js");
+`,
+		// The error comes after an import, whose specifier must not shift it
+		"lib/a.js":     "import { b } from \"./b.js\"; export const a = b +;\n",
+		"lib/b.js":     "export const b = 1;\n\nexport function broken( {\n",
+		"lib/types.ts": "export const t: number = ;\n",
+		"data.json":    `{"ok": true}`,
+	})
+
+	l := loader.NewModuleLoader()
+	graph, errs := l.ResolveGraph(context.Background(), filepath.Join(dir, "main.js"))
+	if graph == nil {
+		t.Fatalf("ResolveGraph returned no graph: %v", errs)
+	}
+	errs = append(errs, runtime.CheckGraph(graph)...)
+
+	var got []string
+	for _, err := range errs {
+		msg := strings.ReplaceAll(err.Error(), dir+string(filepath.Separator), "")
+		got = append(got, msg[:strings.Index(msg, ": ")+2]+kind(err))
+	}
+	want := []string{
+		"main.js:2:22: import",
+		"lib/types.ts:1:26: syntax",
+		"main.js:6:27: import",
+		"lib/a.js:1:49: syntax",
+		"lib/b.js:4:1: syntax",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems = %q, want %q", got, want)
+	}
+
+	// Every module that loaded is still checked
+	for _, name := range []string{"main.js", "lib/a.js", "lib/b.js"} {
+		if _, ok := graph.Modules[filepath.Join(dir, filepath.FromSlash(name))]; !ok {
+			t.Errorf("graph is missing %s", name)
+		}
+	}
+
+	// A module that compiles has nothing to report
+	writeModules(t, dir, map[string]string{"ok.js": `import { b } from "./lib/ok.js"; export default b;`, "lib/ok.js": "export const b = 1;"})
+	graph, errs = l.ResolveGraph(context.Background(), filepath.Join(dir, "ok.js"))
+	if len(errs) != 0 {
+		t.Fatalf("ResolveGraph(ok.js) = %v", errs)
+	}
+	if errs := runtime.CheckGraph(graph); len(errs) != 0 {
+		t.Errorf("CheckGraph(ok.js) = %v", errs)
+	}
+}
+
+// kind names the kind of a check problem
+func kind(err error) string {
+	var importErr *loader.ImportError
+	switch {
+	case errors.As(err, &importErr):
+		return "import"
+	case errors.Is(err, errors.ErrInvalidScript):
+		return "syntax"
+	}
+	return err.Error()
+}
+
+func TestCheckTypeScript(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		// Types esbuild strips move the code up and left in its output
+		"main.ts": `import { pattern, type Shape } from "./shape.ts";
+
+interface Point {
+  x: number;
+  y: number;
+}
+
+const origin: Point = { x: 0, y: 0 };
+import { gone } from "./missing.ts";
+export const shape: Shape = { at: origin, pattern, gone };
+`,
+		"shape.ts": `export interface Shape {
+  at: { x: number; y: number };
+  [key: string]: unknown;
+}
+
+type Flags = "g" | "y";
+export const pattern = /a{2,1}/;
+`,
+	})
+
+	graph, errs := loader.NewModuleLoader().ResolveGraph(context.Background(), filepath.Join(dir, "main.ts"))
+	if graph == nil {
+		t.Fatalf("ResolveGraph returned no graph: %v", errs)
+	}
+	errs = append(errs, runtime.CheckGraph(graph)...)
+
+	var got []string
+	for _, err := range errs {
+		msg := strings.ReplaceAll(err.Error(), dir+string(filepath.Separator), "")
+		got = append(got, msg[:strings.Index(msg, ": ")+2]+kind(err))
+	}
+	want := []string{"main.ts:9:22: import", "shape.ts:7:24: syntax"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems = %q, want %q", got, want)
+	}
+}
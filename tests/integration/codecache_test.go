@@ -0,0 +1,146 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/runtime"
+)
+
+// runCached runs main.js in dir in a fresh runtime, with a fresh loader so
+// nothing is kept in memory between runs
+func runCached(tb testing.TB, dir string, cache *loader.CodeCache) *runtime.Runtime {
+	tb.Helper()
+	rt, err := runtime.New(runtime.WithModuleLoader(loader.NewModuleLoader(loader.WithCodeCache(cache))))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(rt.Close)
+	if err := rt.ExecuteFile(filepath.Join(dir, "main.js")); err != nil {
+		tb.Fatalf("ExecuteFile() error = %v", err)
+	}
+	return rt
+}
+
+func cachedEntries(t *testing.T, cache *loader.CodeCache) int {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(cache.Dir(), "*", "*.qjsc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(matches)
+}
+
+func TestCodeCache(t *testing.T) {
+	dir := t.TempDir()
+	cache := loader.NewCodeCache(t.TempDir())
+	lib := filepath.Join(dir, "lib.js")
+	one, two := `export const value = 1;`, `export const value = 2;`
+	writeModules(t, dir, map[string]string{
+		"main.js": `import { value } from "./lib.js"; globalThis.result = value;`,
+		"lib.js":  one,
+	})
+
+	runCached(t, dir, cache)
+	if n := cachedEntries(t, cache); n != 2 {
+		t.Fatalf("cached %d modules after the first run, want 2", n)
+	}
+
+	// An edited module is compiled again and cached under a new key
+	writeModules(t, dir, map[string]string{"lib.js": two})
+	rt := runCached(t, dir, cache)
+	if err := rt.Eval(`if (globalThis.result !== 2) throw new Error(String(globalThis.result))`); err != nil {
+		t.Errorf("edited module was not recompiled: %v", err)
+	}
+	if n := cachedEntries(t, cache); n != 3 {
+		t.Fatalf("cached %d modules after the edit, want 3", n)
+	}
+
+	// Cached bytecode is used instead of the source: swap in the bytecode of
+	// the edit under the key of the original
+	engine := runtime.EngineVersion()
+	code, ok := cache.Get(loader.CodeKey(engine, lib, two, true))
+	if !ok {
+		t.Fatal("bytecode of the edited module is not cached")
+	}
+	if err := cache.Put(loader.CodeKey(engine, lib, one, true), code); err != nil {
+		t.Fatal(err)
+	}
+	writeModules(t, dir, map[string]string{"lib.js": one})
+	rt = runCached(t, dir, cache)
+	if err := rt.Eval(`if (globalThis.result !== 2) throw new Error(String(globalThis.result))`); err != nil {
+		t.Errorf("module was compiled from source despite cached bytecode: %v", err)
+	}
+
+	// Without a cache everything compiles from source
+	rt = runCached(t, dir, nil)
+	if err := rt.Eval(`if (globalThis.result !== 1) throw new Error(String(globalThis.result))`); err != nil {
+		t.Errorf("uncached run: %v", err)
+	}
+
+	// Clean drops the stale entries along with the rest
+	if removed, err := cache.Clean(); err != nil || removed != 3 {
+		t.Fatalf("Clean() = %d, %v, want 3 entries removed", removed, err)
+	}
+	if n := cachedEntries(t, cache); n != 0 {
+		t.Errorf("cached %d modules after Clean, want 0", n)
+	}
+	if entries, _ := os.ReadDir(cache.Dir()); len(entries) != 0 {
+		t.Errorf("Clean left %d directories behind", len(entries))
+	}
+	runCached(t, dir, cache)
+	if n := cachedEntries(t, cache); n != 2 {
+		t.Errorf("cached %d modules after cleaning and running again, want 2", n)
+	}
+}
+
+// writeLargeFixture writes a main.js importing modules modules of functions
+// functions each
+func writeLargeFixture(tb testing.TB, dir string, modules, functions int) {
+	tb.Helper()
+	var main strings.Builder
+	for m := 0; m < modules; m++ {
+		var src strings.Builder
+		for f := 0; f < functions; f++ {
+			fmt.Fprintf(&src, "export function f%d(items) {\n", f)
+			fmt.Fprintf(&src, "  const seen = new Map();\n  for (const [i, item] of items.entries()) {\n")
+			fmt.Fprintf(&src, "    if (typeof item === \"object\" && item !== null) seen.set(`%d:${i}`, { ...item, index: i });\n", f)
+			fmt.Fprintf(&src, "    else seen.set(String(item), [i, item, %d]);\n  }\n  return seen;\n}\n", f)
+		}
+		name := fmt.Sprintf("mod%d.js", m)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src.String()), 0644); err != nil {
+			tb.Fatal(err)
+		}
+		fmt.Fprintf(&main, "import * as m%d from \"./%s\";\n", m, name)
+	}
+	main.WriteString("globalThis.loaded = true;\n")
+	if err := os.WriteFile(filepath.Join(dir, "main.js"), []byte(main.String()), 0644); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// BenchmarkStartup compares starting a large program from source with
+// starting it from cached bytecode:
+//
+//	go test ./tests/integration -run '^$' -bench Startup
+func BenchmarkStartup(b *testing.B) {
+	dir := b.TempDir()
+	writeLargeFixture(b, dir, 50, 200)
+
+	b.Run("source", func(b *testing.B) {
+		for b.Loop() {
+			runCached(b, dir, nil)
+		}
+	})
+	b.Run("bytecode", func(b *testing.B) {
+		cache := loader.NewCodeCache(b.TempDir())
+		runCached(b, dir, cache)
+		for b.Loop() {
+			runCached(b, dir, cache)
+		}
+	})
+}
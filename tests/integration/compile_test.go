@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"testing"
+)
+
+// buildEdon builds the edon CLI into a temporary directory
+func buildEdon(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds the edon binary")
+	}
+	exe := filepath.Join(t.TempDir(), "edon")
+	build := exec.Command("go", "build", "-o", exe, "../../cmd/edon")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	return exe
+}
+
+func TestCompile(t *testing.T) {
+	if goruntime.GOOS != "linux" {
+		t.Skip("compiled executables are tested on Linux")
+	}
+	edon := buildEdon(t)
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+
+	tool := filepath.Join(dir, "greet")
+	if out, err := exec.Command(edon, "compile", "../fixtures/compile/greet.ts", "-o", tool).CombinedOutput(); err != nil {
+		t.Fatalf("edon compile: %v\n%s", err, out)
+	}
+	// The executable carries everything it needs
+	if err := os.Remove(edon); err != nil {
+		t.Fatal(err)
+	}
+
+	run := exec.Command(tool, "ada", "grace")
+	run.Dir = dir
+	out, err := run.Output()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 2 {
+		t.Fatalf("greet exited with %v, want status 2\n%s", err, out)
+	}
+	if want := "hello, ada and grace\nread: denied\n"; string(out) != want {
+		t.Errorf("greet printed %q, want %q", out, want)
+	}
+}
+
+func TestCompilePermissions(t *testing.T) {
+	if goruntime.GOOS != "linux" {
+		t.Skip("compiled executables are tested on Linux")
+	}
+	edon := buildEdon(t)
+	t.Setenv("HOME", t.TempDir())
+
+	tool := filepath.Join(t.TempDir(), "greet")
+	if out, err := exec.Command(edon, "compile", "-allow-read", "-o", tool, "../fixtures/compile/greet.ts").CombinedOutput(); err != nil {
+		t.Fatalf("edon compile: %v\n%s", err, out)
+	}
+
+	out, err := exec.Command(tool).Output()
+	if err != nil {
+		t.Fatalf("greet: %v\n%s", err, out)
+	}
+	if want := "hello, world\nread: allowed\n"; string(out) != want {
+		t.Errorf("greet printed %q, want %q", out, want)
+	}
+
+	// Compiling again from the tool would run it instead
+	if out, err := exec.Command(tool, "compile").Output(); err == nil || string(out) != "hello, compile\nread: allowed\n" {
+		t.Errorf("greet compile = %q, %v; want the program to run", out, err)
+	}
+}
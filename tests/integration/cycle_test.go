@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/runtime"
+)
+
+func writeCyclicModules(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		// b.js runs first and may call into a.js only once it has evaluated
+		"main.js": `import { a, fromB } from "./a.js"; globalThis.result = [a, fromB, globalThis.early].join(",");`,
+		"a.js":    `import { b, readA } from "./b.js"; export const a = "a"; export const fromB = b + readA();`,
+		"b.js": `import { a } from "./a.js";
+export const b = "b";
+export function readA() { return a; }
+try { a; globalThis.early = "visible"; } catch (e) { globalThis.early = e.name; }`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCyclicModules(t *testing.T) {
+	dir := writeCyclicModules(t)
+
+	rt, err := runtime.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	if err := rt.ExecuteFile(filepath.Join(dir, "main.js")); err != nil {
+		t.Fatalf("ExecuteFile() error = %v", err)
+	}
+	// a.js is still in its temporal dead zone while b.js evaluates
+	if err := rt.Eval(`if (globalThis.result !== "a,ba,ReferenceError") throw new Error(globalThis.result)`); err != nil {
+		t.Errorf("unexpected result: %v", err)
+	}
+}
+
+func TestCyclicModulesStrict(t *testing.T) {
+	dir := writeCyclicModules(t)
+
+	_, err := loader.NewModuleLoader(loader.WithStrictCycles(true)).LoadGraph(context.Background(), filepath.Join(dir, "main.js"))
+	if !errors.Is(err, errors.ErrCircularDependency) {
+		t.Fatalf("LoadGraph() error = %v, want ErrCircularDependency", err)
+	}
+	a, b := filepath.Join(dir, "a.js"), filepath.Join(dir, "b.js")
+	if want := errors.ErrCircularDependency.Error() + ": " + a + " -> " + b + " -> " + a; err.Error() != want {
+		t.Errorf("LoadGraph() error = %q, want %q", err, want)
+	}
+
+	graph, err := loader.NewModuleLoader().LoadGraph(context.Background(), filepath.Join(dir, "main.js"))
+	if err != nil {
+		t.Fatalf("LoadGraph() error = %v", err)
+	}
+	if cycles := graph.Cycles(); len(cycles) != 1 || loader.FormatCycle(cycles[0]) != a+" -> "+b+" -> "+a {
+		t.Errorf("Cycles() = %v", cycles)
+	}
+}
@@ -0,0 +1,121 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/runtime"
+)
+
+func TestImportAttributes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write([]byte(`{"remote": [1, 2.5e3, null]}`))
+	}))
+	t.Cleanup(cdn.Close)
+
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"config.json": `{"name": "edon", "__proto__": {"polluted": true}}`,
+		"page.html":   "<h1>{{title}}</h1>\n",
+		"main.js": `import config from "./config.json" with { type: "json" };
+import page from "./page.html" with { type: "text" };
+import raw from "./config.json" with { type: "text" };
+import bytes from "./logo.bin" with { type: "bytes" };
+import remote from "` + cdn.URL + `/data" with { type: "json" };
+globalThis.result = [
+  config.name, Object.getPrototypeOf(config) === Object.prototype, config.__proto__.polluted,
+  page.trim(), raw.length, bytes instanceof Uint8Array, Array.from(bytes).join(" "),
+  remote.remote[1],
+].join("|");`,
+	})
+	if err := os.WriteFile(filepath.Join(dir, "logo.bin"), []byte{0, 137, 255}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l := loader.NewModuleLoader(loader.WithImportPolicy(loader.NewImportPolicy(strings.TrimPrefix(cdn.URL, "http://"))))
+	rt, err := runtime.New(runtime.WithModuleLoader(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	if err := rt.ExecuteFile(filepath.Join(dir, "main.js")); err != nil {
+		t.Fatalf("ExecuteFile() error = %v", err)
+	}
+	want := "edon|true|true|<h1>{{title}}</h1>|49|true|0 137 255|2500"
+	if err := rt.Eval(`if (globalThis.result !== "` + want + `") throw new Error(globalThis.result)`); err != nil {
+		t.Errorf("unexpected result: %v", err)
+	}
+
+	// Typed modules are cached apart from each other
+	jsonModule, _ := l.LoadModuleAs(context.Background(), filepath.Join(dir, "config.json"), loader.ImportJSON)
+	textModule, _ := l.LoadModuleAs(context.Background(), filepath.Join(dir, "config.json"), loader.ImportText)
+	if jsonModule == nil || jsonModule != l.Cache().Get(filepath.Join(dir, "config.json")+"#type=json") || textModule == jsonModule {
+		t.Error("JSON and text imports of the same file should be cached separately")
+	}
+}
+
+func TestDynamicImportAttributes(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"data.json": `{"items": [1, 2, 3]}`,
+		"main.js": `import raw from "./data.json" with { type: "text" };
+const data = await import("./data.json", { with: { type: "json" } });
+globalThis.result = data.default.items.length + "|" + typeof raw;`,
+	})
+
+	rt, err := runtime.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	if err := rt.ExecuteFile(filepath.Join(dir, "main.js")); err != nil {
+		t.Fatalf("ExecuteFile() error = %v", err)
+	}
+	if err := rt.Eval(`if (globalThis.result !== "3|string") throw new Error(globalThis.result)`); err != nil {
+		t.Errorf("unexpected result: %v", err)
+	}
+}
+
+func TestImportAttributeErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"data.json":   `{"ok": true}`,
+		"broken.json": "{\n  \"a\": 1,\n  \"b\": \n}",
+		"code.js":     `export default 1;`,
+	})
+
+	tests := []struct {
+		name    string
+		source  string
+		wantErr error
+		want    string
+	}{
+		{"json without attribute", `import data from "./data.json";`, errors.ErrInvalidImportType, `must be imported with { type: "json" }`},
+		{"json attribute on javascript", `import code from "./code.js" with { type: "json" };`, errors.ErrInvalidImportType, "but is a .js file"},
+		{"unknown type", `import code from "./code.js" with { type: "yaml" };`, errors.ErrInvalidImportType, `unsupported import type "yaml"`},
+		{"invalid json", `import data from "./broken.json" with { type: "json" };`, errors.ErrInvalidJSON, "broken.json:4:1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			main := filepath.Join(dir, "main.js")
+			if err := os.WriteFile(main, []byte(tt.source), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := loader.NewModuleLoader().LoadGraph(context.Background(), main)
+			if !errors.Is(err, tt.wantErr) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadGraph() error = %v, want %v containing %q", err, tt.wantErr, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/config"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/runtime"
+)
+
+// runWithProjectConfig runs main.js with the import map of the project's
+// edon.json
+func runWithProjectConfig(t *testing.T, files map[string]string) (*runtime.Runtime, error) {
+	t.Helper()
+	dir := t.TempDir()
+	writeModules(t, dir, files)
+	cfg, err := config.Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	importMap, err := cfg.LoadImportMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runModules(t, dir, nil, loader.WithImportMap(importMap))
+}
+
+func TestImportMapFromConfig(t *testing.T) {
+	rt, err := runWithProjectConfig(t, map[string]string{
+		"edon.json":            `{"imports": {"greet": "./lib/greet.js", "utils/": "./lib/utils/"}}`,
+		"lib/greet.js":         `import { upper } from "utils/strings.js"; export const greet = (n) => upper("hello " + n);`,
+		"lib/utils/strings.js": `export const upper = (s) => s.toUpperCase();`,
+		"main.js":              `import { greet } from "greet"; globalThis.result = greet("edon");`,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteFile() error = %v", err)
+	}
+	if err := rt.Eval(`if (globalThis.result !== "HELLO EDON") throw new Error(globalThis.result)`); err != nil {
+		t.Errorf("unexpected result: %v", err)
+	}
+}
+
+func TestImportMapErrorNamesMapping(t *testing.T) {
+	_, err := runWithProjectConfig(t, map[string]string{
+		"edon.json":       `{"importMap": "./import_map.json"}`,
+		"import_map.json": `{"imports": {"greet": "./missing.js"}}`,
+		"main.js":         `import "greet";`,
+	})
+	if err == nil || !strings.Contains(err.Error(), `import "greet" (mapped to`) || !strings.Contains(err.Error(), "import_map.json") {
+		t.Errorf("ExecuteFile() error = %v, want the remapping in the message", err)
+	}
+}
@@ -0,0 +1,105 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+func TestModuleGraphInfo(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`export const remote = true;`))
+	}))
+	t.Cleanup(cdn.Close)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.js":   `import "./left.js"; import "./right.js"; import("./lazy.js");`,
+		"left.js":   `import "./shared.js";`,
+		"right.js":  `import "./shared.js"; import "` + cdn.URL + `/remote.js";`,
+		"shared.js": `export const shared = 1;`,
+		"lazy.js":   `export default 1;`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := loader.NewModuleLoader(loader.WithImportPolicy(loader.NewImportPolicy(strings.TrimPrefix(cdn.URL, "http://"))))
+	graph, err := l.LoadGraph(context.Background(), filepath.Join(dir, "main.js"))
+	if err != nil {
+		t.Fatalf("LoadGraph() error = %v", err)
+	}
+	info, err := l.Info(graph)
+	if err != nil {
+		t.Fatalf("Info() error = %v", err)
+	}
+
+	if len(info.Modules) != 6 || info.Order[len(info.Order)-1] != graph.Root {
+		t.Errorf("Info() = %d modules, order %v", len(info.Modules), info.Order)
+	}
+
+	var tree bytes.Buffer
+	if err := info.WriteTree(&tree); err != nil {
+		t.Fatal(err)
+	}
+	out := tree.String()
+	sharedLine := "./shared.js -> " + filepath.Join(dir, "shared.js")
+	if strings.Count(out, sharedLine) != 2 || !strings.Contains(out, "* already listed above") {
+		t.Errorf("tree should mark the second shared.js as a duplicate:\n%s", out)
+	}
+	if !strings.Contains(out, "dynamic") || !strings.Contains(out, filepath.Join(".edon", "deps")) {
+		t.Errorf("tree should show dynamic imports and cache locations:\n%s", out)
+	}
+
+	var decoded loader.GraphInfo
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Root != graph.Root || len(decoded.Modules) != 6 {
+		t.Errorf("JSON round trip = %+v, %v", decoded, err)
+	}
+
+	var dot bytes.Buffer
+	if err := info.WriteDot(&dot); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(dot.String(), "digraph modules {") || !strings.Contains(dot.String(), "style=dashed") {
+		t.Errorf("unexpected dot output:\n%s", dot.String())
+	}
+}
+
+func TestInfoFlagsAfterEntry(t *testing.T) {
+	edon := buildEdon(t)
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"main.js": `import "./lib.js";`,
+		"lib.js":  `export default 1;`,
+	})
+
+	info := exec.Command(edon, "info", "main.js", "-json")
+	info.Dir = dir
+	info.Env = append(os.Environ(), "HOME="+t.TempDir())
+	out, err := info.Output()
+	if err != nil {
+		t.Fatalf("edon info main.js -json: %v", err)
+	}
+	var parsed struct {
+		Order []string `json:"order"`
+	}
+	if err := json.Unmarshal(out, &parsed); err != nil || len(parsed.Order) != 2 {
+		t.Errorf("edon info main.js -json printed %s, want the graph as JSON", out)
+	}
+}
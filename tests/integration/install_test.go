@@ -0,0 +1,313 @@
+package integration
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/packages"
+	"github.com/katungi/edon/internal/runtime"
+)
+
+// packageRegistry is a stand-in npm registry that serves every published
+// version of a package: packuments at /<name>, version manifests at
+// /<name>/<version> and tarballs at /-/<name>-<version>.tgz
+type packageRegistry struct {
+	*httptest.Server
+	t *testing.T
+
+	mu       sync.Mutex
+	versions map[string]map[string]map[string]any
+	tarballs map[string][]byte
+	tags     map[string]map[string]string
+}
+
+func newPackageRegistry(t *testing.T, packages ...fakePackage) *packageRegistry {
+	t.Helper()
+	r := &packageRegistry{
+		t:        t,
+		versions: make(map[string]map[string]map[string]any),
+		tarballs: make(map[string][]byte),
+		tags:     make(map[string]map[string]string),
+	}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.Close)
+	for _, pkg := range packages {
+		r.publish(pkg)
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NPM_CONFIG_REGISTRY", r.URL)
+	return r
+}
+
+// publish adds a version, which the latest tag moves to. Its dependencies
+// are read from its package.json.
+func (r *packageRegistry) publish(pkg fakePackage) {
+	r.t.Helper()
+	files := map[string]string{"index.js": "export default " + strconv.Quote(pkg.name+"@"+pkg.version) + ";"}
+	for name, content := range pkg.files {
+		files[name] = content
+	}
+	var fields struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if data, ok := files["package.json"]; ok {
+		if err := json.Unmarshal([]byte(data), &fields); err != nil {
+			r.t.Fatal(err)
+		}
+	} else {
+		files["package.json"] = `{"name": "` + pkg.name + `", "version": "` + pkg.version + `"}`
+	}
+
+	tarball := packTarball(r.t, files)
+	sum := sha512.Sum512(tarball)
+	file := pkg.name + "-" + pkg.version + ".tgz"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tarballs[file] = tarball
+	if r.versions[pkg.name] == nil {
+		r.versions[pkg.name] = make(map[string]map[string]any)
+		r.tags[pkg.name] = make(map[string]string)
+	}
+	r.versions[pkg.name][pkg.version] = map[string]any{
+		"name":         pkg.name,
+		"version":      pkg.version,
+		"dependencies": fields.Dependencies,
+		"dist": map[string]string{
+			"tarball":   r.URL + "/-/" + file,
+			"integrity": "sha512-" + base64.StdEncoding.EncodeToString(sum[:]),
+		},
+	}
+	r.tags[pkg.name]["latest"] = pkg.version
+}
+
+// tag points a dist-tag of name at version
+func (r *packageRegistry) tag(name, tag, version string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tags[name][tag] = version
+}
+
+func (r *packageRegistry) serve(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if file, ok := strings.CutPrefix(req.URL.Path, "/-/"); ok {
+		if data, ok := r.tarballs[file]; ok {
+			_, _ = w.Write(data)
+			return
+		}
+		http.NotFound(w, req)
+		return
+	}
+
+	name, version, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/"), "/")
+	versions, ok := r.versions[name]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	if version == "" {
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": name, "dist-tags": r.tags[name], "versions": versions})
+		return
+	}
+	if tagged, ok := r.tags[name][version]; ok {
+		version = tagged
+	}
+	if manifest, ok := versions[version]; ok {
+		_ = json.NewEncoder(w).Encode(manifest)
+		return
+	}
+	http.NotFound(w, req)
+}
+
+// openProject opens the package.json in dir with edon.lock next to it
+func openProject(t *testing.T, dir string, opts ...loader.NPMOption) (*packages.Project, *loader.Lockfile) {
+	t.Helper()
+	pm, err := loader.NewNPMPackageManager(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := loader.LoadLockfile(filepath.Join(dir, loader.LockfileName), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := packages.Open(dir, pm, lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return project, lock
+}
+
+func TestInstallFromPackageJSON(t *testing.T) {
+	registry := newPackageRegistry(t,
+		fakePackage{name: "greeter", version: "1.0.0"},
+		fakePackage{name: "greeter", version: "1.1.0", files: map[string]string{
+			"package.json": `{"name": "greeter", "version": "1.1.0", "dependencies": {"punctuate": "^2.0.0"}}`,
+			"index.js":     `import bang from "punctuate"; export default "hello" + bang;`,
+		}},
+		fakePackage{name: "greeter", version: "2.0.0"},
+		fakePackage{name: "punctuate", version: "2.0.0", files: map[string]string{"index.js": `export default "?";`}},
+		fakePackage{name: "punctuate", version: "2.1.0", files: map[string]string{"index.js": `export default "!";`}},
+		fakePackage{name: "checker", version: "2.0.3"},
+		fakePackage{name: "checker", version: "2.1.0"},
+	)
+
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"package.json": `{
+    "name": "demo",
+    "dependencies": {"greeter": "^1.0.0"},
+    "devDependencies": {"checker": "~2.0.0"}
+}
+`,
+		"main.js": `import greeting from "greeter"; globalThis.result = greeting;`,
+	})
+
+	project, lock := openProject(t, dir)
+	result, err := project.Install(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var direct []string
+	for _, dep := range result.Direct {
+		direct = append(direct, dep.Name+"@"+dep.Version)
+	}
+	if want := []string{"checker@2.0.3", "greeter@1.1.0"}; !reflect.DeepEqual(direct, want) {
+		t.Errorf("installed %v, want %v", direct, want)
+	}
+	if result.Packages != 3 || result.Downloaded != 3 {
+		t.Errorf("installed %d packages, downloaded %d, want 3 and 3", result.Packages, result.Downloaded)
+	}
+
+	saved, err := loader.LoadLockfile(filepath.Join(dir, loader.LockfileName), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSpecifiers := map[string]string{"greeter@^1.0.0": "1.1.0", "punctuate@^2.0.0": "2.1.0", "checker@~2.0.0": "2.0.3"}
+	if saved.NPM == nil || !reflect.DeepEqual(saved.NPM.Specifiers, wantSpecifiers) {
+		t.Fatalf("lockfile npm section = %+v, want specifiers %v", saved.NPM, wantSpecifiers)
+	}
+	if pkg := saved.NPM.Packages["greeter@1.1.0"]; !strings.HasPrefix(pkg.Integrity, "sha512-") || pkg.Dependencies["punctuate"] != "^2.0.0" {
+		t.Errorf("greeter@1.1.0 locked as %+v", pkg)
+	}
+
+	// Newer versions do not change what installs and runs use
+	registry.publish(fakePackage{name: "greeter", version: "1.2.0"})
+	registry.publish(fakePackage{name: "punctuate", version: "2.2.0", files: map[string]string{"index.js": `export default "?!";`}})
+	project, lock = openProject(t, dir)
+	if result, err = project.Install(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if result.Downloaded != 0 || !reflect.DeepEqual(lock.NPMPackages().Specifiers, wantSpecifiers) {
+		t.Errorf("reinstall downloaded %d and locked %v", result.Downloaded, lock.NPMPackages().Specifiers)
+	}
+
+	rt, err := runtime.New(runtime.WithModuleLoader(loader.NewModuleLoader(loader.WithLockfile(lock))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+	if err := rt.ExecuteFile(filepath.Join(dir, "main.js")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Eval(`if (globalThis.result !== "hello!") throw new Error(globalThis.result)`); err != nil {
+		t.Errorf("ran with the wrong versions: %v", err)
+	}
+
+	// A lockfile that disagrees with the registry is rejected
+	tampered := lock.NPMPackages()
+	tampered.Packages["greeter@1.1.0"] = loader.NPMLockedPackage{Integrity: "sha512-AAAA", Dependencies: tampered.Packages["greeter@1.1.0"].Dependencies}
+	lock.SetNPMPackages(tampered)
+	if err := os.RemoveAll(filepath.Join(os.Getenv("HOME"), ".edon", "npm-cache", "greeter")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := project.Install(context.Background()); !errors.Is(err, errors.ErrPackageIntegrity) {
+		t.Errorf("Install with a tampered lockfile error = %v, want ErrPackageIntegrity", err)
+	}
+}
+
+func TestAddAndRemove(t *testing.T) {
+	registry := newPackageRegistry(t,
+		fakePackage{name: "alpha", version: "1.0.0"},
+		fakePackage{name: "beta", version: "3.1.0", files: map[string]string{
+			"package.json": `{"name": "beta", "version": "3.1.0", "dependencies": {"gamma": "1.x"}}`,
+		}},
+		fakePackage{name: "gamma", version: "1.4.0"},
+		fakePackage{name: "zeta", version: "0.2.0"},
+		fakePackage{name: "zeta", version: "0.3.0-rc.1"},
+	)
+	registry.tag("zeta", "latest", "0.2.0")
+	registry.tag("zeta", "next", "0.3.0-rc.1")
+
+	dir := t.TempDir()
+	original := "{\n\t\"name\": \"demo\",\n\t\"dependencies\": {\n\t\t\"alpha\": \"^1.0.0\"\n\t},\n\t\"scripts\": {\"test\": \"edon test\"}\n}\n"
+	writeModules(t, dir, map[string]string{"package.json": original})
+
+	project, lock := openProject(t, dir)
+	if _, err := project.Add(context.Background(), []string{"beta", "zeta@next"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := project.Add(context.Background(), []string{"aardvark"}, true); !errors.Is(err, errors.ErrPackageNotFound) {
+		t.Errorf("adding a missing package error = %v, want ErrPackageNotFound", err)
+	}
+	if _, err := project.Add(context.Background(), []string{"gamma@~1.4.0"}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "{\n\t\"name\": \"demo\",\n\t\"dependencies\": {\n\t\t\"alpha\": \"^1.0.0\",\n\t\t\"beta\": \"^3.1.0\",\n\t\t\"zeta\": \"^0.3.0-rc.1\"\n\t},\n\t\"scripts\": {\"test\": \"edon test\"},\n\t\"devDependencies\": {\n\t\t\"gamma\": \"~1.4.0\"\n\t}\n}\n"
+	if data := readFile(t, filepath.Join(dir, "package.json")); data != want {
+		t.Errorf("package.json after add =\n%s\nwant\n%s", data, want)
+	}
+	if got := lock.NPMPackages().Specifiers; got["gamma@1.x"] != "1.4.0" || got["gamma@~1.4.0"] != "1.4.0" || got["zeta@^0.3.0-rc.1"] != "0.3.0-rc.1" {
+		t.Errorf("lockfile specifiers after add = %v", got)
+	}
+
+	// Adding a devDependency as a dependency moves it
+	if _, err := project.Add(context.Background(), []string{"gamma@^1.0.0"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if project.Manifest.Dependencies["gamma"] != "^1.0.0" || len(project.Manifest.DevDependencies) != 0 {
+		t.Errorf("gamma is in dependencies %v and devDependencies %v", project.Manifest.Dependencies, project.Manifest.DevDependencies)
+	}
+
+	if _, err := project.Remove(context.Background(), []string{"beta", "zeta", "gamma"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := project.Remove(context.Background(), []string{"beta"}); !errors.Is(err, errors.ErrNotDependency) {
+		t.Errorf("removing a missing dependency error = %v, want ErrNotDependency", err)
+	}
+	want = "{\n\t\"name\": \"demo\",\n\t\"dependencies\": {\n\t\t\"alpha\": \"^1.0.0\"\n\t},\n\t\"scripts\": {\"test\": \"edon test\"},\n\t\"devDependencies\": {}\n}\n"
+	if data := readFile(t, filepath.Join(dir, "package.json")); data != want {
+		t.Errorf("package.json after remove =\n%s\nwant\n%s", data, want)
+	}
+	if got, want := lock.NPMPackages().Specifiers, map[string]string{"alpha@^1.0.0": "1.0.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lockfile specifiers after remove = %v, want %v", got, want)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
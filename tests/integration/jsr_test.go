@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/runtime"
+)
+
+// newFakeJSR serves a single @std/math package in the layout of jsr.io.
+// tamper lists files whose served content differs from the manifest.
+func newFakeJSR(t *testing.T, tamper map[string]string) *httptest.Server {
+	t.Helper()
+	versions := map[string]map[string]string{
+		"1.0.0": {
+			"/mod.ts": `export const add = (a: number, b: number): number => a + b + 1000;`,
+		},
+		"1.2.0": {
+			"/mod.ts":     `import { twice } from "./util.ts"; export const add = (a: number, b: number): number => twice(a + b) / 2;`,
+			"/util.ts":    `export function twice(n: number): number { return n * 2; }`,
+			"/strings.ts": `export function shout(s: string): string { return s.toUpperCase(); }`,
+		},
+		"2.0.0": {
+			"/mod.ts": `export const add = (): never => { throw new Error("2.x picked"); };`,
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/@std/math/meta.json", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"scope":    "std",
+			"name":     "math",
+			"latest":   "2.0.0",
+			"versions": map[string]any{"1.0.0": map[string]any{}, "1.2.0": map[string]any{}, "1.3.0": map[string]any{"yanked": true}, "2.0.0": map[string]any{}},
+		})
+	})
+	for version, files := range versions {
+		manifest := make(map[string]any)
+		for name, content := range files {
+			sum := sha256.Sum256([]byte(content))
+			manifest[name] = map[string]any{"size": len(content), "checksum": "sha256-" + hex.EncodeToString(sum[:])}
+
+			served := content
+			if replacement, ok := tamper[name]; ok {
+				served = replacement
+			}
+			mux.HandleFunc("/@std/math/"+version+name, func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(served))
+			})
+		}
+		meta := map[string]any{"manifest": manifest, "exports": map[string]string{".": "./mod.ts", "./strings": "./strings.ts"}}
+		mux.HandleFunc("/@std/math/"+version+"_meta.json", func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(meta)
+		})
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// runJSRScript runs script as main.ts against the stand-in JSR registry
+func runJSRScript(t *testing.T, registry, script string) (*runtime.Runtime, error) {
+	t.Helper()
+	t.Setenv("JSR_URL", registry)
+	return runModules(t, t.TempDir(), map[string]string{"main.ts": script})
+}
+
+func TestJSRModuleLoading(t *testing.T) {
+	registry := newFakeJSR(t, nil)
+
+	rt, err := runJSRScript(t, registry.URL, `
+        import { add } from "jsr:@std/math@^1.0.0";
+        import { shout } from "jsr:@std/math@1/strings";
+        const total: number = add(2, 3);
+        globalThis.result = shout("sum") + "=" + total;
+    `)
+	if err != nil {
+		t.Fatalf("ExecuteFile() error = %v", err)
+	}
+	if err := rt.Eval(`if (globalThis.result !== "SUM=5") throw new Error(globalThis.result)`); err != nil {
+		t.Errorf("unexpected result: %v", err)
+	}
+}
+
+func TestJSRModuleChecksumMismatch(t *testing.T) {
+	registry := newFakeJSR(t, map[string]string{"/util.ts": `export function twice(n: number): number { return 0; }`})
+
+	_, err := runJSRScript(t, registry.URL, `import { add } from "jsr:@std/math@1.2.0"; add(1, 2);`)
+	if err == nil || !strings.Contains(err.Error(), "integrity") {
+		t.Errorf("ExecuteFile() error = %v, want an integrity error", err)
+	}
+}
+
+func TestJSRModuleSubpathNotExported(t *testing.T) {
+	registry := newFakeJSR(t, nil)
+
+	_, err := runJSRScript(t, registry.URL, `import "jsr:@std/math@1.2.0/util";`)
+	if err == nil || !strings.Contains(err.Error(), `"./util"`) {
+		t.Errorf("ExecuteFile() error = %v, want a not-exported error", err)
+	}
+}
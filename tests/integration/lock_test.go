@@ -0,0 +1,127 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+func TestRemoteModuleLockfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var body atomic.Value
+	body.Store(`export const version = 1;`)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mod.js", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body.Load().(string)))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	entry := filepath.Join(dir, "main.js")
+	if err := os.WriteFile(entry, []byte(`import "`+server.URL+`/mod.js";`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lockPath := filepath.Join(dir, loader.LockfileName)
+
+	load := func(write bool, opts ...loader.Option) error {
+		t.Helper()
+		lock, err := loader.LoadLockfile(lockPath, write)
+		if err != nil {
+			t.Fatalf("LoadLockfile() error = %v", err)
+		}
+		opts = append(opts,
+			loader.WithImportPolicy(loader.NewImportPolicy(strings.TrimPrefix(server.URL, "http://"))),
+			loader.WithLockfile(lock),
+		)
+		_, err = loader.NewModuleLoader(opts...).LoadGraph(context.Background(), entry)
+		return err
+	}
+
+	if err := load(false); err != nil {
+		t.Fatalf("first load error = %v", err)
+	}
+	lock, err := loader.LoadLockfile(lockPath, false)
+	if err != nil || len(lock.Remote) != 1 {
+		t.Fatalf("lockfile after first load = %+v, %v", lock, err)
+	}
+
+	// The CDN starts serving something else
+	body.Store(`export const version = 2;`)
+	if err := load(false, loader.WithReload("")); !errors.Is(err, errors.ErrModuleIntegrity) {
+		t.Fatalf("load after change error = %v, want ErrModuleIntegrity", err)
+	}
+	if err := load(true, loader.WithReload("")); err != nil {
+		t.Fatalf("load with -lock-write error = %v", err)
+	}
+	if err := load(false); err != nil {
+		t.Fatalf("load after -lock-write error = %v", err)
+	}
+
+	// Cache reads are checked too
+	depsDir, err := loader.DefaultDepsDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached := loader.NewDiskCache(depsDir).Path(server.URL + "/mod.js")
+	if err := os.WriteFile(cached, []byte(`export const version = "evil";`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := load(false); !errors.Is(err, errors.ErrModuleIntegrity) {
+		t.Errorf("load of tampered cache error = %v, want ErrModuleIntegrity", err)
+	}
+}
+
+func TestRemoteModuleHTTPError(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	l := loader.NewModuleLoader(loader.WithImportPolicy(loader.NewImportPolicy(strings.TrimPrefix(server.URL, "http://"))))
+	_, err := l.LoadModule(context.Background(), server.URL+"/missing.js")
+	if !errors.Is(err, errors.ErrModuleNotFound) || !strings.Contains(err.Error(), "404") {
+		t.Errorf("LoadModule() error = %v, want a 404 error", err)
+	}
+}
+
+// TestLockfileBesidePackageJSON runs a script from below a package without
+// edon.json, which records its hashes in the edon.lock next to package.json
+// that edon install writes
+func TestLockfileBesidePackageJSON(t *testing.T) {
+	edon := buildEdon(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`export const version = 1;`))
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"package.json":    `{"name": "app", "version": "1.0.0"}`,
+		"scripts/main.js": `import { version } from "` + server.URL + `/mod.js"; console.log(version);`,
+	})
+
+	run := exec.Command(edon, "-allow-import", strings.TrimPrefix(server.URL, "http://"), "main.js")
+	run.Dir = filepath.Join(dir, "scripts")
+	run.Env = append(os.Environ(), "HOME="+t.TempDir())
+	if out, err := run.CombinedOutput(); err != nil {
+		t.Fatalf("edon main.js: %v\n%s", err, out)
+	}
+
+	lock, err := loader.LoadLockfile(filepath.Join(dir, loader.LockfileName), false)
+	if err != nil || len(lock.Remote) != 1 {
+		t.Errorf("edon.lock next to package.json = %+v, %v", lock, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "scripts", loader.LockfileName)); !os.IsNotExist(err) {
+		t.Errorf("the run wrote edon.lock in the working directory: %v", err)
+	}
+}
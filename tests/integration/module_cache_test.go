@@ -0,0 +1,158 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/runtime"
+)
+
+func writeModules(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// runModules writes files into dir and runs its main.ts, or else its main.js,
+// in a new runtime whose loader is set up with opts. HOME is a fresh
+// directory, so nothing is cached from other tests.
+func runModules(t *testing.T, dir string, files map[string]string, opts ...loader.Option) (*runtime.Runtime, error) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	writeModules(t, dir, files)
+	entry := filepath.Join(dir, "main.ts")
+	if _, err := os.Stat(entry); err != nil {
+		entry = filepath.Join(dir, "main.js")
+	}
+
+	rt, err := runtime.New(runtime.WithModuleLoader(loader.NewModuleLoader(opts...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(rt.Close)
+	return rt, rt.ExecuteFile(entry)
+}
+
+func TestModuleCacheReloadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"main.js":  `import { value } from "./value.js"; globalThis.result = value;`,
+		"value.js": `export const value = 1;`,
+	})
+
+	// A long-lived loader shared by fresh runtimes picks up the edit
+	l := loader.NewModuleLoader()
+	run := func() *runtime.Runtime {
+		rt, err := runtime.New(runtime.WithModuleLoader(l))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(rt.Close)
+		if err := rt.ExecuteFile(filepath.Join(dir, "main.js")); err != nil {
+			t.Fatalf("ExecuteFile() error = %v", err)
+		}
+		return rt
+	}
+
+	run()
+	writeModules(t, dir, map[string]string{"value.js": `export const value = 22;`})
+	rt := run()
+	if err := rt.Eval(`if (globalThis.result !== 22) throw new Error(String(globalThis.result))`); err != nil {
+		t.Errorf("edited module was not reloaded: %v", err)
+	}
+}
+
+func TestModuleCacheInvalidation(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{"a.js": ``, "b.js": ``, "c.js": ``})
+	a, b, c := filepath.Join(dir, "a.js"), filepath.Join(dir, "b.js"), filepath.Join(dir, "c.js")
+
+	l := loader.NewModuleLoader(loader.WithCacheLimit(2))
+	ctx := context.Background()
+	for _, path := range []string{a, b, a, c} {
+		if _, err := l.LoadModule(ctx, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cache := l.Cache()
+	if cache.Len() != 2 || cache.Get(b) != nil || cache.Get(a) == nil {
+		t.Errorf("least recently used b.js should have been evicted, %d modules cached", cache.Len())
+	}
+
+	if !cache.Invalidate(a) || cache.Invalidate(a) {
+		t.Error("Invalidate() should remove a.js once")
+	}
+	_, _ = l.LoadModule(ctx, a)
+	if n := cache.InvalidatePrefix(dir); n != 2 || cache.Len() != 0 {
+		t.Errorf("InvalidatePrefix() = %d, %d left", n, cache.Len())
+	}
+	_, _ = l.LoadModule(ctx, a)
+	cache.InvalidateAll()
+	if cache.Len() != 0 {
+		t.Errorf("InvalidateAll() left %d modules", cache.Len())
+	}
+}
+
+func TestModuleCacheWatcher(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"main.js":  `import "./lib.js";`,
+		"lib.js":   `import "./leaf.js";`,
+		"leaf.js":  `export default 1;`,
+		"other.js": `export default 2;`,
+	})
+	main, lib, leaf := filepath.Join(dir, "main.js"), filepath.Join(dir, "lib.js"), filepath.Join(dir, "leaf.js")
+
+	l := loader.NewModuleLoader()
+	if _, err := l.LoadGraph(context.Background(), main); err != nil {
+		t.Fatal(err)
+	}
+	w, err := l.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	writeModules(t, dir, map[string]string{"leaf.js": `export default 3;`})
+	select {
+	case change := <-w.Changes():
+		if change.Path != leaf || !slices.Equal(change.Stale, []string{leaf, lib, main}) {
+			t.Errorf("change = %+v", change)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+	if l.Cache().Len() != 0 {
+		t.Errorf("%d modules still cached", l.Cache().Len())
+	}
+
+	// A new watcher replaces the old one, which stops
+	next, err := l.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer next.Close()
+	closed := make(chan struct{})
+	go func() {
+		for range w.Changes() {
+		}
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the replaced watcher was not closed")
+	}
+}
@@ -0,0 +1,229 @@
+package integration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/runtime"
+)
+
+// nodeCompat is the compatibility matrix of the node: built-ins: for each
+// module, the APIs edon supports and an expression checking each one. The
+// expressions see the module namespace as mod and a scratch directory as dir.
+var nodeCompat = []struct {
+	module string
+	checks [][2]string
+}{
+	{"path", [][2]string{
+		{"join", `mod.join("/a/b", "../c", "./d.js") === "/a/c/d.js"`},
+		{"resolve", `mod.resolve("/a", "b", "../c") === "/a/c" && mod.resolve("x") === process.cwd() + "/x"`},
+		{"normalize", `mod.normalize("a//b/../c/") === "a/c/" && mod.normalize("") === "."`},
+		{"relative", `mod.relative("/a/b/c", "/a/d") === "../../d"`},
+		{"dirname", `mod.dirname("/a/b/c.txt") === "/a/b" && mod.dirname("c.txt") === "."`},
+		{"basename", `mod.basename("/a/b.html", ".html") === "b" && mod.basename("/a/b/") === "b"`},
+		{"extname", `mod.extname("x.tar.gz") === ".gz" && mod.extname(".profile") === ""`},
+		{"isAbsolute", `mod.isAbsolute("/x") && !mod.isAbsolute("x")`},
+		{"parse and format", `(({ root, dir, base, ext, name }) => root === "/" && dir === "/home/u" && base === "f.txt" && ext === ".txt" && name === "f")(mod.parse("/home/u/f.txt")) && mod.format({ dir: "/x", name: "f", ext: "js" }) === "/x/f.js"`},
+		{"sep and delimiter", `mod.sep === "/" && mod.delimiter === ":"`},
+		{"posix", `mod.posix.join === mod.join && mod.default.posix === mod.default`},
+	}},
+	{"events", [][2]string{
+		{"on and emit", `(() => { const e = new mod.EventEmitter(); let sum = 0; e.on("n", (a, b) => (sum += a + b)); return e.emit("n", 1, 2) && sum === 3 && !e.emit("other"); })()`},
+		{"once", `(() => { const e = new mod.EventEmitter(); let n = 0; e.once("x", () => n++); e.emit("x"); e.emit("x"); return n === 1 && e.listenerCount("x") === 0; })()`},
+		{"off", `(() => { const e = new mod.EventEmitter(); const f = () => {}; e.on("x", f); e.off("x", f); return e.listenerCount("x") === 0; })()`},
+		{"prepend and order", `(() => { const e = new mod.EventEmitter(); const out = []; e.on("x", () => out.push(2)); e.prependListener("x", () => out.push(1)); e.emit("x"); return out.join() === "1,2"; })()`},
+		{"unhandled error throws", `(() => { const e = new mod.EventEmitter(); try { e.emit("error", new Error("boom")); } catch (err) { return err.message === "boom"; } return false; })()`},
+		{"static once", `(async () => { const e = new mod.EventEmitter(); setTimeout(() => e.emit("ready", 42), 0); const [value] = await mod.once(e, "ready"); return value === 42; })()`},
+		{"subclassing", `(() => { class Emitter extends mod.EventEmitter {} const e = new Emitter(); let hit = false; e.on("x", () => (hit = true)); e.emit("x"); return hit && mod.default === mod.EventEmitter; })()`},
+		{"eventNames", `(() => { const e = new mod.EventEmitter(); e.on("a", () => {}); e.on("b", () => {}); return e.eventNames().join() === "a,b"; })()`},
+	}},
+	{"util", [][2]string{
+		{"format", `mod.format("%s=%d %j %%", "a", 42, { b: 1 }) === 'a=42 {"b":1} %' && mod.format("x", 1) === "x 1"`},
+		{"inspect objects", `mod.inspect({ a: 1, s: "q", n: null }) === "{ a: 1, s: 'q', n: null }"`},
+		{"inspect collections", `mod.inspect(new Map([[1, "a"]])) === "Map(1) { 1 => 'a' }" && mod.inspect(new Set([1])) === "Set(1) { 1 }" && mod.inspect([1, , 3]) === "[ 1, <1 empty item>, 3 ]"`},
+		{"inspect depth", `mod.inspect({ a: { b: { c: { d: 1 } } } }) === "{ a: { b: { c: [Object] } } }"`},
+		{"inspect circular", `(() => { const o = {}; o.self = o; return mod.inspect(o) === "<ref *1> { self: [Circular *1] }"; })()`},
+		{"inspect.custom", `mod.inspect({ [mod.inspect.custom]: () => "custom" }) === "custom"`},
+		{"promisify", `(async () => (await mod.promisify((a, cb) => cb(null, a * 2))(21)) === 42)()`},
+		{"promisify rejects", `(async () => { try { await mod.promisify((cb) => cb(new Error("no")))(); } catch (e) { return e.message === "no"; } return false; })()`},
+		{"callbackify", `new Promise((resolve) => mod.callbackify(async (x) => x + 1)(1, (err, v) => resolve(err === null && v === 2)))`},
+		{"inherits", `(() => { function A() {} A.prototype.hi = () => "hi"; function B() {} mod.inherits(B, A); return new B().hi() === "hi" && B.super_ === A; })()`},
+		{"isDeepStrictEqual", `mod.isDeepStrictEqual({ a: [1, { b: 2 }] }, { a: [1, { b: 2 }] }) && !mod.isDeepStrictEqual({ a: 1 }, { a: "1" })`},
+		{"types", `mod.types.isPromise(Promise.resolve()) && mod.types.isDate(new Date()) && !mod.types.isRegExp({})`},
+		{"TextEncoder and TextDecoder", `new mod.TextDecoder().decode(new mod.TextEncoder().encode("héllo")) === "héllo"`},
+	}},
+	{"buffer", [][2]string{
+		{"from string", `mod.Buffer.from("héllo").length === 6 && mod.Buffer.from("héllo").toString() === "héllo"`},
+		{"encodings", `mod.Buffer.from("hi").toString("hex") === "6869" && mod.Buffer.from("aGk=", "base64").toString() === "hi" && mod.Buffer.from("hi").toString("base64url") === "aGk"`},
+		{"NUL characters", `mod.Buffer.from("a\0b").length === 3 && mod.Buffer.from("a\0b").toString() === "a\0b"`},
+		{"alloc and fill", `mod.Buffer.alloc(3, 1).join() === "1,1,1" && mod.Buffer.alloc(4).fill("ab").toString() === "abab"`},
+		{"concat", `mod.Buffer.concat([mod.Buffer.from("a"), mod.Buffer.from("bc")]).toString() === "abc"`},
+		{"compare and equals", `mod.Buffer.from("a").equals(mod.Buffer.from("a")) && mod.Buffer.compare(mod.Buffer.from("a"), mod.Buffer.from("b")) === -1`},
+		{"slice shares memory", `(() => { const b = mod.Buffer.from("abc"); b.slice(1)[0] = 0x78; return b.toString() === "axc"; })()`},
+		{"read and write integers", `(() => { const b = mod.Buffer.alloc(8); b.writeUInt32BE(0x01020304); b.writeInt16LE(-2, 4); return b.readUInt32BE(0) === 0x01020304 && b.readInt16LE(4) === -2 && b[0] === 1; })()`},
+		{"indexOf and includes", `mod.Buffer.from("hello").indexOf("l") === 2 && mod.Buffer.from("hello").includes("ell")`},
+		{"isBuffer and byteLength", `mod.Buffer.isBuffer(mod.Buffer.alloc(1)) && !mod.Buffer.isBuffer(new Uint8Array(1)) && mod.Buffer.byteLength("héllo") === 6`},
+		{"toJSON", `JSON.stringify(mod.Buffer.from("hi")) === '{"type":"Buffer","data":[104,105]}'`},
+		{"global", `globalThis.Buffer === mod.Buffer`},
+	}},
+	{"fs", [][2]string{
+		{"writeFileSync and readFileSync", `(() => { mod.writeFileSync(dir + "/a.txt", "héllo"); return mod.readFileSync(dir + "/a.txt", "utf8") === "héllo" && mod.readFileSync(dir + "/a.txt").length === 6; })()`},
+		{"appendFileSync", `(() => { mod.writeFileSync(dir + "/b.txt", "a"); mod.appendFileSync(dir + "/b.txt", "b"); return mod.readFileSync(dir + "/b.txt", "utf8") === "ab"; })()`},
+		{"existsSync", `mod.existsSync(dir) && !mod.existsSync(dir + "/missing")`},
+		{"statSync", `(() => { mod.writeFileSync(dir + "/c.txt", "abc"); const s = mod.statSync(dir + "/c.txt"); return s.isFile() && !s.isDirectory() && s.size === 3 && s.mtime instanceof Date; })()`},
+		{"mkdirSync and readdirSync", `(() => { mod.mkdirSync(dir + "/d/e", { recursive: true }); mod.writeFileSync(dir + "/d/f.txt", ""); const entries = mod.readdirSync(dir + "/d", { withFileTypes: true }); return mod.readdirSync(dir + "/d").sort().join() === "e,f.txt" && entries.find((e) => e.name === "e").isDirectory(); })()`},
+		{"renameSync and unlinkSync", `(() => { mod.writeFileSync(dir + "/g.txt", "g"); mod.renameSync(dir + "/g.txt", dir + "/h.txt"); mod.unlinkSync(dir + "/h.txt"); return !mod.existsSync(dir + "/g.txt") && !mod.existsSync(dir + "/h.txt"); })()`},
+		{"copyFileSync", `(() => { mod.writeFileSync(dir + "/i.txt", "i"); mod.copyFileSync(dir + "/i.txt", dir + "/j.txt"); return mod.readFileSync(dir + "/j.txt", "utf8") === "i"; })()`},
+		{"rmSync", `(() => { mod.mkdirSync(dir + "/k/l", { recursive: true }); mod.rmSync(dir + "/k", { recursive: true }); return !mod.existsSync(dir + "/k"); })()`},
+		{"symlinks", `(() => { mod.writeFileSync(dir + "/m.txt", "m"); mod.symlinkSync(dir + "/m.txt", dir + "/n.txt"); return mod.lstatSync(dir + "/n.txt").isSymbolicLink() && mod.readlinkSync(dir + "/n.txt") === dir + "/m.txt"; })()`},
+		{"system errors", `(() => { try { mod.readFileSync(dir + "/missing"); } catch (e) { return e.code === "ENOENT" && e.syscall === "open" && e.path === dir + "/missing"; } return false; })()`},
+		{"callbacks", `new Promise((resolve) => mod.readFile(dir + "/a.txt", "utf8", (err, data) => resolve(err === null && data === "héllo")))`},
+		{"promises", `(async () => (await mod.promises.readFile(dir + "/a.txt", "utf8")) === "héllo")()`},
+		{"constants", `mod.constants.F_OK === 0 && mod.constants.R_OK === 4`},
+	}},
+	{"fs/promises", [][2]string{
+		{"writeFile and readFile", `(async () => { await mod.writeFile(dir + "/p.txt", "p"); return (await mod.readFile(dir + "/p.txt", "utf8")) === "p"; })()`},
+		{"stat", `(async () => (await mod.stat(dir)).isDirectory())()`},
+		{"mkdir and readdir", `(async () => { await mod.mkdir(dir + "/q"); await mod.writeFile(dir + "/q/r", ""); return (await mod.readdir(dir + "/q")).join() === "r"; })()`},
+		{"rejections", `mod.access(dir + "/missing").then(() => false, (e) => e.code === "ENOENT")`},
+		{"default", `mod.default.readFile === mod.readFile`},
+	}},
+	{"os", [][2]string{
+		{"EOL", `mod.EOL === "\n"`},
+		{"platform and arch", `mod.platform() === process.platform && mod.arch() === process.arch`},
+		{"type", `typeof mod.type() === "string" && mod.type().length > 0`},
+		{"homedir and tmpdir", `typeof mod.homedir() === "string" && mod.tmpdir().length > 0`},
+		{"hostname", `typeof mod.hostname() === "string"`},
+		{"cpus", `mod.cpus().length > 0`},
+		{"endianness", `["LE", "BE"].includes(mod.endianness())`},
+	}},
+	{"process", [][2]string{
+		{"argv", `mod.argv.length === 4 && mod.argv[1].endsWith("main.js") && mod.argv.slice(2).join() === "one,two"`},
+		{"env", `(() => { mod.env.EDON_COMPAT = 1; const set = mod.env.EDON_COMPAT === "1" && "EDON_COMPAT" in mod.env; delete mod.env.EDON_COMPAT; return set && mod.env.EDON_COMPAT === undefined; })()`},
+		{"cwd", `mod.cwd() === process.cwd() && mod.cwd().startsWith("/")`},
+		{"platform and version", `typeof mod.platform === "string" && mod.version.startsWith("v")`},
+		{"hrtime", `typeof mod.hrtime.bigint() === "bigint" && mod.hrtime().length === 2`},
+		{"nextTick", `new Promise((resolve) => mod.nextTick((a) => resolve(a === 1), 1))`},
+		{"global", `globalThis.process === mod.default && typeof process.on === "function"`},
+	}},
+}
+
+func TestNodeCompat(t *testing.T) {
+	for _, entry := range nodeCompat {
+		t.Run(entry.module, func(t *testing.T) {
+			results := runCompatChecks(t, entry.module, entry.checks)
+			for _, check := range entry.checks {
+				t.Run(check[0], func(t *testing.T) {
+					if got := results[check[0]]; got != "ok" {
+						t.Errorf("node:%s %s: %s\n%s", entry.module, check[0], got, check[1])
+					}
+				})
+			}
+		})
+	}
+}
+
+// runCompatChecks evaluates checks against a built-in module, imported both
+// with and without the node: prefix, and returns each check's outcome
+func runCompatChecks(t *testing.T, module string, checks [][2]string) map[string]string {
+	t.Helper()
+	dir := t.TempDir()
+	scratch := filepath.Join(dir, "scratch")
+	if err := os.Mkdir(scratch, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var script strings.Builder
+	fmt.Fprintf(&script, "import * as mod from %q;\n", "node:"+module)
+	fmt.Fprintf(&script, "import * as bare from %q;\n", module)
+	script.WriteString("import { writeFileSync } from \"node:fs\";\n")
+	fmt.Fprintf(&script, "const dir = %q;\n", scratch)
+	script.WriteString("const checks = {\n")
+	script.WriteString("  \"bare specifier\": () => bare.default === mod.default,\n")
+	for _, check := range checks {
+		fmt.Fprintf(&script, "  %q: () => %s,\n", check[0], check[1])
+	}
+	script.WriteString(`};
+const results = {};
+for (const [name, check] of Object.entries(checks)) {
+  try {
+    results[name] = (await check()) === true ? "ok" : "returned false";
+  } catch (e) {
+    results[name] = "threw " + e.name + ": " + e.message;
+  }
+}
+`)
+	fmt.Fprintf(&script, "writeFileSync(%q, JSON.stringify(results));\n", filepath.Join(dir, "results.json"))
+	writeModules(t, dir, map[string]string{"main.js": script.String()})
+
+	rt, err := runtime.New(runtime.WithModuleLoader(loader.NewModuleLoader()), runtime.WithArgs("one", "two"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+	if err := rt.ExecuteFile(filepath.Join(dir, "main.js")); err != nil {
+		t.Fatalf("ExecuteFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "results.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results map[string]string
+	if err := json.Unmarshal(data, &results); err != nil {
+		t.Fatal(err)
+	}
+	if results["bare specifier"] != "ok" {
+		t.Errorf("import %q: %s", module, results["bare specifier"])
+	}
+	return results
+}
+
+func TestNodeUnknownBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{"main.js": `import "node:nope";`})
+
+	rt, err := runtime.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+	err = rt.ExecuteFile(filepath.Join(dir, "main.js"))
+	if err == nil || !strings.Contains(err.Error(), "node:nope") {
+		t.Errorf("ExecuteFile() error = %v, want an unknown built-in error", err)
+	}
+}
+
+func TestProcessExit(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"exit.js": `try { process.exit(3); } catch {} globalThis.after = true;`,
+		"code.js": `process.exitCode = 2;`,
+		"zero.js": `process.exit(0); throw new Error("unreachable");`,
+	})
+
+	for name, want := range map[string]int{"exit.js": 3, "code.js": 2, "zero.js": 0} {
+		rt, err := runtime.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = rt.ExecuteFile(filepath.Join(dir, name))
+		rt.Close()
+
+		var exitErr *runtime.ExitError
+		switch {
+		case want == 0 && err != nil:
+			t.Errorf("%s: ExecuteFile() error = %v, want nil", name, err)
+		case want != 0 && (!errors.As(err, &exitErr) || exitErr.Code != want):
+			t.Errorf("%s: ExecuteFile() error = %v, want exit status %d", name, err, want)
+		case want != 0 && !errors.Is(err, runtime.ErrExit):
+			t.Errorf("%s: ExitError does not unwrap to ErrExit", name)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package integration
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/runtime"
+)
+
+// fakePackage is a package served by the stand-in registry
+type fakePackage struct {
+	name    string
+	version string
+	files   map[string]string
+}
+
+// packTarball builds an npm-style tarball with every file under "package/"
+func packTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: "package/" + name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newFakeRegistry serves version manifests at /<name>/<version> for any
+// requested version and tarballs at /-/<name>.tgz
+func newFakeRegistry(t *testing.T, packages ...fakePackage) *httptest.Server {
+	t.Helper()
+	tarballs := make(map[string][]byte)
+	for _, pkg := range packages {
+		tarballs[pkg.name] = packTarball(t, pkg.files)
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name, ok := strings.CutPrefix(r.URL.Path, "/-/"); ok {
+			data, found := tarballs[strings.TrimSuffix(name, ".tgz")]
+			if !found {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(data)
+			return
+		}
+
+		for _, pkg := range packages {
+			if strings.HasPrefix(r.URL.Path, "/"+pkg.name+"/") {
+				sum := sha512.Sum512(tarballs[pkg.name])
+				manifest := map[string]any{
+					"name":    pkg.name,
+					"version": pkg.version,
+					"dist": map[string]string{
+						"tarball":   server.URL + "/-/" + pkg.name + ".tgz",
+						"integrity": "sha512-" + base64.StdEncoding.EncodeToString(sum[:]),
+					},
+				}
+				_ = json.NewEncoder(w).Encode(manifest)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNPMModuleEntryPoints(t *testing.T) {
+	registry := newFakeRegistry(t,
+		fakePackage{name: "greeter", version: "1.0.0", files: map[string]string{
+			"package.json": `{
+				"name": "greeter",
+				"version": "1.0.0",
+				"dependencies": {"punctuate": "^2.0.0"},
+				"exports": {
+					".": {"require": "./cjs/index.js", "import": "./esm/index.js"},
+					"./format": "./esm/format.js"
+				},
+				"imports": {"#internal/*": "./esm/internal/*.js"}
+			}`,
+			"cjs/index.js":         `module.exports = "wrong entry";`,
+			"index.js":             `export default "wrong entry";`,
+			"esm/index.js":         `import { name } from "#internal/name"; export default name;`,
+			"esm/internal/name.js": `export const name = "edon";`,
+			"esm/format.js":        `import bang from "punctuate"; export const greet = (who) => "hello, " + who + bang;`,
+		}},
+		fakePackage{name: "punctuate", version: "2.1.0", files: map[string]string{
+			"package.json": `{"name": "punctuate", "version": "2.1.0", "main": "lib/bang"}`,
+			"lib/bang.js":  `export default "!";`,
+		}},
+	)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NPM_CONFIG_REGISTRY", registry.URL)
+
+	script := filepath.Join(t.TempDir(), "main.js")
+	if err := os.WriteFile(script, []byte(`
+        import { greet } from "npm:greeter@1/format";
+        import name from "npm:greeter@1";
+        globalThis.result = greet(name);
+    `), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rt, err := runtime.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	if err := rt.ExecuteFile(script); err != nil {
+		t.Fatalf("ExecuteFile() error = %v", err)
+	}
+	if err := rt.Eval(`if (globalThis.result !== "hello, edon!") throw new Error(globalThis.result)`); err != nil {
+		t.Errorf("unexpected result: %v", err)
+	}
+}
+
+func TestNPMModuleSubpathNotExported(t *testing.T) {
+	registry := newFakeRegistry(t, fakePackage{name: "sealed", version: "1.0.0", files: map[string]string{
+		"package.json": `{"name": "sealed", "exports": {".": "./index.js"}}`,
+		"index.js":     `export default 1;`,
+		"internal.js":  `export default 2;`,
+	}})
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NPM_CONFIG_REGISTRY", registry.URL)
+
+	script := filepath.Join(t.TempDir(), "main.js")
+	if err := os.WriteFile(script, []byte(`import x from "npm:sealed/internal.js";`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rt, err := runtime.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	err = rt.ExecuteFile(script)
+	if err == nil || !strings.Contains(err.Error(), `"sealed"`) || !strings.Contains(err.Error(), `"./internal.js"`) {
+		t.Errorf("ExecuteFile() error = %v, want an error naming the package and subpath", err)
+	}
+}
+
+func TestDynamicImports(t *testing.T) {
+	registry := newFakeRegistry(t, fakePackage{name: "lazy", version: "1.0.0", files: map[string]string{
+		"package.json":  `{"name": "lazy", "version": "1.0.0", "main": "index.js"}`,
+		"index.js":      `export const load = () => import("./chunks/big.js").then((m) => m.default);`,
+		"chunks/big.js": `import { size } from "../size.js"; export default "big " + size;`,
+		"size.js":       `export const size = 42;`,
+	}})
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NPM_CONFIG_REGISTRY", registry.URL)
+
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"greeting.js": `import { name } from "./name.js"; export default "hello, " + name;`,
+		"name.js":     `export const name = "edon";`,
+		"main.js": `import { load } from "npm:lazy@1";
+const greeting = await import("./greeting.js");
+const chunk = await load();
+import("./name.js").then((m) => { globalThis.result = [greeting.default, chunk, m.name].join("|"); });`,
+	})
+
+	rt, err := runtime.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	if err := rt.ExecuteFile(filepath.Join(dir, "main.js")); err != nil {
+		t.Fatalf("ExecuteFile() error = %v", err)
+	}
+	if err := rt.Eval(`if (globalThis.result !== "hello, edon|big 42|edon") throw new Error(globalThis.result)`); err != nil {
+		t.Errorf("unexpected result: %v", err)
+	}
+}
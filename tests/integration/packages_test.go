@@ -0,0 +1,154 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/packages"
+)
+
+// TestPackageHousekeeping lists, explains and updates an installed tree
+// as the stand-in registry publishes new versions
+func TestPackageHousekeeping(t *testing.T) {
+	registry := newPackageRegistry(t,
+		fakePackage{name: "app-core", version: "1.0.0", files: map[string]string{
+			"package.json": `{"name": "app-core", "version": "1.0.0", "dependencies": {"util": "^1.0.0", "log": "^1.0.0"}}`,
+		}},
+		fakePackage{name: "util", version: "1.0.0"},
+		fakePackage{name: "util", version: "1.2.0", files: map[string]string{
+			"package.json": `{"name": "util", "version": "1.2.0", "dependencies": {"log": "^1.0.0"}}`,
+		}},
+		fakePackage{name: "log", version: "1.0.0"},
+		fakePackage{name: "lint", version: "3.0.0", files: map[string]string{
+			"package.json": `{"name": "lint", "version": "3.0.0", "dependencies": {"util": "^1.1.0"}}`,
+		}},
+	)
+
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"package.json": `{
+  "name": "demo",
+  "version": "1.0.0",
+  "dependencies": {
+    "app-core": "^1.0.0"
+  },
+  "devDependencies": {
+    "lint": "^3.0.0"
+  }
+}
+`,
+	})
+	project, lock := openProject(t, dir)
+	if _, err := project.Tree(); !errors.Is(err, errors.ErrNotInstalled) {
+		t.Errorf("Tree before installing error = %v, want ErrNotInstalled", err)
+	}
+	if _, err := project.Install(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := project.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := tree.Write(&out, 0); err != nil {
+		t.Fatal(err)
+	}
+	want := `demo@1.0.0
+├── app-core@1.0.0
+└── lint@3.0.0 (dev)
+`
+	if out.String() != want {
+		t.Errorf("list =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := tree.Write(&out, -1); err != nil {
+		t.Fatal(err)
+	}
+	want = `demo@1.0.0
+├── app-core@1.0.0
+│   ├── log@1.0.0
+│   └── util@1.2.0
+│       └── log@1.0.0
+└── lint@3.0.0 (dev)
+    └── util@1.2.0 *
+
+* dependencies already listed above
+`
+	if out.String() != want {
+		t.Errorf("list -depth=-1 =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	paths, err := tree.Why("log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPaths := [][]string{
+		{"demo@1.0.0", "app-core@1.0.0", "log@1.0.0"},
+		{"demo@1.0.0", "app-core@1.0.0", "util@1.2.0", "log@1.0.0"},
+		{"demo@1.0.0", "lint@3.0.0", "util@1.2.0", "log@1.0.0"},
+	}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("why log = %v, want %v", paths, wantPaths)
+	}
+	if _, err := tree.Why("left-pad"); !errors.Is(err, errors.ErrNotInstalled) {
+		t.Errorf("why left-pad error = %v, want ErrNotInstalled", err)
+	}
+
+	registry.publish(fakePackage{name: "app-core", version: "1.1.0"})
+	registry.publish(fakePackage{name: "app-core", version: "2.0.0"})
+	registry.publish(fakePackage{name: "util", version: "1.3.0"})
+
+	outdated, err := project.Outdated(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantOutdated := []packages.Outdated{{Name: "app-core", Range: "^1.0.0", Current: "1.0.0", Wanted: "1.1.0", Latest: "2.0.0"}}
+	if !reflect.DeepEqual(outdated, wantOutdated) {
+		t.Errorf("outdated = %+v, want %+v", outdated, wantOutdated)
+	}
+
+	// Updating a package deep in the tree leaves the rest alone
+	changes, _, err := project.Update(context.Background(), []string{"util"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	specifiers := lock.NPMPackages().Specifiers
+	if len(changes) != 0 || specifiers["util@^1.0.0"] != "1.3.0" || specifiers["util@^1.1.0"] != "1.3.0" || specifiers["app-core@^1.0.0"] != "1.0.0" {
+		t.Errorf("update util changed %v and locked %v", changes, specifiers)
+	}
+
+	changes, _, err = project.Update(context.Background(), nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []packages.Change{{Name: "app-core", From: "1.0.0", To: "1.1.0"}}; !reflect.DeepEqual(changes, want) {
+		t.Errorf("update = %v, want %v", changes, want)
+	}
+
+	changes, _, err = project.Update(context.Background(), []string{"app-core"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []packages.Change{{Name: "app-core", From: "1.1.0", To: "2.0.0"}}; !reflect.DeepEqual(changes, want) {
+		t.Errorf("update -latest = %v, want %v", changes, want)
+	}
+	if got := readFile(t, filepath.Join(dir, "package.json")); !bytes.Contains([]byte(got), []byte(`"app-core": "^2.0.0"`)) {
+		t.Errorf("package.json after update -latest:\n%s", got)
+	}
+	if _, ok := lock.NPMPackages().Packages["log@1.0.0"]; ok {
+		t.Error("log is still locked after nothing depends on it")
+	}
+
+	if _, _, err := project.Update(context.Background(), []string{"left-pad"}, false); !errors.Is(err, errors.ErrNotInstalled) {
+		t.Errorf("update left-pad error = %v, want ErrNotInstalled", err)
+	}
+	if _, _, err := project.Update(context.Background(), []string{"util"}, true); !errors.Is(err, errors.ErrNotDependency) {
+		t.Errorf("update util -latest error = %v, want ErrNotDependency", err)
+	}
+}
@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+// newSlowCDN serves /main.js importing /lib/0.js../lib/n-1.js, each of which
+// imports /shared.js, and answers every request after delay
+func newSlowCDN(t *testing.T, n int, delay time.Duration, hits *atomic.Int64) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(delay)
+		switch {
+		case r.URL.Path == "/main.js":
+			for i := range n {
+				fmt.Fprintf(w, "import \"./lib/%d.js\";\n", i)
+			}
+		case strings.HasPrefix(r.URL.Path, "/lib/"):
+			fmt.Fprintf(w, "import \"../shared.js\"; export const name = %q;\n", r.URL.Path)
+		case r.URL.Path == "/shared.js":
+			fmt.Fprintln(w, "export const shared = true;")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func loadSlowGraph(t *testing.T, server *httptest.Server, concurrency int) time.Duration {
+	t.Helper()
+	l := loader.NewModuleLoader(
+		loader.WithImportPolicy(loader.NewImportPolicy(strings.TrimPrefix(server.URL, "http://"))),
+		loader.WithDiskCache(nil),
+		loader.WithConcurrency(concurrency),
+	)
+	start := time.Now()
+	graph, err := l.LoadGraph(context.Background(), server.URL+"/main.js")
+	if err != nil {
+		t.Fatalf("LoadGraph() error = %v", err)
+	}
+	if len(graph.Modules) != 22 {
+		t.Fatalf("LoadGraph() loaded %d modules, want 22", len(graph.Modules))
+	}
+	return time.Since(start)
+}
+
+func TestParallelPrefetch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var hits atomic.Int64
+	server := newSlowCDN(t, 20, 20*time.Millisecond, &hits)
+
+	sequential := loadSlowGraph(t, server, 1)
+	if got := hits.Swap(0); got != 22 {
+		t.Errorf("sequential load made %d requests, want 22", got)
+	}
+	parallel := loadSlowGraph(t, server, loader.DefaultConcurrency)
+	if got := hits.Load(); got != 22 {
+		t.Errorf("parallel load made %d requests, want 22", got)
+	}
+
+	t.Logf("sequential %v, parallel %v", sequential, parallel)
+	if parallel*3 > sequential {
+		t.Errorf("parallel load took %v, sequential %v", parallel, sequential)
+	}
+}
+
+func TestConcurrentLoadsShareFetch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var hits atomic.Int64
+	server := newSlowCDN(t, 1, 50*time.Millisecond, &hits)
+	l := loader.NewModuleLoader(
+		loader.WithImportPolicy(loader.NewImportPolicy(strings.TrimPrefix(server.URL, "http://"))),
+		loader.WithDiskCache(nil),
+	)
+
+	var wg sync.WaitGroup
+	modules := make([]*loader.Module, 10)
+	for i := range modules {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			module, err := l.LoadModule(context.Background(), server.URL+"/shared.js")
+			if err != nil {
+				t.Errorf("LoadModule() error = %v", err)
+			}
+			modules[i] = module
+		}()
+	}
+	wg.Wait()
+
+	if got := hits.Load(); got != 1 {
+		t.Errorf("10 concurrent loads made %d requests, want 1", got)
+	}
+	for _, module := range modules[1:] {
+		if module != modules[0] {
+			t.Fatal("concurrent loads returned different modules")
+		}
+	}
+}
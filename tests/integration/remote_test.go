@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+// newPrivateMirror serves modules only to requests carrying the token
+func newPrivateMirror(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mod.js", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`import { twice } from "./util.js"; export const value = twice(21);`))
+	})
+	mux.HandleFunc("/util.js", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`export const twice = (n) => n * 2;`))
+	})
+	mux.HandleFunc("/moved.js", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://untrusted.example/mod.js", http.StatusFound)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRemoteImportWithAuthHeaders(t *testing.T) {
+	mirror := newPrivateMirror(t, "secret")
+	host := strings.TrimPrefix(mirror.URL, "http://")
+
+	policy := loader.NewImportPolicy()
+	policy.SetHeaders(host, map[string]string{"Authorization": "Bearer secret"})
+
+	rt, err := runModules(t, t.TempDir(), map[string]string{"main.js": `
+        import { value } from "` + mirror.URL + `/mod.js";
+        globalThis.result = value;
+    `}, loader.WithImportPolicy(policy))
+	if err != nil {
+		t.Fatalf("ExecuteFile() error = %v", err)
+	}
+	if err := rt.Eval(`if (globalThis.result !== 42) throw new Error(String(globalThis.result))`); err != nil {
+		t.Errorf("unexpected result: %v", err)
+	}
+}
+
+func TestRemoteImportRejectedByPolicy(t *testing.T) {
+	mirror := newPrivateMirror(t, "secret")
+	parsed, _ := url.Parse(mirror.URL)
+
+	_, err := runModules(t, t.TempDir(), map[string]string{"main.js": `import "` + mirror.URL + `/util.js";`},
+		loader.WithImportPolicy(loader.DefaultImportPolicy()))
+	if !errors.Is(err, errors.ErrImportNotAllowed) || !strings.Contains(err.Error(), "host rule") {
+		t.Errorf("ExecuteFile() error = %v, want a host rule rejection", err)
+	}
+
+	// Redirects are checked against the policy too
+	_, err = runModules(t, t.TempDir(), map[string]string{"main.js": `import "` + mirror.URL + `/moved.js";`},
+		loader.WithImportPolicy(loader.NewImportPolicy(parsed.Host)))
+	if err == nil || !strings.Contains(err.Error(), "untrusted.example") {
+		t.Errorf("ExecuteFile() error = %v, want the redirect target rejected", err)
+	}
+}
+
+func TestRemoteImportOfLocalFile(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(secret, []byte("hunter2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`import text from "` + (&url.URL{Scheme: "file", Path: filepath.ToSlash(secret)}).String() + `" with { type: "text" }; export default text;`))
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := runModules(t, t.TempDir(), map[string]string{"main.js": `import text from "` + server.URL + `/steal.js"; globalThis.result = text;`},
+		loader.WithImportPolicy(loader.NewImportPolicy(strings.TrimPrefix(server.URL, "http://"))))
+	if !errors.Is(err, errors.ErrUnresolvedImport) || !strings.Contains(err.Error(), "file://") {
+		t.Errorf("ExecuteFile() error = %v, want the file:// import from a remote module rejected", err)
+	}
+}
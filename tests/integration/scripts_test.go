@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/scripts"
+	"github.com/katungi/edon/internal/shell"
+)
+
+func TestRunScripts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stand-in package binary is a shell script")
+	}
+
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"package.json": `{
+  "name": "demo",
+  "version": "1.0.0",
+  "scripts": {
+    "test": "tool --ci",
+    "prebuild": "echo pre:$npm_lifecycle_event",
+    "build": "echo build:$npm_package_name",
+    "postbuild": "echo post",
+    "fail": "echo failing && exit 3",
+    "postfail": "echo never"
+  }
+}`,
+	})
+	bin := filepath.Join(dir, "node_modules", ".bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "tool"), []byte("#!/bin/sh\necho \"tool $*\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "src", "nested")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Scripts are found from subdirectories and listed in package.json order
+	pkg, err := scripts.Find(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range pkg.Scripts {
+		names = append(names, s.Name)
+	}
+	if want := []string{"test", "prebuild", "build", "postbuild", "fail", "postfail"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Scripts = %v, want %v", names, want)
+	}
+
+	run := func(name string, args ...string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		err := pkg.Run(context.Background(), name, args, scripts.WithStdio(nil, &stdout, &stderr))
+		return stdout.String(), err
+	}
+
+	out, err := run("build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "pre:prebuild\nbuild:demo\npost\n" {
+		t.Errorf("build printed %q", out)
+	}
+
+	out, err = run("test", "--grep", "a b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "tool --ci --grep a b\n" {
+		t.Errorf("test printed %q, want node_modules/.bin/tool to get the extra arguments", out)
+	}
+
+	out, err = run("fail")
+	var exitErr *shell.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		t.Errorf("fail: error = %v, want exit status 3", err)
+	}
+	if out != "failing\n" {
+		t.Errorf("fail printed %q, want the post hook skipped", out)
+	}
+
+	if _, err := run("missing"); !errors.Is(err, errors.ErrScriptNotFound) {
+		t.Errorf("missing: error = %v, want ErrScriptNotFound", err)
+	}
+}
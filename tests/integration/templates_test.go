@@ -0,0 +1,122 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/katungi/edon/internal/config"
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/modules/node"
+	"github.com/katungi/edon/internal/templates"
+	"github.com/katungi/edon/internal/testrunner"
+)
+
+// TestTemplates initializes every template and runs its tests with the
+// import map and compiler options of its edon.json
+func TestTemplates(t *testing.T) {
+	for _, tmpl := range templates.List() {
+		t.Run(tmpl.Name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "my-app")
+			files, err := templates.Render(tmpl.Name, templates.Data{Name: templates.PackageName(dir)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := templates.Write(dir, files, false); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{"edon.json", ".gitignore", "package.json"} {
+				if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+					t.Errorf("%s was not written: %v", name, err)
+				}
+			}
+
+			cfg, err := config.Load(filepath.Join(dir, "edon.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			importMap, err := cfg.LoadImportMap()
+			if err != nil {
+				t.Fatal(err)
+			}
+			moduleLoader := loader.NewModuleLoader(
+				loader.WithImportMap(importMap),
+				loader.WithCompilerOptions(cfg.CompilerOptions),
+			)
+
+			tests, err := testrunner.Discover([]string{dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := &recorder{}
+			if _, err := testrunner.Run(tests, rec, testrunner.WithModuleLoader(moduleLoader)); err != nil {
+				t.Fatalf("tests failed: %v (%+v)", err, rec.summary)
+			}
+			if rec.summary.Passed == 0 {
+				t.Fatal("the template has no tests")
+			}
+		})
+	}
+}
+
+func TestTemplateConfig(t *testing.T) {
+	dir := t.TempDir()
+	files, err := templates.Render("cli", templates.Data{Name: "greeter"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := templates.Write(dir, files, false); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(filepath.Join(dir, "edon.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cfg.RunPermissions(), (node.Permissions{Read: true, Env: true}); got != want {
+		t.Errorf("permissions = %+v, want %+v", got, want)
+	}
+	if len(cfg.Tasks) == 0 || len(cfg.CompilerOptions) == 0 {
+		t.Errorf("tasks = %s, compilerOptions = %s", cfg.Tasks, cfg.CompilerOptions)
+	}
+
+	// Without a permissions section everything stays allowed
+	if got := (&config.Config{}).RunPermissions(); got != node.AllowAll {
+		t.Errorf("default permissions = %+v, want AllowAll", got)
+	}
+}
+
+func TestTemplateOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	files, err := templates.Render(templates.Default, templates.Data{Name: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := filepath.Join(dir, "index.js")
+	if err := os.WriteFile(index, []byte("// mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = templates.Write(dir, files, false)
+	if !errors.Is(err, errors.ErrFileExists) {
+		t.Fatalf("Write error = %v, want ErrFileExists", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "edon.json")); !os.IsNotExist(err) {
+		t.Error("Write wrote files despite refusing to overwrite")
+	}
+	if data, _ := os.ReadFile(index); string(data) != "// mine\n" {
+		t.Errorf("index.js = %q, want it untouched", data)
+	}
+
+	if err := templates.Write(dir, files, true); err != nil {
+		t.Fatalf("Write with force: %v", err)
+	}
+	if data, _ := os.ReadFile(index); string(data) == "// mine\n" {
+		t.Error("index.js was not overwritten with force")
+	}
+
+	if _, err := templates.Render("nope", templates.Data{Name: "app"}); !errors.Is(err, errors.ErrUnknownTemplate) {
+		t.Errorf("Render error = %v, want ErrUnknownTemplate", err)
+	}
+}
@@ -0,0 +1,270 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/xml"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/edon"
+	"github.com/katungi/edon/internal/testrunner"
+)
+
+// recorder is a reporter that keeps the results it is given
+type recorder struct {
+	files   []*testrunner.FileResult
+	summary testrunner.Summary
+}
+
+func (r *recorder) File(result *testrunner.FileResult) { r.files = append(r.files, result) }
+
+func (r *recorder) Done(s testrunner.Summary) error {
+	r.summary = s
+	return nil
+}
+
+// statuses maps each test and step, by its full name, to its status
+func statuses(tests []edon.TestResult, parent string) map[string]edon.TestStatus {
+	m := make(map[string]edon.TestStatus)
+	for _, t := range tests {
+		name := t.Name
+		if parent != "" {
+			name = parent + " > " + name
+		}
+		m[name] = t.Status
+		for k, v := range statuses(t.Steps, name) {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+func TestRunTests(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"math_test.js": `
+import { describe, it, beforeAll, afterAll, beforeEach, afterEach } from "edon:test";
+import { assertEquals, assertRejects, assertThrows } from "edon:assert";
+
+const events = [];
+
+Edon.test("async", async () => {
+  await new Promise((resolve) => setTimeout(resolve, 5));
+  assertEquals({ sum: [1 + 1] }, { sum: [2] });
+});
+
+Edon.test("steps", async (t) => {
+  await t.step("passes", () => {});
+  await t.step("nested", async (t) => {
+    await t.step("fails", () => assertEquals(1, 2));
+  });
+});
+
+Edon.test("unawaited step", (t) => {
+  t.step("late", () => new Promise((resolve) => setTimeout(resolve, 5)));
+});
+
+Edon.test.ignore("ignored", () => {
+  throw new Error("must not run");
+});
+
+describe("hooks", () => {
+  beforeAll(() => events.push("beforeAll"));
+  beforeEach(() => events.push("beforeEach"));
+  afterEach(() => events.push("afterEach"));
+  afterAll(() => events.push("afterAll"));
+  it("first", () => events.push("first"));
+  it("second", async () => {
+    events.push("second");
+    await assertRejects(() => Promise.reject(new TypeError("bad input")), TypeError, "bad");
+    assertThrows(() => JSON.parse("{"), SyntaxError);
+  });
+});
+
+Edon.test("hooks ran in order", () => {
+  assertEquals(events, ["beforeAll", "beforeEach", "first", "afterEach", "beforeEach", "second", "afterEach", "afterAll"]);
+});
+`,
+		"lib/strings.test.ts": `
+import { assertStringIncludes } from "edon:assert";
+const greeting: string = "hello edon";
+Edon.test("includes", () => assertStringIncludes(greeting, "edon"));
+`,
+		"lib/helper.js":                `export const notATest = true;`,
+		"node_modules/dep/dep_test.js": `Edon.test("never discovered", () => {});`,
+		".hidden/hidden_test.js":       `Edon.test("never discovered", () => {});`,
+		"only/only_test.js":            `Edon.test("left out", () => {}); Edon.test.only("kept", () => {});`,
+		"broken/broken_test.js":        `import "./missing.js";`,
+		"filtered/filter_test.js":      `Edon.test("alpha one", () => {}); Edon.test("beta", () => {}); Edon.test("alpha two", () => {});`,
+	})
+
+	files, err := testrunner.Discover([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rel []string
+	for _, f := range files {
+		r, _ := filepath.Rel(dir, f)
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	want := []string{"broken/broken_test.js", "filtered/filter_test.js", "lib/strings.test.ts", "math_test.js", "only/only_test.js"}
+	if !reflect.DeepEqual(rel, want) {
+		t.Fatalf("Discover = %v, want %v", rel, want)
+	}
+
+	rec := &recorder{}
+	summary, err := testrunner.Run(files, rec)
+	if !errors.Is(err, testrunner.ErrTestsFailed) {
+		t.Fatalf("Run error = %v, want ErrTestsFailed", err)
+	}
+	if len(rec.files) != len(files) {
+		t.Fatalf("reported %d files, want %d", len(rec.files), len(files))
+	}
+
+	byFile := make(map[string]*testrunner.FileResult)
+	for _, f := range rec.files {
+		byFile[filepath.Base(f.Path)] = f
+	}
+	if byFile["broken_test.js"].Err == nil {
+		t.Error("a file importing a missing module should fail to load")
+	}
+
+	got := statuses(byFile["math_test.js"].Tests, "")
+	wantStatuses := map[string]edon.TestStatus{
+		"async":                  edon.TestPassed,
+		"steps":                  edon.TestFailed,
+		"steps > passes":         edon.TestPassed,
+		"steps > nested":         edon.TestFailed,
+		"steps > nested > fails": edon.TestFailed,
+		"unawaited step":         edon.TestFailed,
+		"unawaited step > late":  edon.TestFailed,
+		"ignored":                edon.TestIgnored,
+		"hooks > first":          edon.TestPassed,
+		"hooks > second":         edon.TestPassed,
+		"hooks ran in order":     edon.TestPassed,
+	}
+	if !reflect.DeepEqual(got, wantStatuses) {
+		t.Errorf("math_test.js statuses = %v, want %v", got, wantStatuses)
+	}
+	for _, test := range byFile["math_test.js"].Tests {
+		if test.Name == "steps" {
+			failing := test.Steps[1].Steps[0]
+			if !strings.Contains(failing.Error, "AssertionError: Values are not equal") {
+				t.Errorf("failing step error = %q", failing.Error)
+			}
+		}
+	}
+
+	only := byFile["only_test.js"]
+	if len(only.Tests) != 1 || only.Tests[0].Name != "kept" || only.Filtered != 1 || !only.Only {
+		t.Errorf("only_test.js = %+v", only)
+	}
+	if !summary.Only || summary.Errors != 1 || summary.Failed != 2 || summary.Ignored != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	// Filters match substrings, or regular expressions between slashes
+	filterFile := []string{filepath.Join(dir, "filtered", "filter_test.js")}
+	for filter, want := range map[string][]string{
+		"alpha":    {"alpha one", "alpha two"},
+		"/^b/":     {"beta"},
+		"/TWO$/i":  {"alpha two"},
+		"no match": nil,
+	} {
+		rec := &recorder{}
+		if _, err := testrunner.Run(filterFile, rec, testrunner.WithFilter(filter)); err != nil {
+			t.Fatalf("filter %q: %v", filter, err)
+		}
+		var names []string
+		for _, test := range rec.files[0].Tests {
+			names = append(names, test.Name)
+		}
+		if !reflect.DeepEqual(names, want) {
+			t.Errorf("filter %q ran %v, want %v", filter, names, want)
+		}
+	}
+}
+
+func TestTestReporters(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"report_test.js": `
+Edon.test("passes", () => {});
+Edon.test("has steps", async (t) => {
+  await t.step("inner", () => {
+    throw new RangeError("out of range");
+  });
+});
+Edon.test.ignore("later", () => {});
+`,
+	})
+	files := []string{filepath.Join(dir, "report_test.js")}
+
+	var tap bytes.Buffer
+	if _, err := testrunner.Run(files, testrunner.NewTAPReporter(&tap)); !errors.Is(err, testrunner.ErrTestsFailed) {
+		t.Fatalf("Run error = %v, want ErrTestsFailed", err)
+	}
+	out := tap.String()
+	for _, line := range []string{
+		"TAP version 13",
+		"ok 1 - passes",
+		"    # Subtest: has steps",
+		"    not ok 1 - inner",
+		"      message: |-",
+		"        RangeError: out of range",
+		"not ok 2 - has steps",
+		"ok 3 - later # SKIP",
+		"1..3",
+		"# fail 1",
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("TAP output is missing %q:\n%s", line, out)
+		}
+	}
+
+	var junit bytes.Buffer
+	if _, err := testrunner.Run(files, testrunner.NewJUnitReporter(&junit)); !errors.Is(err, testrunner.ErrTestsFailed) {
+		t.Fatalf("Run error = %v, want ErrTestsFailed", err)
+	}
+	var report struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Skipped  int `xml:"skipped,attr"`
+		Suites   []struct {
+			Cases []struct {
+				Name    string `xml:"name,attr"`
+				Failure *struct {
+					Type string `xml:"type,attr"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(junit.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JUnit XML: %v\n%s", err, junit.String())
+	}
+	if report.Tests != 4 || report.Failures != 2 || report.Skipped != 1 || len(report.Suites) != 1 {
+		t.Errorf("JUnit counts = %+v", report)
+	}
+	var failed []string
+	for _, c := range report.Suites[0].Cases {
+		if c.Failure != nil {
+			failed = append(failed, c.Name+":"+c.Failure.Type)
+		}
+	}
+	if want := []string{"has steps:Error", "has steps > inner:RangeError"}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("JUnit failures = %v, want %v", failed, want)
+	}
+}
+
+func TestBasicFixture(t *testing.T) {
+	summary, err := testrunner.Run([]string{"../fixtures/basic_test.js"}, &recorder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Passed != 4 {
+		t.Errorf("passed = %d, want 4", summary.Passed)
+	}
+}
@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/runtime"
+)
+
+func TestVendor(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	registry := newFakeRegistry(t,
+		fakePackage{name: "greeter", version: "1.0.0", files: map[string]string{
+			"package.json": `{"name": "greeter", "version": "1.0.0", "dependencies": {"punctuate": "^2.0.0"}, "exports": "./esm/index.js", "imports": {"#name": "./esm/name.js"}}`,
+			"esm/index.js": `import bang from "punctuate"; import { name } from "#name"; export default (who) => "hello " + who + " from " + name + bang;`,
+			"esm/name.js":  `export const name = "greeter";`,
+		}},
+		fakePackage{name: "punctuate", version: "2.1.0", files: map[string]string{
+			"package.json": `{"name": "punctuate", "version": "2.1.0", "main": "bang.js"}`,
+			"bang.js":      `export default "!";`,
+		}},
+	)
+	t.Setenv("NPM_CONFIG_REGISTRY", registry.URL)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/shout", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/shout@1.0.0/mod.js", http.StatusFound)
+	})
+	mux.HandleFunc("/shout@1.0.0/mod.js", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`import { upper } from "/shout@1.0.0/lib/upper.js"; export const shout = upper;`))
+	})
+	mux.HandleFunc("/shout@1.0.0/lib/upper.js", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`import { trim } from "./trim.js"; export const upper = (s) => trim(s).toUpperCase();`))
+	})
+	mux.HandleFunc("/shout@1.0.0/lib/trim.js", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`export const trim = (s) => s.trim();`))
+	})
+	cdn := httptest.NewServer(mux)
+	t.Cleanup(cdn.Close)
+
+	project := t.TempDir()
+	entry := filepath.Join(project, "main.js")
+	if err := os.WriteFile(entry, []byte(`
+        import greet from "npm:greeter@^1.0.0";
+        import { shout } from "`+cdn.URL+`/shout";
+        globalThis.result = shout("  " + greet("edon"));
+    `), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l := loader.NewModuleLoader(loader.WithImportPolicy(loader.NewImportPolicy(strings.TrimPrefix(cdn.URL, "http://"))))
+	graph, err := l.LoadGraph(context.Background(), entry)
+	if err != nil {
+		t.Fatalf("LoadGraph() error = %v", err)
+	}
+	mapPath, err := loader.Vendor(filepath.Join(project, "vendor"), graph)
+	if err != nil {
+		t.Fatalf("Vendor() error = %v", err)
+	}
+
+	host := strings.ReplaceAll(strings.TrimPrefix(cdn.URL, "http://"), ":", "_")
+	for _, file := range []string{host + "/shout@1.0.0/lib/trim.js", "npm/greeter@1.0.0/esm/name.js", "npm/punctuate@2.1.0/package.json"} {
+		if _, err := os.Stat(filepath.Join(project, "vendor", filepath.FromSlash(file))); err != nil {
+			t.Errorf("%s was not vendored: %v", file, err)
+		}
+	}
+
+	// Without the servers or any cache, the import map is all it takes
+	cdn.Close()
+	registry.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	importMap, err := loader.LoadImportMap(mapPath)
+	if err != nil {
+		t.Fatalf("LoadImportMap() error = %v", err)
+	}
+	rt, err := runtime.New(runtime.WithModuleLoader(loader.NewModuleLoader(loader.WithImportMap(importMap), loader.WithCachedOnly(true))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+	if err := rt.ExecuteFile(entry); err != nil {
+		t.Fatalf("ExecuteFile() with the vendor import map error = %v", err)
+	}
+	if err := rt.Eval(`if (globalThis.result !== "HELLO EDON FROM GREETER!") throw new Error(globalThis.result)`); err != nil {
+		t.Errorf("unexpected result: %v", err)
+	}
+}
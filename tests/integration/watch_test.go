@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/katungi/edon/internal/modules/loader"
+	"github.com/katungi/edon/internal/runtime"
+	"github.com/katungi/edon/internal/watch"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "runs.log")
+	writeModules(t, dir, map[string]string{
+		"main.js":      `import fs from "node:fs"; import { value } from "./lib.js"; fs.appendFileSync(process.argv[2], value + "\n");`,
+		"lib.js":       `export const value = 1;`,
+		"assets/a.txt": `a`,
+		"assets/b.tmp": `b`,
+		"unrelated.js": `export default 0;`,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		// Every run gets a fresh runtime
+		done <- watch.Run(ctx, loader.NewModuleLoader(), filepath.Join(dir, "main.js"), func(context.Context) error {
+			rt, err := runtime.New(runtime.WithArgs(log))
+			if err != nil {
+				return err
+			}
+			defer rt.Close()
+			return rt.ExecuteFile(filepath.Join(dir, "main.js"))
+		},
+			watch.WithPaths(filepath.Join(dir, "assets")),
+			watch.WithExclude("*.tmp"),
+			watch.WithDebounce(100*time.Millisecond),
+			watch.WithOutput(io.Discard),
+		)
+	}()
+
+	// waitForRuns waits until the log holds want, one value per run
+	waitForRuns := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			data, _ := os.ReadFile(log)
+			if string(data) == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("runs = %q, want %q", data, want)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	// settle gives a change that should be ignored time to be noticed
+	settle := func() { time.Sleep(400 * time.Millisecond) }
+
+	waitForRuns("1\n")
+
+	// Rapid saves restart the program once, with the last edit
+	for _, v := range []string{"2", "3", "4"} {
+		writeModules(t, dir, map[string]string{"lib.js": `export const value = ` + v + `;`})
+		time.Sleep(10 * time.Millisecond)
+	}
+	waitForRuns("1\n4\n")
+	settle()
+	waitForRuns("1\n4\n")
+
+	// Files outside the graph and excluded files do not restart it
+	writeModules(t, dir, map[string]string{"unrelated.js": `export default 1;`, "assets/b.tmp": `c`})
+	settle()
+	waitForRuns("1\n4\n")
+
+	// Files under an extra path do
+	writeModules(t, dir, map[string]string{"assets/a.txt": `c`})
+	waitForRuns("1\n4\n4\n")
+
+	// New imports are watched from the next run on
+	writeModules(t, dir, map[string]string{
+		"lib.js":   `import { extra } from "./extra.js"; export const value = 5 + extra;`,
+		"extra.js": `export const extra = 1;`,
+	})
+	waitForRuns("1\n4\n4\n6\n")
+	writeModules(t, dir, map[string]string{"extra.js": `export const extra = 2;`})
+	waitForRuns("1\n4\n4\n6\n7\n")
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after its context was cancelled")
+	}
+}
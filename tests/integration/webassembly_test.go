@@ -0,0 +1,201 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mathWasm is a module importing a function and a global from "./env.js":
+//
+//	(func (import "./env.js" "log") (param i32))
+//	(global (import "./env.js" "base") i32)
+//	(memory (export "memory") 1)
+//	(func (export "add") (param i32 i32) (result i32) a + b + base)
+//	(func (export "dbl") (param i64) (result i64) x + x)
+//	(func (export "half") (param f32) (result f32) x * 0.5)
+//	(func (export "callLog") (call $log (i32.const 42)))
+//	(func (export "store") (param i32 i32) (i32.store (local 0) (local 1)))
+//	(func (export "load") (param i32) (result i32) (i32.load (local 0)))
+//	(func (export "trap") unreachable)
+var mathWasm = wasmBinary(
+	wasmSection(1, // types
+		[]byte{0x60, 2, 0x7f, 0x7f, 1, 0x7f},
+		[]byte{0x60, 1, 0x7e, 1, 0x7e},
+		[]byte{0x60, 1, 0x7d, 1, 0x7d},
+		[]byte{0x60, 1, 0x7f, 0},
+		[]byte{0x60, 0, 0},
+		[]byte{0x60, 2, 0x7f, 0x7f, 0},
+		[]byte{0x60, 1, 0x7f, 1, 0x7f},
+	),
+	wasmSection(2, // imports
+		append(append(wasmName("./env.js"), wasmName("log")...), 0, 3),
+		append(append(wasmName("./env.js"), wasmName("base")...), 3, 0x7f, 0),
+	),
+	wasmSection(3, []byte{0}, []byte{1}, []byte{2}, []byte{4}, []byte{5}, []byte{6}, []byte{4}),
+	wasmSection(5, []byte{0, 1}),
+	wasmSection(7, // exports
+		append(wasmName("memory"), 2, 0),
+		append(wasmName("add"), 0, 1),
+		append(wasmName("dbl"), 0, 2),
+		append(wasmName("half"), 0, 3),
+		append(wasmName("callLog"), 0, 4),
+		append(wasmName("store"), 0, 5),
+		append(wasmName("load"), 0, 6),
+		append(wasmName("trap"), 0, 7),
+	),
+	wasmSection(10, // code
+		wasmCode(0x20, 0, 0x20, 1, 0x6a, 0x23, 0, 0x6a),
+		wasmCode(0x20, 0, 0x20, 0, 0x7c),
+		wasmCode(0x20, 0, 0x43, 0, 0, 0, 0x3f, 0x94),
+		wasmCode(0x41, 42, 0x10, 0),
+		wasmCode(0x20, 0, 0x20, 1, 0x36, 2, 0),
+		wasmCode(0x20, 0, 0x28, 2, 0),
+		wasmCode(0x00),
+	),
+)
+
+func wasmBinary(sections ...[]byte) []byte {
+	binary := []byte("\x00asm\x01\x00\x00\x00")
+	for _, section := range sections {
+		binary = append(binary, section...)
+	}
+	return binary
+}
+
+// wasmSection encodes a section holding a vector of entries
+func wasmSection(id byte, entries ...[]byte) []byte {
+	payload := wasmU32(uint32(len(entries)))
+	for _, entry := range entries {
+		payload = append(payload, entry...)
+	}
+	return append(append([]byte{id}, wasmU32(uint32(len(payload)))...), payload...)
+}
+
+// wasmCode encodes a function body without locals
+func wasmCode(instructions ...byte) []byte {
+	body := append(append([]byte{0}, instructions...), 0x0b)
+	return append(wasmU32(uint32(len(body))), body...)
+}
+
+func wasmName(name string) []byte {
+	return append(wasmU32(uint32(len(name))), name...)
+}
+
+func wasmU32(v uint32) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func runWasmScript(t *testing.T, dir, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "math.wasm"), mathWasm, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rt, err := runModules(t, dir, map[string]string{
+		"env.js": `export function log(x) { globalThis.logged = x; }
+export const base = 100;`,
+		"main.js": script,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteFile() error = %v", err)
+	}
+	if err := rt.Eval(`if (globalThis.result !== "ok") throw new Error(globalThis.result)`); err != nil {
+		t.Errorf("unexpected result: %v", err)
+	}
+}
+
+func TestWasmImport(t *testing.T) {
+	runWasmScript(t, t.TempDir(), `import { add, dbl, half, callLog, store, load, memory } from "./math.wasm";
+const check = (name, got, want) => {
+  if (got !== want) throw new Error(name + ": got " + String(got) + ", want " + String(want));
+};
+try {
+  check("add", add(1, 2), 103);
+  check("add wraps", add(0x7fffffff, 0), -2147483549);
+  check("dbl", dbl(21n), 42n);
+  check("dbl wraps", dbl(2n ** 62n), -(2n ** 63n));
+  check("half", half(3), 1.5);
+  check("half rounds to f32", half(0.1), Math.fround(0.1) / 2);
+  callLog();
+  check("import", globalThis.logged, 42);
+
+  store(8, 0x01020304);
+  check("wasm write", new Uint8Array(memory.buffer)[8], 4);
+  new DataView(memory.buffer).setInt32(16, 77, true);
+  check("js write", load(16), 77);
+
+  let err;
+  try { dbl(1); } catch (e) { err = e; }
+  check("i64 needs a BigInt", err instanceof TypeError, true);
+  globalThis.result = "ok";
+} catch (e) {
+  globalThis.result = e.message;
+}`)
+}
+
+func TestWebAssemblyAPI(t *testing.T) {
+	runWasmScript(t, t.TempDir(), `import bytes from "./math.wasm" with { type: "bytes" };
+const check = (name, got, want) => {
+  if (got !== want) throw new Error(name + ": got " + String(got) + ", want " + String(want));
+};
+const boom = new Error("from js");
+const env = { log() { throw boom; }, base: 1 };
+try {
+  const { module, instance } = await WebAssembly.instantiate(bytes, { "./env.js": env });
+  check("module", module instanceof WebAssembly.Module, true);
+  check("exports", WebAssembly.Module.exports(module).map((e) => e.name + ":" + e.kind).join(","),
+    "memory:memory,add:function,dbl:function,half:function,callLog:function,store:function,load:function,trap:function");
+  check("imports", WebAssembly.Module.imports(module).map((i) => i.name + ":" + i.kind).join(","), "log:function,base:global");
+  check("add", instance.exports.add(1, 2), 4);
+  check("frozen", Object.isFrozen(instance.exports), true);
+
+  let err;
+  try { instance.exports.callLog(); } catch (e) { err = e; }
+  check("import exception", err, boom);
+  try { instance.exports.trap(); } catch (e) { err = e; }
+  check("trap", err instanceof WebAssembly.RuntimeError, true);
+  try { new WebAssembly.Instance(module, { "./env.js": {} }); } catch (e) { err = e; }
+  check("missing import", err instanceof WebAssembly.LinkError, true);
+  try { new WebAssembly.Module(new Uint8Array([0, 97, 115, 109, 2])); } catch (e) { err = e; }
+  check("bad module", err instanceof WebAssembly.CompileError, true);
+  check("validate", WebAssembly.validate(bytes) && !WebAssembly.validate(new Uint8Array(8)), true);
+
+  // Growing replaces the buffer with one that still has earlier writes
+  const memory = instance.exports.memory;
+  const buffer = memory.buffer;
+  instance.exports.store(0, 7);
+  check("grow", memory.grow(1), 1);
+  check("grown size", memory.buffer.byteLength, 2 * 65536);
+  check("grown contents", new Uint8Array(memory.buffer)[0], 7);
+  check("same memory", instance.exports.memory, memory);
+
+  const limited = new WebAssembly.Memory({ initial: 1, maximum: 2 });
+  try { limited.grow(2); } catch (e) { err = e; }
+  check("memory maximum", err instanceof RangeError, true);
+
+  const table = new WebAssembly.Table({ element: "anyfunc", initial: 1 });
+  check("table grow", table.grow(2), 1);
+  check("table length", table.length, 3);
+
+  const global = new WebAssembly.Global({ value: "i64", mutable: true }, 5n);
+  global.value = 6n;
+  check("global", global.value, 6n);
+  const base = new WebAssembly.Global({ value: "i32" }, 1000);
+  const streamed = await WebAssembly.instantiateStreaming(Promise.resolve({
+    headers: { get: () => "application/wasm" },
+    arrayBuffer: async () => bytes.buffer,
+  }), { "./env.js": { log() {}, base } });
+  check("streaming", streamed.instance.exports.add(1, 2), 1003);
+  globalThis.result = "ok";
+} catch (e) {
+  globalThis.result = e.message + "\n" + e.stack;
+}`)
+}
@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+func TestDependencyGraphCycles(t *testing.T) {
+	g := loader.NewDependencyGraph()
+	for _, edge := range [][2]string{
+		{"main.js", "a.js"},
+		{"a.js", "b.js"},
+		{"b.js", "a.js"},
+		{"b.js", "c.js"},
+		{"c.js", "c.js"},
+	} {
+		if err := g.AddDependency(edge[0], edge[1]); err != nil {
+			t.Fatalf("AddDependency(%s, %s) error = %v", edge[0], edge[1], err)
+		}
+	}
+
+	want := [][]string{{"a.js", "b.js", "a.js"}, {"c.js", "c.js"}}
+	if got := g.Cycles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Cycles() = %v, want %v", got, want)
+	}
+
+	order, err := g.ResolveDependencies("main.js")
+	if err != nil {
+		t.Fatalf("ResolveDependencies() error = %v", err)
+	}
+	if want := []string{"c.js", "b.js", "a.js", "main.js"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("ResolveDependencies() = %v, want %v", order, want)
+	}
+}
+
+func TestDependencyGraphStrict(t *testing.T) {
+	g := loader.NewDependencyGraph()
+	g.SetStrict(true)
+	_ = g.AddDependency("a.js", "b.js")
+	_ = g.AddDependency("b.js", "c.js")
+
+	err := g.AddDependency("c.js", "a.js")
+	var cycle *loader.CycleError
+	if !errors.As(err, &cycle) || !errors.Is(err, errors.ErrCircularDependency) {
+		t.Fatalf("AddDependency() error = %v, want a CycleError", err)
+	}
+	if got := loader.FormatCycle(cycle.Path); got != "a.js -> b.js -> c.js -> a.js" {
+		t.Errorf("cycle path = %q", got)
+	}
+	if deps := g.GetDependencies("c.js"); len(deps) != 0 {
+		t.Errorf("rejected edge was added: %v", deps)
+	}
+}
+
+func TestDependencyGraphPathsTo(t *testing.T) {
+	g := loader.NewDependencyGraph()
+	for _, edge := range [][2]string{
+		{"app", "a"},
+		{"app", "b"},
+		{"a", "c"},
+		{"b", "a"},
+		{"c", "b"},
+		{"c", "leaf"},
+		{"b", "leaf"},
+	} {
+		if err := g.AddDependency(edge[0], edge[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := [][]string{
+		{"app", "a", "c", "b", "leaf"},
+		{"app", "a", "c", "leaf"},
+		{"app", "b", "a", "c", "leaf"},
+		{"app", "b", "leaf"},
+	}
+	got := g.PathsTo("app", func(id string) bool { return id == "leaf" })
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PathsTo(leaf) = %v, want %v", got, want)
+	}
+	if got := g.PathsTo("app", func(id string) bool { return id == "app" }); got != nil {
+		t.Errorf("PathsTo(app) = %v, want no paths", got)
+	}
+}
@@ -0,0 +1,49 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+func TestScanImportAttributes(t *testing.T) {
+	source := `import config from "./config.json" with { type: "json" };
+import tpl from './page.html' assert { "type": 'text' };
+export { default as logo } from "./logo.png" with { type: "bytes", other: "x" };
+import plain from "./plain.js";
+const data = import("./data.json", { with: { type: "json" } });
+`
+	want := []struct {
+		specifier string
+		typ       loader.ImportType
+	}{
+		{"./config.json", loader.ImportJSON},
+		{"./page.html", loader.ImportText},
+		{"./logo.png", loader.ImportBytes},
+		{"./plain.js", loader.ImportJavaScript},
+		{"./data.json", loader.ImportJSON},
+	}
+
+	imports := loader.ScanImports(source)
+	if len(imports) != len(want) {
+		t.Fatalf("ScanImports() found %d imports %+v, want %d", len(imports), imports, len(want))
+	}
+	for i, imp := range imports {
+		if imp.Specifier != want[i].specifier || imp.Type != want[i].typ {
+			t.Errorf("import %d = %q type %q, want %q type %q", i, imp.Specifier, imp.Type, want[i].specifier, want[i].typ)
+		}
+	}
+
+	rewritten := loader.RewriteImports(source, imports, func(imp loader.Import) (string, bool) {
+		return "m" + imp.Specifier[2:], true
+	})
+	wantSource := `import config from "mconfig.json" ;
+import tpl from "mpage.html" ;
+export { default as logo } from "mlogo.png" ;
+import plain from "mplain.js";
+const data = import("mdata.json");
+`
+	if rewritten != wantSource {
+		t.Errorf("RewriteImports() =\n%s\nwant\n%s", rewritten, wantSource)
+	}
+}
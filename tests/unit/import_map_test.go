@@ -0,0 +1,76 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+func TestImportMapResolve(t *testing.T) {
+	m, err := loader.ParseImportMap([]byte(`{
+		"imports": {
+			"lodash": "npm:lodash@4",
+			"lodash/": "npm:lodash@4/",
+			"@std/path": "jsr:@std/path@1",
+			"app/": "./lib/",
+			"./src/old.js": "./src/new.js",
+			"https://esm.sh/react": "https://esm.sh/react@18.2.0",
+			"blocked": null
+		},
+		"scopes": {
+			"./vendor/": {"lodash": "./vendor/lodash.js"}
+		}
+	}`), "file:///project/edon.json", "edon.json")
+	if err != nil {
+		t.Fatalf("ParseImportMap() error = %v", err)
+	}
+
+	tests := []struct {
+		specifier string
+		referrer  string
+		want      string
+		wantErr   bool
+	}{
+		{specifier: "lodash", referrer: "/project/main.js", want: "npm:lodash@4"},
+		{specifier: "lodash/fp", referrer: "/project/main.js", want: "npm:lodash@4/fp"},
+		{specifier: "@std/path", referrer: "/project/main.js", want: "jsr:@std/path@1"},
+		{specifier: "app/util/a.js", referrer: "/project/main.js", want: "/project/lib/util/a.js"},
+		{specifier: "./old.js", referrer: "/project/src/main.js", want: "/project/src/new.js"},
+		{specifier: "https://esm.sh/react", referrer: "https://esm.sh/preact", want: "https://esm.sh/react@18.2.0"},
+		{specifier: "lodash", referrer: "/project/vendor/x.js", want: "/project/vendor/lodash.js"},
+		{specifier: "react", referrer: "/project/main.js"},
+		{specifier: "blocked", referrer: "/project/main.js", wantErr: true},
+		{specifier: "app/../../secret.js", referrer: "/project/main.js", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.specifier, func(t *testing.T) {
+			got, ok, err := m.Resolve(tt.specifier, tt.referrer)
+			if tt.wantErr {
+				if !errors.Is(err, errors.ErrUnresolvedImport) {
+					t.Fatalf("Resolve() error = %v, want ErrUnresolvedImport", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if ok != (tt.want != "") || got != tt.want {
+				t.Errorf("Resolve() = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportMapInvalid(t *testing.T) {
+	for _, data := range []string{
+		`{"imports": {"a/": "./a"}}`,
+		`{"imports": {"a": 1}}`,
+		`{"imports": {"a": "not a url"}}`,
+	} {
+		if _, err := loader.ParseImportMap([]byte(data), "file:///project/map.json", "map.json"); !errors.Is(err, errors.ErrInvalidImportMap) {
+			t.Errorf("ParseImportMap(%s) error = %v, want ErrInvalidImportMap", data, err)
+		}
+	}
+}
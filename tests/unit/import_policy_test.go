@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/katungi/edon/internal/config"
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+func TestValidateURLWithPolicy(t *testing.T) {
+	policy := loader.NewImportPolicy("esm.sh", "*.example.com", "localhost:8000")
+
+	tests := []struct {
+		url      string
+		wantRule string
+	}{
+		{url: "https://esm.sh/preact@10"},
+		{url: "https://ESM.sh./preact@10"},
+		{url: "https://cdn.example.com/mod.js"},
+		{url: "https://a.b.example.com/mod.js"},
+		{url: "http://localhost:8000/mod.js"},
+		{url: "https://example.com/mod.js", wantRule: loader.RuleHost},
+		{url: "https://esm.sh.evil.com/mod.js", wantRule: loader.RuleHost},
+		{url: "https://notexample.com/mod.js", wantRule: loader.RuleHost},
+		{url: "http://localhost:9000/mod.js", wantRule: loader.RuleHost},
+		{url: "http://esm.sh/preact@10", wantRule: loader.RuleScheme},
+		{url: "ftp://esm.sh/preact@10", wantRule: loader.RuleScheme},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			result := loader.ValidateURLWithPolicy(tt.url, policy)
+			if tt.wantRule == "" {
+				if !result.IsValid || result.PackageType != loader.TypeCDN {
+					t.Fatalf("ValidateURLWithPolicy() = %+v, want a valid remote URL", result)
+				}
+				return
+			}
+			if result.IsValid || result.Rule != tt.wantRule {
+				t.Fatalf("ValidateURLWithPolicy() = %+v, want rejection by the %s rule", result, tt.wantRule)
+			}
+			if !errors.Is(result.Error, errors.ErrImportNotAllowed) {
+				t.Errorf("ValidateURLWithPolicy() error = %v, want ErrImportNotAllowed", result.Error)
+			}
+		})
+	}
+}
+
+func TestConfigImportPolicy(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"edon.json": `{
+			"remoteImports": {
+				"allow": ["mirror.example.com"],
+				"headers": {"mirror.example.com": {"Authorization": "Bearer ${MIRROR_TOKEN}"}}
+			}
+		}`,
+	})
+	t.Setenv("MIRROR_TOKEN", "secret")
+
+	cfg, err := config.Find(filepath.Join(dir, "src", "nested"))
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	policy := cfg.ImportPolicy("esm.sh")
+
+	if result := loader.ValidateURLWithPolicy("https://unpkg.com/react", policy); result.IsValid {
+		t.Error("configured allow list should replace the default hosts")
+	}
+	if result := loader.ValidateURLWithPolicy("https://esm.sh/react", policy); !result.IsValid {
+		t.Errorf("host from the flag rejected: %v", result.Error)
+	}
+
+	result := loader.ValidateURLWithPolicy("https://mirror.example.com/mod.js", policy)
+	if !result.IsValid {
+		t.Fatalf("configured host rejected: %v", result.Error)
+	}
+}
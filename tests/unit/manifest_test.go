@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/packages"
+)
+
+func TestSetDependency(t *testing.T) {
+	tests := []struct {
+		name, in, field, pkg, value, want string
+	}{
+		{
+			name:  "replace keeps everything else",
+			in:    `{"name":"x", "dependencies": {"a": "^1.0.0",   "b": "2"}}`,
+			field: "dependencies", pkg: "a", value: "^1.2.0",
+			want: `{"name":"x", "dependencies": {"a": "^1.2.0",   "b": "2"}}`,
+		},
+		{
+			name:  "sorted object stays sorted",
+			in:    "{\n  \"dependencies\": {\n    \"a\": \"1\",\n    \"c\": \"3\"\n  }\n}\n",
+			field: "dependencies", pkg: "b", value: "2",
+			want: "{\n  \"dependencies\": {\n    \"a\": \"1\",\n    \"b\": \"2\",\n    \"c\": \"3\"\n  }\n}\n",
+		},
+		{
+			name:  "unsorted object is appended to",
+			in:    `{"dependencies": {"c": "3", "a": "1"}}`,
+			field: "dependencies", pkg: "b", value: "2",
+			want: `{"dependencies": {"c": "3", "a": "1", "b": "2"}}`,
+		},
+		{
+			name:  "missing object is added last",
+			in:    "{\n    \"name\": \"x\",\n    \"version\": \"1.0.0\"\n}",
+			field: "devDependencies", pkg: "@scope/tool", value: "^0.1.0",
+			want: "{\n    \"name\": \"x\",\n    \"version\": \"1.0.0\",\n    \"devDependencies\": {\n        \"@scope/tool\": \"^0.1.0\"\n    }\n}",
+		},
+		{
+			name:  "empty object",
+			in:    "{\n  \"dependencies\": {}\n}",
+			field: "dependencies", pkg: "a", value: "<2 >=1",
+			want: "{\n  \"dependencies\": {\n    \"a\": \"<2 >=1\"\n  }\n}",
+		},
+		{
+			name:  "empty object in a tab-indented document",
+			in:    "{\n\t\"name\": \"x\",\n\t\"dependencies\": {}\n}\n",
+			field: "dependencies", pkg: "b2", value: "^1.0.0",
+			want: "{\n\t\"name\": \"x\",\n\t\"dependencies\": {\n\t\t\"b2\": \"^1.0.0\"\n\t}\n}\n",
+		},
+		{
+			name:  "empty document",
+			in:    `{}`,
+			field: "dependencies", pkg: "a", value: "1",
+			want: "{\n  \"dependencies\": {\n    \"a\": \"1\"\n  }\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := packages.SetDependency([]byte(tt.in), tt.field, tt.pkg, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("SetDependency =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := packages.SetDependency([]byte(`{"dependencies": [}`), "dependencies", "a", "1"); !errors.Is(err, errors.ErrInvalidPackageConfig) {
+		t.Errorf("SetDependency on invalid JSON error = %v, want ErrInvalidPackageConfig", err)
+	}
+}
+
+func TestRemoveDependency(t *testing.T) {
+	for in, want := range map[string]string{
+		`{"dependencies": {"a": "1", "b": {"nested": "}"}, "c": "3"}}`: `{"dependencies": {"b": {"nested": "}"}, "c": "3"}}`,
+		"{\"dependencies\": {\n  \"z\": \"1\",\n  \"a\": \"1\"\n}}":    "{\"dependencies\": {\n  \"z\": \"1\"\n}}",
+		`{"dependencies": {"a": "1"}, "x": 1}`:                         `{"dependencies": {}, "x": 1}`,
+	} {
+		got, removed, err := packages.RemoveDependency([]byte(in), "dependencies", "a")
+		if err != nil || !removed {
+			t.Fatalf("RemoveDependency(%s) = %v, %v", in, removed, err)
+		}
+		if string(got) != want {
+			t.Errorf("RemoveDependency(%s) =\n%s\nwant\n%s", in, got, want)
+		}
+	}
+
+	in := `{"devDependencies": {"a": "1"}}`
+	got, removed, err := packages.RemoveDependency([]byte(in), "dependencies", "a")
+	if err != nil || removed || string(got) != in {
+		t.Errorf("RemoveDependency from a missing object = %s, %v, %v", got, removed, err)
+	}
+}
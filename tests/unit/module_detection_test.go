@@ -0,0 +1,26 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+func TestIsModule(t *testing.T) {
+	tests := map[string]bool{
+		`import x from "./x.js";`:                   true,
+		`console.log(1); export { a };`:             true,
+		`var a = 1;` + "\n" + `export default a;`:   true,
+		`const m = await import("./m.js");`:         false,
+		`console.log(import.meta);`:                 false,
+		`const o = { import: 1, export: 2 };`:       false,
+		`o.import(); o?.export;`:                    false,
+		`// import x from "y"` + "\n" + `"export";`: false,
+		`console.log("sloppy script")`:              false,
+	}
+	for source, want := range tests {
+		if got := loader.IsModule(source); got != want {
+			t.Errorf("IsModule(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/loader"
+)
+
+// writeFiles creates files (path -> content) under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseNPMSpecifier(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    loader.NPMSpecifier
+		wantErr bool
+	}{
+		{spec: "npm:lodash", want: loader.NPMSpecifier{Name: "lodash", Version: "latest", Subpath: "."}},
+		{spec: "npm:lodash@4/fp", want: loader.NPMSpecifier{Name: "lodash", Version: "4", Subpath: "./fp"}},
+		{spec: "npm:@scope/pkg@^1.2.0/a/b.js", want: loader.NPMSpecifier{Name: "@scope/pkg", Version: "^1.2.0", Subpath: "./a/b.js"}},
+		{spec: "@types/node", want: loader.NPMSpecifier{Name: "@types/node", Version: "latest", Subpath: "."}},
+		{spec: "npm:@scope", wantErr: true},
+		{spec: "npm:", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := loader.ParseNPMSpecifier(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseNPMSpecifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseNPMSpecifier() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvePackageEntry(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"exports/package.json": `{
+			"name": "exports",
+			"exports": {
+				".": {"require": "./cjs/index.js", "edon": "./edon.js", "import": "./esm/index.js"},
+				"./feature": {"import": "./esm/feature.js", "default": "./cjs/feature.js"},
+				"./utils/*": "./esm/utils/*.js",
+				"./utils/private/*": null,
+				"./bad": "../outside.js"
+			}
+		}`,
+		"exports/edon.js":                "",
+		"exports/esm/index.js":           "",
+		"exports/esm/feature.js":         "",
+		"exports/esm/utils/strings.js":   "",
+		"exports/esm/utils/private/x.js": "",
+		"sugar/package.json":             `{"name": "sugar", "exports": "./main.mjs"}`,
+		"sugar/main.mjs":                 "",
+		"legacy/package.json":            `{"name": "legacy", "main": "lib/main", "module": "es/main.js"}`,
+		"legacy/lib/main.js":             "",
+		"legacy/es/main.js":              "",
+		"legacy/fp.js":                   "",
+		"legacy/dir/index.js":            "",
+	})
+
+	tests := []struct {
+		name       string
+		pkg        string
+		subpath    string
+		conditions []string
+		want       string
+		wantErr    error
+	}{
+		{name: "edon condition wins in object order", pkg: "exports", subpath: ".", conditions: loader.DefaultConditions, want: "edon.js"},
+		{name: "require condition", pkg: "exports", subpath: ".", conditions: []string{"require"}, wantErr: errors.ErrModuleNotFound},
+		{name: "subpath with conditions", pkg: "exports", subpath: "./feature", conditions: loader.DefaultConditions, want: "esm/feature.js"},
+		{name: "subpath pattern", pkg: "exports", subpath: "./utils/strings", conditions: loader.DefaultConditions, want: "esm/utils/strings.js"},
+		{name: "null excludes pattern", pkg: "exports", subpath: "./utils/private/x", conditions: loader.DefaultConditions, wantErr: errors.ErrPackagePathNotExported},
+		{name: "unlisted subpath", pkg: "exports", subpath: "./esm/index.js", conditions: loader.DefaultConditions, wantErr: errors.ErrPackagePathNotExported},
+		{name: "target outside package", pkg: "exports", subpath: "./bad", conditions: loader.DefaultConditions, wantErr: errors.ErrInvalidPackageTarget},
+		{name: "string exports sugar", pkg: "sugar", subpath: ".", conditions: loader.DefaultConditions, want: "main.mjs"},
+		{name: "sugar only exports root", pkg: "sugar", subpath: "./main.mjs", conditions: loader.DefaultConditions, wantErr: errors.ErrPackagePathNotExported},
+		{name: "module field for import", pkg: "legacy", subpath: ".", conditions: loader.DefaultConditions, want: "es/main.js"},
+		{name: "main field with extension search", pkg: "legacy", subpath: ".", conditions: []string{"require"}, want: "lib/main.js"},
+		{name: "deep import without exports", pkg: "legacy", subpath: "./fp", conditions: loader.DefaultConditions, want: "fp.js"},
+		{name: "deep import of directory", pkg: "legacy", subpath: "./dir", conditions: loader.DefaultConditions, want: "dir/index.js"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loader.ResolvePackageEntry(filepath.Join(dir, tt.pkg), tt.subpath, tt.conditions)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ResolvePackageEntry() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolvePackageEntry() error = %v", err)
+			}
+			if want := filepath.Join(dir, tt.pkg, filepath.FromSlash(tt.want)); got != want {
+				t.Errorf("ResolvePackageEntry() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestResolvePackageEntryErrorNamesSubpath(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json": `{"name": "named", "exports": {".": "./index.js"}}`,
+		"index.js":     "",
+	})
+
+	_, err := loader.ResolvePackageEntry(dir, "./missing", loader.DefaultConditions)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), `"named"`) || !strings.Contains(err.Error(), `"./missing"`) {
+		t.Errorf("error %q should name the package and the subpath", err)
+	}
+}
+
+func TestResolvePackageImport(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json": `{
+			"name": "app",
+			"imports": {
+				"#config": {"edon": "./config.edon.js", "default": "./config.js"},
+				"#lib/*": "./src/lib/*.js",
+				"#dep": "some-dependency"
+			}
+		}`,
+		"config.edon.js":     "",
+		"src/lib/math.js":    "",
+		"src/nested/main.js": "",
+	})
+	referrer := filepath.Join(dir, "src", "nested", "main.js")
+
+	got, err := loader.ResolvePackageImport("#config", referrer, loader.DefaultConditions)
+	if err != nil || got != filepath.Join(dir, "config.edon.js") {
+		t.Errorf("#config = %q, %v", got, err)
+	}
+
+	got, err = loader.ResolvePackageImport("#lib/math", referrer, loader.DefaultConditions)
+	if err != nil || got != filepath.Join(dir, "src", "lib", "math.js") {
+		t.Errorf("#lib/math = %q, %v", got, err)
+	}
+
+	got, err = loader.ResolvePackageImport("#dep", referrer, loader.DefaultConditions)
+	if err != nil || got != "some-dependency" {
+		t.Errorf("#dep = %q, %v", got, err)
+	}
+
+	if _, err := loader.ResolvePackageImport("#missing", referrer, loader.DefaultConditions); !errors.Is(err, errors.ErrPackageImportNotDefined) {
+		t.Errorf("#missing error = %v, want %v", err, errors.ErrPackageImportNotDefined)
+	}
+}
+
+func TestScanImports(t *testing.T) {
+	source := `
+import def, { a as b } from "./static.js";
+import * as ns from './namespace.js'
+import "./side-effect.js";
+export { x } from "./reexport.js";
+export * from "./star.js";
+const lazy = import("./dynamic.js");
+// import "./commented.js";
+const str = "import './in-string.js'";
+const tpl = ` + "`import ${ \"./in-template.js\" }`" + `;
+const re = /import "x"/g;
+obj.import("./method.js");
+console.log(import.meta.url);
+`
+	want := []string{"./static.js", "./namespace.js", "./side-effect.js", "./reexport.js", "./star.js", "./dynamic.js"}
+
+	imports := loader.ScanImports(source)
+	if len(imports) != len(want) {
+		t.Fatalf("ScanImports() found %d imports %+v, want %d", len(imports), imports, len(want))
+	}
+	for i, imp := range imports {
+		if imp.Specifier != want[i] {
+			t.Errorf("import %d = %q, want %q", i, imp.Specifier, want[i])
+		}
+		if imp.Dynamic != (want[i] == "./dynamic.js") {
+			t.Errorf("import %q Dynamic = %v", imp.Specifier, imp.Dynamic)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/katungi/edon/internal/semver"
+)
+
+func TestRangeContains(t *testing.T) {
+	tests := []struct {
+		rng     string
+		version string
+		want    bool
+	}{
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^1.2.3", "1.2.2", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"1.x", "1.5.2", true},
+		{"1.2", "1.3.0", false},
+		{"*", "3.0.0", true},
+		{"", "0.0.1", true},
+		{">=1.0.0 <2.0.0", "1.5.0", true},
+		{">= 1.0.0 < 2.0.0", "2.0.0", false},
+		{"1.0.0 - 2.3", "2.3.7", true},
+		{"1.0.0 - 2.3", "2.4.0", false},
+		{"^1.0.0 || ^3.0.0", "3.1.0", true},
+		{"^1.0.0 || ^3.0.0", "2.1.0", false},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{"<=1.2", "1.2.9", true},
+		{"^1.2.3", "1.5.0-beta.1", false},
+		{"^1.2.3-beta.1", "1.2.3-beta.2", true},
+		{"^1.2.3-beta.1", "1.2.4-beta.1", false},
+		{"=1.2.3", "1.2.3", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rng+" "+tt.version, func(t *testing.T) {
+			r, err := semver.ParseRange(tt.rng)
+			if err != nil {
+				t.Fatalf("ParseRange(%q) error = %v", tt.rng, err)
+			}
+			v, err := semver.Parse(tt.version)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.version, err)
+			}
+			if got := r.Contains(v); got != tt.want {
+				t.Errorf("%q.Contains(%q) = %v, want %v", tt.rng, tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxSatisfying(t *testing.T) {
+	versions := []string{"0.9.0", "1.0.0", "1.4.2", "1.10.0", "2.0.0-rc.1", "2.0.0", "2.1.0"}
+
+	tests := []struct {
+		rng  string
+		want string
+		ok   bool
+	}{
+		{"^1.0.0", "1.10.0", true},
+		{"~1.4.0", "1.4.2", true},
+		{"*", "2.1.0", true},
+		{"<2", "1.10.0", true},
+		{"^3", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := semver.MaxSatisfying(versions, tt.rng)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("MaxSatisfying(%q) = %q, %v, want %q, %v", tt.rng, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0"}
+	for i := 1; i < len(ordered); i++ {
+		a, _ := semver.Parse(ordered[i-1])
+		b, _ := semver.Parse(ordered[i])
+		if semver.Compare(a, b) != -1 || semver.Compare(b, a) != 1 {
+			t.Errorf("expected %s < %s", ordered[i-1], ordered[i])
+		}
+	}
+}
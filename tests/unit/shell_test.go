@@ -0,0 +1,120 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/shell"
+)
+
+func TestShellRun(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.js", "b.js", "c.ts", ".hidden.js"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line string
+		want string
+		code int
+	}{
+		{`echo a   b`, "a b\n", 0},
+		{`echo 'a   b' "c  d" e\ f`, "a   b c  d e f\n", 0},
+		{`echo "" x`, " x\n", 0},
+		{`true && echo yes || echo no`, "yes\n", 0},
+		{`false && echo yes || echo no`, "no\n", 0},
+		{`false; echo after`, "after\n", 0},
+		{`echo one && false`, "one\n", 1},
+		{`X=1; echo $X ${X}2 '$X' "$X"`, "1 12 $X 1\n", 0},
+		{`echo $UNSET end`, "end\n", 0},
+		{`export Y=exported && echo $Y`, "exported\n", 0},
+		{`echo *.js`, "a.js b.js\n", 0},
+		{`echo "*.js" '*'.js`, "*.js *.js\n", 0},
+		{`echo *.md`, "*.md\n", 0},
+		{`echo .*.js`, ".hidden.js\n", 0},
+		{`cd sub && echo *`, "*\n", 0},
+		{`echo $ cost`, "$ cost\n", 0},
+		{`# a comment`, "", 0},
+		{`exit 4; echo unreachable`, "", 4},
+	}
+	for _, tt := range tests {
+		var stdout bytes.Buffer
+		sh := shell.New(shell.WithDir(dir), shell.WithStdio(nil, &stdout, &stdout))
+		err := sh.Run(context.Background(), tt.line)
+
+		code := 0
+		var exitErr *shell.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.Code
+		} else if err != nil {
+			t.Errorf("Run(%q) error = %v", tt.line, err)
+			continue
+		}
+		if code != tt.code {
+			t.Errorf("Run(%q) exit status = %d, want %d", tt.line, code, tt.code)
+		}
+		if stdout.String() != tt.want {
+			t.Errorf("Run(%q) printed %q, want %q", tt.line, stdout.String(), tt.want)
+		}
+	}
+}
+
+func TestShellCommandEnv(t *testing.T) {
+	var stdout bytes.Buffer
+	sh := shell.New(shell.WithEnv([]string{"PATH=" + os.Getenv("PATH")}), shell.WithStdio(nil, &stdout, &stdout))
+	if err := sh.Run(context.Background(), `X=1 true; echo "[$X]"`); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "[]\n" {
+		t.Errorf("an assignment before a command leaked into the shell: %q", stdout.String())
+	}
+
+	stdout.Reset()
+	err := sh.Run(context.Background(), `definitely-not-a-command`)
+	var exitErr *shell.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 127 {
+		t.Errorf("missing command: error = %v, want exit status 127", err)
+	}
+}
+
+func TestShellSyntax(t *testing.T) {
+	for _, line := range []string{
+		`echo a | wc`,
+		`echo a > out`,
+		`echo $(pwd)`,
+		`echo "unterminated`,
+		`&& echo`,
+		`echo a &&`,
+		`echo a &`,
+	} {
+		err := shell.New().Run(context.Background(), line)
+		if !errors.Is(err, errors.ErrShellSyntax) {
+			t.Errorf("Run(%q) error = %v, want ErrShellSyntax", line, err)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for in, want := range map[string]string{
+		"plain":       "plain",
+		"--flag=a/b":  "--flag=a/b",
+		"a b":         "'a b'",
+		"it's":        `'it'\''s'`,
+		"":            "''",
+		"*.js":        "'*.js'",
+		"$HOME && rm": "'$HOME && rm'",
+	} {
+		if got := shell.Quote(in); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
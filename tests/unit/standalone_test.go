@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/modules/node"
+	"github.com/katungi/edon/internal/runtime"
+	"github.com/katungi/edon/internal/standalone"
+)
+
+func TestStandaloneRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "edon")
+	binary := []byte("\x7fELF not really an executable")
+	if err := os.WriteFile(base, binary, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if program, err := standalone.Read(base); err != nil || program != nil {
+		t.Fatalf("Read(plain) = %v, %v; want no program", program, err)
+	}
+
+	program := &runtime.Program{
+		Root:        "/app/main.js",
+		Order:       []string{"/app/lib.js", "/app/main.js"},
+		Modules:     map[string][]byte{"/app/lib.js": {1, 2}, "/app/main.js": {3}},
+		Permissions: node.Permissions{Read: true},
+	}
+	first := filepath.Join(dir, "first")
+	if err := standalone.Build(base, first, program); err != nil {
+		t.Fatal(err)
+	}
+
+	// Building from a compiled executable replaces its program
+	program.Permissions = node.Permissions{Env: true}
+	second := filepath.Join(dir, "second")
+	if err := standalone.Build(first, second, program); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := standalone.Read(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, program) {
+		t.Errorf("Read = %+v, want %+v", got, program)
+	}
+	data, err := os.ReadFile(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, binary) || bytes.Count(data, []byte("edon\x00prg")) != 1 {
+		t.Errorf("second executable does not hold exactly one program")
+	}
+
+	// A trailer whose length runs past the start of the file is rejected
+	corrupt := append([]byte{}, data[len(data)-16:]...)
+	corrupt[0] = 0xff
+	bad := filepath.Join(dir, "bad")
+	if err := os.WriteFile(bad, append(binary, corrupt...), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := standalone.Read(bad); !errors.Is(err, errors.ErrInvalidProgram) {
+		t.Errorf("Read(corrupt) error = %v, want ErrInvalidProgram", err)
+	}
+}
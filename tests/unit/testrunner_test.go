@@ -0,0 +1,40 @@
+package unit
+
+import (
+	"io"
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/testrunner"
+)
+
+func TestIsTestFile(t *testing.T) {
+	tests := map[string]bool{
+		"math_test.js":     true,
+		"src/math.test.ts": true,
+		"view_test.tsx":    true,
+		"lib.test.mjs":     true,
+		"math.js":          false,
+		"test.js":          false,
+		"math_test.json":   false,
+		"latest.js":        false,
+		"contest_test.go":  false,
+		"math_test.js.map": false,
+	}
+	for name, want := range tests {
+		if got := testrunner.IsTestFile(name); got != want {
+			t.Errorf("IsTestFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestNewReporter(t *testing.T) {
+	for _, name := range testrunner.Reporters {
+		if _, err := testrunner.NewReporter(name, io.Discard); err != nil {
+			t.Errorf("NewReporter(%q): %v", name, err)
+		}
+	}
+	if _, err := testrunner.NewReporter("xml", io.Discard); !errors.Is(err, errors.ErrUnknownReporter) {
+		t.Errorf("NewReporter(xml) error = %v, want ErrUnknownReporter", err)
+	}
+}
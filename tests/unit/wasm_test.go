@@ -0,0 +1,47 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/katungi/edon/internal/errors"
+	"github.com/katungi/edon/internal/wasm"
+)
+
+func TestWasmParse(t *testing.T) {
+	helper, err := wasm.Parse(wasm.TableHelperModule("edon:table#1", "table", wasm.FuncRef))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(helper.Imports) != 1 || helper.Imports[0].Module != "edon:table#1" || helper.Imports[0].Kind != wasm.KindTable {
+		t.Errorf("unexpected imports %+v", helper.Imports)
+	}
+	if len(helper.Exports) != 2 || helper.Exports[0].Name != wasm.SizeExport || helper.Exports[1].Name != wasm.GrowExport {
+		t.Errorf("unexpected exports %+v", helper.Exports)
+	}
+
+	// Relinking rewrites only the import section
+	relinked, err := wasm.Parse(helper.Relink([][2]string{{"other", "t"}}))
+	if err != nil {
+		t.Fatalf("Parse(Relink()) error = %v", err)
+	}
+	if imp := relinked.Imports[0]; imp.Module != "other" || imp.Name != "t" || imp.Table.Elem != wasm.FuncRef {
+		t.Errorf("unexpected relinked import %+v", imp)
+	}
+	if len(relinked.Exports) != 2 {
+		t.Errorf("relinking lost exports: %+v", relinked.Exports)
+	}
+
+	table, err := wasm.Parse(wasm.TableModule(wasm.TableType{Elem: wasm.FuncRef, Limits: wasm.Limits{Min: 2, Max: 4, HasMax: true}}))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if tt, ok := table.TableType(0); !ok || tt.Limits.Min != 2 || tt.Limits.Max != 4 {
+		t.Errorf("TableType(0) = %+v, %v", tt, ok)
+	}
+
+	for _, binary := range [][]byte{nil, []byte("\x00asm\x02\x00\x00\x00"), []byte("\x00asm\x01\x00\x00\x00\x02\x10")} {
+		if _, err := wasm.Parse(binary); !errors.Is(err, errors.ErrInvalidWasm) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalidWasm", binary, err)
+		}
+	}
+}
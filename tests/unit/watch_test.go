@@ -0,0 +1,31 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/katungi/edon/internal/watch"
+)
+
+func TestWatchExcluded(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{
+		"dist/app.js":        true,
+		"src/app.js":         false,
+		"src/app.test.js":    true,
+		"src/deep/notes.tmp": true,
+		"src/deep/main.js":   false,
+	} {
+		abs := filepath.Join(wd, filepath.FromSlash(path))
+		if got := watch.Excluded(abs, []string{"dist", "*.test.js", "src/deep/*.tmp"}); got != want {
+			t.Errorf("Excluded(%s) = %v, want %v", path, got, want)
+		}
+	}
+	if watch.Excluded(filepath.Join(wd, "x.tmp"), nil) {
+		t.Error("Excluded with no patterns = true")
+	}
+}